@@ -0,0 +1,86 @@
+package tree
+
+import "time"
+
+// These interfaces are the optional accessors of Value. The values of this
+// package implement all of them, and the other Value implementations can
+// implement them to be consumed by ValueUint64, ValueTime, ValueBytes and
+// IsTruthy.
+type (
+	// Uint64Value provides the value as an unsigned integer.
+	Uint64Value interface {
+		Value
+		Uint64() uint64
+	}
+	// TimeValue provides the value as a time. It returns false if the value
+	// is not a time.
+	TimeValue interface {
+		Value
+		Time() (time.Time, bool)
+	}
+	// BytesValue provides the value as bytes.
+	BytesValue interface {
+		Value
+		Bytes() []byte
+	}
+	// TruthyValue reports whether the value is truthy.
+	TruthyValue interface {
+		Value
+		IsTruthy() bool
+	}
+)
+
+var (
+	_ Uint64Value = NumberValue(0)
+	_ TimeValue   = StringValue("")
+	_ BytesValue  = StringValue("")
+	_ TruthyValue = BoolValue(false)
+	_ TruthyValue = Nil
+)
+
+// ValueUint64 returns v as an unsigned integer. If v does not implement
+// Uint64Value, it returns uint64 of v.Int64() or 0 if it is negative.
+func ValueUint64(v Value) uint64 {
+	if uv, ok := v.(Uint64Value); ok {
+		return uv.Uint64()
+	}
+	if i := v.Int64(); i > 0 {
+		return uint64(i)
+	}
+	return 0
+}
+
+// ValueTime returns v as a time. If v does not implement TimeValue, it
+// parses v.String() as RFC 3339.
+func ValueTime(v Value) (time.Time, bool) {
+	if tv, ok := v.(TimeValue); ok {
+		return tv.Time()
+	}
+	t, err := time.Parse(time.RFC3339, v.String())
+	return t, err == nil
+}
+
+// ValueBytes returns v as bytes. If v does not implement BytesValue, it
+// returns the bytes of v.String().
+func ValueBytes(v Value) []byte {
+	if bv, ok := v.(BytesValue); ok {
+		return bv.Bytes()
+	}
+	return []byte(v.String())
+}
+
+// IsTruthy reports whether n is truthy: null and false are falsy and the
+// others including arrays and maps are truthy. The values that do not
+// implement TruthyValue are truthy unless they are null.
+func IsTruthy(n Node) bool {
+	if n == nil {
+		return false
+	}
+	if !n.Type().IsValue() {
+		return true
+	}
+	if tv, ok := n.Value().(TruthyValue); ok {
+		return tv.IsTruthy()
+	}
+	return !n.Type().IsNilValue()
+}
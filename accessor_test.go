@@ -0,0 +1,106 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type baseValue interface {
+	Value
+}
+
+// plainValue implements only the methods of Value.
+type plainValue struct {
+	baseValue
+}
+
+func TestValueUint64(t *testing.T) {
+	tests := []struct {
+		v    Value
+		want uint64
+	}{
+		{v: NumberValue(1), want: 1},
+		{v: NumberValue(-1), want: 0},
+		{v: NumberValue(1e10), want: 1e10},
+		{v: StringValue("1"), want: 0},
+		{v: Nil, want: 0},
+		{v: plainValue{NumberValue(2)}, want: 2},
+		{v: plainValue{NumberValue(-2)}, want: 0},
+	}
+	for i, test := range tests {
+		if got := ValueUint64(test.v); got != test.want {
+			t.Errorf("tests[%d] got %d; want %d", i, got, test.want)
+		}
+	}
+}
+
+func TestValueTime(t *testing.T) {
+	tests := []struct {
+		v      Value
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			v:      StringValue("2006-01-02T15:04:05Z"),
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			wantOk: true,
+		}, {
+			v:      NumberValue(1136214245.5),
+			want:   time.Date(2006, 1, 2, 15, 4, 5, 5e8, time.UTC),
+			wantOk: true,
+		}, {
+			v: StringValue("yesterday"),
+		}, {
+			v: BoolValue(true),
+		}, {
+			v: Nil,
+		},
+	}
+	for i, test := range tests {
+		got, ok := ValueTime(test.v)
+		if ok != test.wantOk || !got.Equal(test.want) {
+			t.Errorf("tests[%d] got %v, %v; want %v, %v", i, got, ok, test.want, test.wantOk)
+		}
+	}
+}
+
+func TestValueBytes(t *testing.T) {
+	tests := []struct {
+		v    Value
+		want []byte
+	}{
+		{v: StringValue("abc"), want: []byte("abc")},
+		{v: NumberValue(1), want: nil},
+		{v: Nil, want: nil},
+		{v: plainValue{NumberValue(1)}, want: []byte("1")},
+	}
+	for i, test := range tests {
+		if got := ValueBytes(test.v); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestIsTruthy(t *testing.T) {
+	tests := []struct {
+		n    Node
+		want bool
+	}{
+		{n: nil, want: false},
+		{n: Nil, want: false},
+		{n: ToValue(false), want: false},
+		{n: ToValue(true), want: true},
+		{n: ToValue(0), want: true},
+		{n: ToValue(""), want: true},
+		{n: Array{}, want: true},
+		{n: Map{}, want: true},
+		{n: plainValue{NumberValue(0)}, want: true},
+		{n: Any{ToValue(false)}, want: false},
+	}
+	for i, test := range tests {
+		if got := IsTruthy(test.n); got != test.want {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
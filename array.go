@@ -0,0 +1,66 @@
+package tree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// InsertAt inserts vs into *n at the index i. i must be between 0 and len(*n).
+func (n *Array) InsertAt(i int, vs ...Node) error {
+	a := *n
+	if i < 0 || i > len(a) {
+		return fmt.Errorf("index %d out of range [0:%d]", i, len(a))
+	}
+	x := make(Array, 0, len(a)+len(vs))
+	x = append(x, a[:i]...)
+	x = append(x, vs...)
+	*n = append(x, a[i:]...)
+	return nil
+}
+
+// RemoveRange removes the elements of *n from the index from to to - 1.
+func (n *Array) RemoveRange(from, to int) error {
+	a := *n
+	if from < 0 || to < from || to > len(a) {
+		return fmt.Errorf("range [%d:%d] out of range [0:%d]", from, to, len(a))
+	}
+	*n = append(a[:from], a[to:]...)
+	return nil
+}
+
+// Swap swaps the elements with the indexes i and j.
+func (n Array) Swap(i, j int) error {
+	if i < 0 || i >= len(n) || j < 0 || j >= len(n) {
+		return fmt.Errorf("index %d or %d out of range [0:%d]", i, j, len(n))
+	}
+	n[i], n[j] = n[j], n[i]
+	return nil
+}
+
+// SortFunc sorts the elements in place by less. The sort is stable.
+func (n Array) SortFunc(less func(a, b Node) bool) {
+	sort.SliceStable(n, func(i, j int) bool {
+		return less(n[i], n[j])
+	})
+}
+
+// Filter returns a new array of the elements that pred returns true.
+func (n Array) Filter(pred func(v Node) bool) Array {
+	x := Array{}
+	for _, v := range n {
+		if pred(v) {
+			x = append(x, v)
+		}
+	}
+	return x
+}
+
+// MapFunc returns a new array of the results of fn for each element.
+// It is not named Map because Map returns this node as a Map.
+func (n Array) MapFunc(fn func(v Node) Node) Array {
+	x := make(Array, len(n))
+	for i, v := range n {
+		x[i] = fn(v)
+	}
+	return x
+}
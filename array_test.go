@@ -0,0 +1,168 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArray_InsertAt(t *testing.T) {
+	tests := []struct {
+		a      Array
+		i      int
+		vs     []Node
+		want   Array
+		errstr string
+	}{
+		{
+			a:    ToArrayValues(1, 2),
+			i:    0,
+			vs:   ToNodeValues(0),
+			want: ToArrayValues(0, 1, 2),
+		}, {
+			a:    ToArrayValues(1, 4),
+			i:    1,
+			vs:   ToNodeValues(2, 3),
+			want: ToArrayValues(1, 2, 3, 4),
+		}, {
+			a:    ToArrayValues(1),
+			i:    1,
+			vs:   ToNodeValues(2),
+			want: ToArrayValues(1, 2),
+		}, {
+			a:      ToArrayValues(1),
+			i:      2,
+			vs:     ToNodeValues(2),
+			errstr: "index 2 out of range [0:1]",
+		}, {
+			a:      ToArrayValues(1),
+			i:      -1,
+			errstr: "index -1 out of range [0:1]",
+		},
+	}
+	for i, test := range tests {
+		a := test.a
+		err := a.InsertAt(test.i, test.vs...)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(a, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, a, test.want)
+		}
+	}
+}
+
+func TestArray_RemoveRange(t *testing.T) {
+	tests := []struct {
+		a        Array
+		from, to int
+		want     Array
+		errstr   string
+	}{
+		{
+			a:    ToArrayValues(0, 1, 2, 3),
+			from: 1,
+			to:   3,
+			want: ToArrayValues(0, 3),
+		}, {
+			a:    ToArrayValues(0, 1),
+			from: 1,
+			to:   1,
+			want: ToArrayValues(0, 1),
+		}, {
+			a:    ToArrayValues(0, 1),
+			from: 0,
+			to:   2,
+			want: Array{},
+		}, {
+			a:      ToArrayValues(0, 1),
+			from:   1,
+			to:     3,
+			errstr: "range [1:3] out of range [0:2]",
+		}, {
+			a:      ToArrayValues(0, 1),
+			from:   1,
+			to:     0,
+			errstr: "range [1:0] out of range [0:2]",
+		},
+	}
+	for i, test := range tests {
+		a := test.a
+		err := a.RemoveRange(test.from, test.to)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(a, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, a, test.want)
+		}
+	}
+}
+
+func TestArray_Swap(t *testing.T) {
+	a := ToArrayValues(0, 1, 2)
+	if err := a.Swap(0, 2); err != nil {
+		t.Fatal(err)
+	}
+	if want := ToArrayValues(2, 1, 0); !reflect.DeepEqual(a, want) {
+		t.Errorf("got %v; want %v", a, want)
+	}
+	if err := a.Swap(0, 3); err == nil || err.Error() != "index 0 or 3 out of range [0:3]" {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestArray_SortFunc(t *testing.T) {
+	a := Array{
+		Map{"n": ToValue(2), "id": ToValue("a")},
+		Map{"n": ToValue(1), "id": ToValue("b")},
+		Map{"n": ToValue(2), "id": ToValue("c")},
+	}
+	a.SortFunc(func(x, y Node) bool {
+		return x.Get("n").Value().Float64() < y.Get("n").Value().Float64()
+	})
+	var got []string
+	for _, v := range a {
+		got = append(got, v.Get("id").Value().String())
+	}
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestArray_Filter(t *testing.T) {
+	a := ToArrayValues(1, "a", 2, nil)
+	got := a.Filter(func(v Node) bool {
+		return v.Type().IsNumberValue()
+	})
+	if want := ToArrayValues(1, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	got = a.Filter(func(v Node) bool { return false })
+	if want := (Array{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestArray_MapFunc(t *testing.T) {
+	a := ToArrayValues(1, 2)
+	got := a.MapFunc(func(v Node) Node {
+		return ToValue(v.Value().Float64() * 10)
+	})
+	if want := ToArrayValues(10, 20); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if want := ToArrayValues(1, 2); !reflect.DeepEqual(a, want) {
+		t.Errorf("got %v; want %v", a, want)
+	}
+}
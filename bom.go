@@ -0,0 +1,114 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// TextEncoding is the encoding of the text detected by DetectTextEncoding.
+type TextEncoding int
+
+const (
+	// TextUTF8 is UTF-8 without the byte order mark.
+	TextUTF8 TextEncoding = iota
+	// TextUTF8BOM is UTF-8 with the byte order mark.
+	TextUTF8BOM
+	// TextUTF16LE is UTF-16 little endian with or without the byte order mark.
+	TextUTF16LE
+	// TextUTF16BE is UTF-16 big endian with or without the byte order mark.
+	TextUTF16BE
+)
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// DetectTextEncoding detects the encoding of the text that begins with head
+// by the byte order mark. UTF-16 without the byte order mark is detected by
+// the NUL byte of the first character, because JSON and YAML documents begin
+// with ASCII characters. At least 2 bytes of head are required.
+func DetectTextEncoding(head []byte) TextEncoding {
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+		return TextUTF8BOM
+	case bytes.HasPrefix(head, utf16LEBOM):
+		return TextUTF16LE
+	case bytes.HasPrefix(head, utf16BEBOM):
+		return TextUTF16BE
+	case len(head) >= 2 && head[0] != 0 && head[1] == 0:
+		return TextUTF16LE
+	case len(head) >= 2 && head[0] == 0 && head[1] != 0:
+		return TextUTF16BE
+	}
+	return TextUTF8
+}
+
+// ToUTF8 returns data that the byte order mark is stripped and UTF-16 is
+// transcoded to UTF-8 by DetectTextEncoding. UTF-8 data without the byte
+// order mark is returned as is.
+func ToUTF8(data []byte) ([]byte, error) {
+	var order binary.ByteOrder
+	switch DetectTextEncoding(data) {
+	case TextUTF8:
+		return data, nil
+	case TextUTF8BOM:
+		return data[len(utf8BOM):], nil
+	case TextUTF16LE:
+		order = binary.LittleEndian
+		data = bytes.TrimPrefix(data, utf16LEBOM)
+	case TextUTF16BE:
+		order = binary.BigEndian
+		data = bytes.TrimPrefix(data, utf16BEBOM)
+	}
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("invalid UTF-16 text of odd length %d", len(data))
+	}
+	u := make([]uint16, len(data)/2)
+	for i := range u {
+		u[i] = order.Uint16(data[i*2:])
+	}
+	b := make([]byte, 0, len(u))
+	for _, r := range utf16.Decode(u) {
+		b = utf8.AppendRune(b, r)
+	}
+	return b, nil
+}
+
+// HasBOM reports whether head begins with the byte order mark of UTF-8 or
+// UTF-16.
+func HasBOM(head []byte) bool {
+	return bytes.HasPrefix(head, utf8BOM) ||
+		bytes.HasPrefix(head, utf16LEBOM) ||
+		bytes.HasPrefix(head, utf16BEBOM)
+}
+
+// FromUTF8 returns UTF-8 data encoded by enc, so it reverts ToUTF8. The byte
+// order mark is prepended if bom is true or enc is TextUTF8BOM. The invalid
+// UTF-8 bytes are encoded as U+FFFD in UTF-16.
+func FromUTF8(data []byte, enc TextEncoding, bom bool) []byte {
+	var order binary.ByteOrder
+	switch enc {
+	case TextUTF8:
+		return data
+	case TextUTF8BOM:
+		return append(append([]byte{}, utf8BOM...), data...)
+	case TextUTF16LE:
+		order = binary.LittleEndian
+	case TextUTF16BE:
+		order = binary.BigEndian
+	}
+	u := utf16.Encode([]rune(string(data)))
+	if bom {
+		u = append([]uint16{0xfeff}, u...)
+	}
+	b := make([]byte, len(u)*2)
+	for i, c := range u {
+		order.PutUint16(b[i*2:], c)
+	}
+	return b
+}
@@ -0,0 +1,105 @@
+package tree
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectTextEncoding(t *testing.T) {
+	tests := []struct {
+		head []byte
+		want TextEncoding
+	}{
+		{head: []byte(`{"a"`), want: TextUTF8},
+		{head: []byte("\xef\xbb\xbf{"), want: TextUTF8BOM},
+		{head: []byte("\xff\xfe{\x00"), want: TextUTF16LE},
+		{head: []byte("\xfe\xff\x00{"), want: TextUTF16BE},
+		{head: []byte("{\x00\"\x00"), want: TextUTF16LE},
+		{head: []byte("\x00{\x00\""), want: TextUTF16BE},
+		{head: []byte("a"), want: TextUTF8},
+		{head: nil, want: TextUTF8},
+	}
+	for i, test := range tests {
+		if got := DetectTextEncoding(test.head); got != test.want {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestToUTF8(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		want   string
+		errstr string
+	}{
+		{data: []byte(`{"a":"é"}`), want: `{"a":"é"}`},
+		{data: []byte("\xef\xbb\xbf{\"a\":\"é\"}"), want: `{"a":"é"}`},
+		{data: []byte("\xff\xfe{\x00}\x00=\xd8\x00\xde"), want: "{}😀"},
+		{data: []byte("\xfe\xff\x00{\x00}\xd8=\xde\x00"), want: "{}😀"},
+		{data: []byte("a\x00:\x00 \x00\xe9\x00"), want: "a: é"},
+		{data: []byte("\xff\xfe{\x00}"), errstr: "invalid UTF-16 text of odd length 3"},
+	}
+	for i, test := range tests {
+		got, err := ToUTF8(test.data)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestFromUTF8(t *testing.T) {
+	tests := []struct {
+		data string
+		enc  TextEncoding
+		bom  bool
+		want []byte
+	}{
+		{data: "{}😀", enc: TextUTF8, want: []byte("{}😀")},
+		{data: "{}", enc: TextUTF8BOM, want: []byte("\xef\xbb\xbf{}")},
+		{data: "{}😀", enc: TextUTF16LE, bom: true, want: []byte("\xff\xfe{\x00}\x00=\xd8\x00\xde")},
+		{data: "{}😀", enc: TextUTF16BE, bom: true, want: []byte("\xfe\xff\x00{\x00}\xd8=\xde\x00")},
+		{data: "a: é", enc: TextUTF16LE, want: []byte("a\x00:\x00 \x00\xe9\x00")},
+	}
+	for i, test := range tests {
+		got := FromUTF8([]byte(test.data), test.enc, test.bom)
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+		back, err := ToUTF8(got)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if string(back) != test.data {
+			t.Errorf("tests[%d] got %q by ToUTF8; want %q", i, back, test.data)
+		}
+	}
+}
+
+func TestDecodeDocuments_UTF16(t *testing.T) {
+	data := []byte("\xff\xfe")
+	for _, r := range "a: 1\n---\na: 2\n" {
+		data = append(data, byte(r), 0)
+	}
+	ds, err := DecodeDocuments(bytes.NewReader(data), "utf16.yaml", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Node{Map{"a": ToValue(1)}, Map{"a": ToValue(2)}}
+	if got := ds.Nodes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if _, err := DecodeDocuments(strings.NewReader("\xef\xbb\xbf[1]"), "bom.json", FormatJSON); err != nil {
+		t.Errorf("got error %v", err)
+	}
+}
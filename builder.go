@@ -0,0 +1,70 @@
+package tree
+
+import "strings"
+
+// Builder builds a tree by chaining the methods. The first error stops the
+// following methods and is returned by Build.
+//
+//	n, err := tree.NewMap().Set("a", 1).SetPath("b.c", true).Build()
+type Builder struct {
+	n   Node
+	err error
+}
+
+// NewMap returns a Builder that builds a map.
+func NewMap() *Builder {
+	return &Builder{n: Map{}}
+}
+
+// NewArray returns a Builder that builds an array.
+func NewArray() *Builder {
+	return &Builder{n: Array{}}
+}
+
+// Set sets v converted by ToNode to the key of the node.
+func (b *Builder) Set(key interface{}, v interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+	switch n := b.n.(type) {
+	case Map:
+		b.err = n.Set(key, ToNode(v))
+	case Array:
+		b.err = n.Set(key, ToNode(v))
+		b.n = n
+	}
+	return b
+}
+
+// Append appends v converted by ToNode to the array.
+func (b *Builder) Append(v interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+	switch n := b.n.(type) {
+	case Map:
+		b.err = n.Append(ToNode(v))
+	case Array:
+		b.err = n.Append(ToNode(v))
+		b.n = n
+	}
+	return b
+}
+
+// SetPath sets v converted by ToNode to the path such as "b.c" or ".b[0]".
+// The intermediate maps and arrays are created. See SetPath.
+func (b *Builder) SetPath(path string, v interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "[") {
+		path = "." + path
+	}
+	b.err = SetPath(&b.n, path, ToNode(v))
+	return b
+}
+
+// Build returns the node and the first error.
+func (b *Builder) Build() (Node, error) {
+	return b.n, b.err
+}
@@ -0,0 +1,103 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		b      *Builder
+		want   Node
+		errstr string
+	}{
+		{
+			b: NewMap().Set("a", 1).SetPath("b.c", true).SetPath(".d[1].e", "x"),
+			want: Map{
+				"a": ToValue(1),
+				"b": Map{"c": ToValue(true)},
+				"d": Array{Nil, Map{"e": ToValue("x")}},
+			},
+		}, {
+			b:    NewMap().Set("a", []interface{}{1, "2"}).Set("b", Map{"c": Nil}),
+			want: Map{"a": ToArrayValues(1, "2"), "b": Map{"c": Nil}},
+		}, {
+			b:    NewArray().Append(1).Set(2, "c").SetPath("[3].d", 1),
+			want: Array{ToValue(1), Nil, ToValue("c"), Map{"d": ToValue(1)}},
+		}, {
+			b:      NewMap().Append(1).Set("a", 1),
+			errstr: "cannot append to map",
+		}, {
+			b:      NewMap().SetPath(".a[", 1),
+			errstr: `syntax error: no right brackets: ".a["`,
+		},
+	}
+	for i, test := range tests {
+		got, err := test.b.Build()
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	tests := []struct {
+		n      Node
+		expr   string
+		v      Node
+		want   Node
+		errstr string
+	}{
+		{
+			n:    Map{},
+			expr: ".a.b[0]",
+			v:    ToValue(1),
+			want: Map{"a": Map{"b": ToArrayValues(1)}},
+		}, {
+			n:    Map{"a": Map{"b": ToArrayValues(1)}},
+			expr: ".a.b[2]",
+			v:    ToValue(3),
+			want: Map{"a": Map{"b": Array{ToValue(1), Nil, ToValue(3)}}},
+		}, {
+			n:    nil,
+			expr: ".a",
+			v:    ToValue(1),
+			want: Map{"a": ToValue(1)},
+		}, {
+			n:    Nil,
+			expr: "[1].a",
+			v:    ToValue(1),
+			want: Array{Nil, Map{"a": ToValue(1)}},
+		}, {
+			n:      ToValue("str"),
+			expr:   ".a",
+			v:      ToValue(1),
+			errstr: `cannot index array with "a"`,
+		},
+	}
+	for i, test := range tests {
+		n := test.n
+		err := SetPath(&n, test.expr, test.v)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(n, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, n, test.want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// CheckExists is the operator of Check that requires any result of the
+	// query that is not null.
+	CheckExists = "exists"
+	// CheckAbsent is the operator of Check that requires no results of the
+	// query except null.
+	CheckAbsent = "absent"
+	// CheckCount is the operator of Check that requires the number of the
+	// results of the query between Min and Max.
+	CheckCount = "count"
+)
+
+// Check is a policy check of RunChecks like "every container must set
+// resources.limits".
+type Check struct {
+	// Name is the name of the check.
+	Name string
+	// For is the query of the nodes to check. "." is used if it is empty.
+	For string
+	// Query is the query executed to each node of For.
+	Query string
+	// Op is CheckExists (default), CheckAbsent, CheckCount or the operator
+	// of the comparators like "==" that compares the result of Query with
+	// Value like select(Query Op Value).
+	Op string
+	// Value is the value compared by the operator.
+	Value Node
+	// Min and Max are the range of the number of the results of CheckCount.
+	// Max 0 means no limit.
+	Min, Max int
+	// Message is the message of the violations.
+	Message string
+}
+
+func (c Check) label(i int) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// CheckViolation is a violation of the check.
+type CheckViolation struct {
+	// Name is the name of the check.
+	Name string `json:"name,omitempty"`
+	// Path is the path of the node of For.
+	Path string `json:"path"`
+	// Message is the message of the check or the default message.
+	Message string `json:"message"`
+}
+
+func (v CheckViolation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// ParseChecks returns the checks of the node that is an array of the checks
+// or a map that has the array as "checks".
+//
+//	checks:
+//	  - name: limits
+//	    for: ..containers[]
+//	    query: .resources.limits
+//	    message: every container must set resources.limits
+//	  - name: replicas
+//	    for: '.select(.kind == "Deployment")'
+//	    query: .spec.replicas
+//	    op: ">="
+//	    value: 2
+func ParseChecks(n Node) ([]Check, error) {
+	n = OrNil(n)
+	if n.Type().IsMap() {
+		n = OrNil(n.Get("checks"))
+	}
+	if !n.Type().IsArray() {
+		return nil, fmt.Errorf("checks must be an array")
+	}
+	checks := make([]Check, len(n.Array()))
+	for i, cn := range n.Array() {
+		cn = OrNil(cn)
+		if !cn.Type().IsMap() {
+			return nil, fmt.Errorf("check #%d must be a map", i+1)
+		}
+		c := Check{
+			Name:    cn.Get("name").Value().String(),
+			For:     cn.Get("for").Value().String(),
+			Query:   cn.Get("query").Value().String(),
+			Op:      cn.Get("op").Value().String(),
+			Value:   cn.Get("value"),
+			Min:     cn.Get("min").Value().Int(),
+			Max:     cn.Get("max").Value().Int(),
+			Message: cn.Get("message").Value().String(),
+		}
+		if err := c.validate(); err != nil {
+			return nil, fmt.Errorf("check %s: %w", c.label(i), err)
+		}
+		checks[i] = c
+	}
+	return checks, nil
+}
+
+func (c Check) validate() error {
+	if c.Query == "" {
+		return fmt.Errorf("query is required")
+	}
+	switch c.Op {
+	case "", CheckExists, CheckAbsent, CheckCount:
+		return nil
+	}
+	if !isOperator(Operator(c.Op)) {
+		return fmt.Errorf("unknown op %q", c.Op)
+	}
+	return nil
+}
+
+// RunChecks runs the checks to n and returns the violations.
+func RunChecks(n Node, checks []Check) ([]CheckViolation, error) {
+	return RunChecksContext(context.Background(), n, checks)
+}
+
+// RunChecksContext is like RunChecks but executes the queries with ctx.
+func RunChecksContext(ctx context.Context, n Node, checks []Check) ([]CheckViolation, error) {
+	n = OrNil(n)
+	var vs []CheckViolation
+	for i, c := range checks {
+		cvs, err := runCheck(ctx, n, c)
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", c.label(i), err)
+		}
+		vs = append(vs, cvs...)
+	}
+	return vs, nil
+}
+
+func runCheck(ctx context.Context, root Node, c Check) ([]CheckViolation, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	forExpr := c.For
+	if forExpr == "" {
+		forExpr = "."
+	}
+	fq, err := ParseQuery(forExpr)
+	if err != nil {
+		return nil, err
+	}
+	q, err := ParseQuery(c.Query)
+	if err != nil {
+		return nil, err
+	}
+	rctx := context.WithValue(withExecRoot(ctx, root), execPathKey{}, &execPath{})
+	ns, ps, err := execQueryWithPaths(rctx, fq, root, &execPath{})
+	if err != nil {
+		return nil, err
+	}
+	var vs []CheckViolation
+	for i, n := range ns {
+		msg, err := c.test(ctx, q, n)
+		if err != nil {
+			return nil, err
+		}
+		if msg == "" {
+			continue
+		}
+		if c.Message != "" {
+			msg = c.Message
+		}
+		path := "?"
+		if ps[i] != nil {
+			path = keysString(ps[i].keys)
+		}
+		vs = append(vs, CheckViolation{Name: c.Name, Path: path, Message: msg})
+	}
+	return vs, nil
+}
+
+// test returns the default message of the violation or an empty string if n
+// passes the check.
+func (c Check) test(ctx context.Context, q Query, n Node) (string, error) {
+	switch c.Op {
+	case "", CheckExists, CheckAbsent, CheckCount:
+		rs, err := ExecContext(ctx, q, n)
+		if err != nil {
+			return "", err
+		}
+		count := 0
+		for _, r := range rs {
+			if r != nil && !r.IsNil() {
+				count++
+			}
+		}
+		switch {
+		case c.Op == CheckCount:
+			if len(rs) < c.Min || (c.Max > 0 && len(rs) > c.Max) {
+				return fmt.Sprintf("%s has %d results, want %s", c.Query, len(rs), c.countRange()), nil
+			}
+		case c.Op == CheckAbsent:
+			if count > 0 {
+				return fmt.Sprintf("%s must not exist", c.Query), nil
+			}
+		default:
+			if count == 0 {
+				return fmt.Sprintf("%s must exist", c.Query), nil
+			}
+		}
+		return "", nil
+	}
+	value := ValueQuery{Node: OrNil(c.Value)}
+	cmp := Comparator{Left: q, Op: Operator(c.Op), Right: value}
+	ok, err := cmp.MatchesContext(ctx, n)
+	if err != nil || ok {
+		return "", err
+	}
+	return fmt.Sprintf("%s must be %s %s", c.Query, c.Op, value), nil
+}
+
+func (c Check) countRange() string {
+	if c.Max > 0 {
+		return fmt.Sprintf("%d to %d", c.Min, c.Max)
+	}
+	return fmt.Sprintf("%d or more", c.Min)
+}
@@ -0,0 +1,121 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChecks(t *testing.T) {
+	tests := []struct {
+		n      Node
+		want   []Check
+		errstr string
+	}{
+		{
+			n: Map{"checks": Array{
+				Map{"name": ToValue("a"), "for": ToValue(".a[]"), "query": ToValue(".b"), "message": ToValue("b is required")},
+				Map{"query": ToValue(".c"), "op": ToValue(">="), "value": ToValue(2)},
+				Map{"query": ToValue(".d[]"), "op": ToValue("count"), "min": ToValue(1), "max": ToValue(3)},
+			}},
+			want: []Check{
+				{Name: "a", For: ".a[]", Query: ".b", Value: Nil, Message: "b is required"},
+				{Query: ".c", Op: ">=", Value: ToValue(2)},
+				{Query: ".d[]", Op: "count", Value: Nil, Min: 1, Max: 3},
+			},
+		}, {
+			n:      Map{},
+			errstr: "checks must be an array",
+		}, {
+			n:      ToArrayValues("x"),
+			errstr: "check #1 must be a map",
+		}, {
+			n:      Array{Map{"name": ToValue("x")}},
+			errstr: "check x: query is required",
+		}, {
+			n:      Array{Map{"query": ToValue(".a"), "op": ToValue("===")}},
+			errstr: `check #1: unknown op "==="`,
+		},
+	}
+	for i, test := range tests {
+		got, err := ParseChecks(test.n)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestRunChecks(t *testing.T) {
+	n := Map{
+		"kind":     ToValue("Deployment"),
+		"replicas": ToValue(1),
+		"containers": Array{
+			Map{"name": ToValue("app"), "resources": Map{"limits": Map{"cpu": ToValue("1")}}},
+			Map{"name": ToValue("sidecar"), "privileged": ToValue(true)},
+		},
+	}
+	tests := []struct {
+		checks []Check
+		want   []CheckViolation
+		errstr string
+	}{
+		{
+			checks: []Check{{Name: "limits", For: ".containers[]", Query: ".resources.limits", Message: "limits are required"}},
+			want:   []CheckViolation{{Name: "limits", Path: ".containers[1]", Message: "limits are required"}},
+		}, {
+			checks: []Check{{For: ".containers[]", Query: ".privileged", Op: CheckAbsent}},
+			want:   []CheckViolation{{Path: ".containers[1]", Message: ".privileged must not exist"}},
+		}, {
+			checks: []Check{
+				{Query: ".containers[]", Op: CheckCount, Min: 1, Max: 1},
+				{Query: ".containers[]", Op: CheckCount, Min: 3},
+				{Query: ".containers[]", Op: CheckCount, Min: 1, Max: 2},
+			},
+			want: []CheckViolation{
+				{Path: ".", Message: ".containers[] has 2 results, want 1 to 1"},
+				{Path: ".", Message: ".containers[] has 2 results, want 3 or more"},
+			},
+		}, {
+			checks: []Check{
+				{Query: ".replicas", Op: ">=", Value: ToValue(2)},
+				{Query: ".kind", Op: "==", Value: ToValue("Deployment")},
+				{For: ".containers[]", Query: ".name", Op: "~=", Value: ToValue("^a")},
+			},
+			want: []CheckViolation{
+				{Path: ".", Message: ".replicas must be >= 2"},
+				{Path: ".containers[1]", Message: `.name must be ~= "^a"`},
+			},
+		}, {
+			checks: []Check{{Query: ".missing"}},
+			want:   []CheckViolation{{Path: ".", Message: ".missing must exist"}},
+		}, {
+			checks: []Check{{For: ".containers[]", Query: ".name"}, {Query: ".kind", Op: CheckExists}},
+		}, {
+			checks: []Check{{Name: "bad", Query: ".a["}},
+			errstr: `check bad: syntax error: no right brackets: ".a["`,
+		},
+	}
+	for i, test := range tests {
+		got, err := RunChecks(n, test.checks)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package tree
+
+import "os"
+
+// chownLike does nothing because the platform does not support file owners.
+func chownLike(f *os.File, info os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !windows && !plan9
+
+package tree
+
+import (
+	"os"
+	"syscall"
+)
+
+// chownLike changes the owner of f to the owner of info.
+func chownLike(f *os.File, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return f.Chown(int(st.Uid), int(st.Gid))
+}
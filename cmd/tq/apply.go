@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jarxorg/tree"
+	"github.com/spf13/pflag"
+)
+
+const (
+	applyCmd          = "apply"
+	applyDesc         = "Apply edits the documents by the rules file: each rule applies the edits to the nodes that the match query finds and the when condition holds for, in order."
+	applyUsage        = cmd + " " + applyCmd + " [flags] [rules] [file...]"
+	applyExamplesText = `Examples:
+  % cat rules.yaml
+  rules:
+    - name: pull-always
+      match: ..containers[]
+      when: .imagePullPolicy == null
+      edits:
+        - .imagePullPolicy = "Always"
+    - match: .metadata
+      edits: .labels.team = "web"
+  % tq apply rules.yaml deployment.yaml
+  % tq apply -U rules.yaml manifests/*.yaml
+`
+)
+
+func (r *runner) runApply(args []string) error {
+	var isHelp bool
+
+	s := pflag.NewFlagSet(args[0], pflag.ExitOnError)
+	s.SetOutput(r.stderr)
+	s.BoolVarP(&isHelp, "help", "h", false, "help for "+applyCmd)
+	s.BoolVarP(&r.isInplace, "inplace", "U", false, "update files, inplace")
+	s.StringVarP(&r.inputFormat, "input-format", "i", "", "input format (json or yaml, default guessed by file)")
+	s.StringVarP(&r.outputFormat, "output-format", "o", "", "output format (json or yaml, default the format of the first file)")
+	s.Usage = func() {
+		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", applyDesc, applyUsage)
+		fmt.Fprintln(r.stderr, "Flags:")
+		s.PrintDefaults()
+		fmt.Fprintf(r.stderr, "\n%s", applyExamplesText)
+	}
+	if err := s.Parse(args[1:]); err != nil {
+		return err
+	}
+	if isHelp || s.NArg() == 0 {
+		s.Usage()
+		return nil
+	}
+	filenames := s.Args()[1:]
+	if len(filenames) == 0 {
+		filenames = []string{filenameStdin}
+	}
+	if r.isInplace && r.outputFormat != "" {
+		return fmt.Errorf("--inplace cannot be used with --output-format")
+	}
+
+	rules, err := loadRules(s.Arg(0))
+	if err != nil {
+		return err
+	}
+	var ds tree.DocumentSet
+	for _, filename := range filenames {
+		if r.isInplace && filename == filenameStdin {
+			return fmt.Errorf("--inplace cannot be used with stdin")
+		}
+		fds, err := r.decodeDocuments(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", displayFilename(filename), err)
+		}
+		for _, d := range fds {
+			if err := tree.ApplyRules(&d.Node, rules); err != nil {
+				return fmt.Errorf("failed to apply to %s: %w", displayFilename(filename), err)
+			}
+		}
+		ds = append(ds, fds...)
+	}
+	if r.isInplace {
+		return ds.Save()
+	}
+	return ds.Encode(r.out, r.output())
+}
+
+// loadRules loads the rules of all documents in the rules file.
+func loadRules(filename string) ([]tree.Rule, error) {
+	ds, err := decodeRulesFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var rules []tree.Rule
+	for _, d := range ds {
+		rs, err := tree.ParseRules(d.Node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+		rules = append(rules, rs...)
+	}
+	return rules, nil
+}
+
+// decodeRulesFile decodes the documents of the rules file as JSON or YAML
+// regardless of --input-format.
+func decodeRulesFile(filename string) (tree.DocumentSet, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return tree.DecodeDocuments(f, filename, "")
+}
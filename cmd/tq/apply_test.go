@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jarxorg/io2"
+)
+
+func TestRunApply(t *testing.T) {
+	tests := []struct {
+		args   []string
+		want   string
+		errstr string
+	}{
+		{
+			args: []string{"testdata/rules.yaml", "testdata/manifests.yaml"},
+			want: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    team: web
+  name: web
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    team: web
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    team: web
+  name: db
+spec:
+  replicas: 1
+`,
+		}, {
+			args: []string{"-o", "json", "testdata/rules.yaml", "testdata/book-0.json"},
+			want: `{
+  "author": "Nigel Rees",
+  "category": "reference",
+  "price": 8.95,
+  "title": "Sayings of the Century"
+}
+`,
+		}, {
+			args:   []string{"testdata/store.json", "testdata/book-0.json"},
+			errstr: "failed to read testdata/store.json: rules must be an array",
+		}, {
+			args:   []string{"-U", "-o", "json", "testdata/rules.yaml", "testdata/book-0.json"},
+			errstr: "--inplace cannot be used with --output-format",
+		},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(out),
+		}
+		err := r.run(append([]string{"tq", "apply"}, test.args...))
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+	}
+}
+
+func TestRunApply_Inplace(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(filename, []byte("metadata:\n  name: web\nspec:\n  replicas: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &runner{
+		stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+		out:    io2.NopWriteCloser(new(bytes.Buffer)),
+	}
+	if err := r.run([]string{"tq", "apply", "-U", "testdata/rules.yaml", filename}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "metadata:\n  labels:\n    team: web\n  name: web\nspec:\n  replicas: 3\n"
+	if string(got) != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
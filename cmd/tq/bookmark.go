@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jarxorg/tree"
+)
+
+const (
+	historyFilename   = "history"
+	bookmarksFilename = "bookmarks.yaml"
+	// historyMax is the max number of the queries in the history file.
+	historyMax = 1000
+)
+
+var bookmarkNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// dataDir returns the directory of the history and the bookmarks:
+// $XDG_DATA_HOME/tq or ~/.local/share/tq.
+func dataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, cmd), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", cmd), nil
+}
+
+// appendHistory appends the query to the history file. The same query as
+// the last one is not appended, and the oldest queries are removed over
+// historyMax.
+func appendHistory(query string) error {
+	dir, err := dataDir()
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(dir, historyFilename)
+	history, err := loadHistory(filename)
+	if err != nil {
+		return err
+	}
+	if len(history) > 0 && history[len(history)-1] == query {
+		return nil
+	}
+	history = append(history, query)
+	if len(history) > historyMax {
+		history = history[len(history)-historyMax:]
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, []byte(strings.Join(history, "\n")+"\n"), 0o644)
+}
+
+// loadHistory returns the queries of the history file.
+func loadHistory(filename string) ([]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var history []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			history = append(history, line)
+		}
+	}
+	return history, nil
+}
+
+// loadBookmarks returns the map of the bookmark names to the queries.
+func loadBookmarks() (tree.Map, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, bookmarksFilename))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return tree.Map{}, nil
+		}
+		return nil, err
+	}
+	n, err := tree.UnmarshalYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmarks: %w", err)
+	}
+	if n.IsNil() {
+		return tree.Map{}, nil
+	}
+	if !n.Type().IsMap() {
+		return nil, fmt.Errorf("failed to read bookmarks: bookmarks must be a map")
+	}
+	return n.Map(), nil
+}
+
+// saveBookmark saves the query as the bookmark name.
+func saveBookmark(name, query string) error {
+	if !bookmarkNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid bookmark name %q", name)
+	}
+	bookmarks, err := loadBookmarks()
+	if err != nil {
+		return err
+	}
+	bookmarks[name] = tree.StringValue(query)
+	data, err := tree.MarshalYAML(bookmarks)
+	if err != nil {
+		return err
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, bookmarksFilename), data, 0o644)
+}
+
+// resolveBookmark returns the query of the bookmark.
+func resolveBookmark(name string) (string, error) {
+	bookmarks, err := loadBookmarks()
+	if err != nil {
+		return "", err
+	}
+	q, ok := bookmarks[name]
+	if !ok || !q.Type().IsStringValue() {
+		return "", fmt.Errorf("unknown bookmark %q", name)
+	}
+	return q.Value().String(), nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jarxorg/io2"
+)
+
+func TestDataDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	got, err := dataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ".local", "share", "tq"); got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+
+	t.Setenv("XDG_DATA_HOME", "/data")
+	got, err = dataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("/data", "tq"); got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
+func TestAppendHistory(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	for _, q := range []string{".a", ".b", ".b", ".a"} {
+		if err := appendHistory(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := loadHistory(filepath.Join(dir, "tq", historyFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{".a", ".b", ".a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := saveBookmark("images", ".spec.containers[].image"); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveBookmark("names", ".metadata.name"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query  string
+		want   string
+		errstr string
+	}{
+		{query: ".a", want: ".a"},
+		{query: "@images", want: ".spec.containers[].image"},
+		{query: "@names", want: ".metadata.name"},
+		{query: "@unknown", errstr: `unknown bookmark "unknown"`},
+	}
+	r := &runner{}
+	for i, test := range tests {
+		got, err := r.resolveQuery(test.query)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+	}
+
+	if err := saveBookmark("a/b", ".a"); err == nil || err.Error() != `invalid bookmark name "a/b"` {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestRun_Bookmark(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := saveBookmark("first-book", ".store.book[0]"); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/book-0.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	r := &runner{
+		stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+		out:    io2.NopWriteCloser(out),
+	}
+	if err := r.run([]string{"tq", "@first-book", "testdata/store.json"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != string(want) {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jarxorg/tree"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+const (
+	browseCmd   = "browse"
+	browseDesc  = "Browse explores the paths of the documents interactively: type to fuzzy search the paths, move by the arrow keys, press Enter to print the query of the path and Ctrl-Y to copy it to the clipboard. The printed queries are appended to the history and \":save name\" saves the query of the path as the bookmark to run it by \"tq @name\"."
+	browseUsage = cmd + " " + browseCmd + " [flags] [file]"
+)
+
+func (r *runner) runBrowse(args []string) error {
+	var isHelp bool
+
+	s := pflag.NewFlagSet(args[0], pflag.ExitOnError)
+	s.SetOutput(r.stderr)
+	s.BoolVarP(&isHelp, "help", "h", false, "help for "+browseCmd)
+	s.StringVarP(&r.inputFormat, "input-format", "i", "", "input format (json or yaml, default guessed by file)")
+	s.Usage = func() {
+		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", browseDesc, browseUsage)
+		fmt.Fprintln(r.stderr, "Flags:")
+		s.PrintDefaults()
+	}
+	if err := s.Parse(args[1:]); err != nil {
+		return err
+	}
+	if isHelp || s.NArg() > 1 {
+		s.Usage()
+		return nil
+	}
+	filename := s.Arg(0)
+	if filename == "" {
+		filename = filenameStdin
+	}
+	ds, err := r.decodeDocuments(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", displayFilename(filename), err)
+	}
+
+	// NOTE: The documents may be read from stdin, so the keys are read from
+	// the terminal.
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("%s requires a terminal: %w", browseCmd, err)
+	}
+	defer tty.Close()
+	query, err := browseTerminal(tty, newBrowser(browseEntries(ds.Nodes()...)))
+	if err != nil || query == "" {
+		return err
+	}
+	if err := appendHistory(query); err != nil {
+		fmt.Fprintf(r.stderr, "[%s] failed to write history: %v\n", cmd, err)
+	}
+	_, err = fmt.Fprintln(r.out, query)
+	return err
+}
+
+// browseTerminal runs the browser on the terminal and returns the accepted
+// query. It returns an empty query if the browser is quit.
+func browseTerminal(tty *os.File, b *browser) (string, error) {
+	fd := int(tty.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+	// NOTE: The alternate screen keeps the scrollback of the terminal.
+	io.WriteString(tty, "\x1b[?1049h")
+	defer io.WriteString(tty, "\x1b[?1049l")
+
+	buf := make([]byte, 64)
+	for {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			return "", err
+		}
+		if width <= 0 || height <= 0 {
+			width, height = 80, 24
+		}
+		if _, err := io.WriteString(tty, b.render(width, height)); err != nil {
+			return "", err
+		}
+		n, err := tty.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			switch b.handleKey(key) {
+			case browseQuit:
+				return "", nil
+			case browseAccept:
+				e, _ := b.selected()
+				return e.query, nil
+			case browseCopy:
+				e, _ := b.selected()
+				io.WriteString(tty, osc52(e.query))
+			case browseCommand:
+				b.runCommand(saveBookmark)
+			}
+		}
+	}
+}
+
+// osc52 returns the escape sequence to copy s to the clipboard of the
+// terminal.
+func osc52(s string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(s)) + "\a"
+}
+
+// browseEntry is a path of the documents.
+type browseEntry struct {
+	query string
+	value string
+}
+
+// browseEntries returns the unique paths of all nodes of the documents.
+func browseEntries(ns ...tree.Node) []browseEntry {
+	var entries []browseEntry
+	seen := map[string]bool{}
+	for _, n := range ns {
+		tree.Walk(n, func(n tree.Node, keys []interface{}) error {
+			q := queryPath(keys)
+			if !seen[q] {
+				seen[q] = true
+				entries = append(entries, browseEntry{query: q, value: browseValue(n)})
+			}
+			return nil
+		})
+	}
+	return entries
+}
+
+// browseValue returns the short description of n.
+func browseValue(n tree.Node) string {
+	switch {
+	case n.Type().IsArray():
+		return fmt.Sprintf("[%d]", len(n.Array()))
+	case n.Type().IsMap():
+		return fmt.Sprintf("{%d}", len(n.Map()))
+	}
+	b, err := tree.MarshalJSON(n)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+var plainKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// queryPath returns the query of the keys like `.a[0]."b c"`.
+func queryPath(keys []interface{}) string {
+	if len(keys) == 0 {
+		return "."
+	}
+	b := new(strings.Builder)
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			b.WriteByte('.')
+			if plainKeyRegexp.MatchString(k) && k != "and" && k != "or" {
+				b.WriteString(k)
+			} else {
+				b.WriteString(strconv.Quote(k))
+			}
+		case int:
+			b.WriteString("[" + strconv.Itoa(k) + "]")
+		}
+	}
+	return b.String()
+}
+
+// fuzzyScore returns the score of s matched by the pattern as a case
+// insensitive subsequence. The consecutive matches and the matches at the
+// start of the keys score high.
+func fuzzyScore(pattern, s string) (int, bool) {
+	score := 0
+	prev := -2
+	rs := []rune(s)
+	i := 0
+	for _, p := range strings.ToLower(pattern) {
+		for i < len(rs) && unicode.ToLower(rs[i]) != p {
+			i++
+		}
+		if i == len(rs) {
+			return 0, false
+		}
+		score++
+		if i == prev+1 {
+			score += 5
+		}
+		if i == 0 || strings.ContainsRune(`.["`, rs[i-1]) {
+			score += 3
+		}
+		prev = i
+		i++
+	}
+	return score, true
+}
+
+type browseAction int
+
+const (
+	browseNone browseAction = iota
+	browseQuit
+	browseAccept
+	browseCopy
+	browseCommand
+)
+
+// browser is the state of tq browse.
+type browser struct {
+	entries []browseEntry
+	filter  string
+	matches []int
+	cursor  int
+	offset  int
+	status  string
+	// command is the command line that starts with ":" like ":save name".
+	command string
+}
+
+func newBrowser(entries []browseEntry) *browser {
+	b := &browser{entries: entries}
+	b.setFilter("")
+	return b
+}
+
+// setFilter sets the filter and sorts the matched entries by the score.
+func (b *browser) setFilter(filter string) {
+	b.filter = filter
+	b.matches = b.matches[:0]
+	scores := map[int]int{}
+	for i, e := range b.entries {
+		if score, ok := fuzzyScore(filter, e.query); ok {
+			b.matches = append(b.matches, i)
+			scores[i] = score
+		}
+	}
+	sort.SliceStable(b.matches, func(i, j int) bool {
+		return scores[b.matches[i]] > scores[b.matches[j]]
+	})
+	b.cursor, b.offset = 0, 0
+}
+
+func (b *browser) selected() (browseEntry, bool) {
+	if b.cursor >= len(b.matches) {
+		return browseEntry{}, false
+	}
+	return b.entries[b.matches[b.cursor]], true
+}
+
+func (b *browser) move(d int) {
+	b.cursor += d
+	if b.cursor >= len(b.matches) {
+		b.cursor = len(b.matches) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// handleKey updates the state by the key and returns the action.
+func (b *browser) handleKey(key string) browseAction {
+	b.status = ""
+	if b.command != "" {
+		return b.handleCommandKey(key)
+	}
+	switch key {
+	case "\x03", "\x1b":
+		return browseQuit
+	case "\r", "\n":
+		if _, ok := b.selected(); ok {
+			return browseAccept
+		}
+	case "\x19":
+		if e, ok := b.selected(); ok {
+			b.status = "copied " + e.query
+			return browseCopy
+		}
+	case "\x1b[A", "\x10":
+		b.move(-1)
+	case "\x1b[B", "\x0e":
+		b.move(1)
+	case "\x1b[5~":
+		b.move(-10)
+	case "\x1b[6~":
+		b.move(10)
+	case "\x7f", "\b":
+		if b.filter != "" {
+			_, size := utf8.DecodeLastRuneInString(b.filter)
+			b.setFilter(b.filter[:len(b.filter)-size])
+		}
+	case ":":
+		b.command = key
+	default:
+		if isPrintKey(key) {
+			b.setFilter(b.filter + key)
+		}
+	}
+	return browseNone
+}
+
+// handleCommandKey updates the command line by the key.
+func (b *browser) handleCommandKey(key string) browseAction {
+	switch key {
+	case "\x03":
+		return browseQuit
+	case "\x1b":
+		b.command = ""
+	case "\r", "\n":
+		return browseCommand
+	case "\x7f", "\b":
+		_, size := utf8.DecodeLastRuneInString(b.command)
+		b.command = b.command[:len(b.command)-size]
+	default:
+		if isPrintKey(key) {
+			b.command += key
+		}
+	}
+	return browseNone
+}
+
+func isPrintKey(key string) bool {
+	r, _ := utf8.DecodeRuneInString(key)
+	return len(key) > 0 && unicode.IsPrint(r)
+}
+
+// runCommand runs the command line and sets the result to the status.
+// ":save name" saves the query of the selected path as the bookmark by save.
+func (b *browser) runCommand(save func(name, query string) error) {
+	fields := strings.Fields(strings.TrimPrefix(b.command, ":"))
+	b.command = ""
+	switch {
+	case len(fields) == 2 && fields[0] == "save":
+		e, ok := b.selected()
+		if !ok {
+			b.status = "no path is selected"
+			return
+		}
+		if err := save(fields[1], e.query); err != nil {
+			b.status = err.Error()
+			return
+		}
+		b.status = "saved @" + fields[1]
+	case len(fields) > 0 && fields[0] == "save":
+		b.status = "usage: :save name"
+	default:
+		b.status = fmt.Sprintf("unknown command %q", ":"+strings.Join(fields, " "))
+	}
+}
+
+// parseKeys splits the input of the terminal into the keys: the escape
+// sequences and the characters.
+func parseKeys(buf []byte) []string {
+	var keys []string
+	s := string(buf)
+	for s != "" {
+		if strings.HasPrefix(s, "\x1b[") {
+			end := strings.IndexFunc(s[2:], func(r rune) bool {
+				return r >= 0x40 && r <= 0x7e
+			})
+			if end >= 0 {
+				keys = append(keys, s[:end+3])
+				s = s[end+3:]
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		keys = append(keys, s[:size])
+		s = s[size:]
+	}
+	return keys
+}
+
+// render returns the screen: the filter, the status and the matched
+// entries around the cursor.
+func (b *browser) render(width, height int) string {
+	rows := height - 2
+	if rows < 1 {
+		rows = 1
+	}
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+rows {
+		b.offset = b.cursor - rows + 1
+	}
+	sb := new(strings.Builder)
+	sb.WriteString("\x1b[H\x1b[2J")
+	status := b.status
+	if status == "" {
+		status = fmt.Sprintf("%d/%d", len(b.matches), len(b.entries))
+	}
+	sb.WriteString(truncateLine(status, width) + "\r\n")
+	for i := b.offset; i < len(b.matches) && i < b.offset+rows; i++ {
+		e := b.entries[b.matches[i]]
+		line := truncateLine(e.query+"  "+e.value, width)
+		if i == b.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		sb.WriteString(line + "\r\n")
+	}
+	// NOTE: The cursor is placed at the end of the filter or the command
+	// line on the last line.
+	if b.command != "" {
+		fmt.Fprintf(sb, "\x1b[%d;1H%s", height, b.command)
+	} else {
+		fmt.Fprintf(sb, "\x1b[%d;1H> %s", height, b.filter)
+	}
+	return sb.String()
+}
+
+func truncateLine(s string, width int) string {
+	if width > 0 && utf8.RuneCountInString(s) > width {
+		return string([]rune(s)[:width])
+	}
+	return s
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jarxorg/tree"
+)
+
+func TestQueryPath(t *testing.T) {
+	tests := []struct {
+		keys []interface{}
+		want string
+	}{
+		{want: "."},
+		{keys: []interface{}{"a", 0, "b_1"}, want: ".a[0].b_1"},
+		{keys: []interface{}{"a b", "k-1", "and", `x"y`}, want: `."a b"."k-1"."and"."x\"y"`},
+	}
+	n := tree.Map{"a b": tree.Map{"k-1": tree.Map{"and": tree.Map{`x"y`: tree.ToValue(1)}}}}
+	for i, test := range tests {
+		got := queryPath(test.keys)
+		if got != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+		if _, err := tree.ParseQuery(got); err != nil {
+			t.Errorf("tests[%d] %v", i, err)
+		}
+	}
+	if got, err := tree.Find(n, tests[2].want); err != nil || !reflect.DeepEqual(got, []tree.Node{tree.ToValue(1)}) {
+		t.Errorf("got %v, %v", got, err)
+	}
+}
+
+func TestBrowseEntries(t *testing.T) {
+	got := browseEntries(
+		tree.Map{"a": tree.ToArrayValues(1, "x")},
+		tree.Map{"a": tree.ToArrayValues(2), "b": tree.Nil},
+	)
+	want := []browseEntry{
+		{query: ".", value: "{1}"},
+		{query: ".a", value: "[2]"},
+		{query: ".a[0]", value: "1"},
+		{query: ".a[1]", value: `"x"`},
+		{query: ".b", value: "null"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		ok      bool
+	}{
+		{pattern: "", s: ".a", ok: true},
+		{pattern: "nm", s: ".users[0].name", ok: true},
+		{pattern: "NAME", s: ".users[0].name", ok: true},
+		{pattern: "mn", s: ".users[0].name", ok: false},
+	}
+	for i, test := range tests {
+		if _, ok := fuzzyScore(test.pattern, test.s); ok != test.ok {
+			t.Errorf("tests[%d] got %v; want %v", i, ok, test.ok)
+		}
+	}
+	exact, _ := fuzzyScore("name", ".name")
+	scattered, _ := fuzzyScore("name", ".n.a.m.e")
+	if exact <= scattered {
+		t.Errorf("got %d <= %d", exact, scattered)
+	}
+}
+
+func TestBrowser(t *testing.T) {
+	b := newBrowser(browseEntries(tree.Map{
+		"users": tree.Array{tree.Map{"name": tree.ToValue("one")}},
+		"names": tree.ToArrayValues("x"),
+	}))
+	for _, key := range parseKeys([]byte("nam\x1b[B")) {
+		if got := b.handleKey(key); got != browseNone {
+			t.Fatalf("got %v for %q", got, key)
+		}
+	}
+	if got := matchedQueries(b); !reflect.DeepEqual(got, []string{".names", ".names[0]", ".users[0].name"}) {
+		t.Errorf("got %v", got)
+	}
+	if e, _ := b.selected(); e.query != ".names[0]" {
+		t.Errorf("got %s", e.query)
+	}
+	if got := b.handleKey("\x19"); got != browseCopy || b.status != "copied .names[0]" {
+		t.Errorf("got %v %q", got, b.status)
+	}
+	want := "\x1b[H\x1b[2Jcopied .names[0]\r\n.names  [1]\r\n\x1b[7m.names[0]  \"x\"\x1b[0m\r\n\x1b[4;1H> nam"
+	if got := b.render(80, 4); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	b.handleKey("\x7f")
+	b.handleKey("e")
+	b.handleKey("\x1b[B")
+	b.handleKey("\x1b[B")
+	if e, _ := b.selected(); e.query != ".users[0].name" {
+		t.Errorf("got %s", e.query)
+	}
+	if got := b.handleKey("\r"); got != browseAccept {
+		t.Errorf("got %v", got)
+	}
+	b.handleKey("z")
+	if got := b.handleKey("\r"); got != browseNone {
+		t.Errorf("got %v with no matches", got)
+	}
+	if got := b.handleKey("\x1b"); got != browseQuit {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestBrowser_Command(t *testing.T) {
+	b := newBrowser(browseEntries(tree.Map{"names": tree.ToArrayValues("x")}))
+	b.handleKey("\x1b[B")
+	for _, key := range parseKeys([]byte(":save namez\x7f")) {
+		if got := b.handleKey(key); got != browseNone {
+			t.Fatalf("got %v for %q", got, key)
+		}
+	}
+	if b.filter != "" || b.command != ":save name" {
+		t.Errorf("got filter %q and command %q", b.filter, b.command)
+	}
+	want := "\x1b[H\x1b[2J3/3\r\n.  {1}\r\n\x1b[7m.names  [1]\x1b[0m\r\n\x1b[4;1H:save name"
+	if got := b.render(80, 4); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if got := b.handleKey("\r"); got != browseCommand {
+		t.Fatalf("got %v", got)
+	}
+
+	saved := map[string]string{}
+	save := func(name, query string) error {
+		saved[name] = query
+		return nil
+	}
+	b.runCommand(save)
+	if want := map[string]string{"name": ".names"}; !reflect.DeepEqual(saved, want) {
+		t.Errorf("got %v; want %v", saved, want)
+	}
+	if b.command != "" || b.status != "saved @name" {
+		t.Errorf("got command %q and status %q", b.command, b.status)
+	}
+
+	tests := []struct {
+		keys string
+		want string
+	}{
+		{keys: ":save\r", want: "usage: :save name"},
+		{keys: ":open x\r", want: `unknown command ":open x"`},
+		{keys: "zz:save y\r", want: "no path is selected"},
+	}
+	for i, test := range tests {
+		b.setFilter("")
+		for _, key := range parseKeys([]byte(test.keys)) {
+			if b.handleKey(key) == browseCommand {
+				b.runCommand(save)
+			}
+		}
+		if b.status != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, b.status, test.want)
+		}
+	}
+
+	b.handleKey(":")
+	b.handleKey("\x1b")
+	if b.command != "" {
+		t.Errorf("got command %q after Esc", b.command)
+	}
+	b.handleKey(":")
+	if got := b.handleKey("\x7f"); got != browseNone || b.command != "" {
+		t.Errorf("got %v and command %q after Backspace", got, b.command)
+	}
+}
+
+func matchedQueries(b *browser) []string {
+	var qs []string
+	for _, i := range b.matches {
+		qs = append(qs, b.entries[i].query)
+	}
+	return qs
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("a\x1b[A\x1b[5~é\x1b"))
+	want := []string{"a", "\x1b[A", "\x1b[5~", "é", "\x1b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jarxorg/tree"
+	"github.com/spf13/pflag"
+)
+
+const (
+	checkCmd          = "check"
+	checkDesc         = "Check enforces the policy checks of the rules file to the documents and reports the violations."
+	checkUsage        = cmd + " " + checkCmd + " [flags] [rules] [file...]"
+	checkExamplesText = `Examples:
+  % cat checks.yaml
+  checks:
+    - name: limits
+      for: ..containers[]
+      query: .resources.limits
+      message: every container must set resources.limits
+    - name: replicas
+      for: '.select(.kind == "Deployment")'
+      query: .spec.replicas
+      op: ">="
+      value: 2
+  % tq check checks.yaml manifests/*.yaml
+  manifests/web.yaml: .spec.template.spec.containers[0]: limits: every container must set resources.limits
+`
+)
+
+var errCheckFailed = errors.New("check failed")
+
+func (r *runner) runCheck(args []string) error {
+	var isHelp bool
+
+	s := pflag.NewFlagSet(args[0], pflag.ExitOnError)
+	s.SetOutput(r.stderr)
+	s.BoolVarP(&isHelp, "help", "h", false, "help for "+checkCmd)
+	s.StringVarP(&r.inputFormat, "input-format", "i", "", "input format (json or yaml, default guessed by file)")
+	s.StringVar(&r.errorFormat, "error-format", errorFormatText, "error format (text or json)")
+	s.Usage = func() {
+		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", checkDesc, checkUsage)
+		fmt.Fprintln(r.stderr, "Flags:")
+		s.PrintDefaults()
+		fmt.Fprintf(r.stderr, "\n%s", checkExamplesText)
+	}
+	if err := s.Parse(args[1:]); err != nil {
+		return err
+	}
+	if err := checkErrorFormat(r.errorFormat); err != nil {
+		return err
+	}
+	if isHelp || s.NArg() < 2 {
+		s.Usage()
+		return nil
+	}
+
+	checks, err := loadChecks(s.Arg(0))
+	if err != nil {
+		return err
+	}
+	filenames := s.Args()[1:]
+	failed := 0
+	for _, filename := range filenames {
+		ds, err := r.decodeDocuments(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", displayFilename(filename), err)
+		}
+		violated := false
+		for i, d := range ds {
+			vs, err := tree.RunChecks(d.Node, checks)
+			if err != nil {
+				return fmt.Errorf("failed to check %s: %w", displayFilename(filename), err)
+			}
+			for _, v := range vs {
+				p := validateProblem{filename: displayFilename(filename), path: v.Path, msg: v.Message}
+				if v.Name != "" {
+					p.msg = v.Name + ": " + v.Message
+				}
+				if len(ds) > 1 {
+					p.document = i + 1
+				}
+				if err := r.outputProblem(p); err != nil {
+					return err
+				}
+				violated = true
+			}
+		}
+		if violated {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d files", errCheckFailed, failed, len(filenames))
+	}
+	return nil
+}
+
+// loadChecks loads the checks of all documents in the rules file.
+func loadChecks(filename string) ([]tree.Check, error) {
+	ds, err := decodeRulesFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var checks []tree.Check
+	for _, d := range ds {
+		cs, err := tree.ParseChecks(d.Node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+		checks = append(checks, cs...)
+	}
+	return checks, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jarxorg/io2"
+)
+
+func TestRunCheck(t *testing.T) {
+	passed := filepath.Join(t.TempDir(), "passed.yaml")
+	if err := os.WriteFile(passed, []byte("kind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args []string
+		want string
+		err  error
+	}{
+		{
+			args: []string{"testdata/checks.yaml", "testdata/manifests.yaml"},
+			want: "testdata/manifests.yaml#3: .: replicas: .spec.replicas must be >= 2\n",
+			err:  errCheckFailed,
+		}, {
+			args: []string{"testdata/checks.yaml", "testdata/manifests.yaml", "testdata/book-0.json"},
+			want: "testdata/manifests.yaml#3: .: replicas: .spec.replicas must be >= 2\n" +
+				"testdata/book-0.json: .: name: metadata.name is required\n",
+			err: errCheckFailed,
+		}, {
+			args: []string{"--error-format", "json", "testdata/checks.yaml", "testdata/book-0.json"},
+			want: `{"file":"testdata/book-0.json","error":"name: metadata.name is required","path":"."}` + "\n",
+			err:  errCheckFailed,
+		}, {
+			args: []string{"testdata/checks.yaml", passed},
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(buf),
+		}
+		err := r.run(append([]string{"tq", "check"}, test.args...))
+		if !errors.Is(err, test.err) {
+			t.Errorf("tests[%d] error %v; want %v", i, err, test.err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestRunCheck_Errors(t *testing.T) {
+	tests := []struct {
+		args   []string
+		errstr string
+	}{
+		{
+			args:   []string{"testdata/store.json", "testdata/book-0.json"},
+			errstr: "failed to read testdata/store.json: checks must be an array",
+		}, {
+			args:   []string{"testdata/rules.yaml", "testdata/book-0.json"},
+			errstr: "failed to read testdata/rules.yaml: checks must be an array",
+		},
+	}
+	for i, test := range tests {
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(new(bytes.Buffer)),
+		}
+		err := r.run(append([]string{"tq", "check"}, test.args...))
+		if err == nil || err.Error() != test.errstr {
+			t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+		}
+	}
+}
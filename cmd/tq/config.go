@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jarxorg/tree"
+)
+
+const configFilename = "config.yaml"
+
+// namedQuery is a query of the config file invoked by "@name".
+type namedQuery struct {
+	query string
+	// params are the variables of the query and the default values. The
+	// params of null default values are required.
+	params tree.Map
+}
+
+// config is the config file of tq.
+//
+//	queries:
+//	  images: ..containers[].image
+//	  by-name:
+//	    query: .[.metadata.name == $name]
+//	    params:
+//	      name: null
+type config struct {
+	queries map[string]namedQuery
+}
+
+// defaultConfigFile returns $XDG_CONFIG_HOME/tq/config.yaml or
+// ~/.config/tq/config.yaml.
+func defaultConfigFile() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, cmd, configFilename), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", cmd, configFilename), nil
+}
+
+// loadConfig loads the config file of --config or the default config file.
+// The default config file may not exist.
+func (r *runner) loadConfig() (*config, error) {
+	filename := r.configFile
+	if filename == "" {
+		var err error
+		if filename, err = defaultConfigFile(); err != nil {
+			return nil, err
+		}
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if r.configFile == "" && errors.Is(err, os.ErrNotExist) {
+			return &config{}, nil
+		}
+		return nil, err
+	}
+	c, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config %s: %w", filename, err)
+	}
+	return c, nil
+}
+
+func parseConfig(data []byte) (*config, error) {
+	n, err := tree.UnmarshalYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	c := &config{queries: map[string]namedQuery{}}
+	if n.IsNil() {
+		return c, nil
+	}
+	if !n.Type().IsMap() {
+		return nil, fmt.Errorf("config must be a map")
+	}
+	queries := n.Get("queries")
+	if queries.IsNil() {
+		return c, nil
+	}
+	if !queries.Type().IsMap() {
+		return nil, fmt.Errorf("queries must be a map")
+	}
+	for name, q := range queries.Map() {
+		switch {
+		case q.Type().IsStringValue():
+			c.queries[name] = namedQuery{query: q.Value().String()}
+		case q.Type().IsMap() && q.Get("query").Type().IsStringValue():
+			params := q.Get("params")
+			if !params.IsNil() && !params.Type().IsMap() {
+				return nil, fmt.Errorf("params of query %q must be a map", name)
+			}
+			c.queries[name] = namedQuery{query: q.Get("query").Value().String(), params: params.Map()}
+		default:
+			return nil, fmt.Errorf("query %q must be a string or a map with a query string", name)
+		}
+	}
+	return c, nil
+}
+
+// loadArgs binds the variables of --arg to the strings.
+func (r *runner) loadArgs() error {
+	for _, arg := range r.args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid arg %q", arg)
+		}
+		if r.vars == nil {
+			r.vars = tree.Map{}
+		}
+		r.vars[name] = tree.StringValue(value)
+	}
+	return nil
+}
+
+// resolveQuery returns the named query of the config file or the bookmark if
+// the query is "@name". The config file takes precedence over the bookmarks.
+// The params of the named query are bound to the variables by --arg or the
+// default values.
+func (r *runner) resolveQuery(query string) (string, error) {
+	if !strings.HasPrefix(query, "@") {
+		return query, nil
+	}
+	name := query[1:]
+	c, err := r.loadConfig()
+	if err != nil {
+		return "", err
+	}
+	nq, ok := c.queries[name]
+	if !ok {
+		return resolveBookmark(name)
+	}
+	for _, param := range nq.params.Keys() {
+		if _, ok := r.vars[param]; ok {
+			continue
+		}
+		v := nq.params[param]
+		if v.IsNil() {
+			return "", fmt.Errorf("@%s requires --arg %s=value", name, param)
+		}
+		if r.vars == nil {
+			r.vars = tree.Map{}
+		}
+		r.vars[param] = v
+	}
+	return nq.query, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jarxorg/io2"
+	"github.com/jarxorg/tree"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		data   string
+		want   map[string]namedQuery
+		errstr string
+	}{
+		{
+			data: "",
+			want: map[string]namedQuery{},
+		}, {
+			data: "queries:\n  a: .a\n  b:\n    query: .[.id == $id]\n    params:\n      id: 1\n",
+			want: map[string]namedQuery{
+				"a": {query: ".a"},
+				"b": {query: ".[.id == $id]", params: tree.Map{"id": tree.ToValue(1)}},
+			},
+		}, {
+			data:   "- a",
+			errstr: "config must be a map",
+		}, {
+			data:   "queries: [a]",
+			errstr: "queries must be a map",
+		}, {
+			data:   "queries:\n  a: 1",
+			errstr: `query "a" must be a string or a map with a query string`,
+		}, {
+			data:   "queries:\n  a:\n    query: .a\n    params: [x]",
+			errstr: `params of query "a" must be a map`,
+		},
+	}
+	for i, test := range tests {
+		got, err := parseConfig([]byte(test.data))
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got.queries, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got.queries, test.want)
+		}
+	}
+}
+
+func TestDefaultConfigFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	got, err := defaultConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ".config", "tq", "config.yaml"); got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+	r := &runner{}
+	if _, err := r.loadConfig(); err != nil {
+		t.Errorf("got error %v without the default config file", err)
+	}
+	r.configFile = filepath.Join(home, "unknown.yaml")
+	if _, err := r.loadConfig(); err == nil {
+		t.Errorf("got no error without --config file")
+	}
+}
+
+func TestRun_Config(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tests := []struct {
+		args   []string
+		want   string
+		errstr string
+	}{
+		{
+			args: []string{"-s", "@titles"},
+			want: `["Sayings of the Century", "Sword of Honour", "Moby Dick", "The Lord of the Rings"]`,
+		}, {
+			args: []string{"-s", "@category"},
+			want: `["Sayings of the Century"]`,
+		}, {
+			args: []string{"-s", "--arg", "category=fiction", "@category"},
+			want: `["Sword of Honour", "Moby Dick", "The Lord of the Rings"]`,
+		}, {
+			args: []string{"--arg", "author=Herman Melville", "@by-author"},
+			want: `"Moby Dick"`,
+		}, {
+			args:   []string{"@by-author"},
+			errstr: "@by-author requires --arg author=value",
+		}, {
+			args:   []string{"@unknown"},
+			errstr: `unknown bookmark "unknown"`,
+		}, {
+			args:   []string{"--arg", "x", "@titles"},
+			errstr: `invalid arg "x"`,
+		},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(out),
+		}
+		args := append([]string{"tq", "--config", "testdata/config.yaml", "--max-width", "200"}, test.args...)
+		err := r.run(append(args, "testdata/store.json"))
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := out.String(); got != test.want+"\n" {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+	}
+}
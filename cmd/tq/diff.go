@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContextLines = 3
+
+// diffMaxCells limits the size of the matrix of the longest common
+// subsequence of diffLCS.
+const diffMaxCells = 1 << 20
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns the unified diff of a and b. It returns an empty string
+// if a and b are same.
+func unifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	// aPos and bPos hold the line numbers before each op.
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != '+' {
+			aPos[i+1]++
+		}
+		if op.kind != '-' {
+			bPos[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > diffContextLines*2 {
+				break
+			}
+			end = next
+		}
+		end += diffContextLines
+		if end > len(ops) {
+			end = len(ops)
+		}
+		writeDiffHunk(&sb, ops[start:end], aPos[start], aPos[end], bPos[start], bPos[end])
+		i = end
+	}
+	return sb.String()
+}
+
+func writeDiffHunk(sb *strings.Builder, ops []diffOp, aFrom, aTo, bFrom, bTo int) {
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", diffRange(aFrom, aTo), diffRange(bFrom, bTo))
+	for _, op := range ops {
+		sb.WriteByte(op.kind)
+		if strings.HasSuffix(op.line, "\n") {
+			sb.WriteString(op.line)
+			continue
+		}
+		sb.WriteString(op.line)
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+func diffRange(from, to int) string {
+	if n := to - from; n != 1 {
+		if n == 0 {
+			return fmt.Sprintf("%d,0", from)
+		}
+		return fmt.Sprintf("%d,%d", from+1, n)
+	}
+	return fmt.Sprintf("%d", from+1)
+}
+
+// splitLines splits s into lines that keep the trailing newlines.
+func splitLines(s string) []string {
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n')
+		if i == -1 {
+			lines = append(lines, s)
+			break
+		}
+		lines = append(lines, s[:i+1])
+		s = s[i+1:]
+	}
+	return lines
+}
+
+// diffLines returns the edit script from a to b. The lines between the
+// common prefix and suffix are diffed by diffLCS.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffLCS(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// diffLCS returns the edit script from a to b using the longest common
+// subsequence, or the removal of all lines of a followed by the addition of
+// all lines of b if the matrix exceeds diffMaxCells.
+func diffLCS(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a) > 0 && len(b) > 0 && len(a) > diffMaxCells/len(b) {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
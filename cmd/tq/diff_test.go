@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		}, {
+			a:    "a\nb\nc\n",
+			b:    "a\nB\nc\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		}, {
+			a:    "",
+			b:    "a\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+a\n",
+		}, {
+			a:    "a\n",
+			b:    "a",
+			want: "--- a\n+++ b\n@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n",
+		}, {
+			a: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b: "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			want: "--- a\n+++ b\n" +
+				"@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n" +
+				"@@ -9,4 +10,3 @@\n 9\n 10\n 11\n-12\n",
+		},
+	}
+	for i, test := range tests {
+		got := unifiedDiff("a", "b", test.a, test.b)
+		if got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestDiffLines_Large(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 1100; i++ {
+		a = append(a, fmt.Sprintf("a%d\n", i))
+		b = append(b, fmt.Sprintf("b%d\n", i))
+	}
+	a = append(append([]string{"x\n"}, a...), "y\n")
+	b = append(append([]string{"x\n"}, b...), "y\n")
+
+	ops := diffLines(a, b)
+	var kinds, got strings.Builder
+	for _, op := range ops {
+		if op.kind != ' ' && (kinds.Len() == 0 || kinds.String()[kinds.Len()-1] != op.kind) {
+			kinds.WriteByte(op.kind)
+		}
+		if op.kind != '-' {
+			got.WriteString(op.line)
+		}
+	}
+	if kinds.String() != "-+" {
+		t.Errorf("got kinds %q; want %q", kinds.String(), "-+")
+	}
+	if want := strings.Join(b, ""); got.String() != want {
+		t.Errorf("got %q; want %q", got.String(), want)
+	}
+	if len(ops) != 2+1100*2 {
+		t.Errorf("got %d ops; want %d", len(ops), 2+1100*2)
+	}
+}
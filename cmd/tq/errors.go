@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jarxorg/tree"
+	"github.com/jarxorg/tree/tq"
+)
+
+const (
+	errorFormatText = "text"
+	errorFormatJSON = "json"
+)
+
+func checkErrorFormat(format string) error {
+	if format != errorFormatText && format != errorFormatJSON {
+		return fmt.Errorf("unknown error format %q", format)
+	}
+	return nil
+}
+
+// errInputsFailed is the error of --keep-going if any inputs failed. The
+// errors of the inputs are printed before it.
+var errInputsFailed = errors.New("some inputs failed")
+
+// fileError is an error that occurred while evaluating the file.
+type fileError struct {
+	filename string
+	line     int
+	column   int
+	err      error
+}
+
+func (e *fileError) Error() string {
+	return fmt.Sprintf("failed to evaluate %s: %v", e.filename, e.err)
+}
+
+func (e *fileError) Unwrap() error {
+	return e.err
+}
+
+// errorPosition returns the line and column where err occurred in the input.
+// It returns 0 if the position is unknown.
+func errorPosition(in io.ReadSeeker, err error) (int, int) {
+	var perr *tq.PartialError
+	if errors.As(err, &perr) {
+		if perr.Offset < 0 {
+			return errorPosition(in, perr.Err)
+		}
+		if _, err := in.Seek(0, io.SeekStart); err != nil {
+			return 0, 0
+		}
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return 0, 0
+		}
+		return lineColumn(data, perr.Offset)
+	}
+	var errs tq.FormatErrors
+	if !errors.As(err, &errs) {
+		errs = tq.FormatErrors{err}
+	}
+	errs = sortFormatErrors(errs, detectFormat(in))
+	for i := len(errs) - 1; i >= 0; i-- {
+		var serr *json.SyntaxError
+		if errors.As(errs[i], &serr) {
+			if _, err := in.Seek(0, io.SeekStart); err != nil {
+				return 0, 0
+			}
+			data, err := io.ReadAll(io.LimitReader(in, serr.Offset))
+			if err != nil || serr.Offset == 0 {
+				return 0, 0
+			}
+			return lineColumn(data, int64(len(data))-1)
+		}
+		if m := yamlLineRegexp.FindStringSubmatch(errs[i].Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return line, 0
+		}
+	}
+	return 0, 0
+}
+
+// detectFormat returns the format that the input looks like: JSON if it
+// begins with "{", "[" or `"` after the spaces, otherwise YAML.
+func detectFormat(in io.ReadSeeker) tree.Format {
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(in, head)
+	head = bytes.TrimLeft(bytes.TrimPrefix(head[:n], []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(head) > 0 && bytes.IndexByte([]byte(`{["`), head[0]) != -1 {
+		return tree.FormatJSON
+	}
+	return tree.FormatYAML
+}
+
+// sortFormatErrors returns the copy of errs those errors of the format are
+// moved to the last, so errorPosition finds them first.
+func sortFormatErrors(errs tq.FormatErrors, format tree.Format) tq.FormatErrors {
+	sorted := make(tq.FormatErrors, 0, len(errs))
+	var matched tq.FormatErrors
+	for _, err := range errs {
+		var derr *tq.DecodeError
+		if errors.As(err, &derr) && derr.Format == format {
+			matched = append(matched, err)
+			continue
+		}
+		sorted = append(sorted, err)
+	}
+	return append(sorted, matched...)
+}
+
+// exitStatusError is the exit status of --exit-status. It is not printed.
+type exitStatusError int
+
+func (e exitStatusError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+type errorJSON struct {
+	File    string `json:"file,omitempty"`
+	Error   string `json:"error"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Warning bool   `json:"warning,omitempty"`
+}
+
+func (r *runner) printError(err error) {
+	if r.errorFormat != errorFormatJSON {
+		fmt.Fprintf(r.stderr, "Error: %s\n", err)
+		return
+	}
+	e := errorJSON{Error: err.Error()}
+	var ferr *fileError
+	if errors.As(err, &ferr) {
+		e.File = ferr.filename
+		e.Error = ferr.err.Error()
+		e.Line = ferr.line
+		e.Column = ferr.column
+	}
+	json.NewEncoder(r.stderr).Encode(e)
+}
+
+// printPartial prints the warning of --partial that the rest of the file
+// after the valid documents is not evaluated.
+func (r *runner) printPartial(filename string, line, column int, err *tq.PartialError) {
+	if r.errorFormat == errorFormatJSON {
+		json.NewEncoder(r.stderr).Encode(errorJSON{
+			File:    filename,
+			Error:   err.Error(),
+			Line:    line,
+			Column:  column,
+			Warning: true,
+		})
+		return
+	}
+	pos := ""
+	if line > 0 {
+		pos = fmt.Sprintf(" at line %d", line)
+		if column > 0 {
+			pos += fmt.Sprintf(", column %d", column)
+		}
+	}
+	fmt.Fprintf(r.stderr, "Warning: partially evaluated %s%s: %v\n", filename, pos, err)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jarxorg/io2"
+	"github.com/jarxorg/tree"
+	"github.com/jarxorg/tree/tq"
+)
+
+func TestPrintError(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{
+			args: []string{"-i", "json", ".", "testdata/invalid-json"},
+			want: "Error: failed to evaluate testdata/invalid-json: invalid character 'i' looking for beginning of value\n",
+		}, {
+			args: []string{"--error-format", "json", "-i", "json", ".", "testdata/invalid-json"},
+			want: `{"file":"testdata/invalid-json","error":"invalid character 'i' looking for beginning of value","line":1,"column":1}` + "\n",
+		}, {
+			args: []string{"--error-format", "json", "-i", "yaml", ".", "testdata/invalid-yaml"},
+			want: `{"file":"testdata/invalid-yaml","error":"yaml: found unexpected end of stream"}` + "\n",
+		}, {
+			args: []string{"--error-format", "json", "-i", "yaml", ".", "testdata/missing"},
+			want: `{"error":"open testdata/missing: no such file or directory"}` + "\n",
+		}, {
+			args: []string{"--error-format", "xml", "."},
+			want: "Error: unknown error format \"xml\"\n",
+		},
+	}
+	for i, test := range tests {
+		stderr := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(stderr),
+			out:    io2.NopWriteCloser(new(bytes.Buffer)),
+		}
+		err := r.run(append([]string{"tq"}, test.args...))
+		if err == nil {
+			t.Fatalf("tests[%d] no error", i)
+		}
+		r.printError(err)
+		if got := stderr.String(); got != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	in := bytes.NewReader([]byte("{\n  \"a\": x\n}\n"))
+	tests := []struct {
+		err          error
+		line, column int
+	}{
+		{
+			err:  errors.New("unknown"),
+			line: 0, column: 0,
+		}, {
+			err:  tq.FormatErrors{errors.New("json error"), errors.New("yaml: line 3: mapping values are not allowed in this context")},
+			line: 3, column: 0,
+		}, {
+			err:  &tq.PartialError{Format: tree.FormatJSON, Documents: 1, Offset: 4, Err: errors.New("EOF")},
+			line: 2, column: 3,
+		}, {
+			err:  &tq.PartialError{Format: tree.FormatYAML, Documents: 1, Offset: -1, Err: errors.New("yaml: line 2: did not find expected node content")},
+			line: 2, column: 0,
+		},
+	}
+	for i, test := range tests {
+		line, column := errorPosition(in, test.err)
+		if line != test.line || column != test.column {
+			t.Errorf("tests[%d] got %d:%d; want %d:%d", i, line, column, test.line, test.column)
+		}
+	}
+}
+
+func TestErrorPosition_Format(t *testing.T) {
+	tests := []struct {
+		in           string
+		line, column int
+	}{
+		{
+			in:   "{\n  \"a\": 1,\n  \"b\": [1,,2]\n}\n",
+			line: 3, column: 11,
+		}, {
+			in:   "\n[1,\n 2,,\n 3]\n",
+			line: 3, column: 4,
+		}, {
+			in:   "a: 1\nb: [\n",
+			line: 2, column: 0,
+		},
+	}
+	for i, test := range tests {
+		r, err := tq.NewRunner(tq.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := bytes.NewReader([]byte(test.in))
+		_, err = r.Run(context.Background(), in, new(bytes.Buffer))
+		if err == nil {
+			t.Fatalf("tests[%d] no error", i)
+		}
+		line, column := errorPosition(in, err)
+		if line != test.line || column != test.column {
+			t.Errorf("tests[%d] got %d:%d; want %d:%d (%v)", i, line, column, test.line, test.column, err)
+		}
+	}
+}
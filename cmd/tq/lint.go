@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jarxorg/tree"
+)
+
+var errLintFailed = errors.New("lint failed")
+
+// lintQuery prints the issues of the query reported by tree.LintQuery instead
+// of evaluating it. The issues are printed as JSON lines by --error-format json.
+func (r *runner) lintQuery() error {
+	query, err := r.resolveQuery(r.flagSet.Arg(0))
+	if err != nil {
+		return err
+	}
+	issues, err := tree.LintQuery(query)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(r.out)
+	for _, issue := range issues {
+		if r.errorFormat == errorFormatJSON {
+			if err := enc.Encode(issue); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintln(r.out, issue)
+	}
+	switch len(issues) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%w: 1 issue", errLintFailed)
+	}
+	return fmt.Errorf("%w: %d issues", errLintFailed, len(issues))
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/jarxorg/io2"
+)
+
+func TestLintQuery(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+		err  error
+	}{
+		{
+			args: []string{"--lint", ".store.book[.price < 10].title"},
+		}, {
+			args: []string{"--lint", ".store.book[.price > true].title."},
+			want: ".store.book[.price > true].title.: trailing . is redundant (did you mean .store.book[.price > true].title?)\n" +
+				".price > true: bool is not ordered, so it never matches (did you mean .price == true?)\n",
+			err: errLintFailed,
+		}, {
+			args: []string{"--lint", "--error-format", "json", ".uuidd()"},
+			want: `{"query":"uuidd()","message":"unknown method","suggestion":"uuid()"}` + "\n",
+			err:  errLintFailed,
+		}, {
+			args: []string{"--lint", "--config", "testdata/config.yaml", "@titles"},
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(buf),
+		}
+		err := r.run(append([]string{"tq"}, test.args...))
+		if !errors.Is(err, test.err) {
+			t.Errorf("tests[%d] error %v; want %v", i, err, test.err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
@@ -1,25 +1,30 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
-	"text/template"
+	"time"
 
 	"github.com/jarxorg/io2"
 	"github.com/jarxorg/tree"
+	"github.com/jarxorg/tree/k8s"
+	"github.com/jarxorg/tree/tq"
 	"github.com/spf13/pflag"
 	"golang.org/x/term"
-	"gopkg.in/yaml.v2"
 )
 
 const (
 	cmd          = "tq"
 	desc         = cmd + " is a command-line JSON/YAML processor."
-	usage        = cmd + " [flags] [query] ([file...])"
+	usage        = cmd + " [flags] [query] ([file...])\n  " + validateUsage + "\n  " + valuesUsage + "\n  " + serveUsage + "\n  " + splitUsage + "\n  " + joinUsage + "\n  " + statsUsage + "\n  " + browseUsage + "\n  " + applyUsage + "\n  " + checkUsage + "\n  " + testUsage
 	examplesText = `Examples:
   % echo '{"colors": ["red", "green", "blue"]}' | tq '.colors[0]'
   "red"
@@ -51,19 +56,6 @@ func (l *stringList) Set(value string) error {
 	return nil
 }
 
-type decodeError struct {
-	err error
-}
-
-func (e *decodeError) Error() string {
-	return e.err.Error()
-}
-
-func isDecodeError(err error) bool {
-	_, ok := err.(*decodeError)
-	return ok
-}
-
 type inputFiles struct {
 	filenames []string
 	off       int
@@ -74,6 +66,31 @@ func newInputFiles(filenames []string) *inputFiles {
 	return &inputFiles{filenames: filenames}
 }
 
+// expandGlobs expands the patterns of the filenames those are not expanded
+// by the shell like cmd.exe of Windows. The slashes of the patterns are the
+// path separators on Windows too. The filenames those exist and the patterns
+// those are invalid or match no files are kept as is like the shells.
+func expandGlobs(filenames []string) []string {
+	var expanded []string
+	for _, name := range filenames {
+		if name == filenameStdin || !strings.ContainsAny(name, "*?[") {
+			expanded = append(expanded, name)
+			continue
+		}
+		if _, err := os.Stat(name); err == nil {
+			expanded = append(expanded, name)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.FromSlash(name))
+		if err != nil || len(matches) == 0 {
+			expanded = append(expanded, name)
+			continue
+		}
+		expanded = append(expanded, matches...)
+	}
+	return expanded
+}
+
 func (f *inputFiles) nextReader() (io.ReadSeekCloser, error) {
 	if f.off >= len(f.filenames) {
 		return nil, io.EOF
@@ -114,23 +131,78 @@ type runner struct {
 	isSlurp      bool
 	isRaw        bool
 	isInplace    bool
+	isDryRun     bool
+	isDiff       bool
+	isVerbose    bool
+	isRPC        bool
+	isSOPS       bool
+	isStats      bool
+	isCount      bool
+	isFirst      bool
+	isExitStatus bool
+	isLint       bool
+	isKeepGoing  bool
+	isPartial    bool
+	truthiness   string
+	truthy       func(n tree.Node) bool
+	seed         int64
 	isColor      bool
+	isASCII      bool
+	isNoEscHTML  bool
+	floatFormat  string
+	floatPrec    int
+	isYAMLQuote  bool
+	isYAMLSingle bool
+	yamlFlow     int
+	isYAMLV2     bool
+	maxWidth     int
+	isDocStart   bool
+	isDocEnd     bool
+	isSeq        bool
+	defaultsFile string
+	keyCase      string
+	isNul        bool
+	separator    string
 	isInputJSON  bool
 	isInputYAML  bool
 	isOutputJSON bool
 	isOutputYAML bool
 	outputFile   string
+	outputPat    string
+	backupSuffix string
+	errorFormat  string
 	tmplText     string
+	jsonTmpl     string
+	escape       string
 	inputFormat  string
 	outputFormat string
 	editExprs    []string
+	columns      []string
+	columnWidth  int
+	truncateStrs int
+	maxOutBytes  int
+	graphDepth   int
+	isGraphVals  bool
+	slurpFiles   []string
+	args         []string
+	configFile   string
+	selector     k8s.Selector
+
+	stderr   io.Writer
+	out      io.WriteCloser
+	vars     tree.Map
+	defaults tree.Node
+	pipeline *tq.Runner
+	stats    stats
+	filename string
+	failures int
+}
 
-	tmpl             *template.Template
-	stderr           io.Writer
-	out              io.WriteCloser
-	guessFormat      string
-	outputYAMLCalled int
-	slurpResults     tree.Array
+type stats struct {
+	files     int
+	documents int
+	bytes     int64
+	start     time.Time
 }
 
 func newRunner() *runner {
@@ -151,23 +223,99 @@ func (r *runner) initFlagSet(args []string) error {
 	s.BoolVarP(&r.isSlurp, "slurp", "s", false, "slurp all results into an array")
 	s.BoolVarP(&r.isRaw, "raw", "r", false, "output raw strings")
 	s.BoolVarP(&r.isInplace, "inplace", "U", false, "update files, inplace")
+	s.StringVar(&r.backupSuffix, "backup", "", "backup files with the suffix before updating inplace")
+	s.BoolVar(&r.isDryRun, "dry-run", false, "print the updated files instead of updating inplace")
+	s.BoolVar(&r.isDiff, "diff", false, "print the diff of the updated files instead of updating inplace")
 	s.BoolVarP(&r.isColor, "color", "c", false, "output with colors")
+	s.BoolVar(&r.isASCII, "ascii", false, "escape non-ASCII characters of JSON strings as \\uXXXX")
+	s.BoolVar(&r.isNoEscHTML, "no-escape-html", false, "output <, > and & of JSON strings as is")
+	s.StringVar(&r.floatFormat, "float-format", "", "number format (shortest, scientific or fixed)")
+	s.IntVar(&r.floatPrec, "float-precision", -1, "digits after the decimal point of --float-format scientific or fixed")
+	s.BoolVar(&r.isYAMLQuote, "yaml-always-quote", false, "quote all YAML string values")
+	s.BoolVar(&r.isYAMLSingle, "yaml-single-quote", false, "prefer single quotes for YAML strings")
+	s.IntVar(&r.yamlFlow, "yaml-flow", 0, "output nested YAML maps and arrays in flow style if the lines fit in the width")
+	s.Lookup("yaml-flow").NoOptDefVal = "80"
+	s.BoolVar(&r.isYAMLV2, "yaml-v2", false, "output YAML in the format of yaml.v2 that does not indent arrays in maps")
+	s.IntVar(&r.maxWidth, "max-width", 0, "output JSON arrays and objects in one line if the lines fit in the width")
+	s.BoolVar(&r.isDocStart, "yaml-doc-start", false, "output \"---\" before each YAML document")
+	s.BoolVar(&r.isDocEnd, "yaml-doc-end", false, "output \"...\" after each YAML document")
+	s.BoolVar(&r.isSeq, "seq", false, "output JSON text sequences (RFC 7464) prefixed by the record separator 0x1E")
+	s.BoolVar(&r.isNul, "nul", false, "terminate each result with NUL instead of the newline")
+	s.StringVar(&r.separator, "separator", "", "terminate each result with the string instead of the newline (escapes such as \\t are allowed)")
+	s.BoolVar(&r.isCount, "count", false, "print only the number of results")
+	s.BoolVar(&r.isFirst, "first", false, "stop after the first result across all inputs")
+	s.BoolVar(&r.isExitStatus, "exit-status", false, "exit with 1 if the last result is falsy by --truthiness, or 4 if there are no results")
+	s.StringVar(&r.truthiness, "truthiness", truthinessLoose, "truthiness of select() and --exit-status (loose: null, false, 0 and \"\" are false, jq: null and false are false)")
+	s.Int64Var(&r.seed, "seed", 0, "seed of the random source of sample() for deterministic results")
+	s.BoolVarP(&r.isKeepGoing, "keep-going", "k", false, "report the errors of the inputs and documents and continue with the rest, then exit with non-zero status")
+	s.BoolVar(&r.isPartial, "partial", false, "evaluate the valid leading documents of the inputs those have truncated or invalid data after them, and warn where the decoding failed")
+	s.BoolVar(&r.isLint, "lint", false, "report the suspicious parts of the query with the suggested fixes instead of evaluating it")
+	s.BoolVar(&r.isVerbose, "verbose", false, "log each stage to stderr")
+	s.BoolVar(&r.isVerbose, "trace", false, "alias --verbose")
+	s.BoolVar(&r.isStats, "stats", false, "print the numbers of documents, results and bytes read and the elapsed time to stderr")
+	s.BoolVar(&r.isSOPS, "sops", false, "keep SOPS encrypted values and metadata untouched by edits")
+	s.BoolVar(&r.isRPC, "rpc", false, "serve JSON-RPC 2.0 over stdio (parse, query, edit and format methods)")
 	s.BoolVarP(&r.isInputJSON, "input-json", "j", false, "alias --input-format json")
 	s.BoolVarP(&r.isInputYAML, "input-yaml", "y", false, "alias --input-format yaml")
 	s.BoolVarP(&r.isOutputJSON, "output-json", "J", false, "alias --output-format json")
 	s.BoolVarP(&r.isOutputYAML, "output-yaml", "Y", false, "alias --output-format yaml")
 	s.StringVarP(&r.outputFile, "output", "O", "", "output file")
+	s.StringVar(&r.outputPat, "output-pattern", "", "write each result to the file named by the golang text/template string")
 	s.StringVarP(&r.tmplText, "template", "t", "", "golang text/template string")
-	s.StringVarP(&r.inputFormat, "input-format", "i", "", "input format (json or yaml)")
-	s.StringVarP(&r.outputFormat, "output-format", "o", "", "output format (json or yaml, default json)")
+	s.StringVar(&r.escape, "escape", "", "escape the values of --template for the context (json, csv, shell or html)")
+	s.StringVar(&r.jsonTmpl, "jsontemplate", "", "construct each result by the object like '{\"name\": .name, \"env\": $env}' and output it as JSON")
+	s.StringVarP(&r.inputFormat, "input-format", "i", "", "input format (json, yaml, jsonc or frontmatter)")
+	s.StringVarP(&r.outputFormat, "output-format", "o", "", "output format (json, yaml, table, tree, dot, mermaid or shell, default json)")
+	s.StringSliceVar(&r.columns, "columns", nil, "columns of the table output (a,b,c)")
+	s.IntVar(&r.columnWidth, "column-width", 40, "truncate the cells of the table output and the values of the tree, dot and mermaid outputs wider than the width (0 means no limit)")
+	s.IntVar(&r.truncateStrs, "truncate-strings", 0, "cut the string values longer than the characters with the ellipsis marker like …(12345 bytes) (0 means no limit)")
+	s.IntVar(&r.maxOutBytes, "max-output-bytes", 0, "stop the output at the bytes with the ellipsis marker (0 means no limit)")
+	s.IntVar(&r.graphDepth, "graph-depth", 0, "omit the nodes deeper than the depth of the dot and mermaid outputs (0 means no limit)")
+	s.BoolVar(&r.isGraphVals, "graph-values", false, "show the values of the dot and mermaid outputs")
+	s.StringVar(&r.errorFormat, "error-format", errorFormatText, "error format (text or json)")
 	s.StringArrayVarP(&r.editExprs, "edit", "e", nil, "edit expression")
+	s.StringVar(&r.selector.Kind, "kind", "", "evaluate only the Kubernetes manifests of the kind")
+	s.StringVar(&r.selector.Name, "name", "", "evaluate only the Kubernetes manifests of the metadata.name")
+	s.StringVar(&r.selector.Namespace, "namespace", "", "evaluate only the Kubernetes manifests of the metadata.namespace")
+	s.StringVar(&r.defaultsFile, "defaults", "", "fill the missing keys of each document from the documents in the file")
+	s.StringVar(&r.keyCase, "keys", "", "convert the keys of each document to the case before the query (camel, snake or kebab)")
+	s.StringArrayVar(&r.slurpFiles, "slurpfile", nil, "bind $name to an array of the documents in the file (name=file, or name file as jq)")
+	s.StringArrayVar(&r.args, "arg", nil, "bind $name to the string (name=value)")
+	s.StringVar(&r.configFile, "config", "", "config file of the named queries invoked by @name (default $XDG_CONFIG_HOME/tq/config.yaml or ~/.config/tq/config.yaml)")
 	s.Usage = func() {
 		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", desc, usage)
 		fmt.Fprintln(r.stderr, "Flags:")
 		s.PrintDefaults()
 		fmt.Fprintf(r.stderr, "\n%s", examplesText)
 	}
-	return s.Parse(args[1:])
+	return s.Parse(joinSlurpFileArgs(args[1:]))
+}
+
+// slurpFileNameRegexp matches the name of $name.
+var slurpFileNameRegexp = regexp.MustCompile(`^\w+$`)
+
+// joinSlurpFileArgs joins the arguments "--slurpfile name file" of jq into
+// "--slurpfile name=file". The arguments after "--" are not joined.
+func joinSlurpFileArgs(args []string) []string {
+	joined := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			return append(joined, args[i:]...)
+		}
+		if args[i] == "--slurpfile" && i+2 < len(args) && slurpFileNameRegexp.MatchString(args[i+1]) {
+			joined = append(joined, args[i], args[i+1]+"="+args[i+2])
+			i += 2
+			continue
+		}
+		joined = append(joined, args[i])
+	}
+	return joined
+}
+
+func (r *runner) logf(format string, args ...interface{}) {
+	if r.isVerbose {
+		fmt.Fprintf(r.stderr, "[%s] %s\n", cmd, fmt.Sprintf(format, args...))
+	}
 }
 
 func (r *runner) close() {
@@ -180,28 +328,96 @@ func (r *runner) close() {
 func (r *runner) run(args []string) error {
 	defer r.close()
 
+	if len(args) > 1 {
+		switch args[1] {
+		case validateCmd:
+			return r.runValidate(args[1:])
+		case valuesCmd:
+			return r.runValues(args[1:])
+		case serveCmd:
+			return r.runServe(args[1:])
+		case splitCmd:
+			return r.runSplit(args[1:])
+		case joinCmd:
+			return r.runJoin(args[1:])
+		case statsCmd:
+			return r.runStats(args[1:])
+		case browseCmd:
+			return r.runBrowse(args[1:])
+		case applyCmd:
+			return r.runApply(args[1:])
+		case checkCmd:
+			return r.runCheck(args[1:])
+		case testCmd:
+			return r.runTest(args[1:])
+		}
+	}
 	if err := r.initFlagSet(args); err != nil {
 		return err
 	}
+	if err := checkErrorFormat(r.errorFormat); err != nil {
+		return err
+	}
 	if r.isVersion {
 		fmt.Fprintln(r.out, tree.VERSION)
 		return nil
 	}
+	if r.isRPC {
+		return tq.ServeRPC(context.Background(), os.Stdin, r.out)
+	}
 	if r.isHelp || (r.flagSet.Arg(0) == "" && len(r.editExprs) == 0) {
 		r.flagSet.Usage()
 		return nil
 	}
-	if r.tmplText != "" {
-		tmpl, err := template.New("").Parse(r.tmplText)
-		if err != nil {
-			return err
-		}
-		r.tmpl = tmpl
+	if (r.isCount || r.isFirst) && (r.isInplace || r.isDryRun || r.isDiff) {
+		return fmt.Errorf("--count and --first cannot be used with --inplace, --dry-run or --diff")
+	}
+	if (r.isSeq || r.isNul || r.separator != "") && (r.isInplace || r.isDryRun || r.isDiff) {
+		return fmt.Errorf("--seq, --nul and --separator cannot be used with --inplace, --dry-run or --diff")
+	}
+	if r.outputPat != "" && (r.outputFile != "" || r.isInplace || r.isDryRun || r.isDiff) {
+		return fmt.Errorf("--output-pattern cannot be used with --output, --inplace, --dry-run or --diff")
+	}
+	if f := r.output(); isDisplayFormat(f) && (r.isInplace || r.isDryRun || r.isDiff) {
+		return fmt.Errorf("--output-format %s cannot be used with --inplace, --dry-run or --diff", f)
+	}
+	if r.isPartial && (r.isInplace || r.isDryRun || r.isDiff) {
+		return fmt.Errorf("--partial cannot be used with --inplace, --dry-run or --diff")
+	}
+	if (r.truncateStrs > 0 || r.maxOutBytes > 0) && (r.isInplace || r.isDryRun || r.isDiff) {
+		return fmt.Errorf("--truncate-strings and --max-output-bytes cannot be used with --inplace, --dry-run or --diff")
+	}
+	if r.isNul && r.separator != "" {
+		return fmt.Errorf("--nul and --separator cannot be used together")
+	}
+	if r.tmplText != "" && r.jsonTmpl != "" {
+		return fmt.Errorf("--template and --jsontemplate cannot be used together")
+	}
+	if r.escape != "" && r.tmplText == "" {
+		return fmt.Errorf("--escape cannot be used without --template")
+	}
+	if err := r.setTruthiness(); err != nil {
+		return err
+	}
+	if err := r.loadArgs(); err != nil {
+		return err
+	}
+	if r.isLint {
+		return r.lintQuery()
+	}
+	if err := r.loadSlurpFiles(); err != nil {
+		return err
+	}
+	if err := r.loadDefaults(); err != nil {
+		return err
+	}
+	if err := r.initPipeline(); err != nil {
+		return err
 	}
 
 	var filenames []string
 	if args := r.flagSet.Args(); len(args) > 1 {
-		filenames = args[1:]
+		filenames = expandGlobs(args[1:])
 	}
 	if len(filenames) == 0 {
 		if term.IsTerminal(0) {
@@ -218,205 +434,377 @@ func (r *runner) run(args []string) error {
 		}
 		r.out = out
 	}
-	return r.evaluateInputFiles(newInputFiles(filenames))
+	if err := r.evaluateInputFiles(newInputFiles(filenames)); err != nil {
+		return err
+	}
+	if r.isCount {
+		fmt.Fprintln(r.out, r.pipeline.Results())
+	}
+	switch r.failures {
+	case 0:
+	case 1:
+		return fmt.Errorf("%w: 1 error", errInputsFailed)
+	default:
+		return fmt.Errorf("%w: %d errors", errInputsFailed, r.failures)
+	}
+	if r.isExitStatus {
+		return r.exitStatus()
+	}
+	return nil
 }
 
-func (r *runner) evaluateInputFiles(f *inputFiles) error {
-	in, err := f.nextReader()
+const (
+	truthinessLoose = "loose"
+	truthinessJQ    = "jq"
+)
+
+func (r *runner) setTruthiness() error {
+	switch r.truthiness {
+	case truthinessLoose:
+		r.truthy = tree.IsLooseTruthy
+	case truthinessJQ:
+		r.truthy = tree.IsTruthy
+	default:
+		return fmt.Errorf("unknown truthiness %q", r.truthiness)
+	}
+	return nil
+}
+
+// exitStatus returns the error of --exit-status by the last result.
+func (r *runner) exitStatus() error {
+	last := r.pipeline.LastResult()
+	if last == nil {
+		return exitStatusError(4)
+	}
+	if !r.truthy(last) {
+		return exitStatusError(1)
+	}
+	return nil
+}
+
+func (r *runner) initPipeline() error {
+	floatFormat, err := tree.ParseFloatFormat(r.floatFormat)
 	if err != nil {
-		if err == io.EOF {
-			return nil
-		}
 		return err
 	}
-	defer in.Close()
-
-	filename := f.filename
-	var inplaceTmp *os.File
-	if r.outputFile == "" && r.isInplace && !r.isSlurp && filename != filenameStdin {
-		inplaceTmp, err = os.CreateTemp("", "*.tq.tmp")
-		if err != nil {
-			return err
-		}
-		r.out = inplaceTmp
-		defer func() {
-			inplaceTmp.Close()
-			os.Remove(inplaceTmp.Name())
-		}()
-	}
-	if err := r.evaluate(in); err != nil {
-		if filename == filenameStdin {
-			filename = "STDIN"
-		}
-		return fmt.Errorf("failed to evaluate %s: %w", filename, err)
+	separator := r.separator
+	if r.isNul {
+		separator = "\x00"
+	} else if s, err := strconv.Unquote(`"` + separator + `"`); err == nil {
+		separator = s
 	}
-	if inplaceTmp != nil {
-		if _, err := inplaceTmp.Seek(0, io.SeekStart); err != nil {
-			return err
-		}
-		out, err := os.Create(filename)
-		if err != nil {
-			return err
-		}
-		defer out.Close()
-		if _, err := io.Copy(out, inplaceTmp); err != nil {
-			return err
-		}
+	keyCase, err := tree.ParseKeyCase(r.keyCase)
+	if err != nil {
+		return err
 	}
-	return r.evaluateInputFiles(f)
+	query, err := r.resolveQuery(r.flagSet.Arg(0))
+	if err != nil {
+		return err
+	}
+	opts := tq.Options{
+		Query:           query,
+		Edits:           r.editExprs,
+		InputFormat:     r.input(),
+		Partial:         r.isPartial,
+		OutputFormat:    r.output(),
+		Columns:         r.columns,
+		ColumnWidth:     r.columnWidth,
+		TruncateStrings: r.truncateStrs,
+		MaxOutputBytes:  r.maxOutBytes,
+		GraphDepth:      r.graphDepth,
+		GraphValues:     r.isGraphVals,
+		Expand:          r.isExpand,
+		Slurp:           r.isSlurp,
+		Raw:             r.isRaw,
+		Color:           r.isColor,
+		EncodeOptions: tree.EncodeOptions{
+			ASCII:           r.isASCII,
+			NoEscapeHTML:    r.isNoEscHTML,
+			FloatFormat:     floatFormat,
+			FloatPrecision:  r.floatPrec,
+			YAMLAlwaysQuote: r.isYAMLQuote,
+			YAMLSingleQuote: r.isYAMLSingle,
+			YAMLFlowWidth:   r.yamlFlow,
+			YAMLV2:          r.isYAMLV2,
+			MaxWidth:        r.maxWidth,
+		},
+		DocumentStart: r.isDocStart,
+		DocumentEnd:   r.isDocEnd,
+		Seq:           r.isSeq,
+		Separator:     separator,
+		SOPS:          r.isSOPS,
+		Template:      r.tmplText,
+		Escape:        r.escape,
+		JSONTemplate:  r.jsonTmpl,
+		OutputPattern: r.outputPat,
+		Variables:     r.vars,
+		Truthiness:    r.truthy,
+		Defaults:      r.defaults,
+		Keys:          keyCase,
+		CountOnly:     r.isCount,
+	}
+	if r.isFirst {
+		opts.Limit = 1
+	}
+	if r.flagSet.Changed("seed") {
+		opts.SampleSeed = &r.seed
+	}
+	if !r.selector.IsEmpty() {
+		opts.Filter = r.selector.Match
+	}
+	if r.isVerbose {
+		opts.Logf = r.logf
+	}
+	if r.isKeepGoing {
+		opts.OnDocumentError = r.keepGoing
+	}
+	pipeline, err := tq.NewRunner(opts)
+	if err != nil {
+		return err
+	}
+	r.pipeline = pipeline
+	return nil
 }
 
-func (r *runner) evaluate(in io.ReadSeekCloser) error {
+func (r *runner) input() tree.Format {
 	if r.inputFormat == "json" || r.isInputJSON {
-		return r.evaluateJSON(in)
+		return tree.FormatJSON
 	}
 	if r.inputFormat == "yaml" || r.isInputYAML {
-		return r.evaluateYAML(in)
+		return tree.FormatYAML
 	}
-	fns := []func(io.Reader) error{
-		r.evaluateJSON,
-		r.evaluateYAML,
-	}
-	var errs []string
-	for _, fn := range fns {
-		if _, err := in.Seek(0, io.SeekStart); err != nil {
-			return err
-		}
-		if err := fn(in); err != nil {
-			errs = append(errs, err.Error())
-			if !isDecodeError(err) {
-				break
-			}
-			continue
-		}
-		return nil
+	switch tree.Format(r.inputFormat) {
+	case tree.FormatJSONC, tree.FormatFrontMatter:
+		return tree.Format(r.inputFormat)
 	}
-	return errors.New(strings.Join(errs, "; "))
+	return ""
 }
 
-func (r *runner) evaluateJSON(in io.Reader) error {
-	dec := json.NewDecoder(in)
-	for dec.More() {
-		n, err := tree.DecodeJSON(dec)
-		if err != nil {
-			return &decodeError{err}
-		}
-		r.guessFormat = "json"
-		if err := r.evaluateNode(n); err != nil {
-			return err
-		}
+func (r *runner) output() tree.Format {
+	if r.outputFormat == "yaml" || r.isOutputYAML {
+		return tree.FormatYAML
 	}
-	if len(r.slurpResults) > 0 {
-		defer func() { r.slurpResults = nil }()
-		return r.output(r.slurpResults)
+	if r.outputFormat == "json" || r.isOutputJSON {
+		return tree.FormatJSON
 	}
-	return nil
+	if f := tree.Format(r.outputFormat); isDisplayFormat(f) {
+		return f
+	}
+	return ""
 }
 
-func (r *runner) evaluateYAML(in io.Reader) error {
-	dec := yaml.NewDecoder(in)
-	for {
-		n, err := tree.DecodeYAML(dec)
+// isDisplayFormat reports whether the format is only for output, so it
+// cannot be decoded.
+func isDisplayFormat(f tree.Format) bool {
+	switch f {
+	case tree.FormatTable, tree.FormatTree, tree.FormatDot, tree.FormatMermaid, tree.FormatShell:
+		return true
+	}
+	return false
+}
+
+func (r *runner) loadSlurpFiles() error {
+	for _, slurpFile := range r.slurpFiles {
+		name, filename, ok := strings.Cut(slurpFile, "=")
+		if !ok || name == "" || filename == "" {
+			return fmt.Errorf("invalid slurpfile %q", slurpFile)
+		}
+		docs, err := r.decodeFile(filename)
 		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return &decodeError{err}
+			return fmt.Errorf("failed to slurp %s: %w", filename, err)
 		}
-		r.guessFormat = "yaml"
-		if err := r.evaluateNode(n); err != nil {
-			return err
+		if r.vars == nil {
+			r.vars = tree.Map{}
 		}
-	}
-	if len(r.slurpResults) > 0 {
-		defer func() { r.slurpResults = nil }()
-		return r.output(r.slurpResults)
+		r.vars[name] = docs
 	}
 	return nil
 }
 
-func (r *runner) evaluateNode(node tree.Node) error {
-	for _, expr := range r.editExprs {
-		if err := tree.Edit(&node, expr); err != nil {
-			return err
-		}
-	}
-	expr := r.flagSet.Arg(0)
-	if expr == "" {
-		expr = "."
+// loadDefaults loads the documents of --defaults. The former documents take
+// precedence over the latter.
+func (r *runner) loadDefaults() error {
+	if r.defaultsFile == "" {
+		return nil
 	}
-	results, err := tree.Find(node, expr)
+	docs, err := r.decodeFile(r.defaultsFile)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to load defaults %s: %w", r.defaultsFile, err)
 	}
-	if len(results) == 0 {
-		return nil
+	for _, doc := range docs {
+		r.defaults = tree.ApplyDefaults(r.defaults, doc)
 	}
-	if r.isSlurp {
-		r.slurpResults = append(r.slurpResults, results...)
-		return nil
+	return nil
+}
+
+func (r *runner) decodeFile(filename string) (tree.Array, error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	ds, err := tree.DecodeDocuments(in, filename, r.input())
+	if err != nil {
+		return nil, err
 	}
-	if r.isExpand {
-		cb := func(_ interface{}, v tree.Node) error {
-			return r.output(v)
+	return append(tree.Array{}, ds.Nodes()...), nil
+}
+
+func (r *runner) evaluateInputFiles(f *inputFiles) error {
+	r.stats = stats{start: time.Now()}
+	if r.isStats {
+		defer r.printStats()
+	}
+	for !r.pipeline.Done() {
+		in, err := f.nextReader()
+		if err == nil {
+			err = r.evaluateInputFile(f.filename, in)
+			in.Close()
+		} else if err == io.EOF {
+			return nil
 		}
-		for _, result := range results {
-			if err := result.Each(cb); err != nil {
+		if err != nil {
+			if !r.isKeepGoing {
 				return err
 			}
+			r.printError(err)
+			r.failures++
 		}
-		return nil
 	}
-	for _, result := range results {
-		if err := r.output(result); err != nil {
-			return err
+	return nil
+}
+
+// keepGoing prints the error of the document of --keep-going and continues
+// with the following documents.
+func (r *runner) keepGoing(doc int, err error) error {
+	r.printError(&fileError{
+		filename: displayFilename(r.filename),
+		err:      fmt.Errorf("document %d: %w", doc, err),
+	})
+	r.failures++
+	return nil
+}
+
+func (r *runner) evaluateInputFile(filename string, in io.ReadSeekCloser) error {
+	r.logf("open %s", displayFilename(filename))
+	r.filename = filename
+	var n int
+	var err error
+	if filename != filenameStdin && (r.isDryRun || r.isDiff) {
+		n, err = r.evaluatePreview(filename, in)
+	} else if r.outputFile == "" && r.isInplace && filename != filenameStdin {
+		n, err = r.evaluateInplace(filename, in)
+	} else {
+		n, err = r.pipeline.Run(context.Background(), in, r.out)
+	}
+	var perr *tq.PartialError
+	if errors.As(err, &perr) {
+		line, column := errorPosition(in, err)
+		r.printPartial(displayFilename(filename), line, column, perr)
+		err = nil
+	}
+	if err != nil {
+		line, column := errorPosition(in, err)
+		return &fileError{
+			filename: displayFilename(filename),
+			line:     line,
+			column:   column,
+			err:      err,
 		}
 	}
+	r.logf("%d documents evaluated in %s", n, displayFilename(filename))
+	r.stats.files++
+	r.stats.documents += n
+	if size, err := in.Seek(0, io.SeekEnd); err == nil {
+		r.stats.bytes += size
+	}
 	return nil
 }
 
-func (r *runner) output(node tree.Node) error {
-	if r.isRaw && node.Type().IsValue() {
-		if _, err := fmt.Fprintln(r.out, node.Value().String()); err != nil {
-			return err
-		}
-		return nil
+func (r *runner) printStats() {
+	fmt.Fprintf(r.stderr, "[%s] stats: %d files, %d documents, %d results, %d bytes read in %v\n",
+		cmd, r.stats.files, r.stats.documents, r.pipeline.Results(), r.stats.bytes, time.Since(r.stats.start))
+}
+
+func displayFilename(filename string) string {
+	if filename == filenameStdin {
+		return "STDIN"
 	}
-	if r.tmpl != nil {
-		if err := r.tmpl.Execute(r.out, node); err != nil {
-			return err
-		}
-		if _, err := fmt.Fprintln(r.out); err != nil {
-			return err
-		}
-		return nil
+	return filename
+}
+
+// evaluatePreview outputs the updated contents (--dry-run) or the diff (--diff)
+// of the file without updating it.
+func (r *runner) evaluatePreview(filename string, in io.ReadSeekCloser) (int, error) {
+	buf := new(bytes.Buffer)
+	n, err := r.pipeline.Update(context.Background(), in, buf)
+	if err != nil {
+		return n, err
+	}
+	if !r.isDiff {
+		_, err := buf.WriteTo(r.out)
+		return n, err
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return n, err
+	}
+	orig, err := io.ReadAll(in)
+	if err != nil {
+		return n, err
+	}
+	// NOTE: The diff of UTF-16 is shown in UTF-8.
+	if orig, err = tree.ToUTF8(orig); err != nil {
+		return n, err
 	}
-	if r.outputFormat == "yaml" || r.isOutputYAML || r.guessFormat == "yaml" {
-		return r.outputYAML(node)
+	updated, err := tree.ToUTF8(buf.Bytes())
+	if err != nil {
+		return n, err
 	}
-	return r.outputJSON(node)
+	_, err = io.WriteString(r.out, unifiedDiff("a/"+filename, "b/"+filename, string(orig), string(updated)))
+	return n, err
 }
 
-func (r *runner) outputYAML(n tree.Node) error {
-	if r.outputYAMLCalled > 0 && !r.isInplace {
-		if _, err := fmt.Fprintln(r.out, "---"); err != nil {
-			return err
-		}
+// evaluateInplace evaluates in and writes the updated contents back to the
+// file by tree.ReplaceFile.
+func (r *runner) evaluateInplace(filename string, in io.ReadSeekCloser) (int, error) {
+	buf := new(bytes.Buffer)
+	n, err := r.pipeline.Update(context.Background(), in, buf)
+	if err != nil {
+		return n, err
 	}
-	r.outputYAMLCalled++
-	if r.isColor {
-		return tree.OutputColorYAML(r.out, n)
+	if r.backupSuffix != "" {
+		resolved, err := filepath.EvalSymlinks(filename)
+		if err != nil {
+			return n, err
+		}
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return n, err
+		}
+		if _, err := in.Seek(0, io.SeekStart); err != nil {
+			return n, err
+		}
+		if err := copyToFile(resolved+r.backupSuffix, in, info.Mode().Perm()); err != nil {
+			return n, err
+		}
 	}
-	return yaml.NewEncoder(r.out).Encode(n)
+	return n, tree.ReplaceFile(filename, func(w io.Writer) error {
+		_, err := buf.WriteTo(w)
+		return err
+	})
 }
 
-func (r *runner) outputJSON(n tree.Node) error {
-	if r.isColor {
-		return tree.OutputColorJSON(r.out, n)
+func copyToFile(filename string, in io.Reader, perm os.FileMode) error {
+	out, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
 	}
-	enc := json.NewEncoder(r.out)
-	enc.SetIndent("", "  ")
-	return enc.Encode(n)
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
 }
 
 func main() {
@@ -424,7 +812,11 @@ func main() {
 	defer r.close()
 
 	if err := r.run(os.Args); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		var serr exitStatusError
+		if errors.As(err, &serr) {
+			os.Exit(int(serr))
+		}
+		r.printError(err)
 		os.Exit(1)
 	}
 }
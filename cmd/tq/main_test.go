@@ -2,8 +2,14 @@ package main
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/jarxorg/io2"
@@ -114,6 +120,206 @@ func TestRun(t *testing.T) {
 		}, {
 			args: []string{".", "testdata/book-0.yaml", "testdata/book-0.yaml"},
 			want: mustReadFileString("testdata/book-0.yaml") + "---\n" + mustReadFileString("testdata/book-0.yaml"),
+		}, {
+			stdin: "testdata/empty-object.json",
+			args:  []string{"--slurpfile", "book=testdata/book-0.yaml", "$book[0]"},
+			want:  mustReadFileString("testdata/book-0.json"),
+		}, {
+			stdin: "testdata/empty-object.json",
+			args:  []string{"--slurpfile", "book", "testdata/book-0.yaml", "$book[0]"},
+			want:  mustReadFileString("testdata/book-0.json"),
+		}, {
+			stdin: "testdata/empty-object.json",
+			args:  []string{"--slurpfile", "store=testdata/store.json", "-e", ".book = $store[0].store.book[0]", ".book"},
+			want:  mustReadFileString("testdata/book-0.json"),
+		}, {
+			stdin:  "testdata/empty-object.json",
+			args:   []string{"--slurpfile", "testdata/store.json", "."},
+			errstr: `invalid slurpfile "testdata/store.json"`,
+		}, {
+			stdin:  "testdata/empty-object.json",
+			args:   []string{"--slurpfile", "x=testdata/invalid-json", "-i", "json", "."},
+			errstr: `failed to slurp testdata/invalid-json: invalid character 'i' looking for beginning of value`,
+		}, {
+			args: []string{"--kind", "Deployment", ".spec.replicas", "testdata/manifests.yaml"},
+			want: "2\n---\n1\n",
+		}, {
+			args: []string{"--kind", "Deployment", "--name", "web", ".spec.replicas", "testdata/manifests.yaml"},
+			want: "2\n",
+		}, {
+			args: []string{"--count", ".store.book[.price > 10]", "testdata/store.json", "testdata/store.yaml"},
+			want: "4\n",
+		}, {
+			args: []string{"--first", ".store.book[.price > 10].title", "testdata/store.json", "testdata/not-found.json"},
+			want: "\"Sword of Honour\"\n",
+		}, {
+			args:   []string{"--first", "-U", ".", "testdata/store.json"},
+			errstr: "--count and --first cannot be used with --inplace, --dry-run or --diff",
+		}, {
+			args: []string{"--exit-status", ".store.book[].select(.price < 9).title", "testdata/store.json"},
+			want: "\"Sayings of the Century\"\n\"Moby Dick\"\n",
+		}, {
+			args:   []string{"--exit-status", ".store.bicycle.size", "testdata/store.json"},
+			errstr: "exit status 4",
+		}, {
+			args:   []string{"--exit-status", ".store.book[0].price.numbers().select(. > 10)", "testdata/store.json"},
+			errstr: "exit status 4",
+		}, {
+			args:   []string{"--exit-status", ".store.book[0].title.test(\"^M\")", "testdata/store.json"},
+			errstr: "exit status 1",
+		}, {
+			args:   []string{"--exit-status", ".store.book[0].title.test(\"^Z\").count()", "testdata/store.json"},
+			errstr: "exit status 1",
+		}, {
+			args: []string{"--truthiness", "jq", "--exit-status", ".store.book[0].title.test(\"^Z\").count()", "testdata/store.json"},
+			want: "0\n",
+		}, {
+			args:   []string{"--truthiness", "lua", "."},
+			errstr: `unknown truthiness "lua"`,
+		}, {
+			args: []string{"-o", "table", "--columns", "title,price", "--column-width", "10", ".store.book", "testdata/store.json"},
+			want: "title       price\nSayings o…  8.95\nSword of …  12.99\nMoby Dick   8.99\nThe Lord …  22.99\n",
+		}, {
+			args:   []string{"-o", "table", "-U", ".store.book", "testdata/store.json"},
+			errstr: "--output-format table cannot be used with --inplace, --dry-run or --diff",
+		}, {
+			args: []string{"-o", "tree", ".store.bicycle", "testdata/store.json"},
+			want: ". (map, 2 keys)\n├── color (string): \"red\"\n└── price (number): 19.95\n",
+		}, {
+			args: []string{"-o", "mermaid", "--graph-depth", "1", "--graph-values", ".store", "testdata/store.json"},
+			want: "graph TD\n  n0[\". (map, 2 keys)\"]\n  n0 --> n1[\"bicycle (map, 2 keys)\"]\n  n0 --> n2[\"book (array, 4 items)\"]\n",
+		}, {
+			args: []string{"-o", "shell", ".store.bicycle", "testdata/store.json"},
+			want: "export color='red'\nexport price='19.95'\n",
+		}, {
+			args:   []string{"-o", "shell", ".store.book[0].title", "testdata/store.json"},
+			errstr: "failed to evaluate testdata/store.json: cannot format Sayings of the Century as shell variables",
+		}, {
+			args:   []string{"-o", "dot", "--dry-run", ".", "testdata/store.json"},
+			errstr: "--output-format dot cannot be used with --inplace, --dry-run or --diff",
+		}, {
+			args: []string{"--seed", "1", ".store.book.sample(2) | [0][].title", "testdata/store.json"},
+			want: "\"Moby Dick\"\n\"The Lord of the Rings\"\n",
+		}, {
+			stdin: "testdata/unicode.json",
+			args:  []string{"--ascii", "."},
+			want:  "{\n  \"text\": \"\\u003c\\u00e9\\u003e\"\n}\n",
+		}, {
+			stdin: "testdata/unicode.json",
+			args:  []string{"--no-escape-html", "."},
+			want:  "{\n  \"text\": \"<é>\"\n}\n",
+		}, {
+			stdin: "testdata/store.json",
+			args:  []string{"--float-format", "fixed", "--float-precision", "1", "-s", ".store.book[].price"},
+			want:  "[\n  8.9,\n  13.0,\n  9.0,\n  23.0\n]\n",
+		}, {
+			stdin:  "testdata/store.json",
+			args:   []string{"--float-format", "x", "."},
+			errstr: `unknown float format "x"`,
+		}, {
+			stdin: "testdata/store.json",
+			args:  []string{"-o", "yaml", "--yaml-always-quote", "--yaml-single-quote", ".store.bicycle"},
+			want:  "color: 'red'\nprice: 19.95\n",
+		}, {
+			stdin: "testdata/store.json",
+			args:  []string{"-o", "yaml", "--yaml-flow", ".store"},
+			want:  mustReadFileString("testdata/store-flow.yaml"),
+		}, {
+			stdin: "testdata/store.json",
+			args:  []string{"-o", "yaml", "--yaml-flow=20", ".store.bicycle"},
+			want:  "color: red\nprice: 19.95\n",
+		}, {
+			stdin: "testdata/store.json",
+			args:  []string{"--max-width", "40", ".store.bicycle"},
+			want:  "{\"color\": \"red\", \"price\": 19.95}\n",
+		}, {
+			stdin: "testdata/store.json",
+			args:  []string{"--defaults", "testdata/defaults.yaml", ".store.bicycle"},
+			want:  "{\n  \"color\": \"red\",\n  \"gears\": 21,\n  \"price\": 19.95\n}\n",
+		}, {
+			stdin: "testdata/store.json",
+			args:  []string{"--defaults", "testdata/defaults.yaml", "-r", ".store.owner"},
+			want:  "nobody\n",
+		}, {
+			stdin: "testdata/keys.json",
+			args:  []string{"--keys", "snake", ".home_address"},
+			want:  "{\n  \"zip_code\": \"100\"\n}\n",
+		}, {
+			stdin: "testdata/keys.json",
+			args:  []string{"--keys", "kebab", "-o", "yaml", "."},
+			want:  "home-address:\n  zip-code: \"100\"\nuser-name: one\n",
+		}, {
+			args:   []string{"--keys", "pascal", "."},
+			errstr: `unknown key case "pascal"`,
+		}, {
+			args: []string{
+				"--arg", "env=prod", "--jsontemplate", `{"title": .title, "env": $env}`,
+				".store.book[0]", "testdata/store.yaml",
+			},
+			want: "{\n  \"env\": \"prod\",\n  \"title\": \"Sayings of the Century\"\n}\n",
+		}, {
+			args:   []string{"-t", "{{.title}}", "--jsontemplate", `{"title": .title}`, ".", "testdata/store.json"},
+			errstr: "--template and --jsontemplate cannot be used together",
+		}, {
+			args: []string{"--escape", "shell", "-t", "echo {{.title}} {{.price}}", ".store.book[0:2]", "testdata/store.json"},
+			want: "echo 'Sayings of the Century' 8.95\necho 'Sword of Honour' 12.99\n",
+		}, {
+			args: []string{".name", "testdata/utf16.json"},
+			want: "\"café\"\n",
+		}, {
+			args: []string{"--partial", ".name", "testdata/truncated.json"},
+			want: "\"one\"\n\"two\"\nWarning: partially evaluated testdata/truncated.json at line 3, column 1: invalid json after 2 documents: unexpected EOF\n",
+		}, {
+			args:   []string{".name", "testdata/truncated.json"},
+			errstr: "failed to evaluate testdata/truncated.json: unexpected EOF; yaml: line 1: did not find expected <document start>",
+		}, {
+			args:   []string{"--partial", "-U", ".name", "testdata/truncated.json"},
+			errstr: "--partial cannot be used with --inplace, --dry-run or --diff",
+		}, {
+			args: []string{"--truncate-strings", "5", ".store.book[0].title", "testdata/store.json"},
+			want: "\"Sayin…(22 bytes)\"\n",
+		}, {
+			args: []string{"--max-output-bytes", "20", ".store.book[0].title", "testdata/store.json", "testdata/store.json"},
+			want: "\"Sayings of the Cent\n…(output truncated at 20 bytes)\n",
+		}, {
+			args:   []string{"--max-output-bytes", "20", "-U", ".", "testdata/store.json"},
+			errstr: "--truncate-strings and --max-output-bytes cannot be used with --inplace, --dry-run or --diff",
+		}, {
+			args:   []string{"--escape", "csv", ".", "testdata/store.json"},
+			errstr: "--escape cannot be used without --template",
+		}, {
+			args:   []string{"--defaults", "testdata/missing.yaml", "."},
+			errstr: "failed to load defaults testdata/missing.yaml: open testdata/missing.yaml: no such file or directory",
+		}, {
+			stdin: "testdata/store.json",
+			args:  []string{"--seq", ".store.bicycle[]"},
+			want:  "\x1e\"red\"\n\x1e19.95\n",
+		}, {
+			stdin: "testdata/store.json",
+			args:  []string{"-r", "--nul", ".store.bicycle[]"},
+			want:  "red\x0019.95\x00",
+		}, {
+			stdin: "testdata/store.json",
+			args:  []string{"-r", "--separator", `\t`, ".store.bicycle[]"},
+			want:  "red\t19.95\t",
+		}, {
+			args:   []string{"--nul", "--separator", ",", "."},
+			errstr: "--nul and --separator cannot be used together",
+		}, {
+			args:   []string{"--output-pattern", "{{.id}}.json", "-U", "."},
+			errstr: "--output-pattern cannot be used with --output, --inplace, --dry-run or --diff",
+		}, {
+			args:   []string{"--seq", "-U", ".", "testdata/store.json"},
+			errstr: "--seq, --nul and --separator cannot be used with --inplace, --dry-run or --diff",
+		}, {
+			args: []string{"-i", "jsonc", ".compilerOptions.strict", "testdata/tsconfig.jsonc"},
+			want: "true\n",
+		}, {
+			args: []string{"--sops", "-e", `.user = "root"`, ".user", "testdata/sops.yaml"},
+			want: "root\n",
+		}, {
+			args:   []string{"--sops", "-y", "-e", `.password = "x"`, ".", "testdata/sops.yaml"},
+			errstr: "failed to evaluate testdata/sops.yaml: cannot edit SOPS encrypted value at .password",
 		},
 	}
 	fn := func(i int) {
@@ -155,3 +361,417 @@ func TestRun(t *testing.T) {
 		fn(i)
 	}
 }
+
+func TestRun_Verbose(t *testing.T) {
+	stdinOrg := os.Stdin
+	defer func() { os.Stdin = stdinOrg }()
+
+	in, err := os.Open("testdata/store.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	os.Stdin = in
+
+	stderr := new(bytes.Buffer)
+	r := &runner{
+		stderr: io2.NopWriteCloser(stderr),
+		out:    io2.NopWriteCloser(io.Discard),
+	}
+	if err := r.run([]string{"tq", "--verbose", "-e", ".bicycle = null", ".store.book[.price > 10].title"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `[tq] open STDIN
+[tq] failed to decode as json: invalid character 's' looking for beginning of value
+[tq] decoded document 1 as yaml
+[tq] edited .bicycle = null
+[tq] query .store: 1 results
+[tq] query .book: 1 results
+[tq] query [.price > 10]: 2 results
+[tq] query .title: 2 results
+[tq] 1 documents evaluated in STDIN
+`
+	if got := stderr.String(); got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
+func TestRun_Stats(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	r := &runner{
+		stderr: io2.NopWriteCloser(stderr),
+		out:    io2.NopWriteCloser(io.Discard),
+	}
+	if err := r.run([]string{"tq", "--stats", "-x", ".store.book", "testdata/store.json", "testdata/store.yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	var size int64
+	for _, name := range []string{"testdata/store.json", "testdata/store.yaml"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size += info.Size()
+	}
+	want := regexp.MustCompile(fmt.Sprintf(`^\[tq\] stats: 2 files, 2 documents, 8 results, %d bytes read in \S+\n$`, size))
+	if got := stderr.String(); !want.MatchString(got) {
+		t.Errorf("got %q; want %v", got, want)
+	}
+}
+
+func TestRun_KeepGoing(t *testing.T) {
+	tests := []struct {
+		args   []string
+		want   string
+		stderr string
+		errstr string
+	}{
+		{
+			args: []string{"-k", "-e", ".spec.replicas -= 1", ".metadata.name", "testdata/manifests.yaml", "testdata/missing.json", "testdata/store.json"},
+			want: "web\n",
+			stderr: "Error: failed to evaluate testdata/manifests.yaml: document 1: cannot remove from .replicas\n" +
+				"Error: failed to evaluate testdata/manifests.yaml: document 3: cannot remove from .replicas\n" +
+				"Error: open testdata/missing.json: no such file or directory\n",
+			errstr: "some inputs failed: 3 errors",
+		}, {
+			args:   []string{"-k", "--error-format", "json", ".store.bicycle.color", "testdata/missing.json", "testdata/store.json"},
+			want:   "\"red\"\n",
+			stderr: `{"error":"open testdata/missing.json: no such file or directory"}` + "\n",
+			errstr: "some inputs failed: 1 error",
+		}, {
+			args: []string{"-k", ".store.bicycle.color", "testdata/store.json", "testdata/store.yaml"},
+			want: "\"red\"\nred\n",
+		},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(stderr),
+			out:    io2.NopWriteCloser(out),
+		}
+		err := r.run(append([]string{"tq"}, test.args...))
+		if test.errstr == "" && err != nil {
+			t.Errorf("tests[%d] %v", i, err)
+		} else if test.errstr != "" && (err == nil || err.Error() != test.errstr) {
+			t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+		if got := stderr.String(); got != test.stderr {
+			t.Errorf("tests[%d] got stderr %q; want %q", i, got, test.stderr)
+		}
+	}
+}
+
+func TestRun_OutputPattern(t *testing.T) {
+	dir := t.TempDir()
+	buf := new(bytes.Buffer)
+	r := &runner{
+		stderr: io2.NopWriteCloser(buf),
+		out:    io2.NopWriteCloser(buf),
+	}
+	if err := r.run([]string{"tq", "-x", "--output-pattern", dir + "/{{.category}}.json", ".store.book", "testdata/store.json"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("got output %q", buf.String())
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "reference.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "author": "Nigel Rees",
+  "category": "reference",
+  "price": 8.95,
+  "title": "Sayings of the Century"
+}
+`
+	if string(got) != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "fiction.json")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRun_RPC(t *testing.T) {
+	stdinOrg := os.Stdin
+	defer func() { os.Stdin = stdinOrg }()
+
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	req := `{"jsonrpc":"2.0","id":1,"method":"query","params":{"document":"{\"a\":1}","query":".a"}}` + "\n"
+	if _, err := stdin.WriteString(req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	os.Stdin = stdin
+
+	buf := new(bytes.Buffer)
+	r := &runner{
+		stderr: io2.NopWriteCloser(buf),
+		out:    io2.NopWriteCloser(buf),
+	}
+	if err := r.run([]string{"tq", "--rpc"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"jsonrpc":"2.0","id":1,"result":{"output":"1\n"}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
+func TestRun_Inplace(t *testing.T) {
+	tests := []struct {
+		files  map[string]string
+		args   []string
+		want   map[string]string
+		out    string
+		errstr string
+	}{
+		{
+			files: map[string]string{
+				"a.json": `{"id":1}`,
+				"b.json": `{"id":2}`,
+			},
+			args: []string{"-U", "-e", ".ok = true", ".", "a.json", "b.json"},
+			want: map[string]string{
+				"a.json": "{\n  \"id\": 1,\n  \"ok\": true\n}\n",
+				"b.json": "{\n  \"id\": 2,\n  \"ok\": true\n}\n",
+			},
+		}, {
+			files: map[string]string{
+				"a.yaml": "a:\n  id: 1\n---\nb:\n  id: 2\n---\na:\n  id: 3\n",
+			},
+			args: []string{"-U", ".a", "a.yaml"},
+			want: map[string]string{
+				"a.yaml": "id: 1\n---\nb:\n  id: 2\n---\nid: 3\n",
+			},
+		}, {
+			files: map[string]string{
+				"a.yaml": "id: 1\n---\nid: 2\n",
+				"b.yaml": "id: 3\n",
+			},
+			args: []string{"-U", "-s", ".id", "a.yaml", "b.yaml"},
+			want: map[string]string{
+				"a.yaml": "- 1\n- 2\n",
+				"b.yaml": "- 3\n",
+			},
+		}, {
+			files: map[string]string{
+				"a.md": "+++\ntitle = \"Hello\"\n+++\n# Hello\n",
+			},
+			args: []string{"-U", "-i", "frontmatter", "-e", ".draft = true", ".", "a.md"},
+			want: map[string]string{
+				"a.md": "+++\ndraft = true\ntitle = \"Hello\"\n+++\n# Hello\n",
+			},
+		}, {
+			files: map[string]string{
+				"a.yaml": "%YAML 1.1\n---\na: 1\n...\n",
+			},
+			args: []string{"-U", "-e", ".a = 2", ".", "a.yaml"},
+			want: map[string]string{
+				"a.yaml": "%YAML 1.1\n---\na: 2\n...\n",
+			},
+		}, {
+			files: map[string]string{
+				"a.yaml": "a: 1\n",
+			},
+			args: []string{"-U", "--yaml-doc-start", "--yaml-doc-end", "-e", ".a = 2", ".", "a.yaml"},
+			want: map[string]string{
+				"a.yaml": "---\na: 2\n...\n",
+			},
+		}, {
+			files: map[string]string{
+				"a.json": `{"id":1}`,
+			},
+			args: []string{"-U", "--backup", ".bak", ".id", "a.json"},
+			want: map[string]string{
+				"a.json":     "1\n",
+				"a.json.bak": `{"id":1}`,
+			},
+		}, {
+			files: map[string]string{
+				"a.json": `{"id":1}`,
+			},
+			args: []string{"--dry-run", "-e", ".ok = true", ".", "a.json"},
+			want: map[string]string{
+				"a.json": `{"id":1}`,
+			},
+			out: "{\n  \"id\": 1,\n  \"ok\": true\n}\n",
+		}, {
+			files: map[string]string{
+				"a.yaml": "a: 1\n---\nb:\n  name: two\n",
+			},
+			args: []string{"-U", "--diff", ".b", "a.yaml"},
+			want: map[string]string{
+				"a.yaml": "a: 1\n---\nb:\n  name: two\n",
+			},
+			out: "--- a/a.yaml\n+++ b/a.yaml\n@@ -1,4 +1,3 @@\n a: 1\n ---\n-b:\n-  name: two\n+name: two\n",
+		}, {
+			files: map[string]string{
+				"a.json": "{\n  \"id\": 1\n}\n",
+			},
+			args: []string{"--diff", ".", "a.json"},
+			want: map[string]string{
+				"a.json": "{\n  \"id\": 1\n}\n",
+			},
+		}, {
+			files: map[string]string{
+				"a.yaml": "a: 1\r\nb: |\r\n  x\r\n---\r\na: 3\r\n",
+			},
+			args: []string{"-U", "-e", ".a = 2", ".", "a.yaml"},
+			want: map[string]string{
+				"a.yaml": "a: 2\r\nb: |\r\n  x\r\n---\r\na: 2\r\n",
+			},
+		}, {
+			files: map[string]string{
+				"a.json": "{\r\n  \"a\": 1\r\n}\r\n",
+				"b.json": "{\"a\": 2}",
+			},
+			args: []string{"-U", "-e", ".ok = true", ".", "*.json"},
+			want: map[string]string{
+				"a.json": "{\r\n  \"a\": 1,\r\n  \"ok\": true\r\n}\r\n",
+				"b.json": "{\n  \"a\": 2,\n  \"ok\": true\n}\n",
+			},
+		}, {
+			files: map[string]string{
+				"a.json": `{"id":1}`,
+			},
+			args:   []string{"-U", "-i", "json", ".id", "a.json", "missing.json"},
+			errstr: "open missing.json: no such file or directory",
+			want: map[string]string{
+				"a.json": "1\n",
+			},
+		},
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for i, test := range tests {
+		dir := t.TempDir()
+		for name, data := range test.files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+
+		buf := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(buf),
+			out:    io2.NopWriteCloser(buf),
+		}
+		err := r.run(append([]string{"tq"}, test.args...))
+		if test.errstr != "" {
+			if err == nil {
+				t.Errorf("tests[%d] no error", i)
+			} else if err.Error() != test.errstr {
+				t.Errorf("tests[%d] error %s; want %s", i, err.Error(), test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.out {
+			t.Errorf("tests[%d] output %q; want %q", i, got, test.out)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if strings.HasSuffix(e.Name(), ".tmp") {
+				t.Errorf("tests[%d] temporary file %s remains", i, e.Name())
+			}
+		}
+		for name, want := range test.want {
+			got, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatalf("tests[%d] %v", i, err)
+			}
+			if string(got) != want {
+				t.Errorf("tests[%d] %s got %q; want %q", i, name, got, want)
+			}
+		}
+	}
+}
+
+func TestExpandGlobs(t *testing.T) {
+	tests := []struct {
+		filenames []string
+		want      []string
+	}{
+		{
+			filenames: []string{"testdata/store.*", "-", "testdata/usage"},
+			want:      []string{filepath.FromSlash("testdata/store.json"), filepath.FromSlash("testdata/store.yaml"), "-", "testdata/usage"},
+		}, {
+			filenames: []string{"testdata/book-[01]*.json"},
+			want:      []string{filepath.FromSlash("testdata/book-0.json"), filepath.FromSlash("testdata/book-1-3.json")},
+		}, {
+			filenames: []string{"testdata/missing*.json", "testdata/["},
+			want:      []string{"testdata/missing*.json", "testdata/["},
+		},
+	}
+	for i, test := range tests {
+		if got := expandGlobs(test.filenames); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestRun_InplacePreservesFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "a.json")
+	link := filepath.Join(dir, "link.json")
+	if err := os.WriteFile(name, []byte(`{"id":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(name, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(name, link); err != nil {
+		t.Skip(err)
+	}
+
+	r := &runner{
+		stderr: io2.NopWriteCloser(io.Discard),
+		out:    io2.NopWriteCloser(io.Discard),
+	}
+	if err := r.run([]string{"tq", "-U", ".id", link}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s is not a symlink", link)
+	}
+	if info, err = os.Stat(name); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Mode().Perm(), os.FileMode(0600); got != want {
+		t.Errorf("mode got %v; want %v", got, want)
+	}
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1\n"; string(got) != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
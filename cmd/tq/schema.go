@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jarxorg/tree"
+)
+
+// validateSchema validates n with the subset of JSON Schema and returns the problems.
+// The supported keywords are type, enum, const, properties, required,
+// additionalProperties, items, minimum, maximum, minLength, maxLength, pattern,
+// minItems and maxItems.
+func validateSchema(schema, n tree.Node, path string) []validateProblem {
+	sm := schema.Map()
+	if sm == nil {
+		return nil
+	}
+	var problems []validateProblem
+	add := func(format string, args ...interface{}) {
+		p := path
+		if p == "" {
+			p = "."
+		}
+		problems = append(problems, validateProblem{path: p, msg: fmt.Sprintf(format, args...)})
+	}
+
+	if t := sm.Get("type"); !t.IsNil() {
+		var types []string
+		if t.Type().IsArray() {
+			for _, tt := range t.Array() {
+				types = append(types, tt.Value().String())
+			}
+		} else {
+			types = []string{t.Value().String()}
+		}
+		if !matchesSchemaType(types, n) {
+			add("expected %s but %s", strings.Join(types, " or "), schemaTypeOf(n))
+			return problems
+		}
+	}
+	if e := sm.Get("enum"); !e.IsNil() && e.Type().IsArray() {
+		found := false
+		for _, v := range e.Array() {
+			if reflect.DeepEqual(v, n) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("must be one of %s", encodeJSONString(e))
+		}
+	}
+	if c, ok := sm["const"]; ok && !reflect.DeepEqual(c, n) {
+		add("must be %s", encodeJSONString(c))
+	}
+
+	switch n.Type() {
+	case tree.TypeMap:
+		m := n.Map()
+		if req := sm.Get("required"); !req.IsNil() {
+			for _, k := range req.Array() {
+				if !m.Has(k.Value().String()) {
+					add("missing required key %q", k.Value().String())
+				}
+			}
+		}
+		props := sm.Get("properties").Map()
+		additional := sm.Get("additionalProperties")
+		for _, k := range m.Keys() {
+			if ps := props.Get(k); !ps.IsNil() {
+				problems = append(problems, validateSchema(ps, m[k], path+"."+k)...)
+				continue
+			}
+			if additional.IsNil() {
+				continue
+			}
+			if additional.Type().IsBoolValue() {
+				if !additional.Value().Bool() {
+					add("unexpected key %q", k)
+				}
+				continue
+			}
+			problems = append(problems, validateSchema(additional, m[k], path+"."+k)...)
+		}
+	case tree.TypeArray:
+		a := n.Array()
+		if v := sm.Get("minItems"); !v.IsNil() && len(a) < v.Value().Int() {
+			add("must have at least %d items", v.Value().Int())
+		}
+		if v := sm.Get("maxItems"); !v.IsNil() && len(a) > v.Value().Int() {
+			add("must have at most %d items", v.Value().Int())
+		}
+		if items := sm.Get("items"); !items.IsNil() {
+			for i, v := range a {
+				problems = append(problems, validateSchema(items, v, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case tree.TypeStringValue:
+		s := n.Value().String()
+		l := utf8.RuneCountInString(s)
+		if v := sm.Get("minLength"); !v.IsNil() && l < v.Value().Int() {
+			add("must be at least %d characters", v.Value().Int())
+		}
+		if v := sm.Get("maxLength"); !v.IsNil() && l > v.Value().Int() {
+			add("must be at most %d characters", v.Value().Int())
+		}
+		if v := sm.Get("pattern"); !v.IsNil() {
+			re, err := regexp.Compile(v.Value().String())
+			if err != nil {
+				add("invalid pattern %q: %v", v.Value().String(), err)
+			} else if !re.MatchString(s) {
+				add("must match %q", v.Value().String())
+			}
+		}
+	case tree.TypeNumberValue:
+		f := n.Value().Float64()
+		if v := sm.Get("minimum"); !v.IsNil() && f < v.Value().Float64() {
+			add("must be >= %v", v.Value().Float64())
+		}
+		if v := sm.Get("maximum"); !v.IsNil() && f > v.Value().Float64() {
+			add("must be <= %v", v.Value().Float64())
+		}
+	}
+	return problems
+}
+
+func matchesSchemaType(types []string, n tree.Node) bool {
+	t := schemaTypeOf(n)
+	for _, tt := range types {
+		if tt == t || (tt == "number" && t == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func schemaTypeOf(n tree.Node) string {
+	switch {
+	case n == nil || n.IsNil():
+		return "null"
+	case n.Type().IsMap():
+		return "object"
+	case n.Type().IsArray():
+		return "array"
+	case n.Type().IsStringValue():
+		return "string"
+	case n.Type().IsBoolValue():
+		return "boolean"
+	case n.Type().IsNumberValue():
+		if f := n.Value().Float64(); f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	return "unknown"
+}
+
+func encodeJSONString(n tree.Node) string {
+	b, err := tree.MarshalJSON(n)
+	if err != nil {
+		return fmt.Sprintf("%v", n)
+	}
+	return string(b)
+}
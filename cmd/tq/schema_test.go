@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jarxorg/tree"
+)
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		schema tree.Node
+		n      tree.Node
+		want   []string
+	}{
+		{
+			schema: tree.Map{"type": tree.ToValue("string")},
+			n:      tree.ToValue("a"),
+		}, {
+			schema: tree.Map{"type": tree.ToArrayValues("string", "null")},
+			n:      tree.Nil,
+		}, {
+			schema: tree.Map{"type": tree.ToValue("number")},
+			n:      tree.ToValue(1),
+		}, {
+			schema: tree.Map{"type": tree.ToValue("integer")},
+			n:      tree.ToValue(1.5),
+			want:   []string{".: expected integer but number"},
+		}, {
+			schema: tree.Map{"enum": tree.ToArrayValues("a", "b")},
+			n:      tree.ToValue("c"),
+			want:   []string{`.: must be one of ["a","b"]`},
+		}, {
+			schema: tree.Map{"const": tree.Nil},
+			n:      tree.ToValue(false),
+			want:   []string{".: must be null"},
+		}, {
+			schema: tree.Map{
+				"required": tree.ToArrayValues("a", "b"),
+				"properties": tree.Map{
+					"a": tree.Map{"type": tree.ToValue("boolean")},
+				},
+				"additionalProperties": tree.Map{"type": tree.ToValue("number")},
+			},
+			n: tree.Map{"a": tree.ToValue(1), "c": tree.ToValue("x")},
+			want: []string{
+				`.: missing required key "b"`,
+				".a: expected boolean but integer",
+				".c: expected number but string",
+			},
+		}, {
+			schema: tree.Map{
+				"minItems": tree.ToValue(3),
+				"items": tree.Map{
+					"minLength": tree.ToValue(2),
+					"maxLength": tree.ToValue(3),
+					"pattern":   tree.ToValue("^[a-z]+$"),
+				},
+			},
+			n: tree.ToArrayValues("ab", "abcd", "A1"),
+			want: []string{
+				"[1]: must be at most 3 characters",
+				`[2]: must match "^[a-z]+$"`,
+			},
+		}, {
+			schema: tree.Map{"maxItems": tree.ToValue(1), "items": tree.Map{"minimum": tree.ToValue(0), "maximum": tree.ToValue(10)}},
+			n:      tree.ToArrayValues(-1, 11),
+			want: []string{
+				".: must have at most 1 items",
+				"[0]: must be >= 0",
+				"[1]: must be <= 10",
+			},
+		},
+	}
+	for i, test := range tests {
+		var got []string
+		for _, p := range validateSchema(test.schema, test.n, "") {
+			got = append(got, p.path+": "+p.msg)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jarxorg/tree/tq"
+	"github.com/spf13/pflag"
+)
+
+const (
+	serveCmd          = "serve"
+	serveDesc         = "Serve runs an HTTP server that transforms the posted document with the same semantics as " + cmd + "."
+	serveUsage        = cmd + " " + serveCmd + " [flags]"
+	serveExamplesText = `Examples:
+  % tq serve --listen :8080
+  % curl -X POST -d '{"colors": ["red", "green"]}' 'http://localhost:8080/?query=.colors[0]'
+  "red"
+
+  % curl -X POST -d '{}' 'http://localhost:8080/?edit=.colors+%3D+["red"]&output-format=yaml'
+  colors:
+  - red
+`
+)
+
+const (
+	serveReadHeaderTimeout = 10 * time.Second
+	serveReadTimeout       = time.Minute
+	serveTimeout           = 10 * time.Second
+)
+
+// listenAndServe is replaced in tests.
+var listenAndServe = func(srv *http.Server) error {
+	return srv.ListenAndServe()
+}
+
+func (r *runner) runServe(args []string) error {
+	var isHelp bool
+	var listen string
+	h := &tq.Handler{}
+
+	s := pflag.NewFlagSet(args[0], pflag.ExitOnError)
+	s.SetOutput(r.stderr)
+	s.BoolVarP(&isHelp, "help", "h", false, "help for "+serveCmd)
+	s.StringVar(&listen, "listen", ":8080", "address to listen on")
+	s.Int64Var(&h.MaxBodyBytes, "max-body-bytes", tq.DefaultMaxBodyBytes, "limit of the request body size")
+	s.DurationVar(&h.ExecOptions.Timeout, "timeout", serveTimeout, "limit of the evaluation time of each request (0 means no limit)")
+	s.IntVar(&h.ExecOptions.MaxResults, "max-results", 0, "limit of the number of the results of each query (0 means no limit)")
+	s.IntVar(&h.ExecOptions.MaxDepth, "max-depth", 0, "limit of the depth visited by recursive queries (0 means no limit)")
+	s.Usage = func() {
+		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", serveDesc, serveUsage)
+		fmt.Fprintln(r.stderr, "Flags:")
+		s.PrintDefaults()
+		fmt.Fprintf(r.stderr, "\n%s", serveExamplesText)
+	}
+	if err := s.Parse(args[1:]); err != nil {
+		return err
+	}
+	if isHelp {
+		s.Usage()
+		return nil
+	}
+	srv := &http.Server{
+		Addr:              listen,
+		Handler:           h,
+		ReadHeaderTimeout: serveReadHeaderTimeout,
+		ReadTimeout:       serveReadTimeout,
+	}
+	if h.ExecOptions.Timeout > 0 {
+		// NOTE: WriteTimeout starts when the request header is read.
+		srv.WriteTimeout = serveReadTimeout + h.ExecOptions.Timeout
+	}
+	fmt.Fprintf(r.stderr, "listening on %s\n", listen)
+	return listenAndServe(srv)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarxorg/io2"
+	"github.com/jarxorg/tree"
+	"github.com/jarxorg/tree/tq"
+)
+
+func TestRunServe(t *testing.T) {
+	orig := listenAndServe
+	defer func() { listenAndServe = orig }()
+
+	var got *http.Server
+	listenAndServe = func(srv *http.Server) error {
+		got = srv
+		return nil
+	}
+
+	stderr := new(bytes.Buffer)
+	r := &runner{
+		stderr: io2.NopWriteCloser(stderr),
+		out:    io2.NopWriteCloser(new(bytes.Buffer)),
+	}
+	args := []string{"tq", "serve", "--listen", ":9090", "--max-body-bytes", "1024", "--timeout", "2s", "--max-results", "100"}
+	if err := r.run(args); err != nil {
+		t.Fatal(err)
+	}
+	if got.Addr != ":9090" {
+		t.Errorf("got addr %q; want %q", got.Addr, ":9090")
+	}
+	if got.ReadHeaderTimeout != serveReadHeaderTimeout || got.ReadTimeout != serveReadTimeout {
+		t.Errorf("got read timeouts %v, %v", got.ReadHeaderTimeout, got.ReadTimeout)
+	}
+	if want := serveReadTimeout + 2*time.Second; got.WriteTimeout != want {
+		t.Errorf("got write timeout %v; want %v", got.WriteTimeout, want)
+	}
+	h, ok := got.Handler.(*tq.Handler)
+	if !ok {
+		t.Fatalf("got handler %#v", got.Handler)
+	}
+	if h.MaxBodyBytes != 1024 {
+		t.Errorf("got max body bytes %d; want 1024", h.MaxBodyBytes)
+	}
+	if want := (tree.ExecOptions{MaxResults: 100, Timeout: 2 * time.Second}); h.ExecOptions != want {
+		t.Errorf("got exec options %+v; want %+v", h.ExecOptions, want)
+	}
+	if got, want := stderr.String(), "listening on :9090\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
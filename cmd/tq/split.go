@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/template"
+
+	"github.com/jarxorg/tree"
+	"github.com/jarxorg/tree/tq"
+	"github.com/spf13/pflag"
+)
+
+const (
+	splitCmd          = "split"
+	splitDesc         = "Split writes each result of the query of the documents to a separate file in the directory."
+	splitUsage        = cmd + " " + splitCmd + " [flags] [file] [dir]"
+	splitExamplesText = `Examples:
+  % tq split --by '.items[]' big.json out/
+  % tq split --name '{{.kind}}-{{.metadata.name}}.yaml' manifests.yaml out/
+`
+	joinCmd          = "join"
+	joinDesc         = "Join writes the documents of the files as a multi-document stream or an array."
+	joinUsage        = cmd + " " + joinCmd + " [flags] [file...]"
+	joinExamplesText = `Examples:
+  % tq join out/*.json -o yaml
+  % tq join -s out/*.json > big.json
+`
+)
+
+func (r *runner) runSplit(args []string) error {
+	var isHelp bool
+	var by, nameText string
+
+	s := pflag.NewFlagSet(args[0], pflag.ExitOnError)
+	s.SetOutput(r.stderr)
+	s.BoolVarP(&isHelp, "help", "h", false, "help for "+splitCmd)
+	s.StringVar(&by, "by", ".", "query of the results written to each file")
+	s.StringVar(&nameText, "name", "", "golang text/template string of the filenames (default the zero-padded index)")
+	s.StringVarP(&r.inputFormat, "input-format", "i", "", "input format (json or yaml, default guessed by file)")
+	s.StringVarP(&r.outputFormat, "output-format", "o", "", "output format (json or yaml, default the input format)")
+	s.Usage = func() {
+		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", splitDesc, splitUsage)
+		fmt.Fprintln(r.stderr, "Flags:")
+		s.PrintDefaults()
+		fmt.Fprintf(r.stderr, "\n%s", splitExamplesText)
+	}
+	if err := s.Parse(args[1:]); err != nil {
+		return err
+	}
+	if isHelp || s.NArg() != 2 {
+		s.Usage()
+		return nil
+	}
+	if _, err := tree.ParseQuery(by); err != nil {
+		return err
+	}
+	var tmpl *template.Template
+	if nameText != "" {
+		var err error
+		tmpl, err = template.New("").Option("missingkey=error").Parse(nameText)
+		if err != nil {
+			return err
+		}
+	}
+	filename, dir := s.Arg(0), s.Arg(1)
+
+	ds, err := r.decodeDocuments(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", displayFilename(filename), err)
+	}
+	var parts tree.DocumentSet
+	for _, d := range ds {
+		results, err := tree.Find(d.Node, by)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			format := r.output()
+			if format == "" {
+				format = d.Format
+			}
+			parts = append(parts, &tree.Document{Node: tree.OrNil(result), Format: format})
+		}
+	}
+	width := len(strconv.Itoa(len(parts) - 1))
+	for i, d := range parts {
+		name := fmt.Sprintf("%0*d.%s", width, i, d.Format)
+		if tmpl != nil {
+			buf := new(bytes.Buffer)
+			if err := tmpl.Execute(buf, d.Node); err != nil {
+				return err
+			}
+			name = buf.String()
+			if !tq.IsLocalPath(name) {
+				return fmt.Errorf("invalid filename %q", name)
+			}
+			if f := tree.GuessFormat(name); f != "" && r.output() == "" {
+				d.Format = f
+			}
+		}
+		d.Source = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(d.Source), 0o755); err != nil {
+			return err
+		}
+	}
+	return parts.Save()
+}
+
+// decodeDocuments decodes the documents of the file or stdin with the
+// input format.
+func (r *runner) decodeDocuments(filename string) (tree.DocumentSet, error) {
+	var in io.Reader = os.Stdin
+	if filename != filenameStdin {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+	return tree.DecodeDocuments(in, filename, r.input())
+}
+
+func (r *runner) runJoin(args []string) error {
+	var isHelp bool
+
+	s := pflag.NewFlagSet(args[0], pflag.ExitOnError)
+	s.SetOutput(r.stderr)
+	s.BoolVarP(&isHelp, "help", "h", false, "help for "+joinCmd)
+	s.BoolVarP(&r.isSlurp, "slurp", "s", false, "join the documents into an array")
+	s.StringVarP(&r.inputFormat, "input-format", "i", "", "input format (json or yaml, default guessed by file)")
+	s.StringVarP(&r.outputFormat, "output-format", "o", "", "output format (json or yaml, default the format of the first file)")
+	s.Usage = func() {
+		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", joinDesc, joinUsage)
+		fmt.Fprintln(r.stderr, "Flags:")
+		s.PrintDefaults()
+		fmt.Fprintf(r.stderr, "\n%s", joinExamplesText)
+	}
+	if err := s.Parse(args[1:]); err != nil {
+		return err
+	}
+	if isHelp || s.NArg() == 0 {
+		s.Usage()
+		return nil
+	}
+
+	var ds tree.DocumentSet
+	for _, filename := range s.Args() {
+		fds, err := r.decodeDocuments(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", displayFilename(filename), err)
+		}
+		ds = append(ds, fds...)
+	}
+	if r.isSlurp && len(ds) > 0 {
+		ds = tree.DocumentSet{{Node: tree.Array(ds.Nodes()), Format: ds[0].Format}}
+	}
+	return ds.Encode(r.out, r.output())
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/jarxorg/io2"
+)
+
+func TestRunSplit(t *testing.T) {
+	tests := []struct {
+		args   []string
+		files  map[string]string
+		errstr string
+	}{
+		{
+			args: []string{"--by", ".store.book[]", "testdata/store.json"},
+			files: map[string]string{
+				"0.json": "{\n  \"author\": \"Nigel Rees\",\n  \"category\": \"reference\",\n  \"price\": 8.95,\n  \"title\": \"Sayings of the Century\"\n}\n",
+				"1.json": "",
+				"2.json": "",
+				"3.json": "",
+			},
+		}, {
+			args: []string{"--name", "{{.kind}}/{{.metadata.name}}.yaml", "testdata/manifests.yaml"},
+			files: map[string]string{
+				"Deployment/web.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 2\n",
+				"Deployment/db.yaml":  "",
+				"Service/web.yaml":    "",
+			},
+		}, {
+			args: []string{"-o", "json", "--by", ".metadata", "testdata/manifests.yaml"},
+			files: map[string]string{
+				"0.json": "{\n  \"name\": \"web\"\n}\n",
+				"1.json": "",
+				"2.json": "",
+			},
+		}, {
+			args:   []string{"--name", "{{.id}}", "testdata/manifests.yaml"},
+			errstr: `template: :1:2: executing "" at <.id>: map has no entry for key "id"`,
+		}, {
+			args:   []string{"--name", "{{.metadata.name}}/../../{{.kind}}.yaml", "testdata/manifests.yaml"},
+			errstr: `invalid filename "web/../../Deployment.yaml"`,
+		}, {
+			args:   []string{"--name", "/{{.metadata.name}}.yaml", "testdata/manifests.yaml"},
+			errstr: `invalid filename "/web.yaml"`,
+		}, {
+			args:   []string{"--by", ".a[", "testdata/manifests.yaml"},
+			errstr: `syntax error: no right brackets: ".a["`,
+		},
+	}
+	for i, test := range tests {
+		dir := t.TempDir()
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(new(bytes.Buffer)),
+		}
+		err := r.run(append([]string{"tq", "split"}, append(test.args, dir)...))
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		var got, want []string
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				rel, _ := filepath.Rel(dir, path)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			return err
+		})
+		for name, content := range test.files {
+			want = append(want, name)
+			if content == "" {
+				continue
+			}
+			b, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatalf("tests[%d] %v", i, err)
+			}
+			if string(b) != content {
+				t.Errorf("tests[%d] %s got %q; want %q", i, name, b, content)
+			}
+		}
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("tests[%d] got files %v; want %v", i, got, want)
+		}
+	}
+}
+
+func TestRunJoin(t *testing.T) {
+	tests := []struct {
+		args   []string
+		want   string
+		errstr string
+	}{
+		{
+			args: []string{"testdata/book-0.json", "testdata/book-0.yaml"},
+			want: "{\n  \"author\": \"Nigel Rees\",\n  \"category\": \"reference\",\n  \"price\": 8.95,\n  \"title\": \"Sayings of the Century\"\n}\n" +
+				"{\n  \"author\": \"Nigel Rees\",\n  \"category\": \"reference\",\n  \"price\": 8.95,\n  \"title\": \"Sayings of the Century\"\n}\n",
+		}, {
+			args: []string{"-o", "yaml", "testdata/manifests.yaml", "testdata/book-0.json"},
+			want: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 2\n---\n" +
+				"apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n---\n" +
+				"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: db\nspec:\n  replicas: 1\n---\n" +
+				"author: Nigel Rees\ncategory: reference\nprice: 8.95\ntitle: Sayings of the Century\n",
+		}, {
+			args: []string{"-s", "-o", "json", "testdata/null", "testdata/empty-object.json"},
+			want: "[\n  null,\n  {}\n]\n",
+		}, {
+			args:   []string{"testdata/not-found.json"},
+			errstr: "failed to read testdata/not-found.json: open testdata/not-found.json: no such file or directory",
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(buf),
+		}
+		err := r.run(append([]string{"tq", "join"}, test.args...))
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jarxorg/tree"
+	"github.com/spf13/pflag"
+)
+
+const (
+	statsCmd          = "stats"
+	statsDesc         = "Stats prints the statistics of each document: the numbers of nodes per type, the max depth, the key cardinalities and the largest subtrees."
+	statsUsage        = cmd + " " + statsCmd + " [flags] [file...]"
+	statsExamplesText = `Examples:
+  % tq stats big.json
+  % cat big.json | tq stats -o json
+`
+)
+
+func (r *runner) runStats(args []string) error {
+	var isHelp bool
+
+	s := pflag.NewFlagSet(args[0], pflag.ExitOnError)
+	s.SetOutput(r.stderr)
+	s.BoolVarP(&isHelp, "help", "h", false, "help for "+statsCmd)
+	s.StringVarP(&r.inputFormat, "input-format", "i", "", "input format (json or yaml, default guessed by file)")
+	s.StringVarP(&r.outputFormat, "output-format", "o", "yaml", "output format (json or yaml)")
+	s.Usage = func() {
+		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", statsDesc, statsUsage)
+		fmt.Fprintln(r.stderr, "Flags:")
+		s.PrintDefaults()
+		fmt.Fprintf(r.stderr, "\n%s", statsExamplesText)
+	}
+	if err := s.Parse(args[1:]); err != nil {
+		return err
+	}
+	if isHelp {
+		s.Usage()
+		return nil
+	}
+	format := r.output()
+	if format == "" {
+		return fmt.Errorf("unknown output format %q", r.outputFormat)
+	}
+	filenames := s.Args()
+	if len(filenames) == 0 {
+		filenames = []string{filenameStdin}
+	}
+
+	var results tree.DocumentSet
+	for _, filename := range filenames {
+		ds, err := r.decodeDocuments(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", displayFilename(filename), err)
+		}
+		for _, d := range ds {
+			n, err := tree.MarshalViaJSON(tree.Stats(d.Node))
+			if err != nil {
+				return err
+			}
+			m := n.Map()
+			m["file"] = tree.StringValue(displayFilename(filename))
+			m["document"] = tree.NumberValue(d.Index)
+			results = append(results, &tree.Document{Node: m})
+		}
+	}
+	return results.Encode(r.out, format)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jarxorg/io2"
+)
+
+func TestRunStats(t *testing.T) {
+	tests := []struct {
+		args   []string
+		want   string
+		errstr string
+	}{
+		{
+			args: []string{"-o", "json", "testdata/null"},
+			want: `{
+  "distinctKeys": 0,
+  "document": 0,
+  "file": "testdata/null",
+  "largest": null,
+  "maxDepth": 0,
+  "nodes": 1,
+  "topKeys": null,
+  "types": {
+    "null": 1
+  }
+}
+`,
+		}, {
+			args: []string{"testdata/book-1-3.json"},
+			want: "distinctKeys: 5\ndocument: 0\nfile: testdata/book-1-3.json\nlargest:\n" +
+				"  - nodes: 6\n    path: '[1]'\n  - nodes: 5\n    path: '[0]'\n" +
+				"maxDepth: 2\nnodes: 12\ntopKeys:\n" +
+				"  - count: 2\n    key: author\n  - count: 2\n    key: category\n  - count: 2\n    key: price\n" +
+				"  - count: 2\n    key: title\n  - count: 1\n    key: isbn\n" +
+				"types:\n  array: 1\n  map: 2\n  number: 2\n  string: 7\n",
+		}, {
+			args:   []string{"-o", "x", "testdata/null"},
+			errstr: `unknown output format "x"`,
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(buf),
+		}
+		err := r.run(append([]string{"tq", "stats"}, test.args...))
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jarxorg/tree"
+	"github.com/jarxorg/tree/tq"
+	"github.com/spf13/pflag"
+)
+
+const (
+	testCmd          = "test"
+	testDesc         = "Test runs the test cases of the queries and the edits, and reports the cases whose results differ from the expected output."
+	testUsage        = cmd + " " + testCmd + " [flags] [cases...]"
+	testExamplesText = `Examples:
+  % cat cases.yaml
+  tests:
+    - name: first title
+      input: {"books": [{"title": "a"}, {"title": "b"}]}
+      query: .books[0].title
+      output: a
+    - name: images
+      inputFile: deployment.yaml
+      query: "@images"
+      outputs: [app:1, proxy:2]
+    - name: edit
+      input: {"a": 1}
+      edits: [.b = 2]
+      output: {"a": 1, "b": 2}
+  % tq test cases.yaml
+  PASS: 3 cases
+`
+)
+
+var errTestFailed = errors.New("test failed")
+
+// testCase is a case of tq test.
+type testCase struct {
+	name string
+	// dir is the directory of the cases file that inputFile is relative to.
+	dir       string
+	input     tree.Node
+	inputFile string
+	query     string
+	edits     []string
+	args      tree.Map
+	// output is the expected single result and outputs are the expected
+	// results.
+	output  tree.Node
+	outputs tree.Array
+	// err is the substring of the expected error.
+	err string
+}
+
+func (r *runner) runTest(args []string) error {
+	var isHelp, isVerbose bool
+
+	s := pflag.NewFlagSet(args[0], pflag.ExitOnError)
+	s.SetOutput(r.stderr)
+	s.BoolVarP(&isHelp, "help", "h", false, "help for "+testCmd)
+	s.BoolVarP(&isVerbose, "verbose", "v", false, "print the passed cases too")
+	s.StringVar(&r.configFile, "config", "", "config file of the named queries invoked by @name")
+	s.Usage = func() {
+		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", testDesc, testUsage)
+		fmt.Fprintln(r.stderr, "Flags:")
+		s.PrintDefaults()
+		fmt.Fprintf(r.stderr, "\n%s", testExamplesText)
+	}
+	if err := s.Parse(args[1:]); err != nil {
+		return err
+	}
+	if isHelp || s.NArg() == 0 {
+		s.Usage()
+		return nil
+	}
+
+	var cases []testCase
+	for _, filename := range s.Args() {
+		cs, err := loadTestCases(filename)
+		if err != nil {
+			return err
+		}
+		cases = append(cases, cs...)
+	}
+	failed := 0
+	for _, c := range cases {
+		if msg := r.runTestCase(c); msg != "" {
+			failed++
+			fmt.Fprintf(r.out, "--- FAIL: %s\n%s", c.name, msg)
+		} else if isVerbose {
+			fmt.Fprintf(r.out, "--- PASS: %s\n", c.name)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d cases", errTestFailed, failed, len(cases))
+	}
+	_, err := fmt.Fprintf(r.out, "PASS: %d cases\n", len(cases))
+	return err
+}
+
+// loadTestCases loads the cases of all documents in the file that are
+// arrays of the cases or maps that have the arrays as "tests".
+func loadTestCases(filename string) ([]testCase, error) {
+	ds, err := decodeRulesFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	var cases []testCase
+	for _, d := range ds {
+		n := tree.OrNil(d.Node)
+		if n.Type().IsMap() {
+			n = tree.OrNil(n.Get("tests"))
+		}
+		if !n.Type().IsArray() {
+			return nil, fmt.Errorf("failed to read %s: tests must be an array", filename)
+		}
+		for _, cn := range n.Array() {
+			c, err := parseTestCase(tree.OrNil(cn))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: case #%d: %w", filename, len(cases)+1, err)
+			}
+			if c.name == "" {
+				c.name = fmt.Sprintf("%s#%d", filename, len(cases)+1)
+			}
+			c.dir = filepath.Dir(filename)
+			cases = append(cases, c)
+		}
+	}
+	return cases, nil
+}
+
+func parseTestCase(n tree.Node) (testCase, error) {
+	if !n.Type().IsMap() {
+		return testCase{}, fmt.Errorf("case must be a map")
+	}
+	c := testCase{
+		name:      n.Get("name").Value().String(),
+		inputFile: n.Get("inputFile").Value().String(),
+		query:     n.Get("query").Value().String(),
+		err:       n.Get("error").Value().String(),
+	}
+	if m := n.Map(); m.Has("input") {
+		c.input = tree.OrNil(m["input"])
+	}
+	if c.input == nil && c.inputFile == "" {
+		return c, fmt.Errorf("input or inputFile is required")
+	}
+	switch edits := n.Get("edits"); {
+	case edits.Type().IsStringValue():
+		c.edits = []string{edits.Value().String()}
+	case edits.Type().IsArray():
+		for _, e := range edits.Array() {
+			c.edits = append(c.edits, e.Value().String())
+		}
+	}
+	if args := n.Get("args"); args.Type().IsMap() {
+		c.args = args.Map()
+	}
+	m := n.Map()
+	if m.Has("output") {
+		c.output = tree.OrNil(m["output"])
+	}
+	if outputs := n.Get("outputs"); outputs.Type().IsArray() {
+		c.outputs = outputs.Array()
+	}
+	if (c.output != nil) == (c.outputs != nil) && c.err == "" {
+		return c, fmt.Errorf("either output, outputs or error is required")
+	}
+	return c, nil
+}
+
+// runTestCase runs the case and returns the message of the failure or an
+// empty string if the case passes.
+func (r *runner) runTestCase(c testCase) string {
+	results, err := r.evaluateTestCase(c)
+	switch {
+	case err != nil && c.err != "" && strings.Contains(err.Error(), c.err):
+		return ""
+	case err != nil:
+		return fmt.Sprintf("    error: %v\n", err)
+	case c.err != "":
+		return fmt.Sprintf("    got:   %s\n    want error: %s\n", compactJSON(results), c.err)
+	case c.outputs != nil:
+		if got, want := compactJSON(results), compactJSON(c.outputs); got != want {
+			return fmt.Sprintf("    got:   %s\n    want:  %s\n", got, want)
+		}
+	case len(results) != 1:
+		return fmt.Sprintf("    got:   %d results %s\n    want:  %s\n", len(results), compactJSON(results), compactJSON(c.output))
+	default:
+		if got, want := compactJSON(results[0]), compactJSON(c.output); got != want {
+			return fmt.Sprintf("    got:   %s\n    want:  %s\n", got, want)
+		}
+	}
+	return ""
+}
+
+// evaluateTestCase returns the results of the query and the edits of the
+// case to the inputs.
+func (r *runner) evaluateTestCase(c testCase) (tree.Array, error) {
+	r.vars = tree.Map{}
+	for k, v := range c.args {
+		r.vars[k] = v
+	}
+	query, err := r.resolveQuery(c.query)
+	if err != nil {
+		return nil, err
+	}
+	inputs := tree.Array{c.input}
+	if c.inputFile != "" {
+		filename := c.inputFile
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(c.dir, filename)
+		}
+		if inputs, err = r.decodeFile(filename); err != nil {
+			return nil, err
+		}
+	}
+	pipeline, err := tq.NewRunner(tq.Options{Query: query, Edits: c.edits, Variables: r.vars})
+	if err != nil {
+		return nil, err
+	}
+	var results tree.Array
+	for _, input := range inputs {
+		rs, err := pipeline.Evaluate(context.Background(), tree.OrNil(input))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rs...)
+	}
+	return results, nil
+}
+
+// compactJSON returns the JSON of n that sorts the keys of the maps.
+func compactJSON(n tree.Node) string {
+	b, err := tree.MarshalJSON(n)
+	if err != nil {
+		return fmt.Sprintf("%v", n)
+	}
+	return string(b)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jarxorg/io2"
+)
+
+func TestRunTest(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tests := []struct {
+		args []string
+		want string
+		err  error
+	}{
+		{
+			args: []string{"--config", "testdata/config.yaml", "testdata/test/cases.yaml"},
+			want: "PASS: 5 cases\n",
+		}, {
+			args: []string{"-v", "--config", "testdata/config.yaml", "testdata/test/cases.yaml"},
+			want: `--- PASS: first title
+--- PASS: cheap titles
+--- PASS: edit
+--- PASS: named query
+--- PASS: syntax error
+PASS: 5 cases
+`,
+		}, {
+			args: []string{"testdata/test/failures.yaml"},
+			want: `--- FAIL: wrong output
+    got:   1
+    want:  2
+--- FAIL: many results
+    got:   2 results [1,2]
+    want:  1
+--- FAIL: testdata/test/failures.yaml#3
+    got:   [1]
+    want error: unknown
+--- FAIL: unexpected error
+    error: syntax error: no right brackets: ".a.b("
+`,
+			err: errTestFailed,
+		}, {
+			args: []string{"testdata/test/cases.yaml"},
+			want: `--- FAIL: named query
+    error: unknown bookmark "by-author"
+`,
+			err: errTestFailed,
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(buf),
+		}
+		err := r.run(append([]string{"tq", "test"}, test.args...))
+		if !errors.Is(err, test.err) {
+			t.Errorf("tests[%d] error %v; want %v", i, err, test.err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestParseTestCase_Errors(t *testing.T) {
+	tests := []struct {
+		data   string
+		errstr string
+	}{
+		{data: "tests: {}", errstr: "failed to read %s: tests must be an array"},
+		{data: "- 1", errstr: "failed to read %s: case #1: case must be a map"},
+		{data: "- query: .a\n  output: 1", errstr: "failed to read %s: case #1: input or inputFile is required"},
+		{data: "- input: 1", errstr: "failed to read %s: case #1: either output, outputs or error is required"},
+		{data: "- input: 1\n  output: 1\n  outputs: [1]", errstr: "failed to read %s: case #1: either output, outputs or error is required"},
+	}
+	for i, test := range tests {
+		filename := filepath.Join(t.TempDir(), "cases.yaml")
+		if err := os.WriteFile(filename, []byte(test.data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadTestCases(filename)
+		if want := fmt.Sprintf(test.errstr, filename); err == nil || err.Error() != want {
+			t.Errorf("tests[%d] got error %v; want %s", i, err, want)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jarxorg/tree"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	validateCmd   = "validate"
+	validateDesc  = "Validate parses each file and reports syntax errors, duplicate keys and schema violations."
+	validateUsage = cmd + " " + validateCmd + " [flags] [file...]"
+)
+
+var errValidationFailed = errors.New("validation failed")
+
+var yamlLineRegexp = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+type validateProblem struct {
+	filename string
+	// document is the 1-based index of the document in the multi-document
+	// file, or 0.
+	document int
+	line     int
+	column   int
+	path     string
+	msg      string
+}
+
+type problemJSON struct {
+	File     string `json:"file"`
+	Error    string `json:"error"`
+	Document int    `json:"document,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Path     string `json:"path,omitempty"`
+}
+
+func (p validateProblem) String() string {
+	s := p.filename
+	if p.document > 0 {
+		s += "#" + strconv.Itoa(p.document)
+	}
+	if p.line > 0 {
+		s += ":" + strconv.Itoa(p.line)
+		if p.column > 0 {
+			s += ":" + strconv.Itoa(p.column)
+		}
+	}
+	if p.path != "" {
+		s += ": " + p.path
+	}
+	return s + ": " + p.msg
+}
+
+func (r *runner) runValidate(args []string) error {
+	var isHelp bool
+	var inputFormat, schemaFile string
+
+	s := pflag.NewFlagSet(args[0], pflag.ExitOnError)
+	s.SetOutput(r.stderr)
+	s.BoolVarP(&isHelp, "help", "h", false, "help for "+validateCmd)
+	s.StringVarP(&inputFormat, "input-format", "i", "", "input format (json or yaml, default guessed by file)")
+	s.StringVar(&schemaFile, "schema", "", "JSON schema file to validate each document")
+	s.StringVar(&r.errorFormat, "error-format", errorFormatText, "error format (text or json)")
+	s.Usage = func() {
+		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", validateDesc, validateUsage)
+		fmt.Fprintln(r.stderr, "Flags:")
+		s.PrintDefaults()
+	}
+	if err := s.Parse(args[1:]); err != nil {
+		return err
+	}
+	if err := checkErrorFormat(r.errorFormat); err != nil {
+		return err
+	}
+	if isHelp || s.NArg() == 0 {
+		s.Usage()
+		return nil
+	}
+
+	var schema tree.Node
+	if schemaFile != "" {
+		docs, err := r.decodeFile(schemaFile)
+		if err != nil {
+			return fmt.Errorf("failed to read schema %s: %w", schemaFile, err)
+		}
+		if len(docs) != 1 {
+			return fmt.Errorf("failed to read schema %s: %d documents", schemaFile, len(docs))
+		}
+		schema = docs[0]
+	}
+
+	failed := 0
+	for _, filename := range s.Args() {
+		problems, err := validateFile(filename, inputFormat, schema)
+		if err != nil {
+			return err
+		}
+		for _, p := range problems {
+			if err := r.outputProblem(p); err != nil {
+				return err
+			}
+		}
+		if len(problems) > 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d files", errValidationFailed, failed, s.NArg())
+	}
+	return nil
+}
+
+func (r *runner) outputProblem(p validateProblem) error {
+	if r.errorFormat != errorFormatJSON {
+		_, err := fmt.Fprintln(r.out, p)
+		return err
+	}
+	return json.NewEncoder(r.out).Encode(problemJSON{
+		File:     p.filename,
+		Error:    p.msg,
+		Document: p.document,
+		Line:     p.line,
+		Column:   p.column,
+		Path:     p.path,
+	})
+}
+
+func validateFile(filename, format string, schema tree.Node) ([]validateProblem, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = tree.ToUTF8(data); err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = guessFileFormat(filename, data)
+	}
+
+	var docs []tree.Node
+	var problems []validateProblem
+	if format == "json" {
+		docs, problems = validateJSON(data)
+	} else {
+		docs, problems = validateYAML(data)
+	}
+	if schema != nil && len(problems) == 0 {
+		for _, doc := range docs {
+			problems = append(problems, validateSchema(schema, doc, "")...)
+		}
+	}
+	for i := range problems {
+		problems[i].filename = filename
+	}
+	return problems, nil
+}
+
+func guessFileFormat(filename string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return "json"
+	}
+	return "yaml"
+}
+
+type jsonValidator struct {
+	data     []byte
+	dec      *json.Decoder
+	problems []validateProblem
+}
+
+func validateJSON(data []byte) ([]tree.Node, []validateProblem) {
+	v := &jsonValidator{
+		data: data,
+		dec:  json.NewDecoder(bytes.NewReader(data)),
+	}
+	var docs []tree.Node
+	for {
+		n, err := v.next()
+		if err != nil {
+			if err != io.EOF {
+				v.errorProblem(err)
+			}
+			break
+		}
+		docs = append(docs, n)
+	}
+	return docs, v.problems
+}
+
+func (v *jsonValidator) next() (tree.Node, error) {
+	t, err := v.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case json.Delim('{'):
+		m := tree.Map{}
+		for v.dec.More() {
+			kt, err := v.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := kt.(string)
+			if !ok {
+				return nil, fmt.Errorf("unknown token %#v", kt)
+			}
+			if _, ok := m[key]; ok {
+				v.addProblem(v.dec.InputOffset(), fmt.Sprintf("duplicate key %q", key))
+			}
+			n, err := v.next()
+			if err != nil {
+				return nil, err
+			}
+			m[key] = n
+		}
+		_, err := v.dec.Token()
+		return m, err
+	case json.Delim('['):
+		a := tree.Array{}
+		for v.dec.More() {
+			n, err := v.next()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, n)
+		}
+		_, err := v.dec.Token()
+		return a, err
+	case nil:
+		return tree.Nil, nil
+	}
+	switch tt := t.(type) {
+	case string:
+		return tree.StringValue(tt), nil
+	case float64:
+		return tree.NumberValue(tt), nil
+	case bool:
+		return tree.BoolValue(tt), nil
+	}
+	return nil, fmt.Errorf("unknown token %#v", t)
+}
+
+func (v *jsonValidator) errorProblem(err error) {
+	offset := v.dec.InputOffset()
+	var serr *json.SyntaxError
+	if errors.As(err, &serr) && serr.Offset > 0 {
+		// Offset is after the invalid character.
+		offset = serr.Offset - 1
+	}
+	if err == io.ErrUnexpectedEOF {
+		offset = int64(len(v.data))
+	}
+	v.addProblem(offset, err.Error())
+}
+
+func (v *jsonValidator) addProblem(offset int64, msg string) {
+	line, column := lineColumn(v.data, offset)
+	v.problems = append(v.problems, validateProblem{
+		line:   line,
+		column: column,
+		msg:    msg,
+	})
+}
+
+// lineColumn returns the 1-based line and column of the offset.
+func lineColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	head := data[:offset]
+	line := bytes.Count(head, []byte("\n")) + 1
+	column := len(head) - bytes.LastIndexByte(head, '\n')
+	return line, column
+}
+
+func validateYAML(data []byte) ([]tree.Node, []validateProblem) {
+	// NOTE: yaml.v3 reports the duplicate keys as errors.
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+
+	var docs []tree.Node
+	var problems []validateProblem
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if terr, ok := err.(*yaml.TypeError); ok {
+			for _, msg := range terr.Errors {
+				problems = append(problems, yamlProblem(msg))
+			}
+			continue
+		}
+		if err != nil {
+			problems = append(problems, yamlProblem(err.Error()))
+			break
+		}
+		docs = append(docs, tree.ToNode(v))
+	}
+	return docs, problems
+}
+
+func yamlProblem(msg string) validateProblem {
+	if m := yamlLineRegexp.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return validateProblem{line: line, msg: m[2]}
+	}
+	return validateProblem{msg: strings.TrimPrefix(msg, "yaml: ")}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/jarxorg/io2"
+)
+
+func TestRunValidate(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+		err  error
+	}{
+		{
+			args: []string{"testdata/validate/valid.json", "testdata/validate/schema.json"},
+		}, {
+			args: []string{"testdata/validate/duplicate.json", "testdata/validate/duplicate.yaml"},
+			want: "testdata/validate/duplicate.json:3:6: duplicate key \"a\"\n" +
+				"testdata/validate/duplicate.yaml:2: mapping key \"a\" already defined at line 1\n",
+			err: errValidationFailed,
+		}, {
+			args: []string{"testdata/validate/syntax.yaml"},
+			want: "testdata/validate/syntax.yaml:1: did not find expected ',' or ']'\n",
+			err:  errValidationFailed,
+		}, {
+			args: []string{"--schema", "testdata/validate/schema.json", "testdata/validate/valid.json", "testdata/validate/deployments.yaml"},
+			want: "testdata/validate/deployments.yaml: .: missing required key \"image\"\n" +
+				"testdata/validate/deployments.yaml: .replicas: expected integer but string\n",
+			err: errValidationFailed,
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(buf),
+		}
+		err := r.run(append([]string{"tq", "validate"}, test.args...))
+		if !errors.Is(err, test.err) {
+			t.Errorf("tests[%d] error %v; want %v", i, err, test.err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestValidateFile_JSONSyntax(t *testing.T) {
+	problems, err := validateFile("testdata/validate/syntax.json", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 {
+		t.Fatalf("got %v; want 1 problem", problems)
+	}
+	if p := problems[0]; p.line != 4 || p.column != 1 {
+		t.Errorf("got line %d column %d; want line 4 column 1", p.line, p.column)
+	}
+}
+
+func TestLineColumn(t *testing.T) {
+	data := []byte("ab\ncd\n")
+	tests := []struct {
+		offset       int64
+		line, column int
+	}{
+		{0, 1, 1},
+		{1, 1, 2},
+		{3, 2, 1},
+		{5, 2, 3},
+		{100, 3, 1},
+	}
+	for i, test := range tests {
+		line, column := lineColumn(data, test.offset)
+		if line != test.line || column != test.column {
+			t.Errorf("tests[%d] got %d:%d; want %d:%d", i, line, column, test.line, test.column)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jarxorg/tree"
+	"github.com/spf13/pflag"
+)
+
+const (
+	valuesCmd          = "values"
+	valuesDesc         = "Values merges the values files in order, applies --set overrides like Helm and prints the final values."
+	valuesUsage        = cmd + " " + valuesCmd + " [flags] [file...]"
+	valuesExamplesText = `Examples:
+  % tq values values.yaml env/prod.yaml --set image.tag=1.2.3
+  % tq values values.yaml --set 'ingress.hosts[0]=example.com,replicas=3'
+`
+)
+
+// valuesMergeOption merges maps recursively and replaces arrays as Helm does.
+var valuesMergeOption = tree.MergeOptionOverrideMap | tree.MergeOptionReplaceArray
+
+func (r *runner) runValues(args []string) error {
+	var isHelp bool
+	var sets, setStrings []string
+
+	s := pflag.NewFlagSet(args[0], pflag.ExitOnError)
+	s.SetOutput(r.stderr)
+	s.BoolVarP(&isHelp, "help", "h", false, "help for "+valuesCmd)
+	s.StringArrayVar(&sets, "set", nil, "set values (key1=val1,key2=val2)")
+	s.StringArrayVar(&setStrings, "set-string", nil, "set string values (key1=val1,key2=val2)")
+	s.StringVarP(&r.inputFormat, "input-format", "i", "", "input format (json or yaml, default guessed by file)")
+	s.StringVarP(&r.outputFormat, "output-format", "o", "yaml", "output format (json or yaml)")
+	s.Usage = func() {
+		fmt.Fprintf(r.stderr, "%s\n\nUsage:\n  %s\n\n", valuesDesc, valuesUsage)
+		fmt.Fprintln(r.stderr, "Flags:")
+		s.PrintDefaults()
+		fmt.Fprintf(r.stderr, "\n%s", valuesExamplesText)
+	}
+	if err := s.Parse(args[1:]); err != nil {
+		return err
+	}
+	if isHelp || (s.NArg() == 0 && len(sets) == 0 && len(setStrings) == 0) {
+		s.Usage()
+		return nil
+	}
+	format := r.output()
+	if format == "" {
+		return fmt.Errorf("unknown output format %q", r.outputFormat)
+	}
+
+	var values tree.Node = tree.Map{}
+	for _, filename := range s.Args() {
+		docs, err := r.decodeFile(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+		for _, doc := range docs {
+			if doc.IsNil() {
+				continue
+			}
+			if !doc.Type().IsMap() {
+				return fmt.Errorf("failed to read %s: values must be a map", filename)
+			}
+			values = tree.Merge(values, doc, valuesMergeOption)
+		}
+	}
+	for _, set := range sets {
+		if err := setValues(values.Map(), set, false); err != nil {
+			return err
+		}
+	}
+	for _, set := range setStrings {
+		if err := setValues(values.Map(), set, true); err != nil {
+			return err
+		}
+	}
+	return tree.DocumentSet{{Node: values}}.Encode(r.out, format)
+}
+
+// setValues sets the comma separated assignments like "a.b=1,c[0]=x" to m.
+// The values are parsed as bool, integer and null unless isString, and
+// null removes the key.
+func setValues(m tree.Map, set string, isString bool) error {
+	for _, assign := range splitUnescaped(set, ',') {
+		key, value, ok := strings.Cut(assign, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid set %q: key=value is required", assign)
+		}
+		path, err := parseValuesPath(key)
+		if err != nil {
+			return fmt.Errorf("invalid set %q: %w", assign, err)
+		}
+		value = strings.ReplaceAll(value, `\,`, ",")
+		var v tree.Node = tree.StringValue(value)
+		if !isString {
+			v = parseSetValue(value)
+		}
+		if err := setValuesPath(m, path, v); err != nil {
+			return fmt.Errorf("invalid set %q: %w", assign, err)
+		}
+	}
+	return nil
+}
+
+func splitUnescaped(s string, sep byte) []string {
+	var ss []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			ss = append(ss, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(ss, s[start:])
+}
+
+// parseValuesPath parses the dotted path like "a.b[0].c" into string keys
+// and int indexes. "\." is a dot in a key.
+func parseValuesPath(key string) ([]interface{}, error) {
+	var path []interface{}
+	for _, elem := range splitUnescaped(key, '.') {
+		elem = strings.ReplaceAll(elem, `\.`, ".")
+		name := elem
+		if i := strings.IndexByte(elem, '['); i >= 0 {
+			name = elem[:i]
+		}
+		if name == "" {
+			return nil, fmt.Errorf("empty key in %q", key)
+		}
+		path = append(path, name)
+		for rest := elem[len(name):]; rest != ""; {
+			end := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("invalid index in %q", key)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index in %q", key)
+			}
+			path = append(path, index)
+			rest = rest[end+1:]
+		}
+	}
+	return path, nil
+}
+
+func parseSetValue(s string) tree.Node {
+	switch s {
+	case "true":
+		return tree.BoolValue(true)
+	case "false":
+		return tree.BoolValue(false)
+	case "null":
+		return tree.Nil
+	}
+	// NOTE: Like Helm, numbers with leading zeros and decimals are strings.
+	if len(s) > 1 && s[0] == '0' {
+		return tree.StringValue(s)
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return tree.NumberValue(i)
+	}
+	return tree.StringValue(s)
+}
+
+// setValuesPath sets v at the path under m by tree.SetPathKeys. null
+// removes the key.
+func setValuesPath(m tree.Map, path []interface{}, v tree.Node) error {
+	if key, ok := path[len(path)-1].(string); ok && v.IsNil() {
+		parent := tree.Node(m)
+		if len(path) > 1 {
+			parent = m.Get(path[:len(path)-1]...)
+		}
+		if pm, ok := parent.(tree.Map); ok {
+			delete(pm, key)
+		}
+		return nil
+	}
+	var n tree.Node = m
+	return tree.SetPathKeys(&n, path, v)
+}
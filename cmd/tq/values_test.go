@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/jarxorg/io2"
+	"github.com/jarxorg/tree"
+)
+
+func TestRunValues(t *testing.T) {
+	tests := []struct {
+		args   []string
+		want   string
+		errstr string
+	}{
+		{
+			args: []string{"testdata/values/values.yaml", "testdata/values/prod.yaml"},
+			want: "debug: true\nhosts:\n  - example.com\nimage:\n  repository: nginx\n  tag: 1.0.0\nreplicas: 3\n",
+		}, {
+			args: []string{"testdata/values/values.yaml", "--set", "image.tag=1.2.3,replicas=2", "--set", "debug=null"},
+			want: "hosts:\n  - a.example.com\n  - b.example.com\nimage:\n  repository: nginx\n  tag: 1.2.3\nreplicas: 2\n",
+		}, {
+			args: []string{"--set", "a.b[1]=x", "--set-string", "c=true", "-o", "json"},
+			want: "{\n  \"a\": {\n    \"b\": [\n      null,\n      \"x\"\n    ]\n  },\n  \"c\": \"true\"\n}\n",
+		}, {
+			args:   []string{"--set", "a"},
+			errstr: `invalid set "a": key=value is required`,
+		}, {
+			args:   []string{"--set", "a[x]=1"},
+			errstr: `invalid set "a[x]=1": invalid index in "a[x]"`,
+		}, {
+			args:   []string{"--set", "a[99999999999]=x"},
+			errstr: `invalid set "a[99999999999]=x": cannot index array with 99999999999`,
+		}, {
+			args:   []string{"testdata/book-s"},
+			errstr: "failed to read testdata/book-s: values must be a map",
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		r := &runner{
+			stderr: io2.NopWriteCloser(new(bytes.Buffer)),
+			out:    io2.NopWriteCloser(buf),
+		}
+		err := r.run(append([]string{"tq", "values"}, test.args...))
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestParseSetValue(t *testing.T) {
+	tests := []struct {
+		s    string
+		want tree.Node
+	}{
+		{s: "true", want: tree.BoolValue(true)},
+		{s: "null", want: tree.Nil},
+		{s: "10", want: tree.NumberValue(10)},
+		{s: "010", want: tree.StringValue("010")},
+		{s: "1.5", want: tree.StringValue("1.5")},
+		{s: "x", want: tree.StringValue("x")},
+	}
+	for i, test := range tests {
+		if got := parseSetValue(test.s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
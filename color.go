@@ -9,6 +9,9 @@ import (
 	"strconv"
 	"strings"
 	"unicode/utf8"
+
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 const hex = "0123456789abcdef"
@@ -36,7 +39,9 @@ type ColorEncoder struct {
 	Out        io.Writer
 	IndentSize int
 	NoColor    bool
+	EncodeOptions
 	indent     []byte
+	escapeHTML bool
 	err        error
 }
 
@@ -114,7 +119,8 @@ func (e *ColorEncoder) writeQuotedJSON(s string) {
 	start := 0
 	for i := 0; i < len(s); {
 		if b := s[i]; b < utf8.RuneSelf {
-			if bytes.ContainsRune(jsonSafeRunes, rune(b)) {
+			isHTML := e.escapeHTML && (b == '<' || b == '>' || b == '&')
+			if !isHTML && bytes.ContainsRune(jsonSafeRunes, rune(b)) {
 				i++
 				continue
 			}
@@ -150,6 +156,15 @@ func (e *ColorEncoder) writeQuotedJSON(s string) {
 			start = i
 			continue
 		}
+		if e.ASCII {
+			if start < i {
+				e.writeStr(s[start:i])
+			}
+			e.write(appendASCII(nil, s[i:i+size])...)
+			i += size
+			start = i
+			continue
+		}
 		if c == '\u2028' || c == '\u2029' {
 			if start < i {
 				e.writeStr(s[start:i])
@@ -168,15 +183,52 @@ func (e *ColorEncoder) writeQuotedJSON(s string) {
 	e.write('"')
 }
 
-var yamlNoNeedQuotePattern = regexp.MustCompile(`^[a-zA-Z][0-9a-zA-Z._\-]*$`)
+var yamlSafePattern = regexp.MustCompile(`^[a-zA-Z][0-9a-zA-Z._\-]*$`)
 
-// NOTE: Simple implementation
-func (e *ColorEncoder) writeQuotedYAMLIfNeed(s string, multiline bool) bool {
-	if yamlNoNeedQuotePattern.MatchString(s) {
-		e.writeStr(s)
+// yamlKeywords are the plain scalars that YAML 1.1 resolves to bools and null.
+var yamlKeywords = map[string]bool{
+	"y": true, "n": true, "yes": true, "no": true, "on": true, "off": true,
+	"true": true, "false": true, "null": true,
+}
+
+// isYAMLPlain reports whether s can be written as a plain scalar that is
+// read back as the same string.
+func isYAMLPlain(s string) bool {
+	if yamlSafePattern.MatchString(s) {
+		return !yamlKeywords[strings.ToLower(s)]
+	}
+	if s == "" || strings.ContainsAny(s, "\n\r") {
+		return false
+	}
+	// NOTE: Other strings are plain if both yaml.v2 and yaml.v3 decode them
+	// as the same string, so numbers, timestamps, indicators and comments are
+	// quoted.
+	var v, v2 interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
 		return false
 	}
-	if multiline && strings.Contains(s, "\n") {
+	if err := yamlv2.Unmarshal([]byte(s), &v2); err != nil {
+		return false
+	}
+	vs, ok := v.(string)
+	v2s, ok2 := v2.(string)
+	return ok && ok2 && vs == s && v2s == s
+}
+
+func (e *ColorEncoder) writeQuotedYAML(s string) {
+	// NOTE: Single quoted scalars cannot escape control characters.
+	if e.YAMLSingleQuote && strings.IndexFunc(s, func(r rune) bool { return r < ' ' || r == 0x7f }) == -1 {
+		e.writeStr("'" + strings.ReplaceAll(s, "'", "''") + "'")
+		return
+	}
+	e.writeStr(strconv.Quote(s))
+}
+
+// writeYAMLString writes s as a plain, quoted or literal block scalar.
+// The values are quoted always if YAMLAlwaysQuote, and the values that have
+// line breaks are written as literal block scalars.
+func (e *ColorEncoder) writeYAMLString(s string, isValue bool) bool {
+	if isValue && strings.Contains(s, "\n") && strings.TrimLeft(s, " ") == s {
 		if strings.HasSuffix(s, "\n") {
 			s = strings.TrimRight(s, "\n")
 			e.writeln('|')
@@ -185,23 +237,89 @@ func (e *ColorEncoder) writeQuotedYAMLIfNeed(s string, multiline bool) bool {
 		}
 		e.tab()
 		for _, line := range strings.Split(s, "\n") {
-			e.writeIndent(true)
+			e.writeIndent(line != "")
 			e.writeln([]byte(line)...)
 		}
 		e.untab()
 		return true
 	}
-	e.writeStr(strconv.Quote(s))
+	if (!isValue || !e.YAMLAlwaysQuote) && isYAMLPlain(s) {
+		e.writeStr(s)
+		return false
+	}
+	e.writeQuotedYAML(s)
 	return false
 }
 
 // EncodeJSON writes JSON values with color to an output stream.
 func (e *ColorEncoder) EncodeJSON(n Node) error {
-	e.encodeJSON(n, false)
+	if e.fitsJSONLine(0, n, false) {
+		e.encodeJSONLine(n)
+	} else {
+		e.encodeJSON(n, false)
+	}
 	e.writeln()
 	return e.err
 }
 
+// fitsJSONLine reports whether the line that has the prefix width, n in
+// one line and the comma fits in MaxWidth. It returns false for values.
+func (e *ColorEncoder) fitsJSONLine(prefix int, n Node, comma bool) bool {
+	if e.MaxWidth <= 0 || n == nil || n.Type().IsValue() {
+		return false
+	}
+	buf := new(bytes.Buffer)
+	f := &ColorEncoder{Out: buf, NoColor: true, EncodeOptions: e.EncodeOptions, escapeHTML: e.escapeHTML}
+	f.encodeJSONLine(n)
+	w := len(e.indent) + prefix + utf8.RuneCount(buf.Bytes())
+	if comma {
+		w++
+	}
+	return w <= e.MaxWidth
+}
+
+// keyWidth returns the width of the quoted key k and the colon.
+func (e *ColorEncoder) keyWidth(k string) int {
+	buf := new(bytes.Buffer)
+	f := &ColorEncoder{Out: buf, NoColor: true, EncodeOptions: e.EncodeOptions, escapeHTML: e.escapeHTML}
+	f.writeQuotedJSON(k)
+	return utf8.RuneCount(buf.Bytes()) + 2
+}
+
+func (e *ColorEncoder) encodeJSONLine(n Node) {
+	if n == nil {
+		e.writeNull()
+		return
+	}
+	switch n.Type() {
+	case TypeArray:
+		e.write('[')
+		for i, v := range n.Array() {
+			if i != 0 {
+				e.write(',', ' ')
+			}
+			e.encodeJSONLine(v)
+		}
+		e.write(']')
+	case TypeMap:
+		e.write('{')
+		m := n.Map()
+		for i, k := range m.Keys() {
+			if i != 0 {
+				e.write(',', ' ')
+			}
+			e.startColor(colorKey)
+			e.writeQuotedJSON(k)
+			e.endColor()
+			e.write(':', ' ')
+			e.encodeJSONLine(m[k])
+		}
+		e.write('}')
+	default:
+		e.encodeJSON(n, false)
+	}
+}
+
 func (e *ColorEncoder) encodeJSON(n Node, indent bool) {
 	if n == nil {
 		e.writeIndent(indent)
@@ -217,7 +335,12 @@ func (e *ColorEncoder) encodeJSON(n Node, indent bool) {
 		a := n.Array()
 		last := len(a) - 1
 		for i, v := range a {
-			e.encodeJSON(v, true)
+			if e.fitsJSONLine(0, v, i != last) {
+				e.writeIndent(true)
+				e.encodeJSONLine(v)
+			} else {
+				e.encodeJSON(v, true)
+			}
 			e.writeCn(i != last)
 		}
 		e.untab()
@@ -235,7 +358,11 @@ func (e *ColorEncoder) encodeJSON(n Node, indent bool) {
 			e.writeQuotedJSON(k)
 			e.endColor()
 			e.write(':', ' ')
-			e.encodeJSON(m[k], false)
+			if v := m[k]; e.MaxWidth > 0 && e.fitsJSONLine(e.keyWidth(k), v, i != last) {
+				e.encodeJSONLine(v)
+			} else {
+				e.encodeJSON(v, false)
+			}
 			e.writeCn(i != last)
 			i++
 		}
@@ -250,15 +377,84 @@ func (e *ColorEncoder) encodeJSON(n Node, indent bool) {
 		e.startColor(colorValueStr)
 		e.writeQuotedJSON(n.Value().String())
 		e.endColor()
-	case TypeBoolValue, TypeNumberValue:
+	case TypeBoolValue:
 		e.writeIndent(indent)
 		e.writeStr(n.Value().String())
+	case TypeNumberValue:
+		e.writeIndent(indent)
+		e.writeStr(e.FormatFloat(n.Value().Float64()))
 	default:
 		panic(fmt.Errorf("unknown type %b", t))
 	}
 }
 
-// EncodeJSON writes JSON values with color to an output stream.
+// writeYAMLFlowString writes s as a plain or quoted scalar in flow style.
+func (e *ColorEncoder) writeYAMLFlowString(s string, isValue bool) {
+	if (!isValue || !e.YAMLAlwaysQuote) && !strings.ContainsAny(s, ",[]{}") && isYAMLPlain(s) {
+		e.writeStr(s)
+		return
+	}
+	e.writeQuotedYAML(s)
+}
+
+// fitsYAMLFlow reports whether the line that has the prefix and n in flow
+// style fits in YAMLFlowWidth.
+func (e *ColorEncoder) fitsYAMLFlow(prefix string, n Node) bool {
+	if e.YAMLFlowWidth <= 0 {
+		return false
+	}
+	buf := new(bytes.Buffer)
+	f := &ColorEncoder{Out: buf, NoColor: true, EncodeOptions: e.EncodeOptions}
+	f.encodeYAMLFlow(n)
+	return utf8.RuneCount(e.indent)+utf8.RuneCountInString(prefix)+utf8.RuneCount(buf.Bytes()) <= e.YAMLFlowWidth
+}
+
+func (e *ColorEncoder) encodeYAMLFlow(n Node) {
+	if n == nil {
+		e.writeNull()
+		return
+	}
+	t := n.Type()
+	switch t {
+	case TypeArray:
+		e.write('[')
+		for i, v := range n.Array() {
+			if i != 0 {
+				e.write(',', ' ')
+			}
+			e.encodeYAMLFlow(v)
+		}
+		e.write(']')
+	case TypeMap:
+		e.write('{')
+		m := n.Map()
+		for i, k := range m.Keys() {
+			if i != 0 {
+				e.write(',', ' ')
+			}
+			e.startColor(colorKey)
+			e.writeYAMLFlowString(k, false)
+			e.endColor()
+			e.write(':', ' ')
+			e.encodeYAMLFlow(m[k])
+		}
+		e.write('}')
+	case TypeNilValue:
+		e.writeNull()
+	case TypeStringValue:
+		e.startColor(colorValueStr)
+		e.writeYAMLFlowString(n.Value().String(), true)
+		e.endColor()
+	case TypeBoolValue:
+		e.writeStr(n.Value().String())
+	case TypeNumberValue:
+		e.writeStr(e.FormatFloat(n.Value().Float64()))
+	default:
+		panic(fmt.Errorf("unknown type %b", t))
+	}
+}
+
+// EncodeYAML writes YAML values with color to an output stream.
 func (e *ColorEncoder) EncodeYAML(n Node) error {
 	e.encodeYAML(n, true)
 	return e.err
@@ -278,6 +474,10 @@ func (e *ColorEncoder) encodeYAML(n Node, noIndentFirstKey bool) {
 			if v == nil || v.Type().IsValue() {
 				e.write('-', ' ')
 				e.encodeYAML(v, false)
+			} else if e.fitsYAMLFlow("- ", v) {
+				e.write('-', ' ')
+				e.encodeYAMLFlow(v)
+				e.writeln()
 			} else if v.Type().IsMap() {
 				e.write('-', ' ')
 				e.tab()
@@ -297,11 +497,19 @@ func (e *ColorEncoder) encodeYAML(n Node, noIndentFirstKey bool) {
 			v := m[k]
 			e.writeIndent(i != 0 || !noIndentFirstKey)
 			e.startColor(colorKey)
-			e.writeQuotedYAMLIfNeed(k, false)
+			e.writeYAMLString(k, false)
 			e.endColor()
 			if v == nil || v.Type().IsValue() {
 				e.write(':', ' ')
 				e.encodeYAML(v, false)
+			} else if e.fitsYAMLFlow(k+": ", v) {
+				e.write(':', ' ')
+				e.encodeYAMLFlow(v)
+				e.writeln()
+			} else if e.YAMLV2 && v.Type().IsArray() {
+				// NOTE: yaml.v2 does not indent the arrays in maps.
+				e.writeln(':')
+				e.encodeYAML(v, false)
 			} else {
 				e.writeln(':')
 				e.tab()
@@ -315,14 +523,17 @@ func (e *ColorEncoder) encodeYAML(n Node, noIndentFirstKey bool) {
 		e.writeln()
 	case TypeStringValue:
 		e.startColor(colorValueStr)
-		ln := e.writeQuotedYAMLIfNeed(n.Value().String(), true)
+		ln := e.writeYAMLString(n.Value().String(), true)
 		e.endColor()
 		if !ln {
 			e.writeln()
 		}
-	case TypeBoolValue, TypeNumberValue:
+	case TypeBoolValue:
 		e.writeStr(n.Value().String())
 		e.writeln()
+	case TypeNumberValue:
+		e.writeStr(e.FormatFloat(n.Value().Float64()))
+		e.writeln()
 	default:
 		panic(fmt.Errorf("unknown type %b", t))
 	}
@@ -2,6 +2,7 @@ package tree
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 )
 
@@ -63,6 +64,10 @@ func TestEncodeJSON(t *testing.T) {
 			e:    &ColorEncoder{IndentSize: 2, NoColor: true},
 			n:    ToValue("\"\n\r\t"),
 			want: "\"\\\"\\n\\r\\t\"\n",
+		}, {
+			e:    &ColorEncoder{IndentSize: 2, NoColor: true, EncodeOptions: EncodeOptions{ASCII: true}},
+			n:    ToValue("<é😀>"),
+			want: "\"<\\u00e9\\ud83d\\ude00>\"\n",
 		},
 	}
 	for i, test := range tests {
@@ -90,7 +95,7 @@ func TestOutputColorYAML(t *testing.T) {
 				"bool": ToValue(true),
 				"null": Nil,
 			},
-			want: "\x1b[1;34mbool\x1b[0m: true\n\x1b[1;34mnull\x1b[0m: \x1b[1;30mnull\x1b[0m\n\x1b[1;34mnum\x1b[0m: 1\n\x1b[1;34mstr\x1b[0m: \x1b[0;32m\"2\"\x1b[0m\n",
+			want: "\x1b[1;34mbool\x1b[0m: true\n\x1b[1;34m\"null\"\x1b[0m: \x1b[1;30mnull\x1b[0m\n\x1b[1;34mnum\x1b[0m: 1\n\x1b[1;34mstr\x1b[0m: \x1b[0;32m\"2\"\x1b[0m\n",
 		},
 	}
 	for i, test := range tests {
@@ -181,3 +186,161 @@ d: null
 		}
 	}
 }
+
+func TestEncodeYAML_Quote(t *testing.T) {
+	single := EncodeOptions{YAMLSingleQuote: true}
+	always := EncodeOptions{YAMLAlwaysQuote: true}
+	tests := []struct {
+		opts EncodeOptions
+		s    string
+		want string
+	}{
+		{s: "abc", want: "abc"},
+		{s: "/path/to", want: "/path/to"},
+		{s: "1abc", want: "1abc"},
+		{s: "a b", want: "a b"},
+		{s: "", want: `""`},
+		{s: "yes", want: `"yes"`},
+		{s: "No", want: `"No"`},
+		{s: "on", want: `"on"`},
+		{s: "null", want: `"null"`},
+		{s: "~", want: `"~"`},
+		{s: "0123", want: `"0123"`},
+		{s: "1.5", want: `"1.5"`},
+		{s: "a: b", want: `"a: b"`},
+		{s: "#x", want: `"#x"`},
+		{s: "- x", want: `"- x"`},
+		{s: " x", want: `" x"`},
+		{s: "tab\t", want: `"tab\t"`},
+		{opts: single, s: "yes", want: `'yes'`},
+		{opts: single, s: "it's", want: `it's`},
+		{opts: single, s: "'x'", want: `'''x'''`},
+		{opts: single, s: "tab\t", want: `"tab\t"`},
+		{opts: always, s: "abc", want: `"abc"`},
+		{opts: EncodeOptions{YAMLAlwaysQuote: true, YAMLSingleQuote: true}, s: "abc", want: `'abc'`},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		e := &ColorEncoder{Out: out, NoColor: true, EncodeOptions: test.opts}
+		if err := e.EncodeYAML(ToValue(test.s)); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := out.String(); got != test.want+"\n" {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want+"\n")
+		}
+	}
+}
+
+func TestEncodeYAML_AlwaysQuoteKeys(t *testing.T) {
+	out := new(bytes.Buffer)
+	e := &ColorEncoder{Out: out, NoColor: true, IndentSize: 2}
+	e.YAMLAlwaysQuote = true
+	n := Map{"a": ToValue("x"), "on": ToValue(1), "m": ToValue("l1\nl2")}
+	if err := e.EncodeYAML(n); err != nil {
+		t.Fatal(err)
+	}
+	want := "a: \"x\"\nm: |-\n  l1\n  l2\n\"on\": 1\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestEncodeYAML_Flow(t *testing.T) {
+	n := Map{
+		"a": Array{ToValue(1), ToValue("x,y"), Nil},
+		"b": Map{"c": ToValue("yes"), "d": Map{}},
+		"e": Array{
+			Map{"f": ToValue(1)},
+			Array{ToValue("a long string"), ToValue("another long string")},
+		},
+		"g": Array{},
+	}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{
+			width: 80,
+			want: `a: [1, "x,y", null]
+b: {c: "yes", d: {}}
+e: [{f: 1}, [a long string, another long string]]
+g: []
+`,
+		}, {
+			width: 20,
+			want: `a: [1, "x,y", null]
+b: {c: "yes", d: {}}
+e:
+  - {f: 1}
+  -
+    - a long string
+    - another long string
+g: []
+`,
+		},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		e := &ColorEncoder{Out: out, IndentSize: 2, NoColor: true}
+		e.YAMLFlowWidth = test.width
+		if err := e.EncodeYAML(n); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q\n%s", i, got, test.want, test.want)
+		}
+		got, err := UnmarshalYAML(out.Bytes())
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, n) {
+			t.Errorf("tests[%d] decoded %#v; want %#v", i, got, n)
+		}
+	}
+}
+
+func TestEncodeJSON_MaxWidth(t *testing.T) {
+	n := Map{
+		"a": Array{ToValue(1), ToValue(2), ToValue(3)},
+		"b": Map{"c": ToValue("x"), "d": Array{}},
+		"e": Array{
+			Map{"f": ToValue(1)},
+			Array{ToValue("a long string"), ToValue("another long string")},
+		},
+	}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{
+			width: 103,
+			want: `{"a": [1, 2, 3], "b": {"c": "x", "d": []}, "e": [{"f": 1}, ["a long string", "another long string"]]}
+`,
+		}, {
+			width: 30,
+			want: `{
+  "a": [1, 2, 3],
+  "b": {"c": "x", "d": []},
+  "e": [
+    {"f": 1},
+    [
+      "a long string",
+      "another long string"
+    ]
+  ]
+}
+`,
+		},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		e := &ColorEncoder{Out: out, IndentSize: 2, NoColor: true}
+		e.MaxWidth = test.width
+		if err := e.EncodeJSON(n); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q\n%s", i, got, test.want, test.want)
+		}
+	}
+}
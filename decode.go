@@ -0,0 +1,169 @@
+package tree
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeOptions represents the limits of decoding documents to protect
+// services from huge or deeply nested documents. The zero value of each
+// limit means no limit.
+type DecodeOptions struct {
+	// MaxDepth limits the nesting depth of arrays and maps. The depth of
+	// the values of the root array or map is 1.
+	MaxDepth int
+	// MaxNodes limits the total number of nodes including arrays and maps.
+	MaxNodes int
+	// MaxStringLength limits the length in bytes of each string and key.
+	MaxStringLength int
+	// InternKeys shares the same key strings across the maps of a document
+	// to reduce the memory of large arrays of similar objects.
+	InternKeys bool
+}
+
+// DecodeLimitError is returned when a document exceeds a limit of
+// DecodeOptions.
+type DecodeLimitError struct {
+	// Limit is the name of the exceeded field of DecodeOptions like "MaxDepth".
+	Limit string
+	// Max is the value of the limit.
+	Max int
+}
+
+// Error returns the message of the error.
+func (e *DecodeLimitError) Error() string {
+	return fmt.Sprintf("decode limit exceeded: %s %d", e.Limit, e.Max)
+}
+
+// decodeLimiter counts the nodes and interns the keys of a document with
+// DecodeOptions. The aliases of YAML are counted to protect from the
+// excessive aliasing.
+type decodeLimiter struct {
+	opts  DecodeOptions
+	nodes int
+	keys  map[string]string
+
+	aliases    map[*yaml.Node]bool
+	aliasDepth int
+	aliasNodes int
+}
+
+func (l *decodeLimiter) node(depth int) error {
+	if l.opts.MaxDepth > 0 && depth > l.opts.MaxDepth {
+		return &DecodeLimitError{Limit: "MaxDepth", Max: l.opts.MaxDepth}
+	}
+	l.nodes++
+	if l.opts.MaxNodes > 0 && l.nodes > l.opts.MaxNodes {
+		return &DecodeLimitError{Limit: "MaxNodes", Max: l.opts.MaxNodes}
+	}
+	return nil
+}
+
+func (l *decodeLimiter) string(s string) error {
+	if l.opts.MaxStringLength > 0 && len(s) > l.opts.MaxStringLength {
+		return &DecodeLimitError{Limit: "MaxStringLength", Max: l.opts.MaxStringLength}
+	}
+	return nil
+}
+
+// key returns the interned key if InternKeys is set.
+func (l *decodeLimiter) key(k string) string {
+	if !l.opts.InternKeys {
+		return k
+	}
+	if kk, ok := l.keys[k]; ok {
+		return kk
+	}
+	if l.keys == nil {
+		l.keys = map[string]string{}
+	}
+	l.keys[k] = k
+	return k
+}
+
+// DecodeJSON decodes JSON as a node using the provided decoder like
+// DecodeJSON. The limits are checked while reading the tokens, so the
+// decoding stops before reading the rest of the document.
+func (o DecodeOptions) DecodeJSON(dec *json.Decoder) (Node, error) {
+	l := &decodeLimiter{opts: o}
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return l.jsonNode(dec, t, 0)
+}
+
+func (l *decodeLimiter) jsonNode(dec *json.Decoder, t json.Token, depth int) (Node, error) {
+	if err := l.node(depth); err != nil {
+		return nil, err
+	}
+	d, ok := t.(json.Delim)
+	if !ok {
+		if s, ok := t.(string); ok {
+			if err := l.string(s); err != nil {
+				return nil, err
+			}
+		}
+		return jsonValue(t), nil
+	}
+	switch d.String() {
+	case "{":
+		m := Map{}
+		for {
+			t, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if d, ok := t.(json.Delim); ok && d.String() == "}" {
+				return m, nil
+			}
+			key, ok := t.(string)
+			if !ok {
+				return nil, fmt.Errorf("unknown token %#v", t)
+			}
+			if err := l.string(key); err != nil {
+				return nil, err
+			}
+			if t, err = dec.Token(); err != nil {
+				return nil, err
+			}
+			v, err := l.jsonNode(dec, t, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			m[l.key(key)] = v
+		}
+	case "[":
+		a := Array{}
+		for {
+			t, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if d, ok := t.(json.Delim); ok && d.String() == "]" {
+				return a, nil
+			}
+			v, err := l.jsonNode(dec, t, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+	}
+	return nil, fmt.Errorf("unknown token %#v", t)
+}
+
+// DecodeYAML decodes YAML as a node using the provided decoder like
+// DecodeYAML. The limits are checked while converting the parsed yaml.Node,
+// so the aliases are not expanded beyond the limits. The nodes repeated by
+// the aliases are counted each time.
+func (o DecodeOptions) DecodeYAML(dec *yaml.Decoder) (Node, error) {
+	var yn yaml.Node
+	if err := dec.Decode(&yn); err != nil {
+		return nil, err
+	}
+	l := &decodeLimiter{opts: o}
+	return l.yamlNode(&yn, 0)
+}
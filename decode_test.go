@@ -0,0 +1,149 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDecodeOptions(t *testing.T) {
+	tests := []struct {
+		opts   DecodeOptions
+		json   string
+		yaml   string
+		want   Node
+		errstr string
+	}{
+		{
+			json: `{"a":[1,{"b":"c"}]}`,
+			yaml: "a: [1, {b: c}]",
+			want: Map{"a": Array{ToValue(1), Map{"b": ToValue("c")}}},
+		}, {
+			opts: DecodeOptions{MaxDepth: 3, MaxNodes: 5, MaxStringLength: 1},
+			json: `{"a":[1,{"b":"c"}]}`,
+			yaml: "a: [1, {b: c}]",
+			want: Map{"a": Array{ToValue(1), Map{"b": ToValue("c")}}},
+		}, {
+			opts:   DecodeOptions{MaxDepth: 2},
+			json:   `{"a":[1,{"b":"c"}]}`,
+			yaml:   "a: [1, {b: c}]",
+			errstr: "decode limit exceeded: MaxDepth 2",
+		}, {
+			opts:   DecodeOptions{MaxNodes: 4},
+			json:   `{"a":[1,{"b":"c"}]}`,
+			yaml:   "a: [1, {b: c}]",
+			errstr: "decode limit exceeded: MaxNodes 4",
+		}, {
+			opts:   DecodeOptions{MaxStringLength: 2},
+			json:   `["abc"]`,
+			yaml:   "[abc]",
+			errstr: "decode limit exceeded: MaxStringLength 2",
+		}, {
+			opts:   DecodeOptions{MaxStringLength: 2},
+			json:   `{"abc":1}`,
+			yaml:   "abc: 1",
+			errstr: "decode limit exceeded: MaxStringLength 2",
+		}, {
+			opts:   DecodeOptions{MaxDepth: 1},
+			json:   `[[[[[[`,
+			yaml:   "[[[[[]]]]]",
+			errstr: "decode limit exceeded: MaxDepth 1",
+		},
+	}
+	for i, test := range tests {
+		got, err := test.opts.DecodeJSON(json.NewDecoder(strings.NewReader(test.json)))
+		checkDecode(t, i, "json", got, err, test.want, test.errstr)
+		got, err = test.opts.DecodeYAML(yaml.NewDecoder(strings.NewReader(test.yaml)))
+		checkDecode(t, i, "yaml", got, err, test.want, test.errstr)
+	}
+}
+
+func checkDecode(t *testing.T, i int, format string, got Node, err error, want Node, errstr string) {
+	t.Helper()
+	if errstr != "" {
+		var lerr *DecodeLimitError
+		if !errors.As(err, &lerr) || err.Error() != errstr {
+			t.Errorf("tests[%d] %s got error %v; want %s", i, format, err, errstr)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("tests[%d] %s %v", i, format, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tests[%d] %s got %v; want %v", i, format, got, want)
+	}
+}
+
+func TestDecodeOptions_DecodeYAML_Aliases(t *testing.T) {
+	data := []byte(`a: &a [x, x, x, x, x, x, x, x, x, x]
+b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a, *a]
+`)
+	opts := DecodeOptions{MaxNodes: 100}
+	_, err := opts.DecodeYAML(yaml.NewDecoder(bytes.NewReader(data)))
+	if err == nil || err.Error() != "decode limit exceeded: MaxNodes 100" {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestDecodeOptions_DecodeYAML_AliasBomb(t *testing.T) {
+	lines := []string{`a0: &a0 ["lol", "lol", "lol", "lol", "lol", "lol", "lol", "lol", "lol", "lol"]`}
+	for i := 1; i < 9; i++ {
+		refs := strings.TrimSuffix(strings.Repeat(fmt.Sprintf("*a%d, ", i-1), 10), ", ")
+		lines = append(lines, fmt.Sprintf("a%d: &a%d [%s]", i, i, refs))
+	}
+	data := []byte(strings.Join(lines, "\n"))
+	opts := DecodeOptions{MaxNodes: 1000}
+	_, err := opts.DecodeYAML(yaml.NewDecoder(bytes.NewReader(data)))
+	if err == nil || err.Error() != "decode limit exceeded: MaxNodes 1000" {
+		t.Errorf("got error %v", err)
+	}
+}
+
+// stringData returns the pointer to the bytes of s.
+func stringData(s string) uintptr {
+	return *(*uintptr)(unsafe.Pointer(&s))
+}
+
+func TestDecodeOptions_InternKeys(t *testing.T) {
+	data := `[{"name":"a","id":1},{"name":"b","id":2},{"name":"c","id":3}]`
+	want := Array{
+		Map{"name": ToValue("a"), "id": ToValue(1)},
+		Map{"name": ToValue("b"), "id": ToValue(2)},
+		Map{"name": ToValue("c"), "id": ToValue(3)},
+	}
+	for _, format := range []string{"json", "yaml"} {
+		for _, intern := range []bool{false, true} {
+			opts := DecodeOptions{InternKeys: intern}
+			var got Node
+			var err error
+			if format == "json" {
+				got, err = opts.DecodeJSON(json.NewDecoder(strings.NewReader(data)))
+			} else {
+				got, err = opts.DecodeYAML(yaml.NewDecoder(strings.NewReader(data)))
+			}
+			if err != nil {
+				t.Fatalf("%s %v", format, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s got %v; want %v", format, got, want)
+			}
+			ptrs := map[uintptr]bool{}
+			for _, v := range got.Array() {
+				for k := range v.Map() {
+					ptrs[stringData(k)] = true
+				}
+			}
+			if shared := len(ptrs) == 2; shared != intern {
+				t.Errorf("%s InternKeys %v got %d key strings", format, intern, len(ptrs))
+			}
+		}
+	}
+}
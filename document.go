@@ -0,0 +1,255 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format represents the encoding format of documents.
+type Format string
+
+const (
+	// FormatJSON represents JSON.
+	FormatJSON Format = "json"
+	// FormatYAML represents YAML.
+	FormatYAML Format = "yaml"
+)
+
+// GuessFormat returns the format of the filename by its extension.
+// It returns an empty format if the extension is unknown.
+func GuessFormat(filename string) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	}
+	return ""
+}
+
+// Document is a node with the metadata of its source.
+type Document struct {
+	// Node is the root node of the document.
+	Node Node
+	// Source is the name of the source like a filename.
+	Source string
+	// Format is the format of the source.
+	Format Format
+	// Index is the index of the document in the source.
+	Index int
+}
+
+// DocumentSet is an ordered list of documents that may be decoded from
+// multiple sources and each source may contain multiple documents.
+type DocumentSet []*Document
+
+// DecodeDocuments decodes all documents from r as the format.
+// If the format is empty, decodes as JSON first and then as YAML.
+// FormatJSONC documents are decoded as FormatJSON without the comments.
+// The byte order mark is stripped and UTF-16 is transcoded by ToUTF8.
+func DecodeDocuments(r io.Reader, source string, format Format) (DocumentSet, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = ToUTF8(data); err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatJSON:
+		return decodeJSONDocuments(data, source)
+	case FormatJSONC:
+		return decodeJSONDocuments(StripJSONC(data), source)
+	case FormatYAML:
+		return decodeYAMLDocuments(data, source)
+	case "":
+		ds, jsonErr := decodeJSONDocuments(data, source)
+		if jsonErr == nil {
+			return ds, nil
+		}
+		ds, yamlErr := decodeYAMLDocuments(data, source)
+		if yamlErr != nil {
+			return nil, fmt.Errorf("%v; %w", jsonErr, yamlErr)
+		}
+		return ds, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+func decodeJSONDocuments(data []byte, source string) (DocumentSet, error) {
+	var ds DocumentSet
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		n, err := DecodeJSON(dec)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, &Document{Node: n, Source: source, Format: FormatJSON, Index: len(ds)})
+	}
+	return ds, nil
+}
+
+func decodeYAMLDocuments(data []byte, source string) (DocumentSet, error) {
+	var ds DocumentSet
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		n, err := DecodeYAML(dec)
+		if err != nil {
+			if err == io.EOF {
+				return ds, nil
+			}
+			return nil, err
+		}
+		ds = append(ds, &Document{Node: n, Source: source, Format: FormatYAML, Index: len(ds)})
+	}
+}
+
+// LoadDocuments loads all documents from the files.
+// The format of each file is guessed by GuessFormat or the contents.
+func LoadDocuments(filenames ...string) (DocumentSet, error) {
+	return loadDocuments(func(name string) (io.ReadCloser, error) {
+		return os.Open(name)
+	}, filenames)
+}
+
+// LoadDocumentsFS loads all documents from the files in fsys like embed.FS
+// or fstest.MapFS. The format of each file is guessed by GuessFormat or the
+// contents.
+func LoadDocumentsFS(fsys fs.FS, names ...string) (DocumentSet, error) {
+	return loadDocuments(func(name string) (io.ReadCloser, error) {
+		return fsys.Open(name)
+	}, names)
+}
+
+func loadDocuments(open func(name string) (io.ReadCloser, error), names []string) (DocumentSet, error) {
+	var ds DocumentSet
+	for _, name := range names {
+		f, err := open(name)
+		if err != nil {
+			return nil, err
+		}
+		fds, err := DecodeDocuments(f, name, GuessFormat(name))
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		ds = append(ds, fds...)
+	}
+	return ds, nil
+}
+
+// Nodes returns the nodes of the documents.
+func (ds DocumentSet) Nodes() []Node {
+	ns := make([]Node, len(ds))
+	for i, d := range ds {
+		ns[i] = d.Node
+	}
+	return ns
+}
+
+// Sources returns the unique sources of the documents in order.
+func (ds DocumentSet) Sources() []string {
+	var sources []string
+	seen := map[string]bool{}
+	for _, d := range ds {
+		if !seen[d.Source] {
+			seen[d.Source] = true
+			sources = append(sources, d.Source)
+		}
+	}
+	return sources
+}
+
+// BySource returns the documents of the source.
+func (ds DocumentSet) BySource(source string) DocumentSet {
+	var sds DocumentSet
+	for _, d := range ds {
+		if d.Source == source {
+			sds = append(sds, d)
+		}
+	}
+	return sds
+}
+
+// Encode writes the documents to w as the format.
+// If the format is empty, the format of the first document is used.
+// JSON documents are indented and YAML documents are separated by "---".
+func (ds DocumentSet) Encode(w io.Writer, format Format) error {
+	if format == "" && len(ds) > 0 {
+		format = ds[0].Format
+	}
+	for i, d := range ds {
+		switch format {
+		case FormatJSON:
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(d.Node); err != nil {
+				return err
+			}
+		case FormatYAML:
+			if i > 0 {
+				if _, err := io.WriteString(w, "---\n"); err != nil {
+					return err
+				}
+			}
+			if err := newYAMLEncoder(w).Encode(d.Node); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown format %q", format)
+		}
+	}
+	return nil
+}
+
+// Save writes the documents back to each source file in its format.
+// The file is replaced by ReplaceFile.
+func (ds DocumentSet) Save() error {
+	for _, source := range ds.Sources() {
+		if err := ds.BySource(source).saveFile(source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ds DocumentSet) saveFile(filename string) error {
+	return ReplaceFile(filename, func(w io.Writer) error {
+		return ds.Encode(w, "")
+	})
+}
+
+// WriteFileFS is a file system that can write files, like the writable file
+// systems of github.com/jarxorg/wfs.
+type WriteFileFS interface {
+	fs.FS
+	// WriteFile writes data to the named file, creating it if necessary.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// SaveFS writes the documents back to each source file in fsys in its format.
+// The file mode of the existing file is preserved.
+func (ds DocumentSet) SaveFS(fsys WriteFileFS) error {
+	for _, source := range ds.Sources() {
+		perm := fs.FileMode(0644)
+		if info, err := fs.Stat(fsys, source); err == nil {
+			perm = info.Mode().Perm()
+		}
+		buf := new(bytes.Buffer)
+		if err := ds.BySource(source).Encode(buf, ""); err != nil {
+			return err
+		}
+		if err := fsys.WriteFile(source, buf.Bytes(), perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package tree
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_GuessFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     Format
+	}{
+		{filename: "a.json", want: FormatJSON},
+		{filename: "a.JSON", want: FormatJSON},
+		{filename: "a.yaml", want: FormatYAML},
+		{filename: "dir/a.yml", want: FormatYAML},
+		{filename: "a.txt", want: ""},
+		{filename: "a", want: ""},
+	}
+	for i, test := range tests {
+		if got := GuessFormat(test.filename); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func Test_DecodeDocuments(t *testing.T) {
+	tests := []struct {
+		data   string
+		format Format
+		want   DocumentSet
+		errstr string
+	}{
+		{
+			data: `{"a":1} {"a":2}`,
+			want: DocumentSet{
+				{Node: Map{"a": ToValue(1)}, Source: "src", Format: FormatJSON, Index: 0},
+				{Node: Map{"a": ToValue(2)}, Source: "src", Format: FormatJSON, Index: 1},
+			},
+		}, {
+			data: "a: 1\n---\n- 2\n",
+			want: DocumentSet{
+				{Node: Map{"a": ToValue(1)}, Source: "src", Format: FormatYAML, Index: 0},
+				{Node: ToArrayValues(2), Source: "src", Format: FormatYAML, Index: 1},
+			},
+		}, {
+			data:   `{"a":1}`,
+			format: FormatYAML,
+			want: DocumentSet{
+				{Node: Map{"a": ToValue(1)}, Source: "src", Format: FormatYAML, Index: 0},
+			},
+		}, {
+			data:   "{\"a\": 1, /* comment */}",
+			format: FormatJSONC,
+			want: DocumentSet{
+				{Node: Map{"a": ToValue(1)}, Source: "src", Format: FormatJSON, Index: 0},
+			},
+		}, {
+			data:   "",
+			format: FormatJSON,
+		}, {
+			data:   "a: 1",
+			format: FormatJSON,
+			errstr: "invalid character 'a' looking for beginning of value",
+		}, {
+			data:   "{}",
+			format: "xml",
+			errstr: `unknown format "xml"`,
+		},
+	}
+	for i, test := range tests {
+		got, err := DecodeDocuments(strings.NewReader(test.data), "src", test.format)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func Test_DocumentSet_Encode(t *testing.T) {
+	ds := DocumentSet{
+		{Node: Map{"a": ToValue(1)}, Format: FormatYAML},
+		{Node: ToArrayValues(2), Format: FormatYAML},
+	}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{format: "", want: "a: 1\n---\n- 2\n"},
+		{format: FormatJSON, want: "{\n  \"a\": 1\n}\n[\n  2\n]\n"},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		if err := ds.Encode(buf, test.format); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func Test_LoadDocuments_Save(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yaml")
+	b := filepath.Join(dir, "b.json")
+	if err := os.WriteFile(a, []byte("id: 1\n---\nid: 2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(`{"id":3}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := LoadDocuments(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ds.Sources(), []string{a, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got sources %v; want %v", got, want)
+	}
+	if got, want := len(ds.BySource(a)), 2; got != want {
+		t.Errorf("got %d documents; want %d", got, want)
+	}
+	for _, d := range ds {
+		if err := Edit(&d.Node, ".ok = true"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ds.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		filename string
+		want     string
+		perm     os.FileMode
+	}{
+		{filename: a, want: "id: 1\nok: true\n---\nid: 2\nok: true\n", perm: 0600},
+		{filename: b, want: "{\n  \"id\": 3,\n  \"ok\": true\n}\n", perm: 0644},
+	}
+	for i, test := range tests {
+		got, err := os.ReadFile(test.filename)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+		info, err := os.Stat(test.filename)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if info.Mode().Perm() != test.perm {
+			t.Errorf("tests[%d] got mode %v; want %v", i, info.Mode().Perm(), test.perm)
+		}
+	}
+	if _, err := LoadDocuments(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("no error")
+	}
+}
+
+func Test_LoadDocuments_Save_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "a.json")
+	link := filepath.Join(dir, "link.json")
+	if err := os.WriteFile(name, []byte(`{"id":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(name, link); err != nil {
+		t.Skip(err)
+	}
+
+	ds, err := LoadDocuments(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Edit(&ds[0].Node, ".ok = true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s is not a symlink", link)
+	}
+	if info, err = os.Stat(name); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Mode().Perm(), os.FileMode(0600); got != want {
+		t.Errorf("got mode %v; want %v", got, want)
+	}
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"id\": 1,\n  \"ok\": true\n}\n"; string(got) != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+type mapWriteFS struct {
+	fstest.MapFS
+}
+
+func (fsys mapWriteFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	fsys.MapFS[name] = &fstest.MapFile{Data: data, Mode: perm}
+	return nil
+}
+
+func Test_LoadDocumentsFS_SaveFS(t *testing.T) {
+	fsys := mapWriteFS{fstest.MapFS{
+		"a.yaml":     {Data: []byte("id: 1\n---\nid: 2\n"), Mode: 0600},
+		"dir/b.json": {Data: []byte(`{"id":3}`), Mode: 0640},
+	}}
+	ds, err := LoadDocumentsFS(fsys, "a.yaml", "dir/b.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(ds), 3; got != want {
+		t.Fatalf("got %d documents; want %d", got, want)
+	}
+	for _, d := range ds {
+		if err := Edit(&d.Node, ".ok = true"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ds.SaveFS(fsys); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+		perm fs.FileMode
+	}{
+		{name: "a.yaml", want: "id: 1\nok: true\n---\nid: 2\nok: true\n", perm: 0600},
+		{name: "dir/b.json", want: "{\n  \"id\": 3,\n  \"ok\": true\n}\n", perm: 0640},
+	}
+	for i, test := range tests {
+		f := fsys.MapFS[test.name]
+		if got := string(f.Data); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+		if f.Mode != test.perm {
+			t.Errorf("tests[%d] got mode %v; want %v", i, f.Mode, test.perm)
+		}
+	}
+
+	if _, err := LoadDocumentsFS(fsys, "missing.json"); err == nil {
+		t.Errorf("no error")
+	}
+}
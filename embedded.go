@@ -0,0 +1,208 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+)
+
+// embeddedCodec decodes and encodes the documents embedded in string values.
+type embeddedCodec struct {
+	decode func(s string) (Node, error)
+	encode func(n Node) (string, error)
+}
+
+var embeddedCodecs = map[string]embeddedCodec{
+	"fromjson": {decode: decodeEmbeddedJSON, encode: encodeEmbeddedJSON},
+	"fromyaml": {decode: decodeEmbeddedYAML, encode: encodeEmbeddedYAML},
+}
+
+func init() {
+	RegisterMethod("fromjson", fromEmbedded("fromjson"))
+	RegisterMethod("fromyaml", fromEmbedded("fromyaml"))
+	RegisterMethod("tojson", toEmbedded("tojson", encodeEmbeddedJSON))
+	RegisterMethod("toyaml", toEmbedded("toyaml", encodeEmbeddedYAML))
+}
+
+func decodeEmbeddedJSON(s string) (Node, error) {
+	return UnmarshalJSON([]byte(s))
+}
+
+func encodeEmbeddedJSON(n Node) (string, error) {
+	b, err := MarshalJSON(n)
+	return string(b), err
+}
+
+func decodeEmbeddedYAML(s string) (Node, error) {
+	return UnmarshalYAML([]byte(s))
+}
+
+func encodeEmbeddedYAML(n Node) (string, error) {
+	b, err := MarshalYAML(n)
+	return string(b), err
+}
+
+// fromEmbedded returns the method like "fromjson()" that parses the string
+// value as a document. It returns no results if n is not a string.
+func fromEmbedded(name string) MethodFunc {
+	codec := embeddedCodecs[name]
+	return func(ctx context.Context, n Node, args []Query) ([]Node, error) {
+		if err := checkMethodArgs(name, args, 0, 0); err != nil {
+			return nil, err
+		}
+		if !n.Type().IsStringValue() {
+			return nil, nil
+		}
+		d, err := codec.decode(n.Value().String())
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %w", name, err)
+		}
+		return []Node{d}, nil
+	}
+}
+
+// toEmbedded returns the method like "tojson()" that serializes the node
+// as a string value.
+func toEmbedded(name string, encode func(n Node) (string, error)) MethodFunc {
+	return func(ctx context.Context, n Node, args []Query) ([]Node, error) {
+		if err := checkMethodArgs(name, args, 0, 0); err != nil {
+			return nil, err
+		}
+		s, err := encode(n)
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %w", name, err)
+		}
+		return []Node{StringValue(s)}, nil
+	}
+}
+
+// embeddedIndex returns the index of the first "fromjson()" or "fromyaml()"
+// in fq that is followed by other queries, or -1 if not found.
+func embeddedIndex(fq FilterQuery) int {
+	for i, q := range fq[:len(fq)-1] {
+		if mq, ok := q.(MethodQuery); ok {
+			if _, ok := embeddedCodecs[mq.Name]; ok && len(mq.Args) == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// execForEditEmbedded edits the documents embedded in the string values
+// selected by fq[:i]. The documents are decoded by fq[i], edited by the rest
+// of fq and encoded back to the string values, so the formatting of the
+// embedded documents is not preserved.
+func execForEditEmbedded(ctx context.Context, pn *Node, fq FilterQuery, i int, op string, v Node) error {
+	name := fq[i].(MethodQuery).Name
+	codec := embeddedCodecs[name]
+	var rest Query = fq[i+1:]
+	if len(fq) == i+2 {
+		rest = fq[i+1]
+	}
+	edit := func(pn *Node) error {
+		if *pn == nil || !(*pn).Type().IsStringValue() {
+			return nil
+		}
+		d, err := codec.decode((*pn).Value().String())
+		if err != nil {
+			return fmt.Errorf("%s(): %w", name, err)
+		}
+		if err := editQuery(ctx, &d, rest, op, v); err != nil {
+			return err
+		}
+		s, err := codec.encode(d)
+		if err != nil {
+			return fmt.Errorf("%s(): %w", name, err)
+		}
+		*pn = StringValue(s)
+		return nil
+	}
+	// NOTE: The queries before fq[i] are like "config" and NopQuery{} for ".config.fromjson()".
+	var head FilterQuery
+	for _, q := range fq[:i] {
+		if _, ok := q.(NopQuery); !ok {
+			head = append(head, q)
+		}
+	}
+	if len(head) == 0 {
+		return edit(pn)
+	}
+	parents := []Node{*pn}
+	if len(head) > 1 {
+		var err error
+		parents, err = head.execForEdit(ctx, *pn)
+		if err != nil {
+			return err
+		}
+	}
+	switch q := head[len(head)-1].(type) {
+	case SelectQuery:
+		for _, p := range parents {
+			if err := editSelected(ctx, p, q, edit); err != nil {
+				return err
+			}
+		}
+		return nil
+	case EditorQuery:
+		for _, p := range parents {
+			rs, err := ExecContext(ctx, q, p)
+			if err != nil {
+				return err
+			}
+			for _, r := range rs {
+				if err := edit(&r); err != nil {
+					return err
+				}
+				if err := q.Set(&p, r); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("syntax error: unsupported edit query: %s", head[len(head)-1])
+}
+
+// editSelected edits the elements of the array or the map n those match the
+// selector of q in place.
+func editSelected(ctx context.Context, n Node, q SelectQuery, edit func(pn *Node) error) error {
+	matches := func(e Node) (bool, error) {
+		if q.Selector == nil {
+			return true, nil
+		}
+		return MatchesContext(ctx, q.Selector, e)
+	}
+	switch n.Type() {
+	case TypeArray:
+		a := n.Array()
+		for i := range a {
+			ok, err := matches(a[i])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if err := edit(&a[i]); err != nil {
+				return err
+			}
+		}
+	case TypeMap:
+		m := n.Map()
+		for _, k := range m.Keys() {
+			e := m[k]
+			ok, err := matches(e)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if err := edit(&e); err != nil {
+				return err
+			}
+			m[k] = e
+		}
+	}
+	return nil
+}
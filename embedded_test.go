@@ -0,0 +1,126 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_EmbeddedMethods(t *testing.T) {
+	n := Map{
+		"annotations": Map{
+			"config": ToValue(`{"a":1,"b":{"c":[2,3]}}`),
+			"values": ToValue("image:\n  tag: v1\n"),
+			"broken": ToValue(`{"a":`),
+		},
+		"number": ToValue(1),
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.annotations.config.fromjson().b.c[1]`,
+			want: ToNodeValues(3),
+		}, {
+			expr: `.annotations.values.fromyaml().image.tag`,
+			want: ToNodeValues("v1"),
+		}, {
+			expr: `.annotations.config.fromjson().b.tojson()`,
+			want: ToNodeValues(`{"c":[2,3]}`),
+		}, {
+			expr: `.annotations.config.fromjson().toyaml()`,
+			want: ToNodeValues("a: 1\nb:\n  c:\n    - 2\n    - 3\n"),
+		}, {
+			expr: `.number.tojson()`,
+			want: ToNodeValues("1"),
+		}, {
+			expr: `.number.fromjson()`,
+		}, {
+			expr:   `.annotations.broken.fromjson()`,
+			errstr: "fromjson(): EOF",
+		}, {
+			expr:   `.annotations.config.fromjson(1)`,
+			errstr: "invalid number of arguments for fromjson(): 1",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func Test_Edit_Embedded(t *testing.T) {
+	tests := []struct {
+		n      Node
+		expr   string
+		want   Node
+		errstr string
+	}{
+		{
+			n:    Map{"config": ToValue(`{"a":1}`)},
+			expr: `.config.fromjson().a = 2`,
+			want: Map{"config": ToValue(`{"a":2}`)},
+		}, {
+			n:    Map{"config": ToValue(`{"a":1}`)},
+			expr: `.config.fromjson().b += "x"`,
+			want: Map{"config": ToValue(`{"a":1,"b":["x"]}`)},
+		}, {
+			n:    Map{"config": ToValue(`{"a":1,"b":2}`)},
+			expr: `.config.fromjson().a ^?`,
+			want: Map{"config": ToValue(`{"b":2}`)},
+		}, {
+			n:    Map{"values": ToValue("image:\n  tag: v1\n")},
+			expr: `.values.fromyaml().image.tag = "v2"`,
+			want: Map{"values": ToValue("image:\n  tag: v2\n")},
+		}, {
+			n:    Array{ToValue(`{"a":1}`), ToValue(2), ToValue(`{"a":3}`)},
+			expr: `[].fromjson().a = 0`,
+			want: Array{ToValue(`{"a":0}`), ToValue(2), ToValue(`{"a":0}`)},
+		}, {
+			n:    ToValue(`{"a":1}`),
+			expr: `.fromjson().a = 2`,
+			want: ToValue(`{"a":2}`),
+		}, {
+			n:    Map{"outer": ToValue(`{"inner":"{\"a\":1}"}`)},
+			expr: `.outer.fromjson().inner.fromjson().a = 2`,
+			want: Map{"outer": ToValue(`{"inner":"{\"a\":2}"}`)},
+		}, {
+			n:      Map{"config": ToValue(`{"a":`)},
+			expr:   `.config.fromjson().a = 2`,
+			errstr: "fromjson(): EOF",
+		},
+	}
+	for i, test := range tests {
+		err := Edit(&test.n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] for %v; no error", i, test.expr)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] for %v; got %s; want %s", i, test.expr, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] for %v; %+v", i, test.expr, err)
+		}
+		if !reflect.DeepEqual(test.n, test.want) {
+			t.Errorf("tests[%d] for %v; got %#v; want %#v", i, test.expr, test.n, test.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	yamlv2 "gopkg.in/yaml.v2"
+)
+
+// FloatFormat represents the format of numbers.
+type FloatFormat int
+
+const (
+	// FloatFormatDefault formats numbers as each encoder does by default.
+	FloatFormatDefault FloatFormat = iota
+	// FloatFormatShortest formats numbers in the shortest representation
+	// that round-trips like 0.1 and 1e+21. The exponent is used if the
+	// absolute value is less than 1e-6 or greater than or equal to 1e21.
+	FloatFormatShortest
+	// FloatFormatScientific formats numbers like 1.5e+20.
+	FloatFormatScientific
+	// FloatFormatFixed formats numbers like 150000000000000000000.00.
+	FloatFormatFixed
+)
+
+// ParseFloatFormat parses "shortest", "scientific" or "fixed" to the FloatFormat.
+// The empty string is FloatFormatDefault.
+func ParseFloatFormat(s string) (FloatFormat, error) {
+	switch s {
+	case "":
+		return FloatFormatDefault, nil
+	case "shortest":
+		return FloatFormatShortest, nil
+	case "scientific":
+		return FloatFormatScientific, nil
+	case "fixed":
+		return FloatFormatFixed, nil
+	}
+	return FloatFormatDefault, fmt.Errorf("unknown float format %q", s)
+}
+
+// EncodeOptions represents the options to encode nodes.
+type EncodeOptions struct {
+	// ASCII escapes the non-ASCII characters of JSON strings as \uXXXX.
+	ASCII bool
+	// NoEscapeHTML writes <, > and & of JSON strings as is instead of
+	// \u003c, \u003e and \u0026. ColorEncoder never escapes them.
+	NoEscapeHTML bool
+	// FloatFormat is the format of numbers.
+	FloatFormat FloatFormat
+	// FloatPrecision is the number of digits after the decimal point of
+	// FloatFormatScientific and FloatFormatFixed. -1 uses the smallest number
+	// of digits necessary to represent the value exactly.
+	FloatPrecision int
+	// YAMLAlwaysQuote quotes all YAML string values except multiline ones.
+	// The keys are quoted only if needed.
+	YAMLAlwaysQuote bool
+	// YAMLSingleQuote quotes YAML strings with single quotes instead of
+	// double quotes unless they have control characters.
+	YAMLSingleQuote bool
+	// YAMLFlowWidth writes the nested YAML maps and arrays in flow style
+	// such as {a: 1, b: [2, 3]} if the lines fit in the width. 0 disables it.
+	YAMLFlowWidth int
+	// MaxWidth writes the JSON arrays and objects in one line such as
+	// {"a": 1, "b": [2, 3]} if the lines fit in the width. 0 disables it.
+	MaxWidth int
+	// YAMLV2 writes YAML in the format of yaml.v2 that the previous versions
+	// used, that does not indent the arrays in maps.
+	YAMLV2 bool
+}
+
+// isDefaultYAML reports whether yaml.v3 can encode YAML with the options.
+func (o EncodeOptions) isDefaultYAML() bool {
+	return o.FloatFormat == FloatFormatDefault && !o.YAMLAlwaysQuote && !o.YAMLSingleQuote &&
+		o.YAMLFlowWidth <= 0 && keyLess == nil
+}
+
+// FormatFloat formats f using FloatFormat and FloatPrecision. It returns
+// the same string as NumberValue.String if FloatFormat is FloatFormatDefault.
+func (o EncodeOptions) FormatFloat(f float64) string {
+	switch o.FloatFormat {
+	case FloatFormatShortest:
+		if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+			s := strconv.FormatFloat(f, 'e', -1, 64)
+			// NOTE: Clean up e-09 to e-9 like encoding/json.
+			if n := len(s); n >= 4 && s[n-4] == 'e' && s[n-2] == '0' {
+				s = s[:n-2] + s[n-1:]
+			}
+			return s
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	case FloatFormatScientific:
+		return strconv.FormatFloat(f, 'e', o.FloatPrecision, 64)
+	case FloatFormatFixed:
+		return strconv.FormatFloat(f, 'f', o.FloatPrecision, 64)
+	}
+	return NumberValue(f).String()
+}
+
+// jsonNumbers returns a copy of n that the numbers are formatted by FormatFloat.
+func (o EncodeOptions) jsonNumbers(n Node) interface{} {
+	if n == nil {
+		return nil
+	}
+	switch n.Type() {
+	case TypeArray:
+		a := n.Array()
+		x := make([]interface{}, len(a))
+		for i, v := range a {
+			x[i] = o.jsonNumbers(v)
+		}
+		return x
+	case TypeMap:
+		m := n.Map()
+		x := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			x[k] = o.jsonNumbers(v)
+		}
+		return x
+	case TypeNumberValue:
+		f := n.Value().Float64()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return n
+		}
+		return json.Number(o.FormatFloat(f))
+	}
+	return n
+}
+
+// EncodeJSON writes the JSON encoding of n indented by two spaces to w.
+// If MaxWidth or the key order by SetKeyOrder is set, n is encoded by
+// ColorEncoder without colors.
+func (o EncodeOptions) EncodeJSON(w io.Writer, n Node) error {
+	if o.MaxWidth > 0 || keyLess != nil {
+		e := &ColorEncoder{Out: w, IndentSize: 2, NoColor: true, EncodeOptions: o, escapeHTML: !o.NoEscapeHTML}
+		return e.EncodeJSON(n)
+	}
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(!o.NoEscapeHTML)
+	var v interface{} = n
+	if o.FloatFormat != FloatFormatDefault {
+		v = o.jsonNumbers(n)
+	}
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	b := buf.Bytes()
+	if o.ASCII {
+		// NOTE: The non-ASCII characters of the JSON encoding are only in strings.
+		b = appendASCII(nil, string(b))
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// EncodeYAML writes the YAML encoding of n indented by two spaces to w. If
+// the options or SetKeyOrder change the format of numbers, the quoting, the
+// style or the order of keys, n is encoded by ColorEncoder without colors
+// because yaml.v3 does not support them.
+func (o EncodeOptions) EncodeYAML(w io.Writer, n Node) error {
+	if !o.isDefaultYAML() {
+		e := &ColorEncoder{Out: w, IndentSize: 2, NoColor: true, EncodeOptions: o}
+		return e.EncodeYAML(n)
+	}
+	if o.YAMLV2 {
+		return yamlv2.NewEncoder(w).Encode(n)
+	}
+	return newYAMLEncoder(w).Encode(n)
+}
+
+// appendASCII appends s to b escaping the non-ASCII characters as \uXXXX.
+func appendASCII(b []byte, s string) []byte {
+	for _, c := range s {
+		if c < utf8.RuneSelf {
+			b = append(b, byte(c))
+			continue
+		}
+		if r1, r2 := utf16.EncodeRune(c); r1 != utf8.RuneError {
+			b = appendUnicodeEscape(b, r1)
+			b = appendUnicodeEscape(b, r2)
+			continue
+		}
+		b = appendUnicodeEscape(b, c)
+	}
+	return b
+}
+
+func appendUnicodeEscape(b []byte, c rune) []byte {
+	return append(b, '\\', 'u', hex[c>>12&0xF], hex[c>>8&0xF], hex[c>>4&0xF], hex[c&0xF])
+}
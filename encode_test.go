@@ -0,0 +1,182 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeOptions_EncodeJSON(t *testing.T) {
+	n := Map{"a": ToValue("<é😀>"), "b": ToValue(1)}
+	tests := []struct {
+		opts EncodeOptions
+		want string
+	}{
+		{
+			want: "{\n  \"a\": \"\\u003cé😀\\u003e\",\n  \"b\": 1\n}\n",
+		}, {
+			opts: EncodeOptions{ASCII: true},
+			want: "{\n  \"a\": \"\\u003c\\u00e9\\ud83d\\ude00\\u003e\",\n  \"b\": 1\n}\n",
+		}, {
+			opts: EncodeOptions{NoEscapeHTML: true},
+			want: "{\n  \"a\": \"<é😀>\",\n  \"b\": 1\n}\n",
+		}, {
+			opts: EncodeOptions{ASCII: true, NoEscapeHTML: true},
+			want: "{\n  \"a\": \"<\\u00e9\\ud83d\\ude00>\",\n  \"b\": 1\n}\n",
+		},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		if err := test.opts.EncodeJSON(out, n); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestEncodeOptions_FormatFloat(t *testing.T) {
+	tests := []struct {
+		opts EncodeOptions
+		f    float64
+		want string
+	}{
+		{f: 1e20, want: "100000000000000000000"},
+		{f: 8.95, want: "8.95"},
+		{opts: EncodeOptions{FloatFormat: FloatFormatShortest}, f: 1e21, want: "1e+21"},
+		{opts: EncodeOptions{FloatFormat: FloatFormatShortest}, f: 1e-7, want: "1e-7"},
+		{opts: EncodeOptions{FloatFormat: FloatFormatShortest}, f: 0.1, want: "0.1"},
+		{opts: EncodeOptions{FloatFormat: FloatFormatShortest}, f: 0, want: "0"},
+		{opts: EncodeOptions{FloatFormat: FloatFormatScientific, FloatPrecision: -1}, f: 1e20, want: "1e+20"},
+		{opts: EncodeOptions{FloatFormat: FloatFormatScientific, FloatPrecision: 2}, f: 8.95, want: "8.95e+00"},
+		{opts: EncodeOptions{FloatFormat: FloatFormatFixed, FloatPrecision: 2}, f: 3, want: "3.00"},
+		{opts: EncodeOptions{FloatFormat: FloatFormatFixed, FloatPrecision: 0}, f: 2.5, want: "2"},
+	}
+	for i, test := range tests {
+		if got := test.opts.FormatFloat(test.f); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestEncodeOptions_FloatFormat(t *testing.T) {
+	n := Map{"a": ToValue(1e20), "b": Array{ToValue(1.5), ToValue("x")}}
+	opts := EncodeOptions{FloatFormat: FloatFormatScientific, FloatPrecision: 1}
+
+	out := new(bytes.Buffer)
+	if err := opts.EncodeJSON(out, n); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"a\": 1.0e+20,\n  \"b\": [\n    1.5e+00,\n    \"x\"\n  ]\n}\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	out.Reset()
+	if err := opts.EncodeYAML(out, n); err != nil {
+		t.Fatal(err)
+	}
+	want = "a: 1.0e+20\nb:\n  - 1.5e+00\n  - x\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestParseFloatFormat(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   FloatFormat
+		errstr string
+	}{
+		{s: "", want: FloatFormatDefault},
+		{s: "shortest", want: FloatFormatShortest},
+		{s: "scientific", want: FloatFormatScientific},
+		{s: "fixed", want: FloatFormatFixed},
+		{s: "x", errstr: `unknown float format "x"`},
+	}
+	for i, test := range tests {
+		got, err := ParseFloatFormat(test.s)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestEncodeOptions_EncodeYAML_Quote(t *testing.T) {
+	n := Map{"a": ToValue("yes"), "b": ToValue("x")}
+	tests := []struct {
+		opts EncodeOptions
+		want string
+	}{
+		{opts: EncodeOptions{}, want: "a: \"yes\"\nb: x\n"},
+		{opts: EncodeOptions{YAMLSingleQuote: true}, want: "a: 'yes'\nb: x\n"},
+		{opts: EncodeOptions{YAMLAlwaysQuote: true}, want: "a: \"yes\"\nb: \"x\"\n"},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		if err := test.opts.EncodeYAML(out, n); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestEncodeOptions_EncodeYAML_V2(t *testing.T) {
+	n := Map{"a": Array{ToValue(1), Map{"b": ToValue("x")}}}
+	tests := []struct {
+		opts EncodeOptions
+		want string
+	}{
+		{opts: EncodeOptions{}, want: "a:\n  - 1\n  - b: x\n"},
+		{opts: EncodeOptions{YAMLV2: true}, want: "a:\n- 1\n- b: x\n"},
+		{opts: EncodeOptions{YAMLV2: true, YAMLAlwaysQuote: true}, want: "a:\n- 1\n- b: \"x\"\n"},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		if err := test.opts.EncodeYAML(out, n); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestEncodeOptions_EncodeJSON_MaxWidth(t *testing.T) {
+	n := Map{"a": Array{ToValue("<&>"), ToValue(1.5)}}
+	tests := []struct {
+		opts EncodeOptions
+		want string
+	}{
+		{
+			opts: EncodeOptions{MaxWidth: 80},
+			want: `{"a": ["\u003c\u0026\u003e", 1.5]}` + "\n",
+		}, {
+			opts: EncodeOptions{MaxWidth: 80, NoEscapeHTML: true},
+			want: `{"a": ["<&>", 1.5]}` + "\n",
+		}, {
+			opts: EncodeOptions{MaxWidth: 10},
+			want: "{\n  \"a\": [\n    \"\\u003c\\u0026\\u003e\",\n    1.5\n  ]\n}\n",
+		},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		if err := test.opts.EncodeJSON(out, n); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package tree
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+)
+
+func init() {
+	RegisterMethod("b64encode", stringMethod("b64encode", b64encode))
+	RegisterMethod("b64decode", stringMethod("b64decode", b64decode))
+	RegisterMethod("urlencode", stringMethod("urlencode", urlencode))
+	RegisterMethod("urldecode", stringMethod("urldecode", urldecode))
+}
+
+// stringMethod returns the MethodFunc that takes no arguments and converts
+// a string value by fn. It returns no results if n is not a string.
+func stringMethod(name string, fn func(s string) (string, error)) MethodFunc {
+	return func(ctx context.Context, n Node, args []Query) ([]Node, error) {
+		if err := checkMethodArgs(name, args, 0, 0); err != nil {
+			return nil, err
+		}
+		if !n.Type().IsStringValue() {
+			return nil, nil
+		}
+		s, err := fn(n.Value().String())
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %w", name, err)
+		}
+		return []Node{StringValue(s)}, nil
+	}
+}
+
+// b64encode encodes s with the standard base64 encoding.
+func b64encode(s string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(s)), nil
+}
+
+// b64decode decodes s with the standard base64 encoding.
+// The padding is optional.
+func b64decode(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		var rawErr error
+		if b, rawErr = base64.RawStdEncoding.DecodeString(s); rawErr != nil {
+			return "", err
+		}
+	}
+	return string(b), nil
+}
+
+// urlencode escapes s so it can be placed inside a URL query.
+func urlencode(s string) (string, error) {
+	return url.QueryEscape(s), nil
+}
+
+// urldecode unescapes s encoded by urlencode.
+func urldecode(s string) (string, error) {
+	return url.QueryUnescape(s)
+}
@@ -0,0 +1,65 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_EncodingMethods(t *testing.T) {
+	n := Map{
+		"data": Map{
+			"password": ToValue("c2VjcmV0"),
+			"token":    ToValue("dG9rZW4"),
+		},
+		"query":  ToValue("a b&c=d/é"),
+		"number": ToValue(1),
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.data.password.b64decode()`,
+			want: ToNodeValues("secret"),
+		}, {
+			expr: `.data.token.b64decode()`,
+			want: ToNodeValues("token"),
+		}, {
+			expr: `.data.password.b64decode().b64encode()`,
+			want: ToNodeValues("c2VjcmV0"),
+		}, {
+			expr: `.query.urlencode()`,
+			want: ToNodeValues("a+b%26c%3Dd%2F%C3%A9"),
+		}, {
+			expr: `.query.urlencode().urldecode()`,
+			want: ToNodeValues("a b&c=d/é"),
+		}, {
+			expr: `.number.b64encode()`,
+		}, {
+			expr:   `.query.b64decode()`,
+			errstr: "b64decode(): illegal base64 data at input byte 1",
+		}, {
+			expr:   `.data.password.b64decode(1)`,
+			errstr: "invalid number of arguments for b64decode(): 1",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/jarxorg/tree"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 func ExampleMarshalJSON() {
@@ -108,7 +110,7 @@ func ExampleMarshalYAML() {
 		"Name":   tree.ToValue("Reds"),
 		"Colors": tree.ToArrayValues("Crimson", "Red", "Ruby", "Maroon"),
 	}
-	b, err := yaml.Marshal(group)
+	b, err := tree.MarshalYAML(group)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -116,10 +118,10 @@ func ExampleMarshalYAML() {
 
 	// Output:
 	// Colors:
-	// - Crimson
-	// - Red
-	// - Ruby
-	// - Maroon
+	//   - Crimson
+	//   - Red
+	//   - Ruby
+	//   - Maroon
 	// ID: 1
 	// Name: Reds
 }
@@ -145,7 +147,7 @@ Name: Reds
 	// map[Colors:[Crimson Red Ruby Maroon] ID:1 Name:Reds]
 }
 
-func ExampleGet() {
+func ExampleMap_Get() {
 	group := tree.Map{
 		"ID":     tree.ToValue(1),
 		"Name":   tree.ToValue("Reds"),
@@ -214,3 +216,68 @@ func ExampleEdit() {
 	// Delete Colors:
 	//   map[ID:1 Name:Blue]
 }
+
+func ExampleNewMap() {
+	group, err := tree.NewMap().
+		Set("ID", 1).
+		Set("Name", "Reds").
+		SetPath("Colors[0]", "Crimson").
+		SetPath("Owner.Name", "Alice").
+		Build()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%+v\n", group)
+
+	// Output:
+	// map[Colors:[Crimson] ID:1 Name:Reds Owner:map[Name:Alice]]
+}
+
+func ExampleValues() {
+	group := tree.Map{
+		"Colors": tree.ToArray([]string{"Crimson", "Red", "Ruby", "Maroon"}),
+	}
+	found, err := tree.Find(group, `.Colors[1:3]`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	colors, err := tree.Values[string](found)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%q\n", colors)
+
+	// Output:
+	// ["Red" "Ruby"]
+}
+
+func ExampleDecodeDocuments() {
+	data := `name: app
+replicas: 1
+---
+name: db
+replicas: 1
+`
+	ds, err := tree.DecodeDocuments(strings.NewReader(data), "deployments.yaml", "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range ds {
+		fmt.Printf("%s[%d] %s: %v\n", d.Source, d.Index, d.Format, d.Node.Get("name"))
+		if err := tree.Edit(&d.Node, ".replicas = 2"); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := ds.Encode(os.Stdout, ""); err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// deployments.yaml[0] yaml: app
+	// deployments.yaml[1] yaml: db
+	// name: app
+	// replicas: 2
+	// ---
+	// name: db
+	// replicas: 2
+}
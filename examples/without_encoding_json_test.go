@@ -120,3 +120,42 @@ func ExampleJSONIteratorMarshal() {
 	// Output:
 	// {"Colors":["Crimson","Red","Ruby","Maroon"],"ID":1,"Name":"Reds"}
 }
+
+func ExampleGoJSONEngine() {
+	tree.SetJSONEngine(tree.JSONEngineFuncs{
+		MarshalFunc:   gojson.Marshal,
+		UnmarshalFunc: gojson.Unmarshal,
+	})
+	defer tree.SetJSONEngine(nil)
+
+	n, err := tree.UnmarshalJSON([]byte(`{"ID": 1, "Colors": ["Crimson", "Red"]}`))
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := tree.MarshalJSON(n)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(b))
+
+	// Output:
+	// {"Colors":["Crimson","Red"],"ID":1}
+}
+
+func ExampleJSONIteratorEngine() {
+	tree.SetJSONEngine(jsoniter.ConfigCompatibleWithStandardLibrary)
+	defer tree.SetJSONEngine(nil)
+
+	n, err := tree.UnmarshalJSON([]byte(`{"ID": 1, "Colors": ["Crimson", "Red"]}`))
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := tree.MarshalJSON(n)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(b))
+
+	// Output:
+	// {"Colors":["Crimson","Red"],"ID":1}
+}
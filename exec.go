@@ -0,0 +1,177 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrMaxDepthExceeded is returned when a query visits nodes deeper than ExecOptions.MaxDepth.
+	ErrMaxDepthExceeded = errors.New("max depth exceeded")
+	// ErrMaxResultsExceeded is returned when a query produces more results than ExecOptions.MaxResults.
+	ErrMaxResultsExceeded = errors.New("max results exceeded")
+)
+
+// ContextQuery is an interface that defines the methods to query a node with a context.
+type ContextQuery interface {
+	Query
+	ExecContext(ctx context.Context, n Node) ([]Node, error)
+}
+
+// ContextSelector is an interface that defines the methods to select a node with a context.
+type ContextSelector interface {
+	Selector
+	MatchesContext(ctx context.Context, n Node) (bool, error)
+}
+
+// ExecOptions represents the limits of executing queries.
+// The zero value of each field means no limit.
+type ExecOptions struct {
+	// MaxDepth limits the depth of nodes visited by recursive queries like "..key".
+	MaxDepth int
+	// MaxResults limits the number of results including intermediate results.
+	MaxResults int
+	// Timeout limits the duration of the execution.
+	Timeout time.Duration
+}
+
+type execOptionsKey struct{}
+
+// WithExecOptions returns a copy of ctx that holds the provided opts.
+func WithExecOptions(ctx context.Context, opts ExecOptions) context.Context {
+	return context.WithValue(ctx, execOptionsKey{}, opts)
+}
+
+func execOptionsFrom(ctx context.Context) ExecOptions {
+	opts, _ := ctx.Value(execOptionsKey{}).(ExecOptions)
+	return opts
+}
+
+// Exec executes q to n with the options.
+func (o ExecOptions) Exec(q Query, n Node) ([]Node, error) {
+	return o.ExecContext(context.Background(), q, n)
+}
+
+// ExecContext executes q to n with the options and ctx.
+func (o ExecOptions) ExecContext(ctx context.Context, q Query, n Node) ([]Node, error) {
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+	rs, err := ExecContext(WithExecOptions(ctx, o), q, n)
+	if err != nil {
+		return nil, err
+	}
+	if o.MaxResults > 0 && len(rs) > o.MaxResults {
+		return nil, ErrMaxResultsExceeded
+	}
+	return rs, nil
+}
+
+type execVarsKey struct{}
+
+// WithVariables returns a copy of ctx that holds the variables referred as
+// $name in queries. The variables held by ctx are inherited unless overridden.
+func WithVariables(ctx context.Context, vars Map) context.Context {
+	merged := Map{}
+	for k, v := range variablesFrom(ctx) {
+		merged[k] = v
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, execVarsKey{}, merged)
+}
+
+func variablesFrom(ctx context.Context) Map {
+	vars, _ := ctx.Value(execVarsKey{}).(Map)
+	return vars
+}
+
+// TraceFunc is the type of the function called after each step of the
+// top-level query is executed, with the step query and its results.
+type TraceFunc func(q Query, results []Node)
+
+type execTraceKey struct{}
+
+// WithTraceFunc returns a copy of ctx that holds fn to trace the steps of
+// the top-level query. The queries executed inside of the steps, like
+// selectors and method arguments, are not traced.
+func WithTraceFunc(ctx context.Context, fn TraceFunc) context.Context {
+	return context.WithValue(ctx, execTraceKey{}, fn)
+}
+
+func traceFuncFrom(ctx context.Context) TraceFunc {
+	fn, _ := ctx.Value(execTraceKey{}).(TraceFunc)
+	return fn
+}
+
+type execRootKey struct{}
+
+// withExecRoot returns a copy of ctx that holds n as the root node of the
+// execution if ctx does not hold it yet.
+func withExecRoot(ctx context.Context, n Node) context.Context {
+	if _, ok := ctx.Value(execRootKey{}).(Node); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, execRootKey{}, n)
+}
+
+// execRootFrom returns the root node of the execution or n if ctx does not hold it.
+func execRootFrom(ctx context.Context, n Node) Node {
+	if root, ok := ctx.Value(execRootKey{}).(Node); ok {
+		return root
+	}
+	return n
+}
+
+// ExecContext executes q to n with ctx.
+// If q does not implement ContextQuery, ctx is checked only before q.Exec is called.
+func ExecContext(ctx context.Context, q Query, n Node) ([]Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ctx = withExecPath(ctx, q)
+	ctx = withExecRoot(ctx, n)
+	if trace := traceFuncFrom(ctx); trace != nil {
+		if _, ok := q.(FilterQuery); !ok {
+			rs, err := ExecContext(WithTraceFunc(ctx, nil), q, n)
+			if err == nil {
+				trace(q, rs)
+			}
+			return rs, err
+		}
+	}
+	if cq, ok := q.(ContextQuery); ok {
+		return cq.ExecContext(ctx, n)
+	}
+	return q.Exec(n)
+}
+
+// MatchesContext evaluates s to n with ctx.
+// If s does not implement ContextSelector, ctx is checked only before s.Matches is called.
+func MatchesContext(ctx context.Context, s Selector, n Node) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if cs, ok := s.(ContextSelector); ok {
+		return cs.MatchesContext(ctx, n)
+	}
+	return s.Matches(n)
+}
+
+func checkMaxResults(ctx context.Context, n int) error {
+	if max := execOptionsFrom(ctx).MaxResults; max > 0 && n > max {
+		return ErrMaxResultsExceeded
+	}
+	return nil
+}
+
+func checkMaxDepth(ctx context.Context, depth int) error {
+	if max := execOptionsFrom(ctx).MaxDepth; max > 0 && depth > max {
+		return ErrMaxDepthExceeded
+	}
+	return nil
+}
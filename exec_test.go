@@ -0,0 +1,147 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_ExecOptions_Exec(t *testing.T) {
+	n := Map{
+		"a": Map{
+			"b": Map{
+				"c": Map{"id": ToValue(1)},
+			},
+			"id": ToValue(2),
+		},
+		"items": ToArrayValues(1, 2, 3, 4),
+	}
+	tests := []struct {
+		opts ExecOptions
+		expr string
+		want []Node
+		err  error
+	}{
+		{
+			opts: ExecOptions{},
+			expr: `..id`,
+			want: ToNodeValues(2, 1),
+		}, {
+			opts: ExecOptions{MaxDepth: 4},
+			expr: `..id`,
+			want: ToNodeValues(2, 1),
+		}, {
+			opts: ExecOptions{MaxDepth: 3},
+			expr: `..id`,
+			err:  ErrMaxDepthExceeded,
+		}, {
+			opts: ExecOptions{MaxResults: 4},
+			expr: `.items[]`,
+			want: ToNodeValues(1, 2, 3, 4),
+		}, {
+			opts: ExecOptions{MaxResults: 3},
+			expr: `.items[]`,
+			err:  ErrMaxResultsExceeded,
+		}, {
+			opts: ExecOptions{MaxResults: 1},
+			expr: `..id`,
+			err:  ErrMaxResultsExceeded,
+		}, {
+			opts: ExecOptions{MaxResults: 1},
+			expr: `.items[] | count()`,
+			err:  ErrMaxResultsExceeded,
+		}, {
+			opts: ExecOptions{Timeout: time.Minute},
+			expr: `.items[.== 2]`,
+			want: ToNodeValues(2),
+		},
+	}
+	for i, test := range tests {
+		q, err := ParseQuery(test.expr)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		got, err := test.opts.Exec(q, n)
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("tests[%d] got error %v; want %v", i, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func Test_ExecContext_Canceled(t *testing.T) {
+	n := Array{Map{"id": ToValue(1)}, Map{"id": ToValue(2)}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	exprs := []string{`.`, `..id`, `[].id`, `[.id == 1]`}
+	for i, expr := range exprs {
+		q, err := ParseQuery(expr)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if _, err := ExecContext(ctx, q, n); err != context.Canceled {
+			t.Errorf("tests[%d] got error %v; want %v", i, err, context.Canceled)
+		}
+	}
+}
+
+func Test_ExecOptions_ExecContext_Deadline(t *testing.T) {
+	a := Array{Map{"id": ToValue(1)}}
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	opts := ExecOptions{Timeout: time.Minute}
+	if _, err := opts.ExecContext(ctx, WalkQuery("id"), a); err != context.DeadlineExceeded {
+		t.Errorf("got error %v; want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func Test_WithTraceFunc(t *testing.T) {
+	n := Map{
+		"users": Array{
+			Map{"id": ToValue(1), "name": ToValue("one")},
+			Map{"id": ToValue(2), "name": ToValue("two")},
+		},
+	}
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{
+			expr: `.users[.id > 1].name`,
+			want: []string{`.users: 1`, `[.id > 1]: 1`, `.name: 1`},
+		}, {
+			expr: `.users[].id|`,
+			want: []string{`.users: 1`, `[]: 2`, `.id: 2`, ` | : 1`},
+		}, {
+			expr: `.users`,
+			want: []string{`.users: 1`},
+		}, {
+			expr: `.none.name`,
+			want: []string{`.none: 0`, `.name: 0`},
+		},
+	}
+	for i, test := range tests {
+		var got []string
+		ctx := WithTraceFunc(context.Background(), func(q Query, rs []Node) {
+			got = append(got, fmt.Sprintf("%s: %d", q, len(rs)))
+		})
+		if _, err := FindContext(ctx, n, test.expr); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
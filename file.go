@@ -0,0 +1,50 @@
+package tree
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ReplaceFile replaces the file with the contents written by write. The
+// contents are written to a temporary file in the same directory that is
+// synced and renamed to the file, so the file is never left half-written.
+// If the file is a symbolic link, the file it refers to is replaced. The
+// file mode and the owner of the existing file are preserved where
+// possible, and a new file is created with the mode 0644.
+func ReplaceFile(filename string, write func(w io.Writer) error) error {
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = resolved
+	}
+	perm := os.FileMode(0644)
+	info, err := os.Stat(filename)
+	if err == nil {
+		perm = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if info != nil {
+		if err := chownLike(tmp, info); err != nil && !os.IsPermission(err) {
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,67 @@
+package tree
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ReplaceFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		filename string
+		data     string
+		perm     os.FileMode
+		want     os.FileMode
+	}{
+		{filename: "a.txt", data: "a", perm: 0600, want: 0600},
+		{filename: "new.txt", data: "new", want: 0644},
+	}
+	for i, test := range tests {
+		name := filepath.Join(dir, test.filename)
+		if test.perm != 0 {
+			if err := os.WriteFile(name, []byte("old"), test.perm); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := ReplaceFile(name, func(w io.Writer) error {
+			_, err := io.WriteString(w, test.data)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if string(got) != test.data {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.data)
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if info.Mode().Perm() != test.want {
+			t.Errorf("tests[%d] got mode %v; want %v", i, info.Mode().Perm(), test.want)
+		}
+	}
+
+	name := filepath.Join(dir, "a.txt")
+	errWrite := errors.New("write error")
+	if err := ReplaceFile(name, func(w io.Writer) error { return errWrite }); err != errWrite {
+		t.Errorf("got %v; want %v", err, errWrite)
+	}
+	if got, err := os.ReadFile(name); err != nil || string(got) != "a" {
+		t.Errorf("got %q, %v; want %q", got, err, "a")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d files; want 2", len(entries))
+	}
+}
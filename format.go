@@ -0,0 +1,147 @@
+package tree
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strings"
+)
+
+func init() {
+	RegisterMethod("format", formatMethod)
+	RegisterMethod("tostring", tostring)
+	RegisterMethod("csv", csvMethod)
+	RegisterMethod("tsv", tsvMethod)
+}
+
+// formatMethod is the method "format(format, args...)" that formats the
+// arguments like fmt.Sprintf. Without the args, n is formatted.
+// Integral numbers are formatted as int64 so "%05d" works, and maps and
+// arrays are formatted as JSON.
+func formatMethod(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("format", args, 1, -1); err != nil {
+		return nil, err
+	}
+	f, err := execMethodArg(ctx, args[0], n)
+	if err != nil {
+		return nil, err
+	}
+	if !f.Type().IsStringValue() {
+		return nil, fmt.Errorf("invalid format for format(): %v", f)
+	}
+	vs := []interface{}{}
+	if len(args) == 1 {
+		v, err := formatArg(n)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	for _, arg := range args[1:] {
+		a, err := execMethodArg(ctx, arg, n)
+		if err != nil {
+			return nil, err
+		}
+		v, err := formatArg(a)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	return []Node{StringValue(fmt.Sprintf(f.Value().String(), vs...))}, nil
+}
+
+func formatArg(n Node) (interface{}, error) {
+	switch n.Type() {
+	case TypeNumberValue:
+		f := n.Value().Float64()
+		if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return int64(f), nil
+		}
+		return f, nil
+	case TypeStringValue:
+		return n.Value().String(), nil
+	case TypeBoolValue:
+		return n.Value().Bool(), nil
+	case TypeArray, TypeMap:
+		b, err := MarshalJSON(n)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+	return nil, nil
+}
+
+// tostring is the method "tostring()" that returns strings as is and the
+// other nodes as JSON.
+func tostring(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("tostring", args, 0, 0); err != nil {
+		return nil, err
+	}
+	if n.Type().IsStringValue() {
+		return []Node{n}, nil
+	}
+	b, err := MarshalJSON(n)
+	if err != nil {
+		return nil, fmt.Errorf("tostring(): %w", err)
+	}
+	return []Node{StringValue(b)}, nil
+}
+
+// rowFields returns the fields of the array n for csv() and tsv().
+// The nil values are empty strings.
+func rowFields(name string, n Node) ([]string, error) {
+	if !n.Type().IsArray() {
+		return nil, fmt.Errorf("%s(): cannot format %v", name, n)
+	}
+	a := n.Array()
+	fields := make([]string, len(a))
+	for i, e := range a {
+		if e == nil || !e.Type().IsValue() {
+			return nil, fmt.Errorf("%s(): cannot format %v", name, e)
+		}
+		fields[i] = e.Value().String()
+	}
+	return fields, nil
+}
+
+// csvMethod is the method "csv()" that formats the array as a line of CSV
+// without the line break. The fields are quoted if needed.
+func csvMethod(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("csv", args, 0, 0); err != nil {
+		return nil, err
+	}
+	fields, err := rowFields("csv", n)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if err := w.Write(fields); err != nil {
+		return nil, fmt.Errorf("csv(): %w", err)
+	}
+	w.Flush()
+	return []Node{StringValue(strings.TrimSuffix(buf.String(), "\n"))}, nil
+}
+
+var tsvReplacer = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// tsvMethod is the method "tsv()" that formats the array as a line of TSV
+// without the line break. The tabs, line breaks and backslashes in the
+// fields are escaped as "\t", "\n", "\r" and "\\".
+func tsvMethod(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("tsv", args, 0, 0); err != nil {
+		return nil, err
+	}
+	fields, err := rowFields("tsv", n)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range fields {
+		fields[i] = tsvReplacer.Replace(f)
+	}
+	return []Node{StringValue(strings.Join(fields, "\t"))}, nil
+}
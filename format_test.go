@@ -0,0 +1,82 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_FormatMethods(t *testing.T) {
+	n := Map{
+		"id":    ToValue(42),
+		"name":  ToValue("one"),
+		"price": ToValue(8.95),
+		"tags":  ToArrayValues("a", "b"),
+		"row":   Array{ToValue("x,y"), ToValue(`say "hi"`), ToValue(1.5), ToValue(true), Nil},
+		"tsv":   ToArrayValues("a\tb", "c\nd", `e\f`),
+		"items": Array{Map{"id": ToValue(1), "name": ToValue("one")}, Map{"id": ToValue(2), "name": ToValue("two")}},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.format("%05d: %s", .id, .name)`,
+			want: ToNodeValues("00042: one"),
+		}, {
+			expr: `.id.format("#%d")`,
+			want: ToNodeValues("#42"),
+		}, {
+			expr: `.format("%.2f %v %v", .price, .tags, .missing)`,
+			want: ToNodeValues(`8.95 ["a","b"] <nil>`),
+		}, {
+			expr: `.items[].format("%d=%s", .id, .name)`,
+			want: ToNodeValues("1=one", "2=two"),
+		}, {
+			expr: `.id.tostring()`,
+			want: ToNodeValues("42"),
+		}, {
+			expr: `.name.tostring()`,
+			want: ToNodeValues("one"),
+		}, {
+			expr: `.tags.tostring()`,
+			want: ToNodeValues(`["a","b"]`),
+		}, {
+			expr: `.row.csv()`,
+			want: ToNodeValues(`"x,y","say ""hi""",1.5,true,`),
+		}, {
+			expr: `.tsv.tsv()`,
+			want: ToNodeValues(`a\tb` + "\t" + `c\nd` + "\t" + `e\\f`),
+		}, {
+			expr:   `.items.csv()`,
+			errstr: `csv(): cannot format map[id:1 name:one]`,
+		}, {
+			expr:   `.name.tsv()`,
+			errstr: `tsv(): cannot format one`,
+		}, {
+			expr:   `.format(.id)`,
+			errstr: "invalid format for format(): 42",
+		}, {
+			expr:   `.format()`,
+			errstr: "invalid number of arguments for format(): 0",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
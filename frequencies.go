@@ -0,0 +1,51 @@
+package tree
+
+import "context"
+
+func init() {
+	RegisterMethod("frequencies", frequencies)
+}
+
+// frequencies is the method "frequencies([key])" that returns a map of the
+// string of each element value (or the key value of each element) to the
+// number of its occurrences. null is counted as "null", and arrays and maps
+// are not counted. The keys are the strings of the values, so the values of
+// the different types that have the same string are counted together: null
+// and "null", 1 and "1", and true and "true".
+func frequencies(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("frequencies", args, 0, 1); err != nil {
+		return nil, err
+	}
+	var elems []Node
+	switch n.Type() {
+	case TypeArray:
+		elems = n.Array()
+	case TypeMap:
+		elems = n.Map().Values()
+	default:
+		return nil, nil
+	}
+	counts := map[string]int{}
+	for _, e := range elems {
+		v := OrNil(e)
+		if len(args) > 0 {
+			var err error
+			if v, err = execMethodArg(ctx, args[0], v); err != nil {
+				return nil, err
+			}
+		}
+		if !v.Type().IsValue() {
+			continue
+		}
+		k := "null"
+		if !v.IsNil() {
+			k = v.Value().String()
+		}
+		counts[k]++
+	}
+	m := Map{}
+	for k, c := range counts {
+		m[k] = NumberValue(c)
+	}
+	return []Node{m}, nil
+}
@@ -0,0 +1,55 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Frequencies(t *testing.T) {
+	n := Map{
+		"logs": Array{
+			Map{"level": ToValue("info")},
+			Map{"level": ToValue("error")},
+			Map{"level": ToValue("info")},
+			Map{"msg": ToValue("no level")},
+			Map{"level": Map{}},
+		},
+		"codes": ToArrayValues(200, 404, 200, "200", true, nil, "null"),
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.logs[].level | frequencies()`,
+			want: []Node{Map{"info": ToValue(2), "error": ToValue(1)}},
+		}, {
+			expr: `.logs.frequencies(.level)`,
+			want: []Node{Map{"info": ToValue(2), "error": ToValue(1), "null": ToValue(1)}},
+		}, {
+			expr: `.codes.frequencies()`,
+			want: []Node{Map{"200": ToValue(3), "404": ToValue(1), "true": ToValue(1), "null": ToValue(2)}},
+		}, {
+			expr: `.none.frequencies()`,
+		}, {
+			expr:   `.logs.frequencies(.a, .b)`,
+			errstr: "invalid number of arguments for frequencies(): 2",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FormatFrontMatter represents Markdown that may have YAML front matter
+// delimited by "---" or TOML front matter delimited by "+++".
+const FormatFrontMatter Format = "frontmatter"
+
+const (
+	frontMatterYAMLDelimiter = "---"
+	frontMatterTOMLDelimiter = "+++"
+)
+
+// FrontMatter is the front matter of a Markdown file and the body that follows it.
+type FrontMatter struct {
+	// Node is the front matter. It is an empty Map if the file has no front matter.
+	Node Node
+	// TOML reports whether the front matter is TOML instead of YAML.
+	TOML bool
+	// Body is the contents after the front matter.
+	Body []byte
+}
+
+// ParseFrontMatter parses the front matter of Markdown data.
+// TOML datetimes are decoded as RFC 3339 strings.
+func ParseFrontMatter(data []byte) (*FrontMatter, error) {
+	delimiter, rest, ok := cutFrontMatterDelimiter(data)
+	if !ok {
+		return &FrontMatter{Node: Map{}, Body: data}, nil
+	}
+	var head []byte
+	found := false
+	for len(rest) > 0 {
+		line := rest
+		next := []byte(nil)
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, next = rest[:i+1], rest[i+1:]
+		}
+		if string(bytes.TrimRight(line, "\r\n")) == delimiter {
+			found = true
+			rest = next
+			break
+		}
+		head = append(head, line...)
+		rest = next
+	}
+	if !found {
+		return nil, fmt.Errorf("no closing front matter delimiter %q", delimiter)
+	}
+
+	fm := &FrontMatter{TOML: delimiter == frontMatterTOMLDelimiter, Body: rest}
+	if fm.TOML {
+		var v map[string]interface{}
+		if err := toml.Unmarshal(head, &v); err != nil {
+			return nil, err
+		}
+		fm.Node = fromTOML(v)
+		return fm, nil
+	}
+	n, err := UnmarshalYAML(head)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil || n.IsNil() {
+		n = Map{}
+	}
+	fm.Node = n
+	return fm, nil
+}
+
+func cutFrontMatterDelimiter(data []byte) (string, []byte, bool) {
+	for _, delimiter := range []string{frontMatterYAMLDelimiter, frontMatterTOMLDelimiter} {
+		for _, nl := range []string{"\n", "\r\n"} {
+			if bytes.HasPrefix(data, []byte(delimiter+nl)) {
+				return delimiter, data[len(delimiter)+len(nl):], true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// Encode writes the front matter and the body to w.
+// The front matter must be a map.
+func (fm *FrontMatter) Encode(w io.Writer) error {
+	if fm.Node == nil || !fm.Node.Type().IsMap() {
+		return fmt.Errorf("front matter must be a map")
+	}
+	delimiter := frontMatterYAMLDelimiter
+	if fm.TOML {
+		delimiter = frontMatterTOMLDelimiter
+	}
+	if _, err := io.WriteString(w, delimiter+"\n"); err != nil {
+		return err
+	}
+	if len(fm.Node.Map()) > 0 {
+		var err error
+		if fm.TOML {
+			err = toml.NewEncoder(w).Encode(toTOML(fm.Node))
+		} else {
+			err = newYAMLEncoder(w).Encode(fm.Node)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, delimiter+"\n"); err != nil {
+		return err
+	}
+	_, err := w.Write(fm.Body)
+	return err
+}
+
+func fromTOML(v interface{}) Node {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		m := Map{}
+		for k, vv := range tv {
+			m[k] = fromTOML(vv)
+		}
+		return m
+	case []map[string]interface{}:
+		a := make(Array, len(tv))
+		for i, vv := range tv {
+			a[i] = fromTOML(vv)
+		}
+		return a
+	case []interface{}:
+		a := make(Array, len(tv))
+		for i, vv := range tv {
+			a[i] = fromTOML(vv)
+		}
+		return a
+	case time.Time:
+		return StringValue(tv.Format(time.RFC3339Nano))
+	case fmt.Stringer:
+		return StringValue(tv.String())
+	}
+	return ToValue(v)
+}
+
+func toTOML(n Node) interface{} {
+	switch n.Type() {
+	case TypeMap:
+		m := map[string]interface{}{}
+		for k, v := range n.Map() {
+			// NOTE: TOML has no null.
+			if v != nil && !v.IsNil() {
+				m[k] = toTOML(v)
+			}
+		}
+		return m
+	case TypeArray:
+		a := n.Array()
+		x := make([]interface{}, len(a))
+		for i, v := range a {
+			x[i] = toTOML(v)
+		}
+		return x
+	case TypeNumberValue:
+		f := n.Value().Float64()
+		if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return int64(f)
+		}
+		return f
+	}
+	return ToAny(n)
+}
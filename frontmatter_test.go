@@ -0,0 +1,98 @@
+package tree
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func Test_ParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		data   string
+		want   *FrontMatter
+		errstr string
+	}{
+		{
+			data: "---\ntitle: Hello\ntags: [a, b]\n---\n# Hello\n",
+			want: &FrontMatter{
+				Node: Map{"title": ToValue("Hello"), "tags": ToArrayValues("a", "b")},
+				Body: []byte("# Hello\n"),
+			},
+		}, {
+			data: "+++\ntitle = \"Hello\"\ndraft = true\ndate = 2024-01-02T03:04:05Z\n+++\nbody\n",
+			want: &FrontMatter{
+				Node: Map{"title": ToValue("Hello"), "draft": ToValue(true), "date": ToValue("2024-01-02T03:04:05Z")},
+				TOML: true,
+				Body: []byte("body\n"),
+			},
+		}, {
+			data: "---\r\n---\r\nbody\r\n",
+			want: &FrontMatter{Node: Map{}, Body: []byte("body\r\n")},
+		}, {
+			data: "# No front matter\n",
+			want: &FrontMatter{Node: Map{}, Body: []byte("# No front matter\n")},
+		}, {
+			data:   "---\ntitle: Hello\n",
+			errstr: `no closing front matter delimiter "---"`,
+		},
+	}
+	for i, test := range tests {
+		got, err := ParseFrontMatter([]byte(test.data))
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func Test_FrontMatter_Encode(t *testing.T) {
+	tests := []struct {
+		fm     *FrontMatter
+		want   string
+		errstr string
+	}{
+		{
+			fm:   &FrontMatter{Node: Map{"title": ToValue("Hello")}, Body: []byte("# Hello\n")},
+			want: "---\ntitle: Hello\n---\n# Hello\n",
+		}, {
+			fm:   &FrontMatter{Node: Map{"title": ToValue("Hello"), "weight": ToValue(2), "x": Nil}, TOML: true, Body: []byte("body\n")},
+			want: "+++\ntitle = \"Hello\"\nweight = 2\n+++\nbody\n",
+		}, {
+			fm:   &FrontMatter{Node: Map{}, Body: []byte("body\n")},
+			want: "---\n---\nbody\n",
+		}, {
+			fm:     &FrontMatter{Node: ToArrayValues(1)},
+			errstr: "front matter must be a map",
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		err := test.fm.Encode(buf)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package tree
+
+import "fmt"
+
+// Values converts the elements of a to []T. The element is converted by
+// ToAny if the result is T, otherwise by UnmarshalViaJSON, so T can be also
+// int or a struct.
+func Values[T any](a Array) ([]T, error) {
+	return MapSlice(a, NodeTo[T])
+}
+
+// NodeTo converts n to T like Values.
+func NodeTo[T any](n Node) (T, error) {
+	if v, ok := ToAny(OrNil(n)).(T); ok {
+		return v, nil
+	}
+	var v T
+	err := UnmarshalViaJSON(OrNil(n), &v)
+	return v, err
+}
+
+// MapSlice returns the results of fn for each element of a as []T.
+// The error of fn is returned with the index.
+func MapSlice[T any](a Array, fn func(v Node) (T, error)) ([]T, error) {
+	x := make([]T, len(a))
+	for i, v := range a {
+		vv, err := fn(v)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		x[i] = vv
+	}
+	return x, nil
+}
+
+// ToArray converts s to an Array. Each element is converted by ToNode.
+func ToArray[T any](s []T) Array {
+	a := make(Array, len(s))
+	for i, v := range s {
+		a[i] = ToNode(v)
+	}
+	return a
+}
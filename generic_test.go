@@ -0,0 +1,100 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	ss, err := Values[string](ToArrayValues("a", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(ss, want) {
+		t.Errorf("got %v; want %v", ss, want)
+	}
+	is, err := Values[int](ToArrayValues(1, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(is, want) {
+		t.Errorf("got %v; want %v", is, want)
+	}
+	items, err := Values[item](Array{Map{"id": ToValue(1), "name": ToValue("one")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []item{{ID: 1, Name: "one"}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("got %v; want %v", items, want)
+	}
+	ps, err := Values[*string](ToArrayValues("a", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 2 || *ps[0] != "a" || ps[1] != nil {
+		t.Errorf("got %v", ps)
+	}
+	anys, err := Values[interface{}](ToArrayValues("a", 1, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"a", 1.0, nil}; !reflect.DeepEqual(anys, want) {
+		t.Errorf("got %v; want %v", anys, want)
+	}
+
+	errTests := []struct {
+		a      Array
+		errstr string
+	}{
+		{
+			a:      ToArrayValues(1, "a"),
+			errstr: "index 1: json: cannot unmarshal string into Go value of type int",
+		}, {
+			a:      ToArrayValues(1.5),
+			errstr: "index 0: json: cannot unmarshal number 1.5 into Go value of type int",
+		},
+	}
+	for i, test := range errTests {
+		_, err := Values[int](test.a)
+		if err == nil || err.Error() != test.errstr {
+			t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+		}
+	}
+}
+
+func TestMapSlice(t *testing.T) {
+	got, err := MapSlice(ToArrayValues("a", "bc"), func(v Node) (int, error) {
+		return len(v.Value().String()), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestToArray(t *testing.T) {
+	tests := []struct {
+		got  Array
+		want Array
+	}{
+		{got: ToArray([]string{"a", "b"}), want: ToArrayValues("a", "b")},
+		{got: ToArray([]int{1, 2}), want: ToArrayValues(1, 2)},
+		{got: ToArray([]Node{Map{}, nil}), want: Array{Map{}, Nil}},
+		{
+			got:  ToArray([]map[string]interface{}{{"a": 1}}),
+			want: Array{Map{"a": ToValue(1)}},
+		},
+		{got: ToArray([]bool{}), want: Array{}},
+	}
+	for i, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, test.got, test.want)
+		}
+	}
+}
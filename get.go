@@ -0,0 +1,70 @@
+package tree
+
+import "math"
+
+// GetString returns the string of the node that n.Get(keys...) returns.
+// It returns false if the node is not a string.
+func GetString(n Node, keys ...interface{}) (string, bool) {
+	v := n.Get(keys...)
+	if v == nil || !v.Type().IsStringValue() {
+		return "", false
+	}
+	return v.Value().String(), true
+}
+
+// GetInt returns the integer of the node that n.Get(keys...) returns.
+// It returns false if the node is not a number or not an integer.
+func GetInt(n Node, keys ...interface{}) (int, bool) {
+	v := n.Get(keys...)
+	if v == nil || !v.Type().IsNumberValue() {
+		return 0, false
+	}
+	f := v.Value().Float64()
+	if f != math.Trunc(f) || f < math.MinInt || f >= -math.MinInt {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// GetBool returns the bool of the node that n.Get(keys...) returns.
+// It returns false as the second value if the node is not a bool.
+func GetBool(n Node, keys ...interface{}) (bool, bool) {
+	v := n.Get(keys...)
+	if v == nil || !v.Type().IsBoolValue() {
+		return false, false
+	}
+	return v.Value().Bool(), true
+}
+
+// GetArray returns the array that n.Get(keys...) returns.
+// It returns false if the node is not an array.
+func GetArray(n Node, keys ...interface{}) (Array, bool) {
+	v := n.Get(keys...)
+	if v == nil || !v.Type().IsArray() {
+		return nil, false
+	}
+	return v.Array(), true
+}
+
+// GetMap returns the map that n.Get(keys...) returns.
+// It returns false if the node is not a map.
+func GetMap(n Node, keys ...interface{}) (Map, bool) {
+	v := n.Get(keys...)
+	if v == nil || !v.Type().IsMap() {
+		return nil, false
+	}
+	return v.Map(), true
+}
+
+// FindOne returns the first node that the query expression finds from n.
+// It returns Nil if no nodes are found.
+func FindOne(n Node, expr string) (Node, error) {
+	rs, err := Find(n, expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(rs) == 0 || rs[0] == nil {
+		return Nil, nil
+	}
+	return rs[0], nil
+}
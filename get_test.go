@@ -0,0 +1,113 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+var getTestNode = Map{
+	"name":  ToValue("app"),
+	"port":  ToValue(8080),
+	"ratio": ToValue(0.5),
+	"debug": ToValue(true),
+	"tags":  ToArrayValues("a", "b"),
+	"db":    Map{"host": ToValue("localhost")},
+	"null":  Nil,
+}
+
+func TestGetString(t *testing.T) {
+	tests := []struct {
+		keys []interface{}
+		want string
+		ok   bool
+	}{
+		{keys: []interface{}{"name"}, want: "app", ok: true},
+		{keys: []interface{}{"db", "host"}, want: "localhost", ok: true},
+		{keys: []interface{}{"tags", 1}, want: "b", ok: true},
+		{keys: []interface{}{"port"}},
+		{keys: []interface{}{"null"}},
+		{keys: []interface{}{"missing"}},
+	}
+	for i, test := range tests {
+		got, ok := GetString(getTestNode, test.keys...)
+		if got != test.want || ok != test.ok {
+			t.Errorf("tests[%d] got %q, %v; want %q, %v", i, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestGetInt(t *testing.T) {
+	tests := []struct {
+		n    Node
+		want int
+		ok   bool
+	}{
+		{n: ToValue(8080), want: 8080, ok: true},
+		{n: ToValue(-1), want: -1, ok: true},
+		{n: ToValue(0.5)},
+		{n: ToValue(1e300)},
+		{n: ToValue("1")},
+		{n: Nil},
+	}
+	for i, test := range tests {
+		got, ok := GetInt(Map{"v": test.n}, "v")
+		if got != test.want || ok != test.ok {
+			t.Errorf("tests[%d] got %d, %v; want %d, %v", i, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestGetBool(t *testing.T) {
+	if got, ok := GetBool(getTestNode, "debug"); !got || !ok {
+		t.Errorf("got %v, %v; want true, true", got, ok)
+	}
+	if got, ok := GetBool(getTestNode, "name"); got || ok {
+		t.Errorf("got %v, %v; want false, false", got, ok)
+	}
+}
+
+func TestGetArray(t *testing.T) {
+	if got, ok := GetArray(getTestNode, "tags"); !ok || !reflect.DeepEqual(got, ToArrayValues("a", "b")) {
+		t.Errorf("got %v, %v", got, ok)
+	}
+	if got, ok := GetArray(getTestNode, "db"); got != nil || ok {
+		t.Errorf("got %v, %v; want nil, false", got, ok)
+	}
+}
+
+func TestGetMap(t *testing.T) {
+	if got, ok := GetMap(getTestNode, "db"); !ok || !reflect.DeepEqual(got, Map{"host": ToValue("localhost")}) {
+		t.Errorf("got %v, %v", got, ok)
+	}
+	if got, ok := GetMap(getTestNode, "tags"); got != nil || ok {
+		t.Errorf("got %v, %v; want nil, false", got, ok)
+	}
+}
+
+func TestFindOne(t *testing.T) {
+	tests := []struct {
+		expr   string
+		want   Node
+		errstr string
+	}{
+		{expr: ".db.host", want: ToValue("localhost")},
+		{expr: ".tags[]", want: ToValue("a")},
+		{expr: ".missing", want: Nil},
+		{expr: ".tags[", errstr: `syntax error: no right brackets: ".tags["`},
+	}
+	for i, test := range tests {
+		got, err := FindOne(getTestNode, test.expr)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
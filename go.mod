@@ -3,10 +3,12 @@ module github.com/jarxorg/tree
 go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/jarxorg/io2 v0.7.1
 	github.com/spf13/pflag v1.0.5
 	golang.org/x/term v0.9.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require golang.org/x/sys v0.9.0 // indirect
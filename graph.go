@@ -0,0 +1,105 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// FormatDot represents a Graphviz DOT graph of the document structure.
+	// It is only used for output.
+	FormatDot Format = "dot"
+	// FormatMermaid represents a Mermaid flowchart of the document structure.
+	// It is only used for output.
+	FormatMermaid Format = "mermaid"
+)
+
+// GraphEncoder writes the structure of nodes as a graph: each node is a
+// vertex labeled like OutlineEncoder and each vertex has the edges to its
+// children.
+type GraphEncoder struct {
+	Out io.Writer
+	// Format is FormatDot or FormatMermaid. The default is FormatDot.
+	Format Format
+	// MaxDepth omits the nodes deeper than the depth if it is positive.
+	// The depth of the values of the root array or map is 1.
+	MaxDepth int
+	// Values shows the values in the labels instead of only the types.
+	Values bool
+	// MaxWidth truncates the values that are wider than the width if it is
+	// positive.
+	MaxWidth int
+}
+
+var (
+	dotLabelReplacer     = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	mermaidLabelReplacer = strings.NewReplacer(`"`, "#quot;")
+)
+
+// Encode writes the graph of n.
+func (e *GraphEncoder) Encode(n Node) error {
+	b := new(strings.Builder)
+	switch e.Format {
+	case "", FormatDot:
+		b.WriteString("digraph tree {\n  node [shape=box];\n")
+	case FormatMermaid:
+		b.WriteString("graph TD\n")
+	default:
+		return fmt.Errorf("unknown graph format %q", e.Format)
+	}
+	id := 0
+	if err := e.encode(b, ".", OrNil(n), -1, &id, 0); err != nil {
+		return err
+	}
+	if e.Format != FormatMermaid {
+		b.WriteString("}\n")
+	}
+	_, err := io.WriteString(e.Out, b.String())
+	return err
+}
+
+// encode writes the vertex of n and the edge from the parent, and then its
+// children.
+func (e *GraphEncoder) encode(b *strings.Builder, name string, n Node, parent int, id *int, depth int) error {
+	label, err := outlineLabel(name, n, e.Values, e.MaxWidth)
+	if err != nil {
+		return err
+	}
+	self := *id
+	*id++
+	v := "n" + strconv.Itoa(self)
+	if e.Format == FormatMermaid {
+		vertex := v + `["` + mermaidLabelReplacer.Replace(label) + `"]`
+		if parent >= 0 {
+			fmt.Fprintf(b, "  n%d --> %s\n", parent, vertex)
+		} else {
+			fmt.Fprintf(b, "  %s\n", vertex)
+		}
+	} else {
+		fmt.Fprintf(b, "  %s [label=\"%s\"];\n", v, dotLabelReplacer.Replace(label))
+		if parent >= 0 {
+			fmt.Fprintf(b, "  n%d -> %s;\n", parent, v)
+		}
+	}
+	if e.MaxDepth > 0 && depth >= e.MaxDepth {
+		return nil
+	}
+	switch {
+	case n.Type().IsArray():
+		for i, c := range n.Array() {
+			if err := e.encode(b, "["+strconv.Itoa(i)+"]", OrNil(c), self, id, depth+1); err != nil {
+				return err
+			}
+		}
+	case n.Type().IsMap():
+		m := n.Map()
+		for _, k := range m.Keys() {
+			if err := e.encode(b, k, OrNil(m[k]), self, id, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGraphEncoder(t *testing.T) {
+	n := Map{
+		"a": Array{ToValue(`x"y`), Map{"b": ToValue(true)}},
+		"c": ToValue(1),
+	}
+	tests := []struct {
+		e      GraphEncoder
+		n      Node
+		want   string
+		errstr string
+	}{
+		{
+			n: n,
+			want: `digraph tree {
+  node [shape=box];
+  n0 [label=". (map, 2 keys)"];
+  n1 [label="a (array, 2 items)"];
+  n0 -> n1;
+  n2 [label="[0] (string)"];
+  n1 -> n2;
+  n3 [label="[1] (map, 1 key)"];
+  n1 -> n3;
+  n4 [label="b (bool)"];
+  n3 -> n4;
+  n5 [label="c (number)"];
+  n0 -> n5;
+}
+`,
+		}, {
+			e: GraphEncoder{Format: FormatDot, MaxDepth: 1, Values: true},
+			n: n,
+			want: `digraph tree {
+  node [shape=box];
+  n0 [label=". (map, 2 keys)"];
+  n1 [label="a (array, 2 items)"];
+  n0 -> n1;
+  n2 [label="c (number): 1"];
+  n0 -> n2;
+}
+`,
+		}, {
+			e: GraphEncoder{Format: FormatMermaid, Values: true, MaxWidth: 4},
+			n: n,
+			want: `graph TD
+  n0[". (map, 2 keys)"]
+  n0 --> n1["a (array, 2 items)"]
+  n1 --> n2["[0] (string): #quot;x\…"]
+  n1 --> n3["[1] (map, 1 key)"]
+  n3 --> n4["b (bool): true"]
+  n0 --> n5["c (number): 1"]
+`,
+		}, {
+			e:      GraphEncoder{Format: FormatJSON},
+			n:      n,
+			errstr: `unknown graph format "json"`,
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		e := test.e
+		e.Out = buf
+		err := e.Encode(test.n)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package tree
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+)
+
+func init() {
+	RegisterMethod("md5", hashMethod("md5", md5.New))
+	RegisterMethod("sha256", hashMethod("sha256", sha256.New))
+	RegisterMethod("uuid", uuid)
+}
+
+// hashMethod returns the method like "sha256()" that returns the hex encoded
+// hash of the node. Strings are hashed as is and the other nodes are hashed
+// as the canonical JSON of MarshalCanonicalJSON, so the hash of a map does
+// not depend on the order of the keys and SetKeyOrder.
+func hashMethod(name string, newHash func() hash.Hash) MethodFunc {
+	return func(ctx context.Context, n Node, args []Query) ([]Node, error) {
+		if err := checkMethodArgs(name, args, 0, 0); err != nil {
+			return nil, err
+		}
+		var b []byte
+		if n.Type().IsStringValue() {
+			b = []byte(n.Value().String())
+		} else {
+			var err error
+			if b, err = MarshalCanonicalJSON(n); err != nil {
+				return nil, fmt.Errorf("%s(): %w", name, err)
+			}
+		}
+		h := newHash()
+		h.Write(b)
+		return []Node{StringValue(fmt.Sprintf("%x", h.Sum(nil)))}, nil
+	}
+}
+
+// uuid is the method "uuid()" that returns a random UUID (version 4).
+func uuid(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("uuid", args, 0, 0); err != nil {
+		return nil, err
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, fmt.Errorf("uuid(): %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return []Node{StringValue(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]))}, nil
+}
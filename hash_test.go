@@ -0,0 +1,85 @@
+package tree
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func Test_HashMethods(t *testing.T) {
+	n := Map{
+		"name":   ToValue("hello"),
+		"config": Map{"b": ToValue(2), "a": ToValue(1)},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.name.md5()`,
+			want: ToNodeValues("5d41402abc4b2a76b9719d911017c592"),
+		}, {
+			expr: `.name.sha256()`,
+			want: ToNodeValues("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"),
+		}, {
+			// NOTE: sha256 of `{"a":1,"b":2}`
+			expr: `.config.sha256()`,
+			want: ToNodeValues("43258cff783fe7036d8a43033f830adfc60ec037382473548ac742b888292777"),
+		}, {
+			expr:   `.name.md5(1)`,
+			errstr: "invalid number of arguments for md5(): 1",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func Test_HashMethods_KeyOrder(t *testing.T) {
+	SetKeyOrder(func(a, b string) bool { return a > b })
+	defer SetKeyOrder(nil)
+
+	n := Map{"config": Map{"b": ToValue(2), "a": ToValue(1)}}
+	got, err := Find(n, `.config.sha256()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ToNodeValues("43258cff783fe7036d8a43033f830adfc60ec037382473548ac742b888292777")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func Test_UUID(t *testing.T) {
+	uuidRegexp := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	got1, err := Find(Map{}, `.uuid()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got2, err := Find(Map{}, `.uuid()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got1) != 1 || !uuidRegexp.MatchString(got1[0].Value().String()) {
+		t.Fatalf("got %v; want a UUID", got1)
+	}
+	if reflect.DeepEqual(got1, got2) {
+		t.Errorf("got the same UUID %v", got1)
+	}
+}
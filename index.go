@@ -0,0 +1,129 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+)
+
+func init() {
+	RegisterMethod("index_by", indexBy)
+}
+
+// Index is an index of nodes by key values that enables to look up nodes
+// without scanning arrays repeatedly.
+type Index struct {
+	entries map[indexKey][]Node
+}
+
+type indexKey struct {
+	t Type
+	s string
+}
+
+func newIndexKey(v Value) indexKey {
+	return indexKey{t: v.Type(), s: v.String()}
+}
+
+// BuildIndex builds an Index from n using the query expression.
+// The expr must contain a select query ("[]" or "[selector]"); the nodes
+// selected by the last select query are indexed by the rest of the query.
+// For example, BuildIndex(n, ".items[].id") indexes each element of .items by .id.
+func BuildIndex(n Node, expr string) (*Index, error) {
+	q, err := ParseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	fq, ok := q.(FilterQuery)
+	if !ok {
+		fq = FilterQuery{q}
+	}
+	last := -1
+	for i, qq := range fq {
+		if _, ok := qq.(SelectQuery); ok {
+			last = i
+		}
+	}
+	if last == -1 || last == len(fq)-1 {
+		return nil, fmt.Errorf("invalid index expression %q", expr)
+	}
+	nodes, err := fq[:last+1].Exec(n)
+	if err != nil {
+		return nil, err
+	}
+	return NewIndex(nodes, fq[last+1:])
+}
+
+// NewIndex builds an Index of the provided nodes by the key query.
+// The nodes whose key is not a value or is nil are not indexed.
+func NewIndex(nodes []Node, key Query) (*Index, error) {
+	idx := &Index{entries: map[indexKey][]Node{}}
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		ks, err := key.Exec(n)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range ks {
+			if k == nil || k.IsNil() || !k.Type().IsValue() {
+				continue
+			}
+			ik := newIndexKey(k.Value())
+			idx.entries[ik] = append(idx.entries[ik], n)
+		}
+	}
+	return idx, nil
+}
+
+// Lookup returns the nodes whose key equals to the provided key.
+// The key is converted using ToValue.
+func (idx *Index) Lookup(key interface{}) []Node {
+	return idx.entries[newIndexKey(ToValue(key).Value())]
+}
+
+// Get returns the first node whose key equals to the provided key.
+// If no nodes are found, returns Nil.
+func (idx *Index) Get(key interface{}) Node {
+	if ns := idx.Lookup(key); len(ns) > 0 {
+		return ns[0]
+	}
+	return Nil
+}
+
+// Len returns the number of the keys.
+func (idx *Index) Len() int {
+	return len(idx.entries)
+}
+
+// indexBy is the method "index_by(key)" that returns a map of the elements
+// keyed by the string of the key value. The later element wins on duplicate keys.
+func indexBy(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("index_by", args, 1, 1); err != nil {
+		return nil, err
+	}
+	var elems []Node
+	switch n.Type() {
+	case TypeArray:
+		elems = n.Array()
+	case TypeMap:
+		elems = n.Map().Values()
+	default:
+		return nil, nil
+	}
+	m := Map{}
+	for _, e := range elems {
+		if e == nil {
+			continue
+		}
+		k, err := execMethodArg(ctx, args[0], e)
+		if err != nil {
+			return nil, err
+		}
+		if k.IsNil() || !k.Type().IsValue() {
+			continue
+		}
+		m[k.Value().String()] = e
+	}
+	return []Node{m}, nil
+}
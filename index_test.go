@@ -0,0 +1,119 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_BuildIndex(t *testing.T) {
+	n := Map{
+		"items": Array{
+			Map{"id": ToValue(1), "name": ToValue("one")},
+			Map{"id": ToValue("1"), "name": ToValue("string one")},
+			Map{"id": ToValue(2), "name": ToValue("two")},
+			Map{"id": ToValue(2), "name": ToValue("two again")},
+			Map{"name": ToValue("no id")},
+		},
+	}
+	idx, err := BuildIndex(n, `.items[].id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key  interface{}
+		want []Node
+	}{
+		{
+			key:  1,
+			want: []Node{n.Get("items", 0)},
+		}, {
+			key:  "1",
+			want: []Node{n.Get("items", 1)},
+		}, {
+			key:  NumberValue(2),
+			want: []Node{n.Get("items", 2), n.Get("items", 3)},
+		}, {
+			key: 3,
+		},
+	}
+	for i, test := range tests {
+		if got := idx.Lookup(test.key); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+	if got, want := idx.Get(2), n.Get("items", 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if got := idx.Get(3); got != Nil {
+		t.Errorf("got %v; want Nil", got)
+	}
+	if got := idx.Len(); got != 3 {
+		t.Errorf("got %d; want 3", got)
+	}
+}
+
+func Test_BuildIndex_Errors(t *testing.T) {
+	tests := []struct {
+		n      Node
+		expr   string
+		errstr string
+	}{
+		{
+			n:      Map{},
+			expr:   `.items.id`,
+			errstr: `invalid index expression ".items.id"`,
+		}, {
+			n:      Map{},
+			expr:   `.items[]`,
+			errstr: `invalid index expression ".items[]"`,
+		}, {
+			n:      Map{},
+			expr:   `[`,
+			errstr: `syntax error: no right brackets: "["`,
+		}, {
+			n:      Map{"items": ToArrayValues("a")},
+			expr:   `.items[].id`,
+			errstr: `cannot index array with "id"`,
+		},
+	}
+	for i, test := range tests {
+		_, err := BuildIndex(test.n, test.expr)
+		if err == nil {
+			t.Fatalf("tests[%d] no error", i)
+		}
+		if err.Error() != test.errstr {
+			t.Errorf("tests[%d] got %s; want %s", i, err.Error(), test.errstr)
+		}
+	}
+}
+
+func Test_IndexBy(t *testing.T) {
+	n := Map{
+		"items": Array{
+			Map{"id": ToValue(1), "name": ToValue("one")},
+			Map{"id": ToValue(2), "name": ToValue("two")},
+			Map{"name": ToValue("no id")},
+		},
+	}
+	got, err := Find(n, `.items.index_by(.id)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Node{
+		Map{
+			"1": n.Get("items", 0),
+			"2": n.Get("items", 1),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	got, err = Find(n, `.items.index_by(.id) | [0]."2".name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ToNodeValues("two"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
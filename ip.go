@@ -0,0 +1,133 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+func init() {
+	RegisterMethod("in_cidr", inCIDR)
+	RegisterMethod("ip", ipMethod)
+	RegisterMethod("cidr", cidrMethod)
+}
+
+// parseIPOrPrefix parses s as an IP address or a CIDR prefix. The address is
+// returned as the prefix of the full bits, and IPv4-mapped IPv6 addresses
+// are unmapped.
+func parseIPOrPrefix(s string) (netip.Prefix, bool) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+		if p.Addr().Is4In6() && p.Bits() >= 96 {
+			p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		}
+		return p.Masked(), true
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	a = a.Unmap()
+	return netip.PrefixFrom(a, a.BitLen()), true
+}
+
+// execCIDRArgs returns the prefixes of the arguments those are the CIDR
+// strings or the arrays of them.
+func execCIDRArgs(ctx context.Context, n Node, args []Query) ([]netip.Prefix, error) {
+	var ps []netip.Prefix
+	add := func(v Node) error {
+		if v == nil || !v.Type().IsStringValue() {
+			return fmt.Errorf("in_cidr(): invalid CIDR %v", v)
+		}
+		p, ok := parseIPOrPrefix(v.Value().String())
+		if !ok {
+			return fmt.Errorf("in_cidr(): invalid CIDR %q", v.Value().String())
+		}
+		ps = append(ps, p)
+		return nil
+	}
+	for _, arg := range args {
+		v, err := execMethodArg(ctx, arg, n)
+		if err != nil {
+			return nil, err
+		}
+		if v.Type().IsArray() {
+			for _, e := range v.Array() {
+				if err := add(e); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if err := add(v); err != nil {
+			return nil, err
+		}
+	}
+	return ps, nil
+}
+
+// inCIDR is the method "in_cidr(cidr...)" that returns whether the IP address
+// or the CIDR prefix of the string is in any of the CIDRs. The arguments may
+// be arrays of CIDRs like in_cidr($private). It returns no results if the
+// string is not an IP address.
+func inCIDR(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("in_cidr", args, 1, -1); err != nil {
+		return nil, err
+	}
+	ps, err := execCIDRArgs(ctx, n, args)
+	if err != nil {
+		return nil, err
+	}
+	if !n.Type().IsStringValue() {
+		return nil, nil
+	}
+	p, ok := parseIPOrPrefix(n.Value().String())
+	if !ok {
+		return nil, nil
+	}
+	for _, cidr := range ps {
+		if cidr.Bits() <= p.Bits() && cidr.Contains(p.Addr()) {
+			return []Node{BoolValue(true)}, nil
+		}
+	}
+	return []Node{BoolValue(false)}, nil
+}
+
+// ipMethod is the method "ip()" that returns the normalized IP address of
+// the string like "2001:db8::1" for "2001:DB8:0::1" and "10.0.0.1" for
+// "::ffff:10.0.0.1". It returns no results if the string is not an IP address.
+func ipMethod(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("ip", args, 0, 0); err != nil {
+		return nil, err
+	}
+	if !n.Type().IsStringValue() {
+		return nil, nil
+	}
+	a, err := netip.ParseAddr(n.Value().String())
+	if err != nil {
+		return nil, nil
+	}
+	return []Node{StringValue(a.Unmap().String())}, nil
+}
+
+// cidrMethod is the method "cidr()" that returns the normalized CIDR of the
+// string that the host bits are cleared like "10.0.0.0/8" for "10.1.2.3/8".
+// An IP address is the CIDR of the single address like "10.0.0.1/32". It
+// returns no results if the string is not a CIDR or an IP address.
+func cidrMethod(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("cidr", args, 0, 0); err != nil {
+		return nil, err
+	}
+	if !n.Type().IsStringValue() {
+		return nil, nil
+	}
+	p, ok := parseIPOrPrefix(n.Value().String())
+	if !ok {
+		return nil, nil
+	}
+	return []Node{StringValue(p.String())}, nil
+}
@@ -0,0 +1,99 @@
+package tree
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestIPMethods(t *testing.T) {
+	n := Map{
+		"rules": Array{
+			Map{"name": ToValue("a"), "source": ToValue("10.1.2.3")},
+			Map{"name": ToValue("b"), "source": ToValue("192.168.0.0/24")},
+			Map{"name": ToValue("c"), "source": ToValue("8.8.8.8")},
+			Map{"name": ToValue("d"), "source": ToValue("::ffff:172.16.0.1")},
+			Map{"name": ToValue("e"), "source": ToValue("any")},
+			Map{"name": ToValue("f"), "source": ToValue("2001:DB8:0::1")},
+		},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.rules[.source.in_cidr("10.0.0.0/8")].name`,
+			want: ToNodeValues("a"),
+		}, {
+			expr: `.rules[.source.in_cidr("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16")].name`,
+			want: ToNodeValues("a", "b", "d"),
+		}, {
+			expr: `.rules[.source.in_cidr("192.168.0.0/25")].name`,
+			want: nil,
+		}, {
+			expr: `.rules[.source.in_cidr($private)].name`,
+			want: ToNodeValues("a", "b", "d"),
+		}, {
+			expr: `.rules[.source.in_cidr("2001:db8::/32")].name`,
+			want: ToNodeValues("f"),
+		}, {
+			expr: `.rules[].source.ip()`,
+			want: ToNodeValues("10.1.2.3", "8.8.8.8", "172.16.0.1", "2001:db8::1"),
+		}, {
+			expr: `.rules[].source.cidr()`,
+			want: ToNodeValues("10.1.2.3/32", "192.168.0.0/24", "8.8.8.8/32", "172.16.0.1/32", "2001:db8::1/128"),
+		}, {
+			expr: `.rules[0].source.in_cidr("10.0.0.0/8").tostring()`,
+			want: ToNodeValues("true"),
+		}, {
+			expr:   `.rules[.source.in_cidr("10.0.0.0/33")].name`,
+			errstr: `in_cidr(): invalid CIDR "10.0.0.0/33"`,
+		}, {
+			expr:   `.rules[.source.in_cidr(8)].name`,
+			errstr: `in_cidr(): invalid CIDR 8`,
+		}, {
+			expr:   `.rules[.source.in_cidr()].name`,
+			errstr: `invalid number of arguments for in_cidr(): 0`,
+		},
+	}
+	vars := Map{"private": ToArrayValues("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16")}
+	for i, test := range tests {
+		got, err := FindContext(WithVariables(context.Background(), vars), n, test.expr)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestParseIPOrPrefix(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+		ok   bool
+	}{
+		{s: "10.1.2.3/8", want: "10.0.0.0/8", ok: true},
+		{s: "::ffff:10.0.0.0/104", want: "10.0.0.0/8", ok: true},
+		{s: "fe80::1", want: "fe80::1/128", ok: true},
+		{s: "10.0.0.256", ok: false},
+		{s: "10.0.0.0/x", ok: false},
+	}
+	for i, test := range tests {
+		got, ok := parseIPOrPrefix(test.s)
+		if ok != test.ok {
+			t.Fatalf("tests[%d] got %v; want %v", i, ok, test.ok)
+		}
+		if ok && got.String() != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+)
+
+func init() {
+	RegisterMethod("join_on", joinOn)
+}
+
+// Join correlates the elements of a and b whose keys are equal and returns
+// the combined elements. The keys of the elements of a are found by aKey and
+// the keys of the elements of b are found by bKey.
+// If both elements are maps, they are combined into a new map that has the
+// keys of both (the element of a takes precedence on duplicate keys),
+// otherwise they are combined into an array of both elements.
+func Join(a, b Array, aKey, bKey Query) (Array, error) {
+	idx, err := NewIndex(b, bKey)
+	if err != nil {
+		return nil, err
+	}
+	joined := Array{}
+	for _, ea := range a {
+		if ea == nil {
+			continue
+		}
+		ks, err := aKey.Exec(ea)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range ks {
+			if k == nil || k.IsNil() || !k.Type().IsValue() {
+				continue
+			}
+			for _, eb := range idx.Lookup(k) {
+				joined = append(joined, combine(ea, eb))
+			}
+		}
+	}
+	return joined, nil
+}
+
+func combine(a, b Node) Node {
+	if a.Type().IsMap() && b.Type().IsMap() {
+		m := Map{}
+		for k, v := range b.Map() {
+			m[k] = v
+		}
+		for k, v := range a.Map() {
+			m[k] = v
+		}
+		return m
+	}
+	return Array{a, b}
+}
+
+// joinOn is the method "join_on(other, key, otherKey)" that joins the array
+// and other using Join. The other is executed relative to the array: against
+// the node that holds the array, so .users.join_on(.orders, ...) joins the
+// sibling .orders of .users. If the holder is unknown like the results of
+// methods, the other is executed against the array.
+func joinOn(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("join_on", args, 3, 3); err != nil {
+		return nil, err
+	}
+	a := n.Array()
+	if a == nil {
+		return nil, nil
+	}
+	other, err := execMethodArg(ctx, args[0], joinOnParent(ctx, n))
+	if err != nil {
+		return nil, err
+	}
+	b := other.Array()
+	if b == nil {
+		return nil, fmt.Errorf("cannot join with %s", args[0])
+	}
+	joined, err := Join(a, b, args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+	return []Node{joined}, nil
+}
+
+// joinOnParent returns the node that holds n, or n if it is unknown.
+func joinOnParent(ctx context.Context, n Node) Node {
+	p, _ := execPathFrom(ctx)
+	if p == nil || len(p.keys) == 0 {
+		return n
+	}
+	if parent := nodeAtKeys(execRootFrom(ctx, n), p.keys[:len(p.keys)-1]); parent != nil {
+		return parent
+	}
+	return n
+}
@@ -0,0 +1,134 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Join(t *testing.T) {
+	users := Array{
+		Map{"id": ToValue(1), "name": ToValue("one")},
+		Map{"id": ToValue(2), "name": ToValue("two")},
+		Map{"id": ToValue(3), "name": ToValue("three")},
+	}
+	orders := Array{
+		Map{"user_id": ToValue(1), "item": ToValue("apple")},
+		Map{"user_id": ToValue(2), "item": ToValue("banana"), "name": ToValue("order")},
+		Map{"user_id": ToValue(1), "item": ToValue("cherry")},
+	}
+	got, err := Join(users, orders, MapQuery("id"), MapQuery("user_id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Array{
+		Map{"id": ToValue(1), "name": ToValue("one"), "user_id": ToValue(1), "item": ToValue("apple")},
+		Map{"id": ToValue(1), "name": ToValue("one"), "user_id": ToValue(1), "item": ToValue("cherry")},
+		Map{"id": ToValue(2), "name": ToValue("two"), "user_id": ToValue(2), "item": ToValue("banana")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	got, err = Join(ToArrayValues(1, 2), ToArrayValues(2, 3), NopQuery{}, NopQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Array{ToArrayValues(2, 2)}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func Test_JoinOn(t *testing.T) {
+	n := Map{
+		"users": Array{
+			Map{"id": ToValue(1), "name": ToValue("one")},
+			Map{"id": ToValue(2), "name": ToValue("two")},
+		},
+		"orders": Array{
+			Map{"user_id": ToValue(2), "item": ToValue("banana")},
+		},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.users.join_on(.orders, .id, .user_id)`,
+			want: []Node{
+				Array{
+					Map{"id": ToValue(2), "name": ToValue("two"), "user_id": ToValue(2), "item": ToValue("banana")},
+				},
+			},
+		}, {
+			expr: `.users.join_on(.orders, .id, .user_id) | [0][].item`,
+			want: ToNodeValues("banana"),
+		}, {
+			expr: `.orders[0].join_on(.users, .user_id, .id)`,
+		}, {
+			expr:   `.users.join_on(.unknown, .id, .user_id)`,
+			errstr: `cannot join with .unknown`,
+		}, {
+			expr:   `.users.join_on(.orders, .id)`,
+			errstr: `invalid number of arguments for join_on(): 2`,
+		}, {
+			expr:   `.users.nth(1).join_on(.orders, .id, .user_id)`,
+			errstr: `cannot join with .orders`,
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got %s; want %s", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func Test_JoinOn_Nested(t *testing.T) {
+	n := Array{
+		Map{
+			"users":  Array{Map{"id": ToValue(1)}},
+			"orders": Array{Map{"user_id": ToValue(1), "item": ToValue("apple")}},
+		},
+		Map{
+			"users":  Array{Map{"id": ToValue(1)}},
+			"orders": Array{Map{"user_id": ToValue(1), "item": ToValue("banana")}},
+		},
+	}
+	tests := []struct {
+		expr string
+		want []Node
+	}{
+		{
+			expr: `[0].users.join_on(.orders, .id, .user_id)`,
+			want: []Node{Array{Map{"id": ToValue(1), "user_id": ToValue(1), "item": ToValue("apple")}}},
+		}, {
+			expr: `[].users.join_on(.orders, .id, .user_id)[].item`,
+			want: ToNodeValues("apple", "banana"),
+		}, {
+			expr: `[1].select(.users.join_on(.orders, .id, .user_id).count() == 1).orders[0].item`,
+			want: ToNodeValues("banana"),
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
@@ -6,9 +6,63 @@ import (
 	"fmt"
 )
 
-// MarshalJSON returns the JSON encoding of the specified node.
+// JSONEngine is the JSON codec that MarshalJSON, UnmarshalJSON,
+// MarshalViaJSON and UnmarshalViaJSON use. For example,
+// jsoniter.ConfigCompatibleWithStandardLibrary of json-iterator implements
+// it. DecodeJSON and EncodeOptions use "encoding/json" always.
+type JSONEngine interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONEngineFuncs is an adapter to use the functions as JSONEngine like
+// JSONEngineFuncs{MarshalFunc: gojson.Marshal, UnmarshalFunc: gojson.Unmarshal}
+// of goccy/go-json.
+type JSONEngineFuncs struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+}
+
+// Marshal calls f.MarshalFunc.
+func (f JSONEngineFuncs) Marshal(v interface{}) ([]byte, error) {
+	return f.MarshalFunc(v)
+}
+
+// Unmarshal calls f.UnmarshalFunc.
+func (f JSONEngineFuncs) Unmarshal(data []byte, v interface{}) error {
+	return f.UnmarshalFunc(data, v)
+}
+
+// jsonEngine is the JSONEngine. nil uses "encoding/json".
+var jsonEngine JSONEngine
+
+// SetJSONEngine sets the JSON codec. nil resets it to "encoding/json".
+// Replacing the engine while the codec is in use is racy.
+func SetJSONEngine(e JSONEngine) {
+	jsonEngine = e
+}
+
+func jsonMarshal(v interface{}) ([]byte, error) {
+	if jsonEngine != nil {
+		return jsonEngine.Marshal(v)
+	}
+	return json.Marshal(v)
+}
+
+func jsonUnmarshal(data []byte, v interface{}) error {
+	if jsonEngine != nil {
+		return jsonEngine.Unmarshal(data, v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// MarshalJSON returns the JSON encoding of the specified node. It uses
+// AppendJSON unless the JSONEngine is set.
 func MarshalJSON(n Node) ([]byte, error) {
-	return json.Marshal(n)
+	if jsonEngine != nil {
+		return jsonEngine.Marshal(n)
+	}
+	return AppendJSON(nil, n)
 }
 
 // DecodeJSON decodes JSON as a node using the provided decoder.
@@ -44,6 +98,13 @@ func DecodeJSON(dec *json.Decoder) (Node, error) {
 
 // UnmarshalJSON parses the JSON-encoded data to a Node.
 func UnmarshalJSON(data []byte) (Node, error) {
+	if jsonEngine != nil {
+		var v interface{}
+		if err := jsonEngine.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return ToNode(v), nil
+	}
 	dec := json.NewDecoder(bytes.NewReader(data))
 	return DecodeJSON(dec)
 }
@@ -197,7 +258,7 @@ func jsonValue(t json.Token) Node {
 	return StringValue(fmt.Sprintf("%#v", t))
 }
 
-// MarshalViaJSON returns the node encoding of v via "encoding/json".
+// MarshalViaJSON returns the node encoding of v via the JSONEngine.
 func MarshalViaJSON(v interface{}) (Node, error) {
 	if v == nil {
 		return Nil, nil
@@ -205,18 +266,18 @@ func MarshalViaJSON(v interface{}) (Node, error) {
 	if n, ok := v.(Node); ok {
 		return n, nil
 	}
-	data, err := json.Marshal(v)
+	data, err := jsonMarshal(v)
 	if err != nil {
 		return nil, err
 	}
 	return UnmarshalJSON(data)
 }
 
-// UnmarshalViaJSON stores the node in the value pointed to by v via "encoding/json".
+// UnmarshalViaJSON stores the node in the value pointed to by v via the JSONEngine.
 func UnmarshalViaJSON(n Node, v interface{}) error {
 	data, err := MarshalJSON(n)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, v)
+	return jsonUnmarshal(data, v)
 }
@@ -259,3 +259,41 @@ func Test_UnmarshalViaJSON(t *testing.T) {
 		t.Errorf("got %#v; want %#v", got, want)
 	}
 }
+
+func TestSetJSONEngine(t *testing.T) {
+	calls := 0
+	SetJSONEngine(JSONEngineFuncs{
+		MarshalFunc: func(v interface{}) ([]byte, error) {
+			calls++
+			return json.Marshal(v)
+		},
+		UnmarshalFunc: func(data []byte, v interface{}) error {
+			calls++
+			return json.Unmarshal(data, v)
+		},
+	})
+	defer SetJSONEngine(nil)
+
+	n := Map{"a": Array{ToValue(1), ToValue("b")}}
+	data, err := MarshalJSON(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, n) {
+		t.Errorf("got %v; want %v", got, n)
+	}
+	var v struct{ A []interface{} }
+	if err := UnmarshalViaJSON(n, &v); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 {
+		t.Errorf("got %d calls; want 4", calls)
+	}
+	if _, err := UnmarshalJSON([]byte(`{`)); err == nil {
+		t.Errorf("no error")
+	}
+}
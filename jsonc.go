@@ -0,0 +1,94 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// FormatJSONC represents JSON with comments and trailing commas like
+// tsconfig.json or settings.json of VS Code.
+const FormatJSONC Format = "jsonc"
+
+// StripJSONC returns a copy of JSONC data that the comments and trailing
+// commas are replaced with spaces, so the offsets and line numbers of the
+// result are the same as data.
+func StripJSONC(data []byte) []byte {
+	b := stripJSONComments(data)
+	lastComma := -1
+	inString := false
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+			lastComma = -1
+		case ',':
+			lastComma = i
+		case '}', ']':
+			if lastComma >= 0 {
+				b[lastComma] = ' '
+			}
+			lastComma = -1
+		case ' ', '\t', '\r', '\n':
+		default:
+			lastComma = -1
+		}
+	}
+	return b
+}
+
+func stripJSONComments(data []byte) []byte {
+	b := make([]byte, len(data))
+	copy(b, data)
+	inString := false
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+			continue
+		}
+		if c != '/' || i+1 >= len(b) {
+			continue
+		}
+		switch b[i+1] {
+		case '/':
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		case '*':
+			end := bytes.Index(b[i+2:], []byte("*/"))
+			last := len(b)
+			if end >= 0 {
+				last = i + 2 + end + 2
+			}
+			for ; i < last; i++ {
+				if b[i] != '\n' && b[i] != '\r' {
+					b[i] = ' '
+				}
+			}
+			i--
+		}
+	}
+	return b
+}
+
+// UnmarshalJSONC is like UnmarshalJSON but allows comments and trailing commas.
+func UnmarshalJSONC(data []byte) (Node, error) {
+	return DecodeJSON(json.NewDecoder(bytes.NewReader(StripJSONC(data))))
+}
@@ -0,0 +1,55 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_StripJSONC(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{
+			data: "{\"a\": 1, // comment\n\"b\": [1, 2,],}",
+			want: "{\"a\": 1,           \n\"b\": [1, 2 ] }",
+		}, {
+			data: `{"a": /* x */ "// not comment", "b": "/* not */"}`,
+			want: `{"a":         "// not comment", "b": "/* not */"}`,
+		}, {
+			data: "/*\n*/[1,\n/* c */]",
+			want: "  \n  [1 \n       ]",
+		}, {
+			data: `["a\"," , ]`,
+			want: `["a\","   ]`,
+		},
+	}
+	for i, test := range tests {
+		if got := string(StripJSONC([]byte(test.data))); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func Test_UnmarshalJSONC(t *testing.T) {
+	data := `{
+  // TypeScript options
+  "compilerOptions": {
+    "strict": true, /* recommended */
+    "paths": ["src/*",],
+  },
+}`
+	got, err := UnmarshalJSONC([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Map{
+		"compilerOptions": Map{
+			"strict": ToValue(true),
+			"paths":  ToArrayValues("src/*"),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,224 @@
+package tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// JSONEncoder writes the compact JSON encoding of nodes to an output stream
+// without the reflection of "encoding/json". The output is the same as
+// json.Encoder except that the keys of maps are ordered by SetKeyOrder.
+// The buffers are reused, so encoding many nodes by the same encoder does
+// not allocate memory after the first one.
+type JSONEncoder struct {
+	Out io.Writer
+	// NoEscapeHTML writes <, > and & of strings as is instead of \u003c,
+	// \u003e and \u0026.
+	NoEscapeHTML bool
+	w            jsonWriter
+}
+
+// Encode writes the JSON encoding of n followed by a newline.
+func (e *JSONEncoder) Encode(n Node) error {
+	e.w.b = e.w.b[:0]
+	e.w.escapeHTML = !e.NoEscapeHTML
+	if err := e.w.encode(n); err != nil {
+		return err
+	}
+	e.w.b = append(e.w.b, '\n')
+	_, err := e.Out.Write(e.w.b)
+	return err
+}
+
+// AppendJSON appends the compact JSON encoding of n to b like json.Marshal.
+func AppendJSON(b []byte, n Node) ([]byte, error) {
+	w := &jsonWriter{b: b, escapeHTML: true}
+	if err := w.encode(n); err != nil {
+		return nil, err
+	}
+	return w.b, nil
+}
+
+// MarshalCanonicalJSON returns the canonical JSON encoding of n that is
+// stable for comparisons and snapshots: it is compact, the keys of maps are
+// sorted lexically regardless of SetKeyOrder, and <, > and & are not
+// escaped.
+func MarshalCanonicalJSON(n Node) ([]byte, error) {
+	w := &jsonWriter{canonical: true}
+	if err := w.encode(n); err != nil {
+		return nil, err
+	}
+	return w.b, nil
+}
+
+// jsonWriter appends the JSON encoding to b. keys is the stack of the keys
+// of the maps those are being encoded. canonical sorts the keys lexically
+// regardless of SetKeyOrder.
+type jsonWriter struct {
+	b          []byte
+	keys       []string
+	escapeHTML bool
+	canonical  bool
+}
+
+func (w *jsonWriter) encode(n Node) error {
+	switch tn := n.(type) {
+	case nil:
+		w.b = append(w.b, "null"...)
+		return nil
+	case Map:
+		return w.encodeMap(tn)
+	case Array:
+		return w.encodeArray(tn)
+	case NilValue:
+		w.b = append(w.b, "null"...)
+		return nil
+	case BoolValue:
+		w.b = strconv.AppendBool(w.b, bool(tn))
+		return nil
+	case NumberValue:
+		return w.encodeFloat(float64(tn))
+	case StringValue:
+		w.b = appendQuotedJSON(w.b, string(tn), w.escapeHTML)
+		return nil
+	}
+	if w.canonical && n.Type().IsMap() {
+		return w.encodeMap(n.Map())
+	}
+	if w.canonical && n.Type().IsArray() {
+		return w.encodeArray(n.Array())
+	}
+	// NOTE: The other implementations of Node may have their own encodings.
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	w.b = append(w.b, data...)
+	return nil
+}
+
+func (w *jsonWriter) encodeMap(m Map) error {
+	if m == nil {
+		w.b = append(w.b, "null"...)
+		return nil
+	}
+	start := len(w.keys)
+	for k := range m {
+		w.keys = append(w.keys, k)
+	}
+	keys := w.keys[start:]
+	if keyLess == nil || w.canonical {
+		sort.Strings(keys)
+	} else {
+		sort.Slice(keys, func(i, j int) bool {
+			return keyLess(keys[i], keys[j])
+		})
+	}
+	w.b = append(w.b, '{')
+	for i, k := range keys {
+		if i > 0 {
+			w.b = append(w.b, ',')
+		}
+		w.b = appendQuotedJSON(w.b, k, w.escapeHTML)
+		w.b = append(w.b, ':')
+		if err := w.encode(m[k]); err != nil {
+			return err
+		}
+	}
+	w.b = append(w.b, '}')
+	w.keys = w.keys[:start]
+	return nil
+}
+
+func (w *jsonWriter) encodeArray(a Array) error {
+	if a == nil {
+		w.b = append(w.b, "null"...)
+		return nil
+	}
+	w.b = append(w.b, '[')
+	for i, v := range a {
+		if i > 0 {
+			w.b = append(w.b, ',')
+		}
+		if err := w.encode(v); err != nil {
+			return err
+		}
+	}
+	w.b = append(w.b, ']')
+	return nil
+}
+
+// NOTE: Copy logics from encoding/json/encode.go
+func (w *jsonWriter) encodeFloat(f float64) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	w.b = strconv.AppendFloat(w.b, f, format, -1, 64)
+	if format == 'e' {
+		// NOTE: Clean up e-09 to e-9.
+		if n := len(w.b); n >= 4 && w.b[n-4] == 'e' && w.b[n-3] == '-' && w.b[n-2] == '0' {
+			w.b[n-2] = w.b[n-1]
+			w.b = w.b[:n-1]
+		}
+	}
+	return nil
+}
+
+// appendQuotedJSON appends the JSON string of s to b like
+// ColorEncoder.writeQuotedJSON.
+func appendQuotedJSON(b []byte, s string, escapeHTML bool) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && (!escapeHTML || (c != '<' && c != '>' && c != '&')) {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			b = append(b, '\\')
+			switch c {
+			case '\\', '"':
+				b = append(b, c)
+			case '\n':
+				b = append(b, 'n')
+			case '\r':
+				b = append(b, 'r')
+			case '\t':
+				b = append(b, 't')
+			default:
+				b = append(b, 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
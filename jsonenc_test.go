@@ -0,0 +1,165 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestAppendJSON(t *testing.T) {
+	tests := []Node{
+		nil,
+		Nil,
+		Map{},
+		Array{},
+		Map(nil),
+		Array(nil),
+		ToValue(true),
+		ToValue(0),
+		ToValue(-1.5),
+		ToValue(1e21),
+		ToValue(1e-7),
+		ToValue(123456789),
+		ToValue(`"\<>&` + "\n\r\t\x00\x1f\x7f\u2028\u2029日本"),
+		Map{
+			"b": Array{ToValue(1), Nil, nil, Map{"<>": ToValue("&")}},
+			"a": Map{"c": ToValue(false)},
+		},
+		Any{Array{ToValue("any")}},
+		LenientStringValue{"lenient"},
+	}
+	for i, n := range tests {
+		want, err := json.Marshal(n)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		got, err := AppendJSON([]byte("prefix:"), n)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if string(got) != "prefix:"+string(want) {
+			t.Errorf("tests[%d] got %s; want %s", i, got, want)
+		}
+	}
+	// NOTE: The recent encoding/json writes U+FFFD itself instead of \ufffd.
+	if got, err := AppendJSON(nil, ToValue("a\xffb")); err != nil || string(got) != `"a\ufffdb"` {
+		t.Errorf("got %s, %v", got, err)
+	}
+	if _, err := AppendJSON(nil, Array{ToValue(math.NaN())}); err == nil || err.Error() != "json: unsupported value: NaN" {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestAppendJSON_KeyOrder(t *testing.T) {
+	SetKeyOrder(NaturalLess)
+	defer SetKeyOrder(nil)
+
+	got, err := AppendJSON(nil, Map{"item10": ToValue(10), "item2": ToValue(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"item2":2,"item10":10}`; string(got) != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
+func TestMarshalCanonicalJSON(t *testing.T) {
+	SetKeyOrder(NaturalLess)
+	defer SetKeyOrder(nil)
+
+	n := Map{
+		"item10": ToValue(1.5),
+		"item2":  Array{ToValue("<&>"), Any{Map{"b": ToValue(true), "a": nil}}},
+	}
+	got, err := MarshalCanonicalJSON(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"item10":1.5,"item2":["<&>",{"a":null,"b":true}]}`; string(got) != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	n := Map{"a": Array{ToValue("<&>"), ToValue(1)}}
+	tests := []struct {
+		enc  *JSONEncoder
+		want string
+	}{
+		{enc: &JSONEncoder{}, want: `{"a":["\u003c\u0026\u003e",1]}` + "\n"},
+		{enc: &JSONEncoder{NoEscapeHTML: true}, want: `{"a":["<&>",1]}` + "\n"},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		test.enc.Out = out
+		for j := 0; j < 2; j++ {
+			if err := test.enc.Encode(n); err != nil {
+				t.Fatalf("tests[%d] %v", i, err)
+			}
+		}
+		if got, want := out.String(), strings.Repeat(test.want, 2); got != want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, want)
+		}
+	}
+}
+
+func TestJSONEncoder_Allocs(t *testing.T) {
+	n := testLargeNode(10)
+	e := &JSONEncoder{Out: io.Discard}
+	if err := e.Encode(n); err != nil {
+		t.Fatal(err)
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		e.Encode(n)
+	})
+	if allocs > 0 {
+		t.Errorf("got %v allocs; want 0", allocs)
+	}
+}
+
+func testLargeNode(size int) Node {
+	a := Array{}
+	for i := 0; i < size; i++ {
+		a = append(a, Map{
+			"id":     ToValue(i),
+			"name":   ToValue("name <" + strings.Repeat("x", i%10) + ">"),
+			"active": ToValue(i%2 == 0),
+			"score":  ToValue(float64(i) / 3),
+			"tags":   ToArrayValues("a", "b", "c"),
+			"nested": Map{"x": ToValue(i), "y": Nil},
+		})
+	}
+	return Map{"items": a}
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	n := testLargeNode(1000)
+	b.Run("AppendJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := AppendJSON(nil, n); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("JSONEncoder", func(b *testing.B) {
+		b.ReportAllocs()
+		e := &JSONEncoder{Out: io.Discard}
+		for i := 0; i < b.N; i++ {
+			if err := e.Encode(n); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("encoding/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(n); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,122 @@
+// Package k8s provides helpers for Kubernetes manifests: selecting documents
+// of YAML streams by kind and name, and splitting or combining multi-document
+// manifests.
+package k8s
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jarxorg/tree"
+)
+
+// APIVersion returns the apiVersion of the manifest.
+func APIVersion(n tree.Node) string {
+	return stringOf(n, "apiVersion")
+}
+
+// Kind returns the kind of the manifest.
+func Kind(n tree.Node) string {
+	return stringOf(n, "kind")
+}
+
+// Name returns the metadata.name of the manifest.
+func Name(n tree.Node) string {
+	return stringOf(n, "metadata", "name")
+}
+
+// Namespace returns the metadata.namespace of the manifest.
+func Namespace(n tree.Node) string {
+	return stringOf(n, "metadata", "namespace")
+}
+
+func stringOf(n tree.Node, keys ...string) string {
+	for _, key := range keys {
+		if n == nil || !n.Type().IsMap() {
+			return ""
+		}
+		n = n.Map().Get(key)
+	}
+	if n == nil || !n.Type().IsStringValue() {
+		return ""
+	}
+	return n.Value().String()
+}
+
+// Selector selects manifests. Empty fields match any manifests.
+type Selector struct {
+	// Kind is the kind of the manifests. It is compared case-insensitively.
+	Kind string
+	// Name is the metadata.name of the manifests.
+	Name string
+	// Namespace is the metadata.namespace of the manifests.
+	Namespace string
+}
+
+// IsEmpty reports whether the selector matches any manifests.
+func (s Selector) IsEmpty() bool {
+	return s.Kind == "" && s.Name == "" && s.Namespace == ""
+}
+
+// Match reports whether n matches the selector.
+func (s Selector) Match(n tree.Node) bool {
+	if s.Kind != "" && !strings.EqualFold(Kind(n), s.Kind) {
+		return false
+	}
+	if s.Name != "" && Name(n) != s.Name {
+		return false
+	}
+	if s.Namespace != "" && Namespace(n) != s.Namespace {
+		return false
+	}
+	return true
+}
+
+// Select returns the documents that match the selector.
+func Select(ds tree.DocumentSet, s Selector) tree.DocumentSet {
+	var sds tree.DocumentSet
+	for _, d := range ds {
+		if s.Match(d.Node) {
+			sds = append(sds, d)
+		}
+	}
+	return sds
+}
+
+// Split decodes a multi-document manifest from r and returns each document.
+// Empty documents like the ones between consecutive "---" are skipped.
+func Split(r io.Reader, source string) (tree.DocumentSet, error) {
+	ds, err := tree.DecodeDocuments(r, source, tree.FormatYAML)
+	if err != nil {
+		return nil, err
+	}
+	var sds tree.DocumentSet
+	for _, d := range ds {
+		if d.Node == nil || d.Node.IsNil() {
+			continue
+		}
+		d.Index = len(sds)
+		sds = append(sds, d)
+	}
+	return sds, nil
+}
+
+// Combine writes the documents to w as a multi-document manifest
+// separated by "---".
+func Combine(w io.Writer, ds tree.DocumentSet) error {
+	return ds.Encode(w, tree.FormatYAML)
+}
+
+// Filename returns the conventional filename of the manifest like
+// "deployment-web.yaml" that is used to split manifests into files.
+func Filename(n tree.Node) string {
+	kind := strings.ToLower(Kind(n))
+	if kind == "" {
+		kind = "unknown"
+	}
+	if name := Name(n); name != "" {
+		return fmt.Sprintf("%s-%s.yaml", kind, name)
+	}
+	return kind + ".yaml"
+}
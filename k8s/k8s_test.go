@@ -0,0 +1,146 @@
+package k8s
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarxorg/tree"
+)
+
+var testManifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  replicas: 2
+---
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+spec:
+  replicas: 1
+`
+
+func Test_Accessors(t *testing.T) {
+	ds, err := Split(strings.NewReader(testManifests), "a.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := ds[0].Node
+	if got, want := APIVersion(n), "apps/v1"; got != want {
+		t.Errorf("got apiVersion %q; want %q", got, want)
+	}
+	if got, want := Kind(n), "Deployment"; got != want {
+		t.Errorf("got kind %q; want %q", got, want)
+	}
+	if got, want := Name(n), "web"; got != want {
+		t.Errorf("got name %q; want %q", got, want)
+	}
+	if got, want := Namespace(n), "prod"; got != want {
+		t.Errorf("got namespace %q; want %q", got, want)
+	}
+	if got := Kind(tree.ToArrayValues(1)); got != "" {
+		t.Errorf("got kind %q of an array", got)
+	}
+}
+
+func Test_Split(t *testing.T) {
+	ds, err := Split(strings.NewReader(testManifests), "a.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range ds {
+		got = append(got, Filename(d.Node))
+		if d.Source != "a.yaml" || d.Format != tree.FormatYAML {
+			t.Errorf("got source %q format %q", d.Source, d.Format)
+		}
+	}
+	want := []string{"deployment-web.yaml", "service-web.yaml", "deployment-db.yaml"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if ds[2].Index != 2 {
+		t.Errorf("got index %d; want 2", ds[2].Index)
+	}
+}
+
+func Test_Select(t *testing.T) {
+	ds, err := Split(strings.NewReader(testManifests), "a.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		selector Selector
+		want     []string
+	}{
+		{selector: Selector{}, want: []string{"deployment-web.yaml", "service-web.yaml", "deployment-db.yaml"}},
+		{selector: Selector{Kind: "deployment"}, want: []string{"deployment-web.yaml", "deployment-db.yaml"}},
+		{selector: Selector{Name: "web"}, want: []string{"deployment-web.yaml", "service-web.yaml"}},
+		{selector: Selector{Kind: "Deployment", Name: "web"}, want: []string{"deployment-web.yaml"}},
+		{selector: Selector{Namespace: "prod"}, want: []string{"deployment-web.yaml"}},
+		{selector: Selector{Kind: "Ingress"}, want: nil},
+	}
+	for i, test := range tests {
+		var got []string
+		for _, d := range Select(ds, test.selector) {
+			got = append(got, Filename(d.Node))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func Test_Combine(t *testing.T) {
+	ds, err := Split(strings.NewReader(testManifests), "a.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := Combine(buf, Select(ds, Selector{Name: "web"})); err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func Test_Filename(t *testing.T) {
+	tests := []struct {
+		n    tree.Node
+		want string
+	}{
+		{n: tree.Map{"kind": tree.ToValue("ConfigMap"), "metadata": tree.Map{"name": tree.ToValue("cfg")}}, want: "configmap-cfg.yaml"},
+		{n: tree.Map{"kind": tree.ToValue("Namespace")}, want: "namespace.yaml"},
+		{n: tree.Map{}, want: "unknown.yaml"},
+	}
+	for i, test := range tests {
+		if got := Filename(test.n); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
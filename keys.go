@@ -0,0 +1,145 @@
+package tree
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeyCase represents the naming convention of map keys.
+type KeyCase int
+
+const (
+	// KeyCaseNone keeps the keys as is.
+	KeyCaseNone KeyCase = iota
+	// KeyCaseCamel converts keys like "fooBar".
+	KeyCaseCamel
+	// KeyCaseSnake converts keys like "foo_bar".
+	KeyCaseSnake
+	// KeyCaseKebab converts keys like "foo-bar".
+	KeyCaseKebab
+)
+
+// ParseKeyCase parses "camel", "snake" or "kebab" to the KeyCase.
+// The empty string is KeyCaseNone.
+func ParseKeyCase(s string) (KeyCase, error) {
+	switch s {
+	case "":
+		return KeyCaseNone, nil
+	case "camel":
+		return KeyCaseCamel, nil
+	case "snake":
+		return KeyCaseSnake, nil
+	case "kebab":
+		return KeyCaseKebab, nil
+	}
+	return KeyCaseNone, fmt.Errorf("unknown key case %q", s)
+}
+
+// Convert returns the key converted to the case. The key is split into words
+// at "_", "-", spaces and the case boundaries like "fooBar" and "HTTPServer",
+// and the leading and trailing "_" and "-" are kept like "_id".
+func (c KeyCase) Convert(key string) string {
+	if c == KeyCaseNone {
+		return key
+	}
+	prefix, words, suffix := keyWords(key)
+	if len(words) == 0 {
+		return key
+	}
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if c == KeyCaseCamel && i > 0 {
+			r, size := utf8.DecodeRuneInString(w)
+			w = string(unicode.ToUpper(r)) + w[size:]
+		}
+		words[i] = w
+	}
+	sep := ""
+	switch c {
+	case KeyCaseSnake:
+		sep = "_"
+	case KeyCaseKebab:
+		sep = "-"
+	}
+	return prefix + strings.Join(words, sep) + suffix
+}
+
+// TransformKeys returns a copy of n that the keys of all maps are converted
+// to the case. The values are not copied. It returns an error if the
+// different keys of a map are converted to the same key.
+func TransformKeys(n Node, c KeyCase) (Node, error) {
+	if n == nil {
+		return nil, nil
+	}
+	switch n.Type() {
+	case TypeMap:
+		m := n.Map()
+		x := make(Map, len(m))
+		from := make(map[string]string, len(m))
+		for _, key := range m.KeysBy(nil) {
+			v, err := TransformKeys(m[key], c)
+			if err != nil {
+				return nil, err
+			}
+			k := c.Convert(key)
+			if prev, ok := from[k]; ok {
+				return nil, fmt.Errorf("keys %q and %q are both converted to %q", prev, key, k)
+			}
+			from[k] = key
+			x[k] = v
+		}
+		return x, nil
+	case TypeArray:
+		a := n.Array()
+		x := make(Array, len(a))
+		for i, v := range a {
+			var err error
+			if x[i], err = TransformKeys(v, c); err != nil {
+				return nil, err
+			}
+		}
+		return x, nil
+	}
+	return n, nil
+}
+
+func isKeySeparator(r rune) bool {
+	return r == '_' || r == '-' || r == ' '
+}
+
+// keyWords splits the key into the words, and returns the leading and
+// trailing separators as the prefix and the suffix.
+func keyWords(key string) (string, []string, string) {
+	body := strings.TrimLeftFunc(key, isKeySeparator)
+	prefix := key[:len(key)-len(body)]
+	body = strings.TrimRightFunc(body, isKeySeparator)
+	suffix := key[len(prefix)+len(body):]
+
+	var words []string
+	var word []rune
+	rs := []rune(body)
+	for i, r := range rs {
+		if isKeySeparator(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := rs[i-1]
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return prefix, words, suffix
+}
@@ -0,0 +1,137 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeyCase(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   KeyCase
+		errstr string
+	}{
+		{s: "", want: KeyCaseNone},
+		{s: "camel", want: KeyCaseCamel},
+		{s: "snake", want: KeyCaseSnake},
+		{s: "kebab", want: KeyCaseKebab},
+		{s: "pascal", errstr: `unknown key case "pascal"`},
+	}
+	for i, test := range tests {
+		got, err := ParseKeyCase(test.s)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestKeyCase_Convert(t *testing.T) {
+	tests := []struct {
+		key                 string
+		camel, snake, kebab string
+	}{
+		{key: "fooBar", camel: "fooBar", snake: "foo_bar", kebab: "foo-bar"},
+		{key: "foo_bar", camel: "fooBar", snake: "foo_bar", kebab: "foo-bar"},
+		{key: "foo-bar baz", camel: "fooBarBaz", snake: "foo_bar_baz", kebab: "foo-bar-baz"},
+		{key: "FooBar", camel: "fooBar", snake: "foo_bar", kebab: "foo-bar"},
+		{key: "HTTPServer", camel: "httpServer", snake: "http_server", kebab: "http-server"},
+		{key: "userID", camel: "userId", snake: "user_id", kebab: "user-id"},
+		{key: "address2Line", camel: "address2Line", snake: "address2_line", kebab: "address2-line"},
+		{key: "_id", camel: "_id", snake: "_id", kebab: "_id"},
+		{key: "__typeName__", camel: "__typeName__", snake: "__type_name__", kebab: "__type-name__"},
+		{key: "ÉtatCivil", camel: "étatCivil", snake: "état_civil", kebab: "état-civil"},
+		{key: "$ref", camel: "$ref", snake: "$ref", kebab: "$ref"},
+		{key: "", camel: "", snake: "", kebab: ""},
+	}
+	for i, test := range tests {
+		if got := KeyCaseCamel.Convert(test.key); got != test.camel {
+			t.Errorf("tests[%d] camel got %q; want %q", i, got, test.camel)
+		}
+		if got := KeyCaseSnake.Convert(test.key); got != test.snake {
+			t.Errorf("tests[%d] snake got %q; want %q", i, got, test.snake)
+		}
+		if got := KeyCaseKebab.Convert(test.key); got != test.kebab {
+			t.Errorf("tests[%d] kebab got %q; want %q", i, got, test.kebab)
+		}
+		if got := KeyCaseNone.Convert(test.key); got != test.key {
+			t.Errorf("tests[%d] none got %q; want %q", i, got, test.key)
+		}
+	}
+}
+
+func TestTransformKeys(t *testing.T) {
+	tests := []struct {
+		n      Node
+		c      KeyCase
+		want   Node
+		errstr string
+	}{
+		{
+			n: Map{
+				"userName": ToValue("a"),
+				"addresses": Array{
+					Map{"zipCode": ToValue("1"), "lineOne": ToValue("x")},
+				},
+				"meta": Map{"createdAt": ToValue(1)},
+			},
+			c: KeyCaseSnake,
+			want: Map{
+				"user_name": ToValue("a"),
+				"addresses": Array{
+					Map{"zip_code": ToValue("1"), "line_one": ToValue("x")},
+				},
+				"meta": Map{"created_at": ToValue(1)},
+			},
+		}, {
+			n:    Map{"user_name": Map{"first-name": ToValue("a")}},
+			c:    KeyCaseCamel,
+			want: Map{"userName": Map{"firstName": ToValue("a")}},
+		}, {
+			n:    ToValue("userName"),
+			c:    KeyCaseSnake,
+			want: ToValue("userName"),
+		}, {
+			n:    nil,
+			c:    KeyCaseSnake,
+			want: nil,
+		}, {
+			n:      Map{"a": Map{"fooBar": ToValue(1), "foo_bar": ToValue(2)}},
+			c:      KeyCaseSnake,
+			errstr: `keys "fooBar" and "foo_bar" are both converted to "foo_bar"`,
+		},
+	}
+	for i, test := range tests {
+		got, err := TransformKeys(test.n, test.c)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestTransformKeys_NotModified(t *testing.T) {
+	n := Map{"fooBar": Array{Map{"bazQux": ToValue(1)}}}
+	if _, err := TransformKeys(n, KeyCaseSnake); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Map{"fooBar": Array{Map{"bazQux": ToValue(1)}}}); !reflect.DeepEqual(n, want) {
+		t.Errorf("got %v; want %v", n, want)
+	}
+}
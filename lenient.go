@@ -0,0 +1,87 @@
+package tree
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LenientStringValue is a StringValue that parses itself in the numeric and
+// bool accessors, since YAML and CSV sources often deliver numbers as strings.
+// StringValue keeps the strict accessors that always return 0 and false.
+//
+// Use Lenient to wrap a value. The type and the comparisons of the value are
+// the same as StringValue.
+type LenientStringValue struct {
+	StringValue
+}
+
+var _ Value = LenientStringValue{}
+
+// Lenient returns v as a LenientStringValue if v is a string value.
+// Otherwise it returns v.
+func Lenient(v Value) Value {
+	switch tv := v.(type) {
+	case StringValue:
+		return LenientStringValue{tv}
+	case *StringValue:
+		if tv != nil {
+			return LenientStringValue{*tv}
+		}
+	}
+	return v
+}
+
+// Value returns this.
+func (n LenientStringValue) Value() Value {
+	return n
+}
+
+// Each calls cb(nil, n).
+func (n LenientStringValue) Each(cb func(key interface{}, n Node) error) error {
+	return cb(nil, n)
+}
+
+// Find finds a node using the query expression.
+func (n LenientStringValue) Find(expr string) ([]Node, error) {
+	return Find(n, expr)
+}
+
+// Bool parses this by strconv.ParseBool. It returns false if this is not a
+// bool.
+func (n LenientStringValue) Bool() bool {
+	b, _ := strconv.ParseBool(n.trim())
+	return b
+}
+
+// Int returns int of Int64.
+func (n LenientStringValue) Int() int {
+	return int(n.Int64())
+}
+
+// Int64 parses this as an integer. A float is truncated. It returns 0 if
+// this is not a number.
+func (n LenientStringValue) Int64() int64 {
+	if i, err := strconv.ParseInt(n.trim(), 10, 64); err == nil {
+		return i
+	}
+	return int64(n.Float64())
+}
+
+// Uint64 parses this as an unsigned integer. It returns 0 if this is not a
+// number or is negative.
+func (n LenientStringValue) Uint64() uint64 {
+	if u, err := strconv.ParseUint(n.trim(), 10, 64); err == nil {
+		return u
+	}
+	return NumberValue(n.Float64()).Uint64()
+}
+
+// Float64 parses this as a float. It returns 0 if this is not a number.
+func (n LenientStringValue) Float64() float64 {
+	f, _ := strconv.ParseFloat(n.trim(), 64)
+	return f
+}
+
+func (n LenientStringValue) trim() string {
+	return strings.TrimSpace(string(n.StringValue))
+}
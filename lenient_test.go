@@ -0,0 +1,70 @@
+package tree
+
+import (
+	"testing"
+)
+
+func TestLenient(t *testing.T) {
+	s := StringValue("1")
+	tests := []struct {
+		v    Value
+		want Value
+	}{
+		{v: StringValue("1"), want: LenientStringValue{"1"}},
+		{v: &s, want: LenientStringValue{"1"}},
+		{v: NumberValue(1), want: NumberValue(1)},
+		{v: Nil, want: Nil},
+	}
+	for i, test := range tests {
+		if got := Lenient(test.v); got != test.want {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func TestLenientStringValue(t *testing.T) {
+	tests := []struct {
+		s       string
+		bool    bool
+		int64   int64
+		uint64  uint64
+		float64 float64
+	}{
+		{s: "1", bool: true, int64: 1, uint64: 1, float64: 1},
+		{s: " 42 ", int64: 42, uint64: 42, float64: 42},
+		{s: "-1.5", int64: -1, float64: -1.5},
+		{s: "1e3", int64: 1000, uint64: 1000, float64: 1000},
+		{s: "9223372036854775807", int64: 9223372036854775807, uint64: 9223372036854775807, float64: 9223372036854775807},
+		{s: "true", bool: true},
+		{s: "FALSE"},
+		{s: "abc"},
+		{s: ""},
+	}
+	for i, test := range tests {
+		v := Lenient(StringValue(test.s))
+		if got := v.Bool(); got != test.bool {
+			t.Errorf("tests[%d] Bool got %v; want %v", i, got, test.bool)
+		}
+		if got := v.Int64(); got != test.int64 {
+			t.Errorf("tests[%d] Int64 got %v; want %v", i, got, test.int64)
+		}
+		if got := v.Int(); got != int(test.int64) {
+			t.Errorf("tests[%d] Int got %v; want %v", i, got, test.int64)
+		}
+		if got := ValueUint64(v); got != test.uint64 {
+			t.Errorf("tests[%d] Uint64 got %v; want %v", i, got, test.uint64)
+		}
+		if got := v.Float64(); got != test.float64 {
+			t.Errorf("tests[%d] Float64 got %v; want %v", i, got, test.float64)
+		}
+		if got := v.String(); got != test.s {
+			t.Errorf("tests[%d] String got %v; want %v", i, got, test.s)
+		}
+		if !v.Type().IsStringValue() || v.Value() != v {
+			t.Errorf("tests[%d] unexpected %#v", i, v)
+		}
+		if !v.Compare(EQ, StringValue(test.s)) {
+			t.Errorf("tests[%d] Compare got false", i)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// LintIssue is a suspicious part of a query expression reported by LintQuery.
+type LintIssue struct {
+	// Query is the part of the expression that has the issue.
+	Query string `json:"query"`
+	// Message describes the issue.
+	Message string `json:"message"`
+	// Suggestion is the replacement of Query that fixes the issue if any.
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// String returns the issue like "query: message (did you mean suggestion?)".
+func (i LintIssue) String() string {
+	s := i.Query + ": " + i.Message
+	if i.Suggestion != "" {
+		s += " (did you mean " + i.Suggestion + "?)"
+	}
+	return s
+}
+
+// LintQuery reports the suspicious parts of the query expression those are
+// valid syntax but probably wrong: the unknown methods with the similar
+// registered names, the comparisons those can never match because of the
+// type conflicts, and the redundant syntax such as a trailing dot or an empty
+// pipe. It returns an error if expr has the other syntax errors.
+func LintQuery(expr string) ([]LintIssue, error) {
+	q, err := ParseQuery(expr)
+	if err != nil {
+		var merr *unknownMethodError
+		if !errors.As(err, &merr) {
+			return nil, err
+		}
+		issue := LintIssue{Query: merr.name + "()", Message: "unknown method"}
+		if name := similarName(merr.name, methodNames()); name != "" {
+			issue.Suggestion = name + "()"
+		}
+		return []LintIssue{issue}, nil
+	}
+	var issues []LintIssue
+	err = VisitQuery(q, func(q Query) error {
+		switch tq := q.(type) {
+		case FilterQuery:
+			issues = append(issues, lintFilterQuery(tq)...)
+		case SelectQuery:
+			issues = append(issues, lintSelector(tq.Selector)...)
+		case MatchQuery:
+			issues = append(issues, lintSelector(tq.Selector)...)
+		}
+		return nil
+	})
+	return issues, err
+}
+
+func lintFilterQuery(fq FilterQuery) []LintIssue {
+	var issues []LintIssue
+	if len(fq) > 1 {
+		if _, ok := fq[len(fq)-1].(NopQuery); ok {
+			issues = append(issues, LintIssue{
+				Query:      fq.String(),
+				Message:    "trailing . is redundant",
+				Suggestion: fq[:len(fq)-1].String(),
+			})
+		}
+	}
+	for i := 1; i < len(fq); i++ {
+		_, ok0 := fq[i-1].(SlurpQuery)
+		_, ok1 := fq[i].(SlurpQuery)
+		if ok0 && ok1 {
+			fixed := append(append(FilterQuery{}, fq[:i]...), fq[i+1:]...)
+			issues = append(issues, LintIssue{
+				Query:      fq.String(),
+				Message:    "empty pipe wraps the results into an array twice",
+				Suggestion: fixed.String(),
+			})
+			break
+		}
+	}
+	return issues
+}
+
+// lintSelector reports the issues of the comparators in s. The queries in
+// the comparators are linted by VisitQuery.
+func lintSelector(s Selector) []LintIssue {
+	var issues []LintIssue
+	switch ts := s.(type) {
+	case And:
+		for _, ss := range ts {
+			issues = append(issues, lintSelector(ss)...)
+		}
+		issues = append(issues, lintConflicts(ts)...)
+	case Or:
+		for _, ss := range ts {
+			issues = append(issues, lintSelector(ss)...)
+		}
+	case Comparator:
+		if issue, ok := lintComparator(ts); ok {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func lintComparator(c Comparator) (LintIssue, bool) {
+	issue := LintIssue{Query: c.String()}
+	l, lok := c.Left.(ValueQuery)
+	r, rok := c.Right.(ValueQuery)
+	if lok && rok {
+		ok, err := c.Matches(Nil)
+		if err != nil {
+			return issue, false
+		}
+		issue.Message = fmt.Sprintf("compares the constants, so it always results in %t", ok)
+		return issue, true
+	}
+	if lok && !rok && c.Op != RE {
+		// NOTE: The constant on the left like [null != .a].
+		c = Comparator{c.Right, c.Op, c.Left}
+		r, rok = l, true
+	}
+	if !rok {
+		return issue, false
+	}
+	rv := OrNil(r.Node)
+	switch c.Op {
+	case EQ:
+	case NE:
+		if rv.Type().IsNilValue() {
+			issue.Message = fmt.Sprintf("!= null matches only when %s is missing", c.Left)
+			return issue, true
+		}
+	case GT, GE, LT, LE:
+		if rv.Type().IsNilValue() || rv.Type().IsBoolValue() {
+			issue.Message = fmt.Sprintf("%s is not ordered, so it never matches", typeName(rv.Type()))
+			issue.Suggestion = Comparator{c.Left, EQ, r}.String()
+			return issue, true
+		}
+	case RE:
+		if !rv.Type().IsStringValue() {
+			issue.Message = "the regular expression must be a string"
+			if rv.Type().IsValue() && !rv.Type().IsNilValue() {
+				issue.Suggestion = Comparator{c.Left, RE, ValueQuery{StringValue(rv.Value().String())}}.String()
+			}
+			return issue, true
+		}
+		if _, err := regexp.Compile(rv.Value().String()); err != nil {
+			issue.Message = fmt.Sprintf("invalid regular expression: %v", err)
+			return issue, true
+		}
+		return issue, false
+	default:
+		return issue, false
+	}
+	if isCountQuery(c.Left) && !rv.Type().IsNumberValue() && !rv.Type().IsNilValue() {
+		match := "never matches"
+		if c.Op == NE {
+			match = "always matches"
+		}
+		issue.Message = fmt.Sprintf("count() returns a number, so comparing with a %s %s", typeName(rv.Type()), match)
+		if rv.Type().IsStringValue() {
+			if f, err := strconv.ParseFloat(rv.Value().String(), 64); err == nil {
+				issue.Suggestion = Comparator{c.Left, c.Op, ValueQuery{NumberValue(f)}}.String()
+			}
+		}
+		return issue, true
+	}
+	return issue, false
+}
+
+// lintConflicts reports the same query that equals different constants in a.
+func lintConflicts(a And) []LintIssue {
+	var issues []LintIssue
+	seen := map[string]ValueQuery{}
+	for _, s := range a {
+		c, ok := s.(Comparator)
+		if !ok || c.Op != EQ {
+			continue
+		}
+		r, ok := c.Right.(ValueQuery)
+		if !ok {
+			continue
+		}
+		left := c.Left.String()
+		prev, ok := seen[left]
+		if !ok {
+			seen[left] = r
+			continue
+		}
+		if prev.String() != r.String() {
+			issues = append(issues, LintIssue{
+				Query:   a.String(),
+				Message: fmt.Sprintf("%s cannot equal both %s and %s, so it never matches", left, prev, r),
+			})
+		}
+	}
+	return issues
+}
+
+func isCountQuery(q Query) bool {
+	if fq, ok := q.(FilterQuery); ok && len(fq) > 0 {
+		q = fq[len(fq)-1]
+	}
+	_, ok := q.(CountQuery)
+	return ok
+}
+
+// similarName returns the name in names that is the most similar to name,
+// or "" if no names are similar enough.
+func similarName(name string, names []string) string {
+	best, bestDist := "", minInt(len(name)/3+1, 2)+1
+	for _, n := range names {
+		if d := editDistance(name, n); d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(x int, ys ...int) int {
+	for _, y := range ys {
+		if y < x {
+			x = y
+		}
+	}
+	return x
+}
@@ -0,0 +1,143 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintQuery(t *testing.T) {
+	tests := []struct {
+		expr   string
+		want   []LintIssue
+		errstr string
+	}{
+		{
+			expr: `.store.book[.category == "fiction" and .price < 10].title`,
+		}, {
+			expr: `.a.`,
+			want: []LintIssue{
+				{Query: ".a.", Message: "trailing . is redundant", Suggestion: ".a"},
+			},
+		}, {
+			expr: `.a | | .b`,
+			want: []LintIssue{
+				{Query: ".a |  | .b", Message: "empty pipe wraps the results into an array twice", Suggestion: ".a | .b"},
+			},
+		}, {
+			expr: `.uuidd()`,
+			want: []LintIssue{
+				{Query: "uuidd()", Message: "unknown method", Suggestion: "uuid()"},
+			},
+		}, {
+			expr: `.unknown()`,
+			want: []LintIssue{
+				{Query: "unknown()", Message: "unknown method"},
+			},
+		}, {
+			expr: `.a[.b > true]`,
+			want: []LintIssue{
+				{Query: ".b > true", Message: "bool is not ordered, so it never matches", Suggestion: ".b == true"},
+			},
+		}, {
+			expr: `.a[.b <= null]`,
+			want: []LintIssue{
+				{Query: ".b <= null", Message: "null is not ordered, so it never matches", Suggestion: ".b == null"},
+			},
+		}, {
+			expr: `.a[.b != null]`,
+			want: []LintIssue{
+				{Query: ".b != null", Message: "!= null matches only when .b is missing"},
+			},
+		}, {
+			expr: `.a[null != .b]`,
+			want: []LintIssue{
+				{Query: "null != .b", Message: "!= null matches only when .b is missing"},
+			},
+		}, {
+			expr: `.a[.b ~= 1]`,
+			want: []LintIssue{
+				{Query: ".b ~= 1", Message: "the regular expression must be a string", Suggestion: `.b ~= "1"`},
+			},
+		}, {
+			expr: `.a[.b ~= "("]`,
+			want: []LintIssue{
+				{Query: `.b ~= "("`, Message: "invalid regular expression: error parsing regexp: missing closing ): `(`"},
+			},
+		}, {
+			expr: `.a[.b.count() == "2"]`,
+			want: []LintIssue{
+				{Query: `.b.count() == "2"`, Message: "count() returns a number, so comparing with a string never matches", Suggestion: ".b.count() == 2"},
+			},
+		}, {
+			expr: `.a[.b.count() != true]`,
+			want: []LintIssue{
+				{Query: ".b.count() != true", Message: "count() returns a number, so comparing with a bool always matches"},
+			},
+		}, {
+			expr: `.a[1 == 2]`,
+			want: []LintIssue{
+				{Query: "1 == 2", Message: "compares the constants, so it always results in false"},
+			},
+		}, {
+			expr: `.a.select(.b == "x" and .b == "y")`,
+			want: []LintIssue{
+				{Query: `(.b == "x" and .b == "y")`, Message: `.b cannot equal both "x" and "y", so it never matches`},
+			},
+		}, {
+			expr: `.a[.b == "x" or .b == "y"]`,
+		}, {
+			expr: `.a[.b[.c > false]].d.`,
+			want: []LintIssue{
+				{Query: ".a[.b[.c > false]].d.", Message: "trailing . is redundant", Suggestion: ".a[.b[.c > false]].d"},
+				{Query: ".c > false", Message: "bool is not ordered, so it never matches", Suggestion: ".c == false"},
+			},
+		}, {
+			expr:   `.a[`,
+			errstr: `syntax error: no right brackets: ".a["`,
+		},
+	}
+	for i, test := range tests {
+		got, err := LintQuery(test.expr)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestLintIssue_String(t *testing.T) {
+	issue := LintIssue{Query: ".a.", Message: "trailing . is redundant", Suggestion: ".a"}
+	if got, want := issue.String(), ".a.: trailing . is redundant (did you mean .a?)"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	issue.Suggestion = ""
+	if got, want := issue.String(), ".a.: trailing . is redundant"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "abc", want: 3},
+		{a: "count", b: "count", want: 0},
+		{a: "cuont", b: "count", want: 2},
+		{a: "uuidd", b: "uuid", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+	}
+	for i, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("tests[%d] got %d; want %d", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pick returns a new map that has only the keys of n. The keys not in n
+// are ignored.
+func (n Map) Pick(keys ...string) Map {
+	m := Map{}
+	for _, k := range keys {
+		if v, ok := n[k]; ok {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// Omit returns a new map that has the keys of n except the keys.
+func (n Map) Omit(keys ...string) Map {
+	omit := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		omit[k] = true
+	}
+	m := Map{}
+	for k, v := range n {
+		if !omit[k] {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// Rename renames the key oldKey to newKey. The value of newKey is replaced
+// if it exists.
+func (n Map) Rename(oldKey, newKey string) error {
+	v, ok := n[oldKey]
+	if !ok {
+		return fmt.Errorf("key %q not found", oldKey)
+	}
+	delete(n, oldKey)
+	n[newKey] = v
+	return nil
+}
+
+// MergeFrom merges other into n with MergeOption. Unlike Merge, n itself is
+// updated even with MergeOptionReplaceMap.
+func (n Map) MergeFrom(other Map, opts MergeOption) {
+	// NOTE: merge returns no error without cancellation.
+	m, _ := mergeMap(context.Background(), n, other, opts, true)
+	for k := range n {
+		if _, ok := m[k]; !ok {
+			delete(n, k)
+		}
+	}
+	for k, v := range m {
+		n[k] = v
+	}
+}
@@ -0,0 +1,123 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMap_Pick(t *testing.T) {
+	m := Map{"a": ToValue(1), "b": ToValue(2), "c": ToValue(3)}
+	tests := []struct {
+		keys []string
+		want Map
+	}{
+		{keys: []string{"a", "c"}, want: Map{"a": ToValue(1), "c": ToValue(3)}},
+		{keys: []string{"a", "x"}, want: Map{"a": ToValue(1)}},
+		{keys: nil, want: Map{}},
+	}
+	for i, test := range tests {
+		if got := m.Pick(test.keys...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+	if len(m) != 3 {
+		t.Errorf("the map is changed %v", m)
+	}
+}
+
+func TestMap_Omit(t *testing.T) {
+	m := Map{"a": ToValue(1), "b": ToValue(2), "c": ToValue(3)}
+	tests := []struct {
+		keys []string
+		want Map
+	}{
+		{keys: []string{"a", "c"}, want: Map{"b": ToValue(2)}},
+		{keys: []string{"x"}, want: Map{"a": ToValue(1), "b": ToValue(2), "c": ToValue(3)}},
+	}
+	for i, test := range tests {
+		if got := m.Omit(test.keys...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+	if len(m) != 3 {
+		t.Errorf("the map is changed %v", m)
+	}
+}
+
+func TestMap_Rename(t *testing.T) {
+	tests := []struct {
+		m              Map
+		oldKey, newKey string
+		want           Map
+		errstr         string
+	}{
+		{
+			m:      Map{"a": ToValue(1), "b": ToValue(2)},
+			oldKey: "a",
+			newKey: "c",
+			want:   Map{"b": ToValue(2), "c": ToValue(1)},
+		}, {
+			m:      Map{"a": ToValue(1), "b": ToValue(2)},
+			oldKey: "a",
+			newKey: "b",
+			want:   Map{"b": ToValue(1)},
+		}, {
+			m:      Map{"a": ToValue(1)},
+			oldKey: "a",
+			newKey: "a",
+			want:   Map{"a": ToValue(1)},
+		}, {
+			m:      Map{"a": ToValue(1)},
+			oldKey: "x",
+			newKey: "y",
+			errstr: `key "x" not found`,
+		},
+	}
+	for i, test := range tests {
+		err := test.m.Rename(test.oldKey, test.newKey)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(test.m, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, test.m, test.want)
+		}
+	}
+}
+
+func TestMap_MergeFrom(t *testing.T) {
+	tests := []struct {
+		m     Map
+		other Map
+		opts  MergeOption
+		want  Map
+	}{
+		{
+			m:     Map{"a": ToValue(1), "b": ToValue(2)},
+			other: Map{"a": ToValue(3), "c": ToValue(4)},
+			opts:  MergeOptionDefault,
+			want:  Map{"a": ToValue(1), "b": ToValue(2), "c": ToValue(4)},
+		}, {
+			m:     Map{"a": ToValue(1), "b": Map{"x": ToValue(1)}},
+			other: Map{"a": ToValue(3), "b": Map{"y": ToValue(2)}},
+			opts:  MergeOptionOverride,
+			want:  Map{"a": ToValue(3), "b": Map{"x": ToValue(1), "y": ToValue(2)}},
+		}, {
+			m:     Map{"a": ToValue(1), "b": ToValue(2)},
+			other: Map{"a": ToValue(3)},
+			opts:  MergeOptionReplace,
+			want:  Map{"a": ToValue(3)},
+		},
+	}
+	for i, test := range tests {
+		test.m.MergeFrom(test.other, test.opts)
+		if !reflect.DeepEqual(test.m, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, test.m, test.want)
+		}
+	}
+}
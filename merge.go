@@ -1,5 +1,7 @@
 package tree
 
+import "context"
+
 type MergeOption int
 
 var (
@@ -54,6 +56,12 @@ var (
 	// - [1, 2, 3] and 4 merges to [1, 2, 3, 4]
 	// - 1 and 2 merges to [1, 2]
 	MergeOptionSlurp MergeOption = 0b100000
+	// MergeOptionKeepEncrypted keeps SOPS encrypted values and the SOPS
+	// metadata block of the root map of a, so that they are never overridden
+	// or replaced. The nested keys named "sops" are merged as usual.
+	// For examples:
+	// - {"a": "ENC[...]", "b": 1} and {"a": "x", "b": 2} merges to {"a": "ENC[...]", "b": 2} with MergeOptionOverride
+	MergeOptionKeepEncrypted MergeOption = 0b1000000
 )
 
 func (o MergeOption) isOverrideMap() bool {
@@ -88,31 +96,60 @@ func (o MergeOption) isSlurp() bool {
 	return o&MergeOptionSlurp == MergeOptionSlurp
 }
 
+func (o MergeOption) isKeepEncrypted() bool {
+	return o&MergeOptionKeepEncrypted == MergeOptionKeepEncrypted
+}
+
 // Merge merges two nodes with MergeOption.
 // If you do not want to change the state of the node given as an argument, use CloneDeep.
 // ex: merged := Merge(CloneDeep(a), CloneDeep(b), opts)
 func Merge(a, b Node, opts MergeOption) Node {
+	// NOTE: merge returns no error without cancellation.
+	n, _ := mergeNode(context.Background(), a, b, opts, true)
+	return n
+}
+
+// MergeContext is like Merge but returns ctx.Err() if ctx is done before
+// merging each node.
+func MergeContext(ctx context.Context, a, b Node, opts MergeOption) (Node, error) {
+	return mergeNode(ctx, a, b, opts, true)
+}
+
+// merge merges the children of the nodes those are not the roots.
+func merge(ctx context.Context, a, b Node, opts MergeOption) (Node, error) {
+	return mergeNode(ctx, a, b, opts, false)
+}
+
+// mergeNode merges a and b. The SOPS metadata block is kept only in the
+// root maps.
+func mergeNode(ctx context.Context, a, b Node, opts MergeOption, root bool) (Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.isKeepEncrypted() && IsSOPSEncrypted(a) {
+		return a, nil
+	}
 	if a.Type().IsMap() {
 		if b.Type().IsMap() {
-			return mergeMap(a.Map(), b.Map(), opts)
+			return mergeMap(ctx, a.Map(), b.Map(), opts, root)
 		}
-		return mergeNoMatchType(a, b, opts)
+		return mergeNoMatchType(a, b, opts), nil
 	}
 	if a.Type().IsArray() {
 		if b.Type().IsArray() {
-			return mergeArray(a.Array(), b.Array(), opts)
+			return mergeArray(ctx, a.Array(), b.Array(), opts)
 		}
 		if opts.isSlurp() {
-			return mergeArray(a.Array(), Array{b}, opts)
+			return mergeArray(ctx, a.Array(), Array{b}, opts)
 		}
-		return mergeNoMatchType(a, b, opts)
+		return mergeNoMatchType(a, b, opts), nil
 	}
 	if opts.isSlurp() {
 		if !b.Type().IsMap() {
-			return mergeArray(Array{a}, Array{b}, opts)
+			return mergeArray(ctx, Array{a}, Array{b}, opts)
 		}
 	}
-	return mergeNoMatchType(a, b, opts)
+	return mergeNoMatchType(a, b, opts), nil
 }
 
 func mergeNoMatchType(a Node, b Node, opts MergeOption) Node {
@@ -122,47 +159,96 @@ func mergeNoMatchType(a Node, b Node, opts MergeOption) Node {
 	return a
 }
 
-func mergeArray(a, b Array, opts MergeOption) Array {
+func mergeArray(ctx context.Context, a, b Array, opts MergeOption) (Array, error) {
 	if opts.isAppend() || opts.isSlurp() {
-		return append(a, b...)
+		return append(a, b...), nil
 	}
 	if opts.isOverrideArray() {
 		for i, v := range b {
 			if i < len(a) {
-				a.Set(i, Merge(a[i], v, opts))
+				m, err := merge(ctx, a[i], v, opts)
+				if err != nil {
+					return nil, err
+				}
+				a.Set(i, m)
 			} else {
 				a = append(a, v)
 			}
 		}
-		return a
+		return a, nil
 	}
 	if opts.isReplaceArray() {
-		return b
+		return b, nil
 	}
 	if len(a) < len(b) {
-		return append(a, b[len(a):]...)
+		return append(a, b[len(a):]...), nil
 	}
-	return a
+	return a, nil
 }
 
-func mergeMap(a, b Map, opts MergeOption) Map {
+func mergeMap(ctx context.Context, a, b Map, opts MergeOption, root bool) (Map, error) {
 	if opts.isSlurp() || opts.isOverrideMap() {
 		for k, v := range b {
 			if vv, exists := a[k]; exists {
-				a[k] = Merge(vv, v, opts)
+				if root && k == SOPSMetadataKey && opts.isKeepEncrypted() {
+					continue
+				}
+				m, err := merge(ctx, vv, v, opts)
+				if err != nil {
+					return nil, err
+				}
+				a[k] = m
 			} else {
 				a[k] = v
 			}
 		}
-		return a
+		return a, nil
 	}
 	if opts.isReplaceMap() {
-		return b
+		if opts.isKeepEncrypted() {
+			return keepEncrypted(a, b, root), nil
+		}
+		return b, nil
 	}
 	for k, v := range b {
 		if _, exists := a[k]; !exists {
 			a[k] = v
 		}
 	}
-	return a
+	return a, nil
+}
+
+// keepEncrypted returns b with the SOPS metadata block of a if a is the root,
+// and the SOPS encrypted values of a those keys are also in b.
+func keepEncrypted(a, b Map, root bool) Map {
+	for k, v := range a {
+		if _, exists := b[k]; (root && k == SOPSMetadataKey) || (exists && IsSOPSEncrypted(v)) {
+			b[k] = v
+		}
+	}
+	return b
+}
+
+// ApplyDefaults fills the keys of n those are missing from defaults
+// recursively, and returns n. Unlike Merge, the existing values including
+// null and arrays are never changed, and the values of defaults are copied
+// by CloneDeep. If n is nil, it returns a copy of defaults.
+func ApplyDefaults(n, defaults Node) Node {
+	if n == nil {
+		return CloneDeep(defaults)
+	}
+	if defaults == nil || !n.Type().IsMap() || !defaults.Type().IsMap() {
+		return n
+	}
+	m := n.Map()
+	for k, dv := range defaults.Map() {
+		if v, ok := m[k]; ok {
+			if v != nil {
+				m[k] = ApplyDefaults(v, dv)
+			}
+			continue
+		}
+		m[k] = CloneDeep(dv)
+	}
+	return m
 }
@@ -1,6 +1,7 @@
 package tree
 
 import (
+	"context"
 	"reflect"
 	"testing"
 )
@@ -312,3 +313,127 @@ func TestMerge(t *testing.T) {
 		}
 	}
 }
+
+func TestMergeContext(t *testing.T) {
+	a := Map{"a": Map{"b": ToValue(1)}}
+	b := Map{"a": Map{"c": ToValue(2)}}
+
+	got, err := MergeContext(context.Background(), CloneDeep(a), CloneDeep(b), MergeOptionOverride)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Map{"a": Map{"b": ToValue(1), "c": ToValue(2)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := MergeContext(ctx, a, b, MergeOptionOverride); err != context.Canceled {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestMerge_KeepEncrypted(t *testing.T) {
+	enc := ToValue("ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:2=,type:str]")
+	tests := []struct {
+		a    Node
+		b    Node
+		opts MergeOption
+		want Node
+	}{
+		{
+			a:    Map{"a": enc, "b": ToValue(1), "sops": Map{"version": ToValue("1")}},
+			b:    Map{"a": ToValue("x"), "b": ToValue(2), "sops": Map{"version": ToValue("2")}},
+			opts: MergeOptionOverride | MergeOptionKeepEncrypted,
+			want: Map{"a": enc, "b": ToValue(2), "sops": Map{"version": ToValue("1")}},
+		}, {
+			a:    Map{"a": enc, "b": ToValue(1), "sops": Map{"version": ToValue("1")}},
+			b:    Map{"a": ToValue("x")},
+			opts: MergeOptionReplace | MergeOptionKeepEncrypted,
+			want: Map{"a": enc, "sops": Map{"version": ToValue("1")}},
+		}, {
+			a:    Map{"a": enc},
+			b:    Map{"a": ToValue("x")},
+			opts: MergeOptionOverride,
+			want: Map{"a": ToValue("x")},
+		}, {
+			a:    Map{"tools": Map{"sops": ToValue("3.7")}},
+			b:    Map{"tools": Map{"sops": ToValue("3.8")}},
+			opts: MergeOptionOverride | MergeOptionKeepEncrypted,
+			want: Map{"tools": Map{"sops": ToValue("3.8")}},
+		}, {
+			a:    Map{"tools": Map{"sops": ToValue("3.7")}},
+			b:    Map{"tools": Map{"sops": ToValue("3.8")}},
+			opts: MergeOptionOverride | MergeOptionReplaceMap | MergeOptionKeepEncrypted,
+			want: Map{"tools": Map{"sops": ToValue("3.8")}},
+		},
+	}
+	for i, test := range tests {
+		got := Merge(CloneDeep(test.a), CloneDeep(test.b), test.opts)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+		got = ParallelOptions{}.Merge(CloneDeep(test.a), CloneDeep(test.b), test.opts)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] parallel got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	defaults := Map{
+		"a": ToValue(1),
+		"b": Map{"c": ToValue(2), "d": ToValue(3)},
+		"e": ToArrayValues(1, 2),
+		"f": Map{"g": ToValue(4)},
+		"h": ToValue(5),
+	}
+	tests := []struct {
+		n    Node
+		want Node
+	}{
+		{
+			n: Map{
+				"a": ToValue(10),
+				"b": Map{"c": ToValue(20)},
+				"e": ToArrayValues(9),
+				"h": Nil,
+			},
+			want: Map{
+				"a": ToValue(10),
+				"b": Map{"c": ToValue(20), "d": ToValue(3)},
+				"e": ToArrayValues(9),
+				"f": Map{"g": ToValue(4)},
+				"h": Nil,
+			},
+		}, {
+			n: Map{"b": ToValue("x"), "f": nil},
+			want: Map{
+				"a": ToValue(1),
+				"b": ToValue("x"),
+				"e": ToArrayValues(1, 2),
+				"f": nil,
+				"h": ToValue(5),
+			},
+		}, {
+			n:    nil,
+			want: defaults,
+		}, {
+			n:    ToArrayValues(1),
+			want: ToArrayValues(1),
+		},
+	}
+	for i, test := range tests {
+		got := ApplyDefaults(test.n, defaults)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+
+	n := ApplyDefaults(Map{}, defaults)
+	n.Map()["f"].Map()["g"] = ToValue(0)
+	if got := defaults["f"].Map()["g"]; !reflect.DeepEqual(got, ToValue(4)) {
+		t.Errorf("defaults are changed %v", got)
+	}
+}
@@ -0,0 +1,183 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MethodFunc is the type of the function called by MethodQuery.
+// The args are the queries provided as the method arguments, they are
+// usually executed against n.
+type MethodFunc func(ctx context.Context, n Node, args []Query) ([]Node, error)
+
+var (
+	methodsMu sync.RWMutex
+	methods   = map[string]MethodFunc{}
+
+	methodNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+	builtinMethodQueries = map[string]Query{
+		"count":  CountQuery{},
+		"keys":   KeysQuery{},
+		"values": ValuesQuery{},
+	}
+)
+
+// RegisterMethod registers the method that can be called as name(args...)
+// in query expressions. The name must consist of lower case letters, digits
+// and underscores. If the name is already registered, it is replaced.
+func RegisterMethod(name string, fn MethodFunc) {
+	if !methodNameRegexp.MatchString(name) {
+		panic(fmt.Errorf("invalid method name %q", name))
+	}
+	if _, ok := builtinMethodQueries[name]; ok {
+		panic(fmt.Errorf("cannot register builtin method %q", name))
+	}
+	methodsMu.Lock()
+	defer methodsMu.Unlock()
+	methods[name] = fn
+}
+
+func lookupMethod(name string) (MethodFunc, bool) {
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+	fn, ok := methods[name]
+	return fn, ok
+}
+
+// methodNames returns the sorted names of the builtin and registered methods.
+func methodNames() []string {
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+	names := make([]string, 0, len(builtinMethodQueries)+len(methods))
+	for name := range builtinMethodQueries {
+		names = append(names, name)
+	}
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unknownMethodError is the syntax error of the method that is not registered.
+type unknownMethodError struct {
+	name string
+	expr string
+}
+
+func (e *unknownMethodError) Error() string {
+	return fmt.Sprintf("syntax error: unknown method %s(): %q", e.name, e.expr)
+}
+
+// MethodQuery is a query that calls the registered method with arguments.
+type MethodQuery struct {
+	Name string
+	Args []Query
+}
+
+var _ ContextQuery = (*MethodQuery)(nil)
+
+// Exec calls the registered method.
+func (q MethodQuery) Exec(n Node) ([]Node, error) {
+	return q.ExecContext(context.Background(), n)
+}
+
+// ExecContext calls the registered method with ctx.
+func (q MethodQuery) ExecContext(ctx context.Context, n Node) ([]Node, error) {
+	fn, ok := lookupMethod(q.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown method %s()", q.Name)
+	}
+	return fn(ctx, n, q.Args)
+}
+
+func (q MethodQuery) String() string {
+	ss := make([]string, len(q.Args))
+	for i, arg := range q.Args {
+		ss[i] = arg.String()
+	}
+	return q.Name + "(" + strings.Join(ss, ", ") + ")"
+}
+
+func isMethodCmd(cmd string) bool {
+	return len(cmd) > 1 && strings.HasSuffix(cmd, "(")
+}
+
+func tokenToMethodQuery(t *token, expr string) (Query, error) {
+	name := strings.TrimSuffix(t.cmd, "(")
+	var args []Query
+	off := 0
+	for i := 0; i <= len(t.children); i++ {
+		if i < len(t.children) && t.children[i].cmd != "," {
+			continue
+		}
+		group := t.children[off:i]
+		off = i + 1
+		if len(group) == 0 {
+			if i == len(t.children) && len(args) == 0 {
+				break
+			}
+			return nil, fmt.Errorf("syntax error: empty argument of %s(): %q", name, expr)
+		}
+		arg, err := tokensToArg(group, expr)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if q, ok := builtinMethodQueries[name]; ok && len(args) == 0 {
+		return q, nil
+	}
+	if _, ok := lookupMethod(name); !ok {
+		return nil, &unknownMethodError{name: name, expr: expr}
+	}
+	return MethodQuery{Name: name, Args: args}, nil
+}
+
+// tokensToArg returns the query of the method argument. The argument that
+// has the operators, and, or or the parentheses is a MatchQuery.
+func tokensToArg(ts []*token, expr string) (Query, error) {
+	for _, t := range ts {
+		if t.cmd == "and" || t.cmd == "or" || t.cmd == "(" || isOperator(Operator(t.cmd)) {
+			s, err := tokensToSelector(ts, expr)
+			if err != nil {
+				return nil, err
+			}
+			return MatchQuery{s}, nil
+		}
+	}
+	return tokenToQuery(&token{children: ts}, expr)
+}
+
+// checkMethodArgs returns an error if the number of args is not between min and max.
+// A negative max means no upper limit.
+func checkMethodArgs(name string, args []Query, min, max int) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		return fmt.Errorf("invalid number of arguments for %s(): %d", name, len(args))
+	}
+	return nil
+}
+
+// execMethodArg executes the argument query against n and returns a single node.
+// It returns Nil if the query returns no results.
+func execMethodArg(ctx context.Context, arg Query, n Node) (Node, error) {
+	rs, err := ExecContext(ctx, arg, n)
+	if err != nil {
+		return nil, err
+	}
+	switch len(rs) {
+	case 0:
+		return Nil, nil
+	case 1:
+		if rs[0] == nil {
+			return Nil, nil
+		}
+		return rs[0], nil
+	}
+	return nil, fmt.Errorf("%q returns no single value %+v", arg, rs)
+}
@@ -0,0 +1,125 @@
+package tree
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterMethod("test_join", func(ctx context.Context, n Node, args []Query) ([]Node, error) {
+		ss := make([]string, len(args))
+		for i, arg := range args {
+			v, err := execMethodArg(ctx, arg, n)
+			if err != nil {
+				return nil, err
+			}
+			ss[i] = v.Value().String()
+		}
+		return []Node{StringValue(strings.Join(ss, "-"))}, nil
+	})
+}
+
+func Test_MethodQuery(t *testing.T) {
+	n := Array{
+		Map{"id": ToValue(1), "name": ToValue("one")},
+		Map{"id": ToValue(2), "name": ToValue("two")},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `[].test_join(.id, .name)`,
+			want: ToNodeValues("1-one", "2-two"),
+		}, {
+			expr: `[0].test_join(.name, "x", 3)`,
+			want: ToNodeValues("one-x-3"),
+		}, {
+			expr: `[0].test_join()`,
+			want: ToNodeValues(""),
+		}, {
+			expr: `[.test_join(.id, .name) == "2-two"].name`,
+			want: ToNodeValues("two"),
+		}, {
+			expr: `.count()`,
+			want: ToNodeValues(2),
+		}, {
+			expr: `[0].keys()`,
+			want: []Node{ToArrayValues("id", "name")},
+		}, {
+			expr:   `.unknown()`,
+			errstr: `syntax error: unknown method unknown(): ".unknown()"`,
+		}, {
+			expr:   `.test_join(.id,)`,
+			errstr: `syntax error: empty argument of test_join(): ".test_join(.id,)"`,
+		}, {
+			expr:   `.test_join(.id`,
+			errstr: `syntax error: no right brackets: ".test_join(.id"`,
+		}, {
+			expr:   `.test_join(..id)`,
+			errstr: `"..id" returns no single value [1 2]`,
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got %s; want %s", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func Test_MethodQuery_String(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{
+			expr: `.test_join(.id, "x", 1)`,
+			want: `.test_join(.id, "x", 1)`,
+		}, {
+			expr: `.test_join()`,
+			want: `.test_join()`,
+		}, {
+			expr: `.a.count()`,
+			want: `.a.count()`,
+		},
+	}
+	for i, test := range tests {
+		q, err := ParseQuery(test.expr)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := q.String(); got != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+	}
+}
+
+func Test_RegisterMethod_Panics(t *testing.T) {
+	names := []string{"Upper", "a-b", "", "count"}
+	for i, name := range names {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("tests[%d] no panic for %q", i, name)
+				}
+			}()
+			RegisterMethod(name, nil)
+		}()
+	}
+}
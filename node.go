@@ -2,7 +2,6 @@ package tree
 
 import (
 	"fmt"
-	"sort"
 	"strconv"
 )
 
@@ -91,61 +90,121 @@ type EditorNode interface {
 	Delete(key interface{}) error
 }
 
-// Any is an interface that defines any node.
+// Any is an interface that defines any node. A nil Node is treated as Nil.
 type Any struct {
 	Node
 }
 
-var _ Node = (*Any)(nil)
+var (
+	_ Node       = (*Any)(nil)
+	_ EditorNode = (*Any)(nil)
+)
+
+func (n Any) node() Node {
+	if n.Node == nil {
+		return Nil
+	}
+	return n.Node
+}
 
 // IsNil returns true if this node is nil.
 func (n Any) IsNil() bool {
-	return n.Node.IsNil()
+	return n.node().IsNil()
 }
 
 // Type returns TypeArray.
 func (n Any) Type() Type {
-	return n.Node.Type()
+	return n.node().Type()
 }
 
 // Array returns this node as an Array.
 func (n Any) Array() Array {
-	return n.Node.Array()
+	return n.node().Array()
 }
 
 // Map returns nil.
 func (n Any) Map() Map {
-	return n.Node.Map()
+	return n.node().Map()
 }
 
 // Value returns nil.
 func (n Any) Value() Value {
-	return n.Node.Value()
+	return n.node().Value()
 }
 
 // Has checks this node has key.
 func (n Any) Has(keys ...interface{}) bool {
-	return n.Node.Has(keys...)
+	return n.node().Has(keys...)
 }
 
 // Get returns an array value as Node.
 func (n Any) Get(keys ...interface{}) Node {
-	return n.Node.Get(keys...)
+	return n.node().Get(keys...)
 }
 
 // Each calls the callback function for each Array values.
 func (n Any) Each(cb func(key interface{}, n Node) error) error {
-	return n.Node.Each(cb)
+	return n.node().Each(cb)
 }
 
 // Find finds a node using the query expression.
 func (n Any) Find(expr string) ([]Node, error) {
-	return n.Node.Find(expr)
+	return n.node().Find(expr)
+}
+
+// Append appends v to the wrapped node if it is an Array or an EditorNode.
+func (n *Any) Append(v Node) error {
+	switch tn := n.Node.(type) {
+	case Array:
+		if err := tn.Append(v); err != nil {
+			return err
+		}
+		n.Node = tn
+		return nil
+	case EditorNode:
+		return tn.Append(v)
+	}
+	return fmt.Errorf("cannot append to value")
+}
+
+// Set sets v to the key of the wrapped node if it is an Array or an EditorNode.
+func (n *Any) Set(key interface{}, v Node) error {
+	switch tn := n.Node.(type) {
+	case Array:
+		if err := tn.Set(key, v); err != nil {
+			return err
+		}
+		n.Node = tn
+		return nil
+	case EditorNode:
+		return tn.Set(key, v)
+	}
+	return fmt.Errorf("cannot index array with %v", key)
+}
+
+// Delete deletes the key of the wrapped node if it is an Array or an EditorNode.
+func (n *Any) Delete(key interface{}) error {
+	switch tn := n.Node.(type) {
+	case Array:
+		if err := tn.Delete(key); err != nil {
+			return err
+		}
+		n.Node = tn
+		return nil
+	case EditorNode:
+		return tn.Delete(key)
+	}
+	return fmt.Errorf("cannot index array with %v", key)
 }
 
 // Array represents an array of Node.
 type Array []Node
 
+// MaxArrayGrowth is the maximum number of the elements that Array.Set adds
+// to extend the array. Setting the index beyond it is an error, so a huge
+// index like .a[10000000000] does not exhaust the memory.
+var MaxArrayGrowth = 1 << 16
+
 var (
 	_ Node       = (Array)(nil)
 	_ EditorNode = (*Array)(nil)
@@ -196,7 +255,7 @@ func (n Array) Has(keys ...interface{}) bool {
 	if len(keys) > 0 {
 		if i, ok := n.toIndex(keys[0]); ok {
 			if len(keys) > 1 {
-				return n[i].Has(keys[1:]...)
+				return OrNil(n[i]).Has(keys[1:]...)
 			}
 			return true
 		}
@@ -209,12 +268,9 @@ func (n Array) Get(keys ...interface{}) Node {
 	if len(keys) > 0 {
 		if i, ok := n.toIndex(keys[0]); ok {
 			if len(keys) > 1 {
-				return n[i].Get(keys[1:]...)
+				return OrNil(n[i]).Get(keys[1:]...)
 			}
-			if n[i] == nil {
-				return Nil
-			}
-			return n[i]
+			return OrNil(n[i])
 		}
 	}
 	return Nil
@@ -223,7 +279,7 @@ func (n Array) Get(keys ...interface{}) Node {
 // Each calls the callback function for each Array values.
 func (n Array) Each(cb func(key interface{}, n Node) error) error {
 	for i, v := range n {
-		if err := cb(i, v); err != nil {
+		if err := cb(i, OrNil(v)); err != nil {
 			return err
 		}
 	}
@@ -241,15 +297,19 @@ func (n *Array) Append(v Node) error {
 	return nil
 }
 
-// Set sets v to n[key].
+// Set sets v to n[key]. If key is out of range, the array is extended and
+// filled with Nil up to MaxArrayGrowth elements.
 func (n *Array) Set(key interface{}, v Node) error {
 	i, ok := n.toIndex(key)
-	if i == -1 {
+	if i == -1 || i-len(*n) >= MaxArrayGrowth {
 		return fmt.Errorf("cannot index array with %v", key)
 	}
 	if !ok {
 		a := make([]Node, i+1)
 		copy(a, *n)
+		for j := len(*n); j < i; j++ {
+			a[j] = Nil
+		}
 		*n = a
 	}
 	(*n)[i] = v
@@ -317,7 +377,7 @@ func (n Map) Has(keys ...interface{}) bool {
 	if len(keys) > 0 {
 		if k, ok := n.toKey(keys[0]); ok {
 			if len(keys) > 1 {
-				return n[k].Has(keys[1:]...)
+				return OrNil(n[k]).Has(keys[1:]...)
 			}
 			return true
 		}
@@ -330,34 +390,24 @@ func (n Map) Get(keys ...interface{}) Node {
 	if len(keys) > 0 {
 		if k, ok := n.toKey(keys[0]); ok {
 			if len(keys) > 1 {
-				return n[k].Get(keys[1:]...)
+				return OrNil(n[k]).Get(keys[1:]...)
 			}
-			if n[k] == nil {
-				return Nil
-			}
-			return n[k]
+			return OrNil(n[k])
 		}
 	}
 	return Nil
 }
 
-// Keys returns sorted keys of the map.
+// Keys returns sorted keys of the map. The order is set by SetKeyOrder.
 func (n Map) Keys() []string {
-	keys := make([]string, len(n))
-	i := 0
-	for k := range n {
-		keys[i] = k
-		i++
-	}
-	sort.Strings(keys)
-	return keys
+	return n.KeysBy(keyLess)
 }
 
-// Values returns values of the map.
+// Values returns values of the map. The nil values are returned as Nil.
 func (n Map) Values() []Node {
 	values := make([]Node, len(n))
 	for i, k := range n.Keys() {
-		values[i] = n[k]
+		values[i] = OrNil(n[k])
 	}
 	return values
 }
@@ -396,7 +446,7 @@ func (n Map) Delete(key interface{}) error {
 // Each calls the callback function for each Map values.
 func (n Map) Each(cb func(key interface{}, n Node) error) error {
 	for _, k := range n.Keys() {
-		if err := cb(k, n[k]); err != nil {
+		if err := cb(k, OrNil(n[k])); err != nil {
 			return err
 		}
 	}
@@ -135,6 +135,14 @@ func Test_Node(t *testing.T) {
 			a:        Array(nil),
 			v:        NumberValue(1),
 			getValue: Nil,
+		}, {
+			n:        Any{},
+			isNil:    true,
+			t:        TypeNilValue,
+			m:        Map(nil),
+			a:        Array(nil),
+			v:        Nil,
+			getValue: Nil,
 		}, {
 			n:        Any{Map(nil)},
 			isNil:    true,
@@ -417,6 +425,10 @@ func Test_EditorNode_Set(t *testing.T) {
 			n:       &Array{},
 			entries: map[interface{}]Node{-2: StringValue("value")},
 			errstr:  "cannot index array with -2",
+		}, {
+			n:       &Array{NumberValue(0)},
+			entries: map[interface{}]Node{1 + MaxArrayGrowth: StringValue("value")},
+			errstr:  fmt.Sprintf("cannot index array with %d", 1+MaxArrayGrowth),
 		}, {
 			n: Map{
 				"1": NumberValue(1),
@@ -528,3 +540,133 @@ func Test_EditorNode_Delete(t *testing.T) {
 		}
 	}
 }
+
+func Test_Any_EditorNode(t *testing.T) {
+	tests := []struct {
+		n      *Any
+		edit   func(n EditorNode) error
+		want   Node
+		errstr string
+	}{
+		{
+			n:    &Any{Map{}},
+			edit: func(n EditorNode) error { return n.Set("a", ToValue(1)) },
+			want: Map{"a": ToValue(1)},
+		}, {
+			n:    &Any{Array{}},
+			edit: func(n EditorNode) error { return n.Append(ToValue(1)) },
+			want: ToArrayValues(1),
+		}, {
+			n:    &Any{ToArrayValues(1, 2)},
+			edit: func(n EditorNode) error { return n.Set(2, ToValue(3)) },
+			want: ToArrayValues(1, 2, 3),
+		}, {
+			n:    &Any{ToArrayValues(1, 2)},
+			edit: func(n EditorNode) error { return n.Delete(0) },
+			want: ToArrayValues(2),
+		}, {
+			n:    &Any{Map{"a": ToValue(1)}},
+			edit: func(n EditorNode) error { return n.Delete("a") },
+			want: Map{},
+		}, {
+			n:      &Any{ToValue("str")},
+			edit:   func(n EditorNode) error { return n.Append(ToValue(1)) },
+			errstr: "cannot append to value",
+		}, {
+			n:      &Any{},
+			edit:   func(n EditorNode) error { return n.Set("a", ToValue(1)) },
+			errstr: "cannot index array with a",
+		},
+	}
+	for i, test := range tests {
+		err := test.edit(test.n)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(test.n.Node, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, test.n.Node, test.want)
+		}
+	}
+}
+
+func Test_Any_Edit(t *testing.T) {
+	tests := []struct {
+		n    Node
+		expr string
+		want Node
+	}{
+		{
+			n:    Any{Map{}},
+			expr: `.a.b[1] = 1`,
+			want: Any{Map{"a": Map{"b": Array{Nil, ToValue(1)}}}},
+		}, {
+			n:    Any{ToArrayValues(1)},
+			expr: `. += 2`,
+			want: Any{ToArrayValues(1, 2)},
+		}, {
+			n:    Map{"a": Any{ToArrayValues(1)}},
+			expr: `.a += 2`,
+			want: Map{"a": Any{ToArrayValues(1, 2)}},
+		}, {
+			n:    &Any{Map{}},
+			expr: `.a = 1`,
+			want: &Any{Map{"a": ToValue(1)}},
+		},
+	}
+	for i, test := range tests {
+		n := test.n
+		if err := Edit(&n, test.expr); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(n, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, n, test.want)
+		}
+	}
+}
+
+func Test_Node_NilChildren(t *testing.T) {
+	nodes := []Node{
+		Map{"a": nil},
+		Array{nil},
+		Any{Map{"a": nil}},
+	}
+	for i, n := range nodes {
+		key := interface{}("a")
+		if n.Type().IsArray() {
+			key = 0
+		}
+		if got := n.Get(key); got != Nil {
+			t.Errorf("tests[%d] Get got %#v; want Nil", i, got)
+		}
+		if got := n.Get(key, "b"); got != Nil {
+			t.Errorf("tests[%d] Get nested got %#v; want Nil", i, got)
+		}
+		if n.Has(key, "b") {
+			t.Errorf("tests[%d] Has nested got true", i)
+		}
+		err := n.Each(func(_ interface{}, v Node) error {
+			if v != Nil {
+				t.Errorf("tests[%d] Each got %#v; want Nil", i, v)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+	}
+	if got := (Map{"a": nil}).Values(); !reflect.DeepEqual(got, []Node{Nil}) {
+		t.Errorf("Values got %#v; want [Nil]", got)
+	}
+	if got := OrNil(nil); got != Nil {
+		t.Errorf("OrNil got %#v; want Nil", got)
+	}
+	if got := OrNil(ToValue(1)); got != ToValue(1) {
+		t.Errorf("OrNil got %#v; want 1", got)
+	}
+}
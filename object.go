@@ -0,0 +1,166 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ObjectField is a field of ObjectQuery.
+type ObjectField struct {
+	Key   string
+	Query Query
+}
+
+// ObjectQuery is a query that constructs a map of the results of the queries
+// like {"name": .name, "env": $env}. Each query must return a single result,
+// and a query that returns no results is null.
+type ObjectQuery []ObjectField
+
+var _ ContextQuery = (ObjectQuery)(nil)
+
+// Exec returns the constructed map.
+func (q ObjectQuery) Exec(n Node) ([]Node, error) {
+	return q.ExecContext(context.Background(), n)
+}
+
+// ExecContext returns the constructed map with ctx.
+func (q ObjectQuery) ExecContext(ctx context.Context, n Node) ([]Node, error) {
+	m := make(Map, len(q))
+	for _, f := range q {
+		rs, err := ExecContext(ctx, f.Query, n)
+		if err != nil {
+			return nil, err
+		}
+		switch len(rs) {
+		case 0:
+			m[f.Key] = Nil
+		case 1:
+			m[f.Key] = OrNil(rs[0])
+		default:
+			return nil, fmt.Errorf("%q returns no single value %+v", f.Query, rs)
+		}
+	}
+	return []Node{m}, nil
+}
+
+func (q ObjectQuery) String() string {
+	ss := make([]string, len(q))
+	for i, f := range q {
+		ss[i] = quoteQueryString(f.Key) + ": " + f.Query.String()
+	}
+	return "{" + strings.Join(ss, ", ") + "}"
+}
+
+// parseObjectQuery parses s that begins with "{" as an ObjectQuery. The keys
+// are quoted strings or words, and the values are queries.
+func parseObjectQuery(s, expr string) (Query, error) {
+	end, err := objectEnd(s, expr)
+	if err != nil {
+		return nil, err
+	}
+	if rest := strings.TrimSpace(s[end:]); rest != "" {
+		return nil, fmt.Errorf("syntax error: unexpected %s after the object: %q", rest, expr)
+	}
+	body := strings.TrimSpace(s[1 : end-1])
+	q := ObjectQuery{}
+	if body == "" {
+		return q, nil
+	}
+	keys := map[string]bool{}
+	for _, field := range splitTopLevel(body, ',') {
+		kv := splitTopLevel(field, ':')
+		if len(kv) < 2 {
+			return nil, fmt.Errorf("syntax error: no value of the field %s: %q", strings.TrimSpace(field), expr)
+		}
+		key := strings.TrimSpace(kv[0])
+		if len(key) >= 2 && key[0] == '"' && key[len(key)-1] == '"' {
+			key = unquoteQueryString(key[1 : len(key)-1])
+		} else if !plainKeyRegexp.MatchString(key) {
+			return nil, fmt.Errorf("syntax error: invalid key %s: %q", key, expr)
+		}
+		if keys[key] {
+			return nil, fmt.Errorf("syntax error: duplicate key %q: %q", key, expr)
+		}
+		keys[key] = true
+		value := strings.TrimSpace(field[len(kv[0])+1:])
+		if value == "" {
+			return nil, fmt.Errorf("syntax error: no value of the field %s: %q", key, expr)
+		}
+		vq, err := ParseQuery(value)
+		if err != nil {
+			return nil, err
+		}
+		q = append(q, ObjectField{Key: key, Query: vq})
+	}
+	return q, nil
+}
+
+// objectEnd returns the index after the "}" that closes the "{" at the
+// beginning of s.
+func objectEnd(s, expr string) (int, error) {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quoted {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				quoted = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			quoted = true
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+			if depth == 0 {
+				if c != '}' {
+					return 0, fmt.Errorf("syntax error: no right brackets: %q", expr)
+				}
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("syntax error: no right brackets: %q", expr)
+}
+
+// splitTopLevel splits s at sep those are outside the quoted strings and the
+// brackets.
+func splitTopLevel(s string, sep byte) []string {
+	var ss []string
+	depth := 0
+	quoted := false
+	off := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quoted {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				quoted = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			quoted = true
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				ss = append(ss, s[off:i])
+				off = i + 1
+			}
+		}
+	}
+	return append(ss, s[off:])
+}
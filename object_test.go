@@ -0,0 +1,111 @@
+package tree
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestObjectQuery(t *testing.T) {
+	n := Map{
+		"name": ToValue("app"),
+		"tags": ToArrayValues("web", "api"),
+		"meta": Map{"owner": ToValue("ops")},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		str    string
+		errstr string
+	}{
+		{
+			expr: `{"name": .name, "env": $env}`,
+			want: []Node{Map{"name": ToValue("app"), "env": ToValue("prod")}},
+			str:  `{"name": .name, "env": $env}`,
+		}, {
+			expr: ` {name: .name, "first tag": .tags[0], "meta": {"owner": .meta.owner, "tags": .tags.count()}} `,
+			want: []Node{Map{
+				"name":      ToValue("app"),
+				"first tag": ToValue("web"),
+				"meta":      Map{"owner": ToValue("ops"), "tags": ToValue(2)},
+			}},
+			str: `{"name": .name, "first tag": .tags[0], "meta": {"owner": .meta.owner, "tags": .tags.count()}}`,
+		}, {
+			expr: `{"missing": .missing, "url": "http://example.com", "tags": .tags}`,
+			want: []Node{Map{
+				"missing": Nil,
+				"url":     ToValue("http://example.com"),
+				"tags":    ToArrayValues("web", "api"),
+			}},
+			str: `{"missing": .missing, "url": "http://example.com", "tags": .tags}`,
+		}, {
+			expr: `{"a\"b": .name}`,
+			want: []Node{Map{`a"b`: ToValue("app")}},
+			str:  `{"a\"b": .name}`,
+		}, {
+			expr: `{}`,
+			want: []Node{Map{}},
+			str:  `{}`,
+		}, {
+			expr:   `{"tag": .tags[]}`,
+			errstr: `".tags[]" returns no single value [web api]`,
+		}, {
+			expr:   `{"name"}`,
+			errstr: `syntax error: no value of the field "name": "{\"name\"}"`,
+		}, {
+			expr:   `{"name": }`,
+			errstr: `syntax error: no value of the field name: "{\"name\": }"`,
+		}, {
+			expr:   `{"a": 1, "a": 2}`,
+			errstr: `syntax error: duplicate key "a": "{\"a\": 1, \"a\": 2}"`,
+		}, {
+			expr:   `{a-b: 1}`,
+			errstr: `syntax error: invalid key a-b: "{a-b: 1}"`,
+		}, {
+			expr:   `{"a": 1} | .a`,
+			errstr: `syntax error: unexpected | .a after the object: "{\"a\": 1} | .a"`,
+		}, {
+			expr:   `{"a": .name`,
+			errstr: `syntax error: no right brackets: "{\"a\": .name"`,
+		},
+	}
+	ctx := WithVariables(context.Background(), Map{"env": ToValue("prod")})
+	for i, test := range tests {
+		q, err := ParseQuery(test.expr)
+		if err == nil {
+			if got := q.String(); test.str != "" && got != test.str {
+				t.Errorf("tests[%d] String() got %s; want %s", i, got, test.str)
+			}
+			var got []Node
+			if got, err = ExecContext(ctx, q, n); err == nil && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+			}
+		}
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("tests[%d] %v", i, err)
+		}
+	}
+}
+
+func TestSplitTopLevel(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{s: `a, b`, want: []string{"a", " b"}},
+		{s: `"a,b", .c[0,1]`, want: []string{`"a,b"`, ` .c[0,1]`}},
+		{s: `.a.f(1, 2), {"b": 1, "c": 2}`, want: []string{`.a.f(1, 2)`, ` {"b": 1, "c": 2}`}},
+		{s: `"a\",b"`, want: []string{`"a\",b"`}},
+	}
+	for i, test := range tests {
+		if got := splitTopLevel(test.s, ','); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
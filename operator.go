@@ -0,0 +1,98 @@
+package tree
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// OperatorFunc is the type of the function called by Comparator for the
+// registered operator. The l and r are the values of the left and right
+// queries of the comparator.
+type OperatorFunc func(l, r Value) bool
+
+var (
+	operatorsMu sync.RWMutex
+	operators   = map[Operator]OperatorFunc{}
+
+	operatorRegexp   = regexp.MustCompile(`^[!#%&*+\-/<=>?@^~]{1,3}$`)
+	builtinOperators = []Operator{EQ, GT, GE, LT, LE, NE, RE}
+
+	// NOTE: "and" and "or" are matched before the method calls, so "and(" is
+	// not a method call.
+	tokenRegexpFormat = `"((?:[^"\\]|\\.)*)"|(\band\b|\bor\b|[a-z_][a-z0-9_]*\(|\$\w+|%s|\.\.|[\.\[\]\(\)\|<>:,])|(-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?\b|\w+)`
+	tokenRegexp       = compileTokenRegexp(nil)
+)
+
+// RegisterOperator registers the comparison operator that can be used in
+// selectors like [.name ^= "a"]. The operator must consist of one to three
+// symbols of "!#%&*+-/<=>?@^~". If the operator is already registered, it
+// is replaced. Builtin operators cannot be replaced.
+func RegisterOperator(op Operator, fn OperatorFunc) {
+	if !operatorRegexp.MatchString(string(op)) {
+		panic(fmt.Errorf("invalid operator %q", op))
+	}
+	if isBuiltinOperator(op) {
+		panic(fmt.Errorf("cannot register builtin operator %q", op))
+	}
+	operatorsMu.Lock()
+	defer operatorsMu.Unlock()
+	operators[op] = fn
+
+	custom := make([]Operator, 0, len(operators))
+	for o := range operators {
+		custom = append(custom, o)
+	}
+	tokenRegexp = compileTokenRegexp(custom)
+}
+
+// compileTokenRegexp returns the regexp of the tokens with the builtin and
+// the custom operators.
+//
+// NOTE: The operators are sorted together and longer operators are matched
+// first, so a custom operator like "!" does not hide the builtin "!=".
+func compileTokenRegexp(custom []Operator) *regexp.Regexp {
+	ops := make([]string, 0, len(builtinOperators)+len(custom))
+	for _, o := range append(append([]Operator{}, builtinOperators...), custom...) {
+		ops = append(ops, regexp.QuoteMeta(string(o)))
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if len(ops[i]) != len(ops[j]) {
+			return len(ops[i]) > len(ops[j])
+		}
+		return ops[i] < ops[j]
+	})
+	return regexp.MustCompile(fmt.Sprintf(tokenRegexpFormat, strings.Join(ops, "|")))
+}
+
+func isBuiltinOperator(op Operator) bool {
+	for _, o := range builtinOperators {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupOperator(op Operator) (OperatorFunc, bool) {
+	operatorsMu.RLock()
+	defer operatorsMu.RUnlock()
+	fn, ok := operators[op]
+	return fn, ok
+}
+
+func isOperator(op Operator) bool {
+	if isBuiltinOperator(op) {
+		return true
+	}
+	_, ok := lookupOperator(op)
+	return ok
+}
+
+func currentTokenRegexp() *regexp.Regexp {
+	operatorsMu.RLock()
+	defer operatorsMu.RUnlock()
+	return tokenRegexp
+}
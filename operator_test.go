@@ -0,0 +1,110 @@
+package tree
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterOperator("^=", func(l, r Value) bool {
+		return strings.HasPrefix(l.String(), r.String())
+	})
+	RegisterOperator("%=", func(l, r Value) bool {
+		ok, _ := path.Match(r.String(), l.String())
+		return ok
+	})
+}
+
+func Test_RegisterOperator(t *testing.T) {
+	n := Array{
+		Map{"id": ToValue(1), "name": ToValue("apple.txt")},
+		Map{"id": ToValue(2), "name": ToValue("banana.md")},
+		Map{"id": ToValue(3), "name": ToValue("avocado.md")},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		str    string
+		errstr string
+	}{
+		{
+			expr: `[.name ^= "a"].id`,
+			want: ToNodeValues(1, 3),
+			str:  `[.name ^= "a"].id`,
+		}, {
+			expr: `[.name %= "*.md"].id`,
+			want: ToNodeValues(2, 3),
+			str:  `[.name %= "*.md"].id`,
+		}, {
+			expr: `[.name ^= "a" and .name %= "*.md"].id`,
+			want: ToNodeValues(3),
+			str:  `[.name ^= "a" and .name %= "*.md"].id`,
+		}, {
+			expr: `[.id >= 2].id`,
+			want: ToNodeValues(2, 3),
+			str:  `[.id >= 2].id`,
+		},
+	}
+	for i, test := range tests {
+		q, err := ParseQuery(test.expr)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := q.String(); got != test.str {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.str)
+		}
+		got, err := Find(n, test.expr)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func Test_RegisterOperator_BuiltinPrefix(t *testing.T) {
+	RegisterOperator("!", func(l, r Value) bool {
+		return l.String() != r.String()
+	})
+	RegisterOperator("=", func(l, r Value) bool {
+		return l.String() == r.String()
+	})
+	n := Array{Map{"a": ToValue(1)}, Map{"a": ToValue(2)}}
+	tests := []struct {
+		expr string
+		want []Node
+	}{
+		{expr: `[.a != 1].a`, want: ToNodeValues(2)},
+		{expr: `[.a == 1].a`, want: ToNodeValues(1)},
+		{expr: `[.a <= 1].a`, want: ToNodeValues(1)},
+		{expr: `[.a >= 2].a`, want: ToNodeValues(2)},
+		{expr: `[.a ! "1"].a`, want: ToNodeValues(2)},
+		{expr: `[.a = "2"].a`, want: ToNodeValues(2)},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func Test_RegisterOperator_Panics(t *testing.T) {
+	ops := []Operator{"", "==", "~=", "abc", "====", ".="}
+	for i, op := range ops {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("tests[%d] no panic for %q", i, op)
+				}
+			}()
+			RegisterOperator(op, nil)
+		}()
+	}
+}
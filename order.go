@@ -0,0 +1,75 @@
+package tree
+
+import (
+	"sort"
+	"strings"
+)
+
+// keyLess is the order of Map.Keys. nil orders the keys lexically.
+var keyLess func(a, b string) bool
+
+// SetKeyOrder sets the order of the keys that Map.Keys returns, so it
+// controls the order of Map.Each, Map.Values, queries and the encoders of
+// this package. nil resets the order to the lexical order. The order is
+// global to the process, so a program sets it once before using the maps.
+//
+// NOTE: Map is a Go map that does not record the insertion order.
+func SetKeyOrder(less func(a, b string) bool) {
+	keyLess = less
+}
+
+// KeysBy returns the keys of the map sorted by less. nil sorts the keys
+// lexically.
+func (n Map) KeysBy(less func(a, b string) bool) []string {
+	keys := make([]string, len(n))
+	i := 0
+	for k := range n {
+		keys[i] = k
+		i++
+	}
+	if less == nil {
+		sort.Strings(keys)
+		return keys
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return less(keys[i], keys[j])
+	})
+	return keys
+}
+
+// NaturalLess reports whether a sorts before b in the natural order that
+// compares the runs of digits as numbers, so "item2" sorts before "item10".
+func NaturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := digits(a), digits(b)
+		if da == "" || db == "" {
+			if a[0] != b[0] {
+				return a[0] < b[0]
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		ta, tb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+		if len(ta) != len(tb) {
+			return len(ta) < len(tb)
+		}
+		if ta != tb {
+			return ta < tb
+		}
+		if len(da) != len(db) {
+			// NOTE: "01" sorts after "1" to keep the order total.
+			return len(da) < len(db)
+		}
+		a, b = a[len(da):], b[len(db):]
+	}
+	return len(a) < len(b)
+}
+
+// digits returns the leading digits of s.
+func digits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
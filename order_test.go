@@ -0,0 +1,84 @@
+package tree
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMap_KeysBy(t *testing.T) {
+	m := Map{"item10": Nil, "item2": Nil, "b": Nil, "a": Nil}
+	tests := []struct {
+		less func(a, b string) bool
+		want []string
+	}{
+		{
+			less: nil,
+			want: []string{"a", "b", "item10", "item2"},
+		}, {
+			less: NaturalLess,
+			want: []string{"a", "b", "item2", "item10"},
+		}, {
+			less: func(a, b string) bool { return a > b },
+			want: []string{"item2", "item10", "b", "a"},
+		},
+	}
+	for i, test := range tests {
+		if got := m.KeysBy(test.less); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "a", b: "b", want: true},
+		{a: "b", b: "a", want: false},
+		{a: "a", b: "a", want: false},
+		{a: "a", b: "ab", want: true},
+		{a: "item2", b: "item10", want: true},
+		{a: "item10", b: "item2", want: false},
+		{a: "item2a", b: "item2b", want: true},
+		{a: "item002", b: "item10", want: true},
+		{a: "item1", b: "item01", want: true},
+		{a: "item01", b: "item1", want: false},
+		{a: "1", b: "a", want: true},
+		{a: "v1.10.0", b: "v1.9.1", want: false},
+	}
+	for i, test := range tests {
+		if got := NaturalLess(test.a, test.b); got != test.want {
+			t.Errorf("tests[%d] NaturalLess(%q, %q) got %v; want %v", i, test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestSetKeyOrder(t *testing.T) {
+	SetKeyOrder(NaturalLess)
+	defer SetKeyOrder(nil)
+
+	m := Map{"item10": ToValue(10), "item2": ToValue(2)}
+	if got, want := m.Keys(), []string{"item2", "item10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if got, want := m.Values(), []Node{ToValue(2), ToValue(10)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	out := new(bytes.Buffer)
+	if err := (EncodeOptions{}).EncodeJSON(out, m); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "{\n  \"item2\": 2,\n  \"item10\": 10\n}\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	out.Reset()
+	if err := (EncodeOptions{}).EncodeYAML(out, m); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "item2: 2\nitem10: 10\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FormatTree represents an indented outline with box-drawing characters
+// like the tree command. It is only used for output.
+const FormatTree Format = "tree"
+
+// OutlineEncoder writes nodes as an outline like the tree command: each
+// line shows the key, the type and the value of a node, and the arrays and
+// maps show the numbers of their children.
+type OutlineEncoder struct {
+	Out io.Writer
+	// MaxWidth truncates the values that are wider than the width if it is
+	// positive.
+	MaxWidth int
+}
+
+// Encode writes n as an outline.
+func (e *OutlineEncoder) Encode(n Node) error {
+	b := new(strings.Builder)
+	if err := e.encode(b, ".", OrNil(n), ""); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.Out, b.String())
+	return err
+}
+
+func (e *OutlineEncoder) encode(b *strings.Builder, name string, n Node, indent string) error {
+	label, err := outlineLabel(name, n, true, e.MaxWidth)
+	if err != nil {
+		return err
+	}
+	b.WriteString(label)
+	b.WriteByte('\n')
+	switch {
+	case n.Type().IsArray():
+		a := n.Array()
+		for i, v := range a {
+			if err := e.child(b, "["+strconv.Itoa(i)+"]", OrNil(v), indent, i == len(a)-1); err != nil {
+				return err
+			}
+		}
+	case n.Type().IsMap():
+		m := n.Map()
+		keys := m.Keys()
+		for i, k := range keys {
+			if err := e.child(b, k, OrNil(m[k]), indent, i == len(keys)-1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *OutlineEncoder) child(b *strings.Builder, name string, n Node, indent string, last bool) error {
+	b.WriteString(indent)
+	if last {
+		b.WriteString("└── ")
+		return e.encode(b, name, n, indent+"    ")
+	}
+	b.WriteString("├── ")
+	return e.encode(b, name, n, indent+"│   ")
+}
+
+// outlineLabel returns the label of the node like `a (map, 2 keys)` or
+// `b (string): "c"`. The value is truncated by the width if it is positive.
+func outlineLabel(name string, n Node, value bool, width int) (string, error) {
+	name = tableCellReplacer.Replace(name)
+	switch {
+	case n.Type().IsArray():
+		return fmt.Sprintf("%s (array, %s)", name, plural(len(n.Array()), "item")), nil
+	case n.Type().IsMap():
+		return fmt.Sprintf("%s (map, %s)", name, plural(len(n.Map()), "key")), nil
+	}
+	if !value {
+		return fmt.Sprintf("%s (%s)", name, typeName(n.Type())), nil
+	}
+	v, err := MarshalJSON(n)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (%s): %s", name, typeName(n.Type()), truncateText(string(v), width)), nil
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return strconv.Itoa(n) + " " + unit + "s"
+}
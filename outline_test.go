@@ -0,0 +1,52 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOutlineEncoder(t *testing.T) {
+	tests := []struct {
+		e    OutlineEncoder
+		n    Node
+		want string
+	}{
+		{
+			n: Map{
+				"a": Array{ToValue(1), Map{"b": ToValue(true)}},
+				"c": Map{"d\ne": Nil},
+				"f": Array{},
+			},
+			want: `. (map, 3 keys)
+├── a (array, 2 items)
+│   ├── [0] (number): 1
+│   └── [1] (map, 1 key)
+│       └── b (bool): true
+├── c (map, 1 key)
+│   └── d\ne (null): null
+└── f (array, 0 items)
+`,
+		}, {
+			e: OutlineEncoder{MaxWidth: 6},
+			n: ToArrayValues("abcdefgh", "abcd"),
+			want: `. (array, 2 items)
+├── [0] (string): "abcd…
+└── [1] (string): "abcd"
+`,
+		}, {
+			n:    nil,
+			want: ". (null): null\n",
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		e := test.e
+		e.Out = buf
+		if err := e.Encode(test.n); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
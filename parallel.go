@@ -0,0 +1,167 @@
+package tree
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ParallelOptions represents the parallelism of cloning and merging very
+// large trees. The top-level keys of maps or the elements of arrays are
+// distributed to the workers, so it is effective if the root has many
+// large children.
+type ParallelOptions struct {
+	// Workers is the number of goroutines. 0 uses runtime.GOMAXPROCS(0),
+	// and 1 runs without goroutines.
+	Workers int
+}
+
+func (o ParallelOptions) workers() int {
+	if o.Workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Workers
+}
+
+// parallelFor calls fn with 0 to n-1 by the workers and returns the first
+// error.
+func (o ParallelOptions) parallelFor(ctx context.Context, n int, fn func(i int) error) error {
+	workers := o.workers()
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var next int64 = -1
+	var once sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				if err := fn(i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// CloneDeep clones the node like CloneDeep.
+func (o ParallelOptions) CloneDeep(n Node) Node {
+	switch n.Type() {
+	case TypeArray:
+		a := n.Array()
+		aa := make(Array, len(a))
+		// NOTE: The clone returns no error.
+		o.parallelFor(context.Background(), len(a), func(i int) error {
+			aa[i] = CloneDeep(a[i])
+			return nil
+		})
+		return aa
+	case TypeMap:
+		m := n.Map()
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		vs := make([]Node, len(keys))
+		o.parallelFor(context.Background(), len(keys), func(i int) error {
+			vs[i] = CloneDeep(m[keys[i]])
+			return nil
+		})
+		mm := make(Map, len(m))
+		for i, k := range keys {
+			mm[k] = vs[i]
+		}
+		return mm
+	}
+	return n
+}
+
+// Merge merges two nodes like Merge.
+func (o ParallelOptions) Merge(a, b Node, opts MergeOption) Node {
+	// NOTE: merge returns no error without cancellation.
+	n, _ := o.MergeContext(context.Background(), a, b, opts)
+	return n
+}
+
+// MergeContext merges two nodes like MergeContext.
+func (o ParallelOptions) MergeContext(ctx context.Context, a, b Node, opts MergeOption) (Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.isKeepEncrypted() && IsSOPSEncrypted(a) {
+		return a, nil
+	}
+	switch {
+	case a.Type().IsMap() && b.Type().IsMap() && (opts.isSlurp() || opts.isOverrideMap()):
+		return o.mergeMap(ctx, a.Map(), b.Map(), opts)
+	case a.Type().IsArray() && b.Type().IsArray() && opts.isOverrideArray() && !opts.isAppend() && !opts.isSlurp():
+		return o.mergeArray(ctx, a.Array(), b.Array(), opts)
+	}
+	// NOTE: The other rules do not merge the children.
+	return mergeNode(ctx, a, b, opts, true)
+}
+
+func (o ParallelOptions) mergeMap(ctx context.Context, a, b Map, opts MergeOption) (Map, error) {
+	var keys []string
+	for k, v := range b {
+		if _, exists := a[k]; !exists {
+			a[k] = v
+		} else if k != SOPSMetadataKey || !opts.isKeepEncrypted() {
+			keys = append(keys, k)
+		}
+	}
+	vs := make([]Node, len(keys))
+	err := o.parallelFor(ctx, len(keys), func(i int) error {
+		m, err := merge(ctx, a[keys[i]], b[keys[i]], opts)
+		vs[i] = m
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, k := range keys {
+		a[k] = vs[i]
+	}
+	return a, nil
+}
+
+func (o ParallelOptions) mergeArray(ctx context.Context, a, b Array, opts MergeOption) (Array, error) {
+	n := len(b)
+	if n > len(a) {
+		n = len(a)
+	}
+	err := o.parallelFor(ctx, n, func(i int) error {
+		m, err := merge(ctx, a[i], b[i], opts)
+		a[i] = m
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(a, b[n:]...), nil
+}
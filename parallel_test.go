@@ -0,0 +1,111 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func testParallelNode(keys, size int, seed int) Map {
+	m := Map{}
+	for k := 0; k < keys; k++ {
+		a := Array{}
+		for i := 0; i < size; i++ {
+			a = append(a, Map{
+				"id":   ToValue(i * seed),
+				"tags": ToArrayValues("a", seed),
+			})
+		}
+		m[fmt.Sprintf("key%d", k)] = Map{"items": a, "n": ToValue(k * seed)}
+	}
+	return m
+}
+
+func TestParallelOptions_CloneDeep(t *testing.T) {
+	tests := []Node{
+		testParallelNode(10, 3, 1),
+		Array{testParallelNode(2, 2, 1), ToValue(1), Nil},
+		Map{},
+		ToValue("a"),
+		Nil,
+	}
+	for i, n := range tests {
+		for _, workers := range []int{0, 1, 4} {
+			got := ParallelOptions{Workers: workers}.CloneDeep(n)
+			if !reflect.DeepEqual(got, n) {
+				t.Errorf("tests[%d] workers %d got %v; want %v", i, workers, got, n)
+			}
+		}
+	}
+
+	n := Map{"a": Map{"b": ToValue(1)}}
+	got := ParallelOptions{Workers: 2}.CloneDeep(n)
+	got.Map()["a"].Map()["b"] = ToValue(2)
+	if want := (Map{"a": Map{"b": ToValue(1)}}); !reflect.DeepEqual(n, want) {
+		t.Errorf("got %v; want %v", n, want)
+	}
+}
+
+func TestParallelOptions_Merge(t *testing.T) {
+	nodes := [][2]Node{
+		{testParallelNode(8, 3, 1), testParallelNode(12, 2, 2)},
+		{Array{testParallelNode(2, 2, 1), ToValue(1)}, Array{testParallelNode(3, 1, 2), Map{}, ToValue(2)}},
+		{ToArrayValues(1, 2), ToValue(3)},
+		{ToValue(1), ToValue(2)},
+		{
+			Map{"a": ToValue("ENC[AES256_GCM,data:x]"), "b": ToValue(1), SOPSMetadataKey: Map{"v": ToValue(1)}},
+			Map{"a": ToValue("x"), "b": ToValue(2), SOPSMetadataKey: Map{"v": ToValue(2)}},
+		},
+	}
+	opts := []MergeOption{
+		MergeOptionDefault,
+		MergeOptionOverride,
+		MergeOptionOverrideMap,
+		MergeOptionOverrideArray,
+		MergeOptionReplace,
+		MergeOptionAppend | MergeOptionOverride,
+		MergeOptionSlurp,
+		MergeOptionOverride | MergeOptionKeepEncrypted,
+	}
+	for i, n := range nodes {
+		for _, o := range opts {
+			want := Merge(CloneDeep(n[0]), CloneDeep(n[1]), o)
+			got := ParallelOptions{Workers: 4}.Merge(CloneDeep(n[0]), CloneDeep(n[1]), o)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("nodes[%d] opts %b got %v; want %v", i, o, got, want)
+			}
+		}
+	}
+}
+
+func TestParallelOptions_MergeContext(t *testing.T) {
+	a := testParallelNode(8, 2, 1)
+	b := testParallelNode(8, 2, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (ParallelOptions{Workers: 4}).MergeContext(ctx, a, b, MergeOptionOverride); err != context.Canceled {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+}
+
+func BenchmarkParallelOptions(b *testing.B) {
+	n := testParallelNode(64, 2000, 1)
+	for _, workers := range []int{1, 2, 4, 8} {
+		o := ParallelOptions{Workers: workers}
+		b.Run(fmt.Sprintf("CloneDeep/workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				o.CloneDeep(n)
+			}
+		})
+		b.Run(fmt.Sprintf("Merge/workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				x, y := CloneDeep(n), CloneDeep(n)
+				b.StartTimer()
+				o.Merge(x, y, MergeOptionOverride)
+			}
+		})
+	}
+}
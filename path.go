@@ -0,0 +1,388 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+func init() {
+	RegisterMethod("path", pathMethod)
+	RegisterMethod("leaf_paths", leafPaths)
+	RegisterMethod("getpath", getpath)
+	RegisterMethod("setpath", setpath)
+}
+
+type execPathKey struct{}
+
+// execPath is the path of the node from the root of the execution.
+// A nil *execPath represents the unknown path like the results of methods.
+type execPath struct {
+	keys []interface{}
+}
+
+func (p *execPath) child(key interface{}) *execPath {
+	if p == nil {
+		return nil
+	}
+	keys := make([]interface{}, len(p.keys)+1)
+	copy(keys, p.keys)
+	keys[len(p.keys)] = key
+	return &execPath{keys: keys}
+}
+
+// withExecPath returns a copy of ctx that tracks the paths of the nodes if
+// q is the top-level query and uses pathMethods.
+func withExecPath(ctx context.Context, q Query) context.Context {
+	if _, ok := ctx.Value(execRootKey{}).(Node); ok {
+		return ctx
+	}
+	if !usesPath(q) {
+		return ctx
+	}
+	return context.WithValue(ctx, execPathKey{}, &execPath{})
+}
+
+// execPathFrom returns the path of the current node and whether the paths
+// are tracked.
+func execPathFrom(ctx context.Context) (*execPath, bool) {
+	p, ok := ctx.Value(execPathKey{}).(*execPath)
+	return p, ok
+}
+
+func usesPath(q Query) bool {
+	found := false
+	VisitQuery(q, func(q Query) error {
+		if mq, ok := q.(MethodQuery); ok && pathMethods[mq.Name] {
+			found = true
+			return errFound
+		}
+		return nil
+	})
+	return found
+}
+
+// pathMethods are the methods those use the paths of the nodes.
+var pathMethods = map[string]bool{
+	"path":    true,
+	"join_on": true,
+}
+
+// errFound stops VisitQuery when the query is found.
+var errFound = errors.New("found")
+
+// pathKeepingMethods are the methods those return the provided node as is,
+// so the results have the path of the node.
+var pathKeepingMethods = map[string]bool{
+	"select":   true,
+	"arrays":   true,
+	"maps":     true,
+	"strings":  true,
+	"numbers":  true,
+	"booleans": true,
+	"nulls":    true,
+}
+
+// execWithPaths is like ExecContext but also returns the paths of the results.
+func (qs FilterQuery) execWithPaths(ctx context.Context, n Node, p *execPath, trace TraceFunc) ([]Node, []*execPath, error) {
+	rs := []Node{n}
+	ps := []*execPath{p}
+	for _, q := range qs {
+		if _, ok := q.(SlurpQuery); ok {
+			nrs, err := ExecContext(ctx, q, Array(rs))
+			if err != nil {
+				return nil, nil, err
+			}
+			rs = nrs
+			ps = make([]*execPath, len(nrs))
+			if trace != nil {
+				trace(q, rs)
+			}
+			continue
+		}
+		var nrs []Node
+		var nps []*execPath
+		for i, r := range rs {
+			if r == nil {
+				continue
+			}
+			nr, np, err := execQueryWithPaths(ctx, q, r, ps[i])
+			if err != nil {
+				return nil, nil, err
+			}
+			nrs = append(nrs, nr...)
+			nps = append(nps, np...)
+			if err := checkMaxResults(ctx, len(nrs)); err != nil {
+				return nil, nil, err
+			}
+		}
+		rs, ps = nrs, nps
+		if trace != nil {
+			trace(q, rs)
+		}
+	}
+	return rs, ps, nil
+}
+
+// execQueryWithPaths executes q to n whose path is p and returns the results
+// and their paths.
+func execQueryWithPaths(ctx context.Context, q Query, n Node, p *execPath) ([]Node, []*execPath, error) {
+	ctx = context.WithValue(ctx, execPathKey{}, p)
+	switch tq := q.(type) {
+	case NopQuery:
+		return []Node{n}, []*execPath{p}, nil
+	case FilterQuery:
+		return tq.execWithPaths(ctx, n, p, nil)
+	case MapQuery:
+		rs, err := tq.Exec(n)
+		if err != nil || len(rs) == 0 {
+			return nil, nil, err
+		}
+		return rs, []*execPath{p.child(string(tq))}, nil
+	case ArrayQuery:
+		rs, err := tq.Exec(n)
+		if err != nil || len(rs) == 0 {
+			return nil, nil, err
+		}
+		return rs, []*execPath{p.child(int(tq))}, nil
+	case ArrayRangeQuery:
+		rs, err := tq.Exec(n)
+		if err != nil {
+			return nil, nil, err
+		}
+		from, _ := tq.bounds(len(n.Array()))
+		ps := make([]*execPath, len(rs))
+		for i := range rs {
+			ps[i] = p.child(from + i)
+		}
+		return rs, ps, nil
+	case SelectQuery:
+		var rs []Node
+		var ps []*execPath
+		err := n.Each(func(key interface{}, v Node) error {
+			if key == nil {
+				return nil
+			}
+			vp := p.child(key)
+			if tq.Selector != nil {
+				ok, err := MatchesContext(context.WithValue(ctx, execPathKey{}, vp), tq.Selector, v)
+				if err != nil || !ok {
+					return err
+				}
+			}
+			rs = append(rs, v)
+			ps = append(ps, vp)
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return rs, ps, nil
+	case MethodQuery:
+		if pathKeepingMethods[tq.Name] {
+			rs, err := ExecContext(ctx, q, n)
+			if err != nil {
+				return nil, nil, err
+			}
+			ps := make([]*execPath, len(rs))
+			for i := range rs {
+				ps[i] = p
+			}
+			return rs, ps, nil
+		}
+	case WalkQuery:
+		key := string(tq)
+		var rs []Node
+		var ps []*execPath
+		err := WalkContext(ctx, n, func(v Node, keys []interface{}) error {
+			if err := checkMaxDepth(ctx, len(keys)); err != nil {
+				return err
+			}
+			if v == nil || !v.Has(key) {
+				return nil
+			}
+			vp := p
+			for _, k := range keys {
+				vp = vp.child(k)
+			}
+			rs = append(rs, v.Get(key))
+			ps = append(ps, vp.child(key))
+			return checkMaxResults(ctx, len(rs))
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return rs, ps, nil
+	}
+	rs, err := ExecContext(ctx, q, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rs, make([]*execPath, len(rs)), nil
+}
+
+func keysNode(keys []interface{}) Array {
+	a := make(Array, len(keys))
+	for i, key := range keys {
+		a[i] = ToValue(key)
+	}
+	return a
+}
+
+// pathMethod is the method "path()" that returns the path of the node from the
+// root of the execution as an array like ["store", "book", 0].
+func pathMethod(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("path", args, 0, 0); err != nil {
+		return nil, err
+	}
+	p, _ := execPathFrom(ctx)
+	if p == nil {
+		return nil, fmt.Errorf("path(): unknown path of %v", n)
+	}
+	return []Node{keysNode(p.keys)}, nil
+}
+
+// leafPaths is the method "leaf_paths()" that returns the paths of all the
+// values that are not arrays or maps under the node.
+func leafPaths(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("leaf_paths", args, 0, 0); err != nil {
+		return nil, err
+	}
+	var rs []Node
+	err := WalkContext(ctx, n, func(v Node, keys []interface{}) error {
+		if len(keys) == 0 || v.Type().IsArray() || v.Type().IsMap() {
+			return nil
+		}
+		rs = append(rs, keysNode(keys))
+		return checkMaxResults(ctx, len(rs))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// execMethodPath executes the argument query against n and returns the path
+// as the keys that are strings and ints.
+func execMethodPath(ctx context.Context, name string, arg Query, n Node) ([]interface{}, error) {
+	p, err := execMethodArg(ctx, arg, n)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Type().IsArray() {
+		return nil, fmt.Errorf("invalid path for %s(): %v", name, p)
+	}
+	a := p.Array()
+	keys := make([]interface{}, len(a))
+	for i, k := range a {
+		switch {
+		case k != nil && k.Type().IsStringValue():
+			keys[i] = k.Value().String()
+		case k != nil && k.Type().IsNumberValue() && k.Value().Float64() == float64(k.Value().Int()):
+			keys[i] = k.Value().Int()
+		default:
+			return nil, fmt.Errorf("invalid path for %s(): %v", name, p)
+		}
+	}
+	return keys, nil
+}
+
+// getpath is the method "getpath(path)" that returns the node at the path
+// like ["store", "book", 0]. It returns no results if the node is not found.
+func getpath(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("getpath", args, 1, 1); err != nil {
+		return nil, err
+	}
+	keys, err := execMethodPath(ctx, "getpath", args[0], n)
+	if err != nil {
+		return nil, err
+	}
+	if v := nodeAtKeys(n, keys); v != nil {
+		return []Node{v}, nil
+	}
+	return nil, nil
+}
+
+// setpath is the method "setpath(path, value)" that returns a copy of the
+// node that the value is set at the path. The missing maps and arrays on
+// the path are created.
+func setpath(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("setpath", args, 2, 2); err != nil {
+		return nil, err
+	}
+	keys, err := execMethodPath(ctx, "setpath", args[0], n)
+	if err != nil {
+		return nil, err
+	}
+	v, err := execMethodArg(ctx, args[1], n)
+	if err != nil {
+		return nil, err
+	}
+	r, err := setNodeAtKeys(CloneDeep(n), keys, v)
+	if err != nil {
+		return nil, fmt.Errorf("setpath(): %w", err)
+	}
+	return []Node{r}, nil
+}
+
+// SetPathKeys sets v at the path of the keys like []interface{}{"a", 0}
+// under *pn as setpath() does. The missing maps and arrays on the path are
+// created, and the arrays are grown by Array.Set.
+func SetPathKeys(pn *Node, keys []interface{}, v Node) error {
+	n, err := setNodeAtKeys(*pn, keys, v)
+	if err != nil {
+		return err
+	}
+	*pn = n
+	return nil
+}
+
+func setNodeAtKeys(n Node, keys []interface{}, v Node) (Node, error) {
+	if len(keys) == 0 {
+		return v, nil
+	}
+	switch key := keys[0].(type) {
+	case string:
+		var m Map
+		switch {
+		case n == nil || n.IsNil():
+			m = Map{}
+		case n.Type().IsMap():
+			m = n.Map()
+		default:
+			return nil, fmt.Errorf("cannot index %v with %q", n, key)
+		}
+		x, err := setNodeAtKeys(m[key], keys[1:], v)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = x
+		return m, nil
+	case int:
+		var a Array
+		switch {
+		case n == nil || n.IsNil():
+			a = Array{}
+		case n.Type().IsArray():
+			a = n.Array()
+		default:
+			return nil, fmt.Errorf("cannot index %v with %d", n, key)
+		}
+		if key < 0 {
+			return nil, fmt.Errorf("cannot index %v with %d", n, key)
+		}
+		var x Node
+		if key < len(a) {
+			x = a[key]
+		}
+		x, err := setNodeAtKeys(x, keys[1:], v)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.Set(key, x); err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+	return n, nil
+}
@@ -0,0 +1,185 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Path(t *testing.T) {
+	n := Map{
+		"store": Map{
+			"book": Array{
+				Map{"title": ToValue("A"), "price": ToValue(8.95)},
+				Map{"title": ToValue("B"), "price": ToValue(12.99), "tags": Array{ToValue("x"), Map{"y": ToValue(1)}}},
+			},
+			"bicycle": Map{"color": ToValue("red"), "price": ToValue(19.95)},
+		},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.path()`,
+			want: []Node{Array{}},
+		}, {
+			expr: `.store.book[0].title.path()`,
+			want: []Node{ToArrayValues("store", "book", 0, "title")},
+		}, {
+			expr: `..price.path()`,
+			want: []Node{
+				ToArrayValues("store", "bicycle", "price"),
+				ToArrayValues("store", "book", 0, "price"),
+				ToArrayValues("store", "book", 1, "price"),
+			},
+		}, {
+			expr: `.store.book[.price > 10].title.path()`,
+			want: []Node{ToArrayValues("store", "book", 1, "title")},
+		}, {
+			expr: `.store.book[1:].path()`,
+			want: []Node{ToArrayValues("store", "book", 1)},
+		}, {
+			expr: `.store[].path()`,
+			want: []Node{ToArrayValues("store", "bicycle"), ToArrayValues("store", "book")},
+		}, {
+			expr: `.store.book[path()[2] == 1].title`,
+			want: ToNodeValues("B"),
+		}, {
+			expr: `.store.book[].format("%v", path())`,
+			want: ToNodeValues(`["store","book",0]`, `["store","book",1]`),
+		}, {
+			expr: `.store.book[1].leaf_paths()`,
+			want: []Node{
+				ToArrayValues("price"),
+				ToArrayValues("tags", 0),
+				ToArrayValues("tags", 1, "y"),
+				ToArrayValues("title"),
+			},
+		}, {
+			expr: `.store.book[0].title.leaf_paths()`,
+		}, {
+			expr:   `.store.book.count().path()`,
+			errstr: "path(): unknown path of 2",
+		}, {
+			expr:   `.path(1)`,
+			errstr: "invalid number of arguments for path(): 1",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func Test_GetpathSetpath(t *testing.T) {
+	n := Map{
+		"store": Map{
+			"book": Array{
+				Map{"title": ToValue("A")},
+				Map{"title": ToValue("B")},
+			},
+		},
+		"ref":  ToArrayValues("store", "book", 1, "title"),
+		"bad":  ToArrayValues("store", true),
+		"name": ToValue("x"),
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.getpath(["store","book",0])`,
+			want: []Node{Map{"title": ToValue("A")}},
+		}, {
+			expr: `.getpath(.ref)`,
+			want: ToNodeValues("B"),
+		}, {
+			expr: `.store.book[0].title.path() | [0]`,
+			want: []Node{ToArrayValues("store", "book", 0, "title")},
+		}, {
+			expr: `.getpath(["store","book",5])`,
+		}, {
+			expr: `.getpath(["store","book",-1])`,
+		}, {
+			expr: `.getpath(["name","x"])`,
+		}, {
+			expr: `.getpath([])`,
+			want: []Node{n},
+		}, {
+			expr: `.store.setpath(["book",0,"title"], "C").book[].title`,
+			want: ToNodeValues("C", "B"),
+		}, {
+			expr: `.store.setpath(["pen",1,"color"], "red").pen`,
+			want: []Node{Array{Nil, Map{"color": ToValue("red")}}},
+		}, {
+			expr: `.store.setpath(["book"], {"a":1}).book`,
+			want: []Node{Map{"a": ToValue(1)}},
+		}, {
+			expr: `.store.book[0].title`,
+			want: ToNodeValues("A"),
+		}, {
+			expr:   `.getpath(.bad)`,
+			errstr: "invalid path for getpath(): [store true]",
+		}, {
+			expr:   `.getpath("store")`,
+			errstr: "invalid path for getpath(): store",
+		}, {
+			expr:   `.setpath(["name","x"], 1)`,
+			errstr: `setpath(): cannot index x with "x"`,
+		}, {
+			expr:   `.setpath(["name"])`,
+			errstr: "invalid number of arguments for setpath(): 1",
+		}, {
+			expr:   `.setpath(["a", 100000000000], 1)`,
+			errstr: "setpath(): cannot index array with 100000000000",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func Test_SetPathKeys(t *testing.T) {
+	var n Node = Map{"a": Array{ToValue(1)}}
+	if err := SetPathKeys(&n, []interface{}{"a", 2, "b"}, ToValue("x")); err != nil {
+		t.Fatal(err)
+	}
+	want := Map{"a": Array{ToValue(1), Nil, Map{"b": ToValue("x")}}}
+	if !reflect.DeepEqual(n, Node(want)) {
+		t.Errorf("got %v; want %v", n, want)
+	}
+	if err := SetPathKeys(&n, []interface{}{"a", 2 + MaxArrayGrowth + 1}, Nil); err == nil {
+		t.Errorf("no error")
+	}
+}
@@ -0,0 +1,128 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+)
+
+// FilterByPolicy returns a copy of n that contains only the nodes that the
+// allow queries find and their ancestors, and that does not contain the
+// nodes that the deny queries find. All nodes are allowed if no allow
+// queries are provided, and deny wins over allow. The elements of the arrays
+// those are not allowed are removed, so the indexes of the copy can differ.
+// It returns Nil if the root is not allowed.
+//
+// For example, FilterByPolicy(n, []string{".users[].name", ".total"},
+// []string{"..password"}) returns only the names of the users and the total.
+func FilterByPolicy(n Node, allowQueries, denyQueries []string) (Node, error) {
+	return FilterByPolicyContext(context.Background(), n, allowQueries, denyQueries)
+}
+
+// FilterByPolicyContext is like FilterByPolicy but executes the queries with
+// ctx.
+func FilterByPolicyContext(ctx context.Context, n Node, allowQueries, denyQueries []string) (Node, error) {
+	n = OrNil(n)
+	var allow *policyTrie
+	if len(allowQueries) > 0 {
+		t, err := newPolicyTrie(ctx, n, allowQueries)
+		if err != nil {
+			return nil, err
+		}
+		allow = t
+	}
+	deny, err := newPolicyTrie(ctx, n, denyQueries)
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := filterByPolicy(n, allow, deny, allow == nil); ok {
+		return r, nil
+	}
+	return Nil, nil
+}
+
+// policyTrie holds the paths of the nodes that the queries find.
+type policyTrie struct {
+	match    bool
+	children map[interface{}]*policyTrie
+}
+
+func newPolicyTrie(ctx context.Context, n Node, exprs []string) (*policyTrie, error) {
+	t := &policyTrie{}
+	for _, expr := range exprs {
+		q, err := ParseQuery(expr)
+		if err != nil {
+			return nil, err
+		}
+		root := &execPath{}
+		pctx := context.WithValue(withExecRoot(ctx, n), execPathKey{}, root)
+		_, ps, err := execQueryWithPaths(pctx, q, n, root)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", expr, err)
+		}
+		for _, p := range ps {
+			if p == nil {
+				return nil, fmt.Errorf("policy %s: unknown path of the result", expr)
+			}
+			t.add(p.keys)
+		}
+	}
+	return t, nil
+}
+
+func (t *policyTrie) add(keys []interface{}) {
+	for _, key := range keys {
+		if t.children == nil {
+			t.children = map[interface{}]*policyTrie{}
+		}
+		c, ok := t.children[key]
+		if !ok {
+			c = &policyTrie{}
+			t.children[key] = c
+		}
+		t = c
+	}
+	t.match = true
+}
+
+func (t *policyTrie) child(key interface{}) *policyTrie {
+	if t == nil {
+		return nil
+	}
+	return t.children[key]
+}
+
+// filterByPolicy returns the copy of n and whether n is kept. allowAll
+// reports whether n or the ancestor is allowed.
+func filterByPolicy(n Node, allow, deny *policyTrie, allowAll bool) (Node, bool) {
+	if deny != nil && deny.match {
+		return nil, false
+	}
+	allowAll = allowAll || (allow != nil && allow.match)
+	if !allowAll && allow == nil {
+		return nil, false
+	}
+	if n == nil {
+		return Nil, allowAll
+	}
+	switch n.Type() {
+	case TypeArray:
+		a := n.Array()
+		x := Array{}
+		for i, v := range a {
+			if r, ok := filterByPolicy(v, allow.child(i), deny.child(i), allowAll); ok {
+				x = append(x, r)
+			}
+		}
+		return x, true
+	case TypeMap:
+		m := n.Map()
+		x := Map{}
+		for k, v := range m {
+			if r, ok := filterByPolicy(v, allow.child(k), deny.child(k), allowAll); ok {
+				x[k] = r
+			}
+		}
+		return x, true
+	}
+	return n, allowAll
+}
@@ -0,0 +1,92 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterByPolicy(t *testing.T) {
+	n := Map{
+		"total": ToValue(2),
+		"users": Array{
+			Map{"name": ToValue("a"), "password": ToValue("x"), "admin": ToValue(true)},
+			Map{"name": ToValue("b"), "password": ToValue("y"), "admin": ToValue(false)},
+		},
+	}
+	tests := []struct {
+		allow  []string
+		deny   []string
+		want   Node
+		errstr string
+	}{
+		{
+			want: n,
+		}, {
+			allow: []string{".users[].name", ".total"},
+			want: Map{
+				"total": ToValue(2),
+				"users": Array{
+					Map{"name": ToValue("a")},
+					Map{"name": ToValue("b")},
+				},
+			},
+		}, {
+			deny: []string{"..password"},
+			want: Map{
+				"total": ToValue(2),
+				"users": Array{
+					Map{"name": ToValue("a"), "admin": ToValue(true)},
+					Map{"name": ToValue("b"), "admin": ToValue(false)},
+				},
+			},
+		}, {
+			allow: []string{".users"},
+			deny:  []string{".users[].password", ".users[1]"},
+			want: Map{
+				"users": Array{
+					Map{"name": ToValue("a"), "admin": ToValue(true)},
+				},
+			},
+		}, {
+			allow: []string{".users[].select(.admin)"},
+			deny:  []string{".users[].password"},
+			want: Map{
+				"users": Array{
+					Map{"name": ToValue("a"), "admin": ToValue(true)},
+				},
+			},
+		}, {
+			allow: []string{".missing"},
+			want:  Map{},
+		}, {
+			allow: []string{"."},
+			deny:  []string{"."},
+			want:  Nil,
+		}, {
+			allow:  []string{".users | length"},
+			errstr: "policy .users | length: unknown path of the result",
+		}, {
+			deny:   []string{"."},
+			allow:  []string{"["},
+			errstr: `syntax error: no right brackets: "["`,
+		},
+	}
+	for i, test := range tests {
+		got, err := FilterByPolicy(n, test.allow, test.deny)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+	if got := n.Get("users", 0, "password"); !reflect.DeepEqual(got, ToValue("x")) {
+		t.Errorf("the provided node is modified: %v", got)
+	}
+}
@@ -0,0 +1,48 @@
+package tree
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Project stores the nodes found from n in the fields of the struct pointed
+// to by v. The fields have the query expressions as the "tree" tag, such as
+// `tree:".store.book[0].title"`, and the found nodes are stored via
+// "encoding/json". If the field is a slice and the query finds multiple
+// nodes or a non-array node, the found nodes are stored as an array. The
+// fields that the query finds no nodes are not changed.
+func Project(n Node, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("project: non-nil pointer to struct required: %T", v)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		expr, ok := f.Tag.Lookup("tree")
+		if !ok || expr == "" || expr == "-" || !f.IsExported() {
+			continue
+		}
+		if !strings.HasPrefix(expr, ".") && !strings.HasPrefix(expr, "[") {
+			expr = "." + expr
+		}
+		rs, err := Find(n, expr)
+		if err != nil {
+			return fmt.Errorf("project %s: %w", f.Name, err)
+		}
+		if len(rs) == 0 {
+			continue
+		}
+		found := rs[0]
+		if k := f.Type.Kind(); (k == reflect.Slice || k == reflect.Array) &&
+			(len(rs) > 1 || found == nil || !found.Type().IsArray()) {
+			found = Array(rs)
+		}
+		if err := UnmarshalViaJSON(found, rv.Field(i).Addr().Interface()); err != nil {
+			return fmt.Errorf("project %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	n := Map{
+		"store": Map{
+			"book": Array{
+				Map{"title": ToValue("Sayings of the Century"), "price": ToValue(8.95)},
+				Map{"title": ToValue("Moby Dick"), "price": ToValue(8.99)},
+			},
+			"bicycle": Map{"color": ToValue("red"), "price": ToValue(19.95)},
+		},
+	}
+
+	type bicycle struct {
+		Color string  `json:"color"`
+		Price float64 `json:"price"`
+	}
+	type projection struct {
+		FirstTitle string    `tree:".store.book[0].title"`
+		Titles     []string  `tree:".store.book[].title"`
+		Prices     []float64 `tree:"store.book[0].price"`
+		Bicycle    bicycle   `tree:".store.bicycle"`
+		Books      []Map     `tree:".store.book"`
+		Missing    string    `tree:".store.missing"`
+		Untagged   string
+		Ignored    string `tree:"-"`
+	}
+	got := projection{Missing: "default", Untagged: "x"}
+	if err := Project(n, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := projection{
+		FirstTitle: "Sayings of the Century",
+		Titles:     []string{"Sayings of the Century", "Moby Dick"},
+		Prices:     []float64{8.95},
+		Bicycle:    bicycle{Color: "red", Price: 19.95},
+		Books: []Map{
+			{"title": ToValue("Sayings of the Century"), "price": ToValue(8.95)},
+			{"title": ToValue("Moby Dick"), "price": ToValue(8.99)},
+		},
+		Missing:  "default",
+		Untagged: "x",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v; want %#v", got, want)
+	}
+}
+
+func TestProject_Errors(t *testing.T) {
+	n := Map{"a": ToValue("str")}
+	var s struct {
+		A int `tree:".a"`
+	}
+	var q struct {
+		A string `tree:".a["`
+	}
+	tests := []struct {
+		v      interface{}
+		errstr string
+	}{
+		{
+			v:      s,
+			errstr: "project: non-nil pointer to struct required: struct { A int \"tree:\\\".a\\\"\" }",
+		}, {
+			v:      &s,
+			errstr: "project A: json: cannot unmarshal string into Go value of type int",
+		}, {
+			v:      &q,
+			errstr: `project A: syntax error: no right brackets: ".a["`,
+		},
+	}
+	for i, test := range tests {
+		err := Project(n, test.v)
+		if err == nil || err.Error() != test.errstr {
+			t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+		}
+	}
+}
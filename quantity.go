@@ -0,0 +1,131 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+func init() {
+	RegisterMethod("quantity", quantityMethod)
+	RegisterMethod("duration", durationMethod)
+}
+
+var (
+	quantityRegexp        = regexp.MustCompile(`^([+-]?(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?)(Ki|Mi|Gi|Ti|Pi|Ei|n|u|m|k|M|G|T|P|E)?$`)
+	durationRegexp        = regexp.MustCompile(`^[+-]?(?:\d+(?:\.\d+)?(?:ns|us|µs|ms|s|m|h|d|w))+$`)
+	durationSegmentRegexp = regexp.MustCompile(`(\d+(?:\.\d+)?)(ns|us|µs|ms|s|m|h|d|w)`)
+
+	quantitySuffixes = map[string]float64{
+		"n": 1e-9, "u": 1e-6, "m": 1e-3, "": 1,
+		"k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18,
+		"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40, "Pi": 1 << 50, "Ei": 1 << 60,
+	}
+	durationUnits = map[string]time.Duration{
+		"ns": time.Nanosecond, "us": time.Microsecond, "µs": time.Microsecond,
+		"ms": time.Millisecond, "s": time.Second, "m": time.Minute, "h": time.Hour,
+		"d": 24 * time.Hour, "w": 7 * 24 * time.Hour,
+	}
+)
+
+// ParseQuantity parses the quantity of Kubernetes like "500m", "2Gi" or "1.5k"
+// to the number. The suffixes are the decimal SI suffixes (n, u, m, k, M, G,
+// T, P and E) and the binary suffixes (Ki, Mi, Gi, Ti, Pi and Ei).
+func ParseQuantity(s string) (float64, error) {
+	f, _, err := parseQuantity(s)
+	return f, err
+}
+
+func parseQuantity(s string) (float64, string, error) {
+	m := quantityRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return 0, "", fmt.Errorf("invalid quantity %q", s)
+	}
+	f, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid quantity %q", s)
+	}
+	return f * quantitySuffixes[m[2]], m[2], nil
+}
+
+// ParseDuration parses the duration like "1h30m" or "500ms". In addition to
+// the units of time.ParseDuration, "d" is 24 hours and "w" is 7 days like
+// the durations of monitoring configs.
+func ParseDuration(s string) (time.Duration, error) {
+	if !durationRegexp.MatchString(s) {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d float64
+	for _, m := range durationSegmentRegexp.FindAllStringSubmatch(s, -1) {
+		f, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d += f * float64(durationUnits[m[2]])
+	}
+	if d > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if s[0] == '-' {
+		d = -d
+	}
+	return time.Duration(d), nil
+}
+
+// compareQuantities parses a and b as the quantities or the durations to
+// compare them as numbers. It returns false if they are not the same kind
+// or both are plain numbers those are compared as strings as before.
+func compareQuantities(a, b string) (float64, float64, bool) {
+	fa, ua, erra := parseQuantity(a)
+	fb, ub, errb := parseQuantity(b)
+	if erra == nil && errb == nil {
+		return fa, fb, ua != "" || ub != ""
+	}
+	da, erra := ParseDuration(a)
+	db, errb := ParseDuration(b)
+	if erra == nil && errb == nil {
+		return da.Seconds(), db.Seconds(), true
+	}
+	return 0, 0, false
+}
+
+// quantityMethod is the method "quantity()" that returns the number of the
+// quantity string like "500m" and "2Gi". Numbers are returned as is.
+func quantityMethod(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("quantity", args, 0, 0); err != nil {
+		return nil, err
+	}
+	if n.Type().IsNumberValue() {
+		return []Node{n}, nil
+	}
+	if !n.Type().IsStringValue() {
+		return nil, fmt.Errorf("quantity(): cannot parse %v", n)
+	}
+	f, err := ParseQuantity(n.Value().String())
+	if err != nil {
+		return nil, fmt.Errorf("quantity(): %w", err)
+	}
+	return []Node{NumberValue(f)}, nil
+}
+
+// durationMethod is the method "duration()" that returns the seconds of the
+// duration string like "1h30m". Numbers are returned as is.
+func durationMethod(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("duration", args, 0, 0); err != nil {
+		return nil, err
+	}
+	if n.Type().IsNumberValue() {
+		return []Node{n}, nil
+	}
+	if !n.Type().IsStringValue() {
+		return nil, fmt.Errorf("duration(): cannot parse %v", n)
+	}
+	d, err := ParseDuration(n.Value().String())
+	if err != nil {
+		return nil, fmt.Errorf("duration(): %w", err)
+	}
+	return []Node{NumberValue(d.Seconds())}, nil
+}
@@ -0,0 +1,125 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   float64
+		errstr string
+	}{
+		{s: "1", want: 1},
+		{s: "500m", want: 0.5},
+		{s: "1.5k", want: 1500},
+		{s: "2Gi", want: 2 << 30},
+		{s: "128Mi", want: 128 << 20},
+		{s: "1e3", want: 1000},
+		{s: "-.5", want: -0.5},
+		{s: "1E", want: 1e18},
+		{s: "1GB", errstr: `invalid quantity "1GB"`},
+		{s: "", errstr: `invalid quantity ""`},
+	}
+	for i, test := range tests {
+		got, err := ParseQuantity(test.s)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   time.Duration
+		errstr string
+	}{
+		{s: "1h30m", want: 90 * time.Minute},
+		{s: "500ms", want: 500 * time.Millisecond},
+		{s: "1.5s", want: 1500 * time.Millisecond},
+		{s: "2d", want: 48 * time.Hour},
+		{s: "1w1d", want: 8 * 24 * time.Hour},
+		{s: "-10us", want: -10 * time.Microsecond},
+		{s: "10", errstr: `invalid duration "10"`},
+		{s: "1y", errstr: `invalid duration "1y"`},
+	}
+	for i, test := range tests {
+		got, err := ParseDuration(test.s)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestQuantityMethods(t *testing.T) {
+	n := Map{
+		"containers": Array{
+			Map{"name": ToValue("a"), "memory": ToValue("256Mi"), "timeout": ToValue("30s")},
+			Map{"name": ToValue("b"), "memory": ToValue("2Gi"), "timeout": ToValue("1h")},
+		},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.containers[.memory > "1Gi"].name`,
+			want: ToNodeValues("b"),
+		}, {
+			expr: `.containers[.timeout < "1m"].name`,
+			want: ToNodeValues("a"),
+		}, {
+			expr: `.containers[0].memory.quantity()`,
+			want: ToNodeValues(256 << 20),
+		}, {
+			expr: `.containers[1].timeout.duration()`,
+			want: ToNodeValues(3600),
+		}, {
+			expr: `.containers[.memory.quantity() >= 1073741824].name`,
+			want: ToNodeValues("b"),
+		}, {
+			expr:   `.containers[0].name.quantity()`,
+			errstr: `quantity(): invalid quantity "a"`,
+		}, {
+			expr:   `.containers[0].duration()`,
+			errstr: `duration(): cannot parse map[memory:256Mi name:a timeout:30s]`,
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
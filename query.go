@@ -1,10 +1,17 @@
 package tree
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Query is an interface that defines the methods to query a node.
@@ -67,10 +74,37 @@ func (q ValueQuery) Exec(n Node) ([]Node, error) {
 }
 
 func (q ValueQuery) String() string {
+	if q.Node != nil && q.Type().IsStringValue() {
+		return quoteQueryString(q.Value().String())
+	}
 	s, _ := MarshalJSON(q.Node)
 	return string(s)
 }
 
+// VariableQuery is a query that returns the value of the variable held by
+// the context. See WithVariables.
+type VariableQuery string
+
+var _ ContextQuery = (VariableQuery)("")
+
+// Exec returns an error because no variables are provided without a context.
+func (q VariableQuery) Exec(n Node) ([]Node, error) {
+	return q.ExecContext(context.Background(), n)
+}
+
+// ExecContext returns the value of the variable.
+func (q VariableQuery) ExecContext(ctx context.Context, n Node) ([]Node, error) {
+	v, ok := variablesFrom(ctx)[string(q)]
+	if !ok {
+		return nil, fmt.Errorf("undefined variable %s", q)
+	}
+	return []Node{v}, nil
+}
+
+func (q VariableQuery) String() string {
+	return "$" + string(q)
+}
+
 // MapQuery is a key of the Map that implements methods of the Query.
 type MapQuery string
 
@@ -126,7 +160,7 @@ func (q MapQuery) Delete(pn *Node) error {
 }
 
 func (q MapQuery) String() string {
-	return "." + string(q)
+	return "." + quoteQueryKey(string(q))
 }
 
 // ArrayQuery is an index of the Array that implements methods of the Query.
@@ -138,7 +172,7 @@ func (q ArrayQuery) Exec(n Node) ([]Node, error) {
 	if a := n.Array(); a != nil {
 		index := int(q)
 		if n.Has(index) {
-			return []Node{a[index]}, nil
+			return []Node{OrNil(a[index])}, nil
 		}
 		return nil, nil
 	}
@@ -195,17 +229,31 @@ func (q ArrayRangeQuery) Exec(n Node) ([]Node, error) {
 		return nil, fmt.Errorf("invalid array range %s", q)
 	}
 	if a := n.Array(); a != nil {
-		from, to := q[0], q[1]
-		if from == -1 {
-			return a[:to], nil
-		} else if q[1] == -1 {
-			return a[from:], nil
-		}
+		from, to := q.bounds(len(a))
 		return a[from:to], nil
 	}
 	return nil, fmt.Errorf("cannot index array with range %d:%d", q[0], q[1])
 }
 
+// bounds returns the range of the array of the length l. -1 is the start
+// or the end, and the range is clamped to the array.
+func (q ArrayRangeQuery) bounds(l int) (int, int) {
+	from, to := q[0], q[1]
+	if to == -1 || to > l {
+		to = l
+	}
+	if to < 0 {
+		to = 0
+	}
+	if from < 0 {
+		from = 0
+	}
+	if from > to {
+		from = to
+	}
+	return from, to
+}
+
 func (q ArrayRangeQuery) String() string {
 	ss := make([]string, len(q))
 	for i, r := range q {
@@ -298,15 +346,33 @@ func (q ValuesQuery) String() string {
 type FilterQuery []Query
 
 func (qs FilterQuery) Exec(n Node) ([]Node, error) {
+	return qs.ExecContext(context.Background(), n)
+}
+
+// ExecContext executes the queries in order with ctx.
+func (qs FilterQuery) ExecContext(ctx context.Context, n Node) ([]Node, error) {
+	ctx = withExecPath(ctx, qs)
+	ctx = withExecRoot(ctx, n)
+	trace := traceFuncFrom(ctx)
+	if trace != nil {
+		ctx = WithTraceFunc(ctx, nil)
+	}
+	if p, ok := execPathFrom(ctx); ok {
+		rs, _, err := qs.execWithPaths(ctx, n, p, trace)
+		return rs, err
+	}
 	rs := []Node{n}
 	for _, q := range qs {
 		switch q.(type) {
 		case SlurpQuery:
-			nrs, err := q.Exec(Array(rs))
+			nrs, err := ExecContext(ctx, q, Array(rs))
 			if err != nil {
 				return nil, err
 			}
 			rs = nrs
+			if trace != nil {
+				trace(q, rs)
+			}
 			continue
 		}
 		var nrs []Node
@@ -314,23 +380,29 @@ func (qs FilterQuery) Exec(n Node) ([]Node, error) {
 			if r == nil {
 				continue
 			}
-			nr, err := q.Exec(r)
+			nr, err := ExecContext(ctx, q, r)
 			if err != nil {
 				return nil, err
 			}
 			nrs = append(nrs, nr...)
+			if err := checkMaxResults(ctx, len(nrs)); err != nil {
+				return nil, err
+			}
 		}
 		rs = nrs
+		if trace != nil {
+			trace(q, rs)
+		}
 	}
 	return rs, nil
 }
 
-func (qs FilterQuery) execForEdit(n Node) ([]Node, error) {
+func (qs FilterQuery) execForEdit(ctx context.Context, n Node) ([]Node, error) {
 	rs := []Node{n}
 	for i, q := range qs[:len(qs)-1] {
 		switch q.(type) {
 		case SlurpQuery:
-			nrs, err := q.Exec(Array(rs))
+			nrs, err := ExecContext(ctx, q, Array(rs))
 			if err != nil {
 				return nil, err
 			}
@@ -342,7 +414,7 @@ func (qs FilterQuery) execForEdit(n Node) ([]Node, error) {
 			if r == nil {
 				continue
 			}
-			nr, err := q.Exec(r)
+			nr, err := ExecContext(ctx, q, r)
 			if err != nil {
 				return nil, err
 			}
@@ -352,14 +424,15 @@ func (qs FilterQuery) execForEdit(n Node) ([]Node, error) {
 				case MapQuery:
 					empty = Map{}
 				case ArrayQuery:
-					empty = Array{}
+					// NOTE: The new array is held to be edited by the next query.
+					empty = &arrayHolder{&Array{}}
 				}
 				if empty != nil {
 					if eq, ok := q.(EditorQuery); ok {
 						if err = eq.Set(&r, empty); err != nil {
 							return nil, err
 						}
-						if nr, err = eq.Exec(r); err != nil {
+						if nr, err = ExecContext(ctx, eq, r); err != nil {
 							return nil, err
 						}
 					}
@@ -387,24 +460,39 @@ var _ EditorQuery = (WalkQuery)("")
 
 // Exec walks the specified root node and collects matching nodes using itself as a key.
 func (q WalkQuery) Exec(root Node) ([]Node, error) {
+	return q.ExecContext(context.Background(), root)
+}
+
+// ExecContext walks the specified root node with ctx and collects matching nodes using itself as a key.
+func (q WalkQuery) ExecContext(ctx context.Context, root Node) ([]Node, error) {
 	key := string(q)
 	var r []Node
-	// NOTE: Walk returns no error.
-	Walk(root, func(n Node, keys []interface{}) error {
+	err := WalkContext(ctx, root, func(n Node, keys []interface{}) error {
+		if err := checkMaxDepth(ctx, len(keys)); err != nil {
+			return err
+		}
 		if n == nil {
 			return nil
 		}
 		if n.Has(key) {
 			r = append(r, n.Get(key))
+			return checkMaxResults(ctx, len(r))
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 	return r, nil
 }
 
 func (q WalkQuery) Set(pn *Node, v Node) error {
+	return q.setContext(context.Background(), pn, v)
+}
+
+func (q WalkQuery) setContext(ctx context.Context, pn *Node, v Node) error {
 	key := string(q)
-	return Walk(*pn, func(n Node, keys []interface{}) error {
+	return WalkContext(ctx, *pn, func(n Node, keys []interface{}) error {
 		if n.Has(key) {
 			if en, ok := n.(EditorNode); ok {
 				en.Set(key, v)
@@ -415,8 +503,12 @@ func (q WalkQuery) Set(pn *Node, v Node) error {
 }
 
 func (q WalkQuery) Append(pn *Node, v Node) error {
+	return q.appendContext(context.Background(), pn, v)
+}
+
+func (q WalkQuery) appendContext(ctx context.Context, pn *Node, v Node) error {
 	key := string(q)
-	return Walk(*pn, func(n Node, keys []interface{}) error {
+	return WalkContext(ctx, *pn, func(n Node, keys []interface{}) error {
 		if n.Has(key) {
 			if nv := n.Get(key); nv != nil {
 				if env, ok := nv.(EditorNode); ok {
@@ -429,8 +521,12 @@ func (q WalkQuery) Append(pn *Node, v Node) error {
 }
 
 func (q WalkQuery) Delete(pn *Node) error {
+	return q.deleteContext(context.Background(), pn)
+}
+
+func (q WalkQuery) deleteContext(ctx context.Context, pn *Node) error {
 	key := string(q)
-	return Walk(*pn, func(n Node, keys []interface{}) error {
+	return WalkContext(ctx, *pn, func(n Node, keys []interface{}) error {
 		if n.Has(key) {
 			if en, ok := n.(EditorNode); ok {
 				en.Delete(key)
@@ -441,7 +537,7 @@ func (q WalkQuery) Delete(pn *Node) error {
 }
 
 func (q WalkQuery) String() string {
-	return ".." + string(q)
+	return ".." + quoteQueryKey(string(q))
 }
 
 // Selector checks if a node is eligible for selection.
@@ -455,8 +551,13 @@ type And []Selector
 
 // Matches returns true if all selectors returns true.
 func (a And) Matches(n Node) (bool, error) {
+	return a.MatchesContext(context.Background(), n)
+}
+
+// MatchesContext returns true if all selectors returns true with ctx.
+func (a And) MatchesContext(ctx context.Context, n Node) (bool, error) {
 	for _, s := range a {
-		ok, err := s.Matches(n)
+		ok, err := MatchesContext(ctx, s, n)
 		if err != nil || !ok {
 			return false, err
 		}
@@ -477,8 +578,13 @@ type Or []Selector
 
 // Matches returns true if anyone returns true.
 func (o Or) Matches(n Node) (bool, error) {
+	return o.MatchesContext(context.Background(), n)
+}
+
+// MatchesContext returns true if anyone returns true with ctx.
+func (o Or) MatchesContext(ctx context.Context, n Node) (bool, error) {
 	for _, s := range o {
-		ok, err := s.Matches(n)
+		ok, err := MatchesContext(ctx, s, n)
 		if err != nil {
 			return false, err
 		}
@@ -506,11 +612,16 @@ type Comparator struct {
 
 // Matches evaluates left and right using the operator. (eg. .id == 0)
 func (c Comparator) Matches(n Node) (bool, error) {
-	l, err := c.Left.Exec(n)
+	return c.MatchesContext(context.Background(), n)
+}
+
+// MatchesContext evaluates left and right using the operator with ctx.
+func (c Comparator) MatchesContext(ctx context.Context, n Node) (bool, error) {
+	l, err := ExecContext(ctx, c.Left, n)
 	if err != nil {
 		return false, err
 	}
-	r, err := c.Right.Exec(n)
+	r, err := ExecContext(ctx, c.Right, n)
 	if err != nil {
 		return false, err
 	}
@@ -534,6 +645,19 @@ func (c Comparator) Matches(n Node) (bool, error) {
 	if l0 == nil || r0 == nil {
 		return (l0 == nil && r0 == nil), nil
 	}
+	if fn, ok := lookupOperator(c.Op); ok {
+		return fn(l0.Value(), r0.Value()), nil
+	}
+	if !l0.Type().IsValue() || !r0.Type().IsValue() {
+		// NOTE: Arrays and maps are only compared for equality.
+		switch c.Op {
+		case EQ:
+			return reflect.DeepEqual(l0, r0), nil
+		case NE:
+			return !reflect.DeepEqual(l0, r0), nil
+		}
+		return false, nil
+	}
 	return l0.Value().Compare(c.Op, r0.Value()), nil
 }
 
@@ -547,13 +671,18 @@ type SelectQuery struct {
 }
 
 func (q SelectQuery) Exec(n Node) ([]Node, error) {
+	return q.ExecContext(context.Background(), n)
+}
+
+// ExecContext returns nodes that matched by selectors with ctx.
+func (q SelectQuery) ExecContext(ctx context.Context, n Node) ([]Node, error) {
 	if a := n.Array(); a != nil {
 		if q.Selector == nil {
 			return a, nil
 		}
 		var rs []Node
 		for _, nn := range a {
-			ok, err := q.Selector.Matches(nn)
+			ok, err := MatchesContext(ctx, q.Selector, nn)
 			if err != nil {
 				return nil, err
 			}
@@ -569,7 +698,7 @@ func (q SelectQuery) Exec(n Node) ([]Node, error) {
 		}
 		var rs []Node
 		for _, nn := range m.Values() {
-			ok, err := q.Selector.Matches(nn)
+			ok, err := MatchesContext(ctx, q.Selector, nn)
 			if err != nil {
 				return nil, err
 			}
@@ -583,19 +712,70 @@ func (q SelectQuery) Exec(n Node) ([]Node, error) {
 }
 
 func (q SelectQuery) String() string {
-	return "[" + q.Selector.String() + "]"
+	if q.Selector == nil {
+		return "[]"
+	}
+	return "[" + selectorString(q.Selector) + "]"
+}
+
+// selectorString returns the string of s without the outer parentheses of
+// And and Or, so the string can be parsed as the same selector.
+func selectorString(s Selector) string {
+	s = unwrapSelector(s)
+	switch ts := s.(type) {
+	case And:
+		return joinSelectors(ts, " and ")
+	case Or:
+		return joinSelectors(ts, " or ")
+	}
+	return s.String()
+}
+
+func joinSelectors(ss []Selector, sep string) string {
+	strs := make([]string, len(ss))
+	for i, s := range ss {
+		strs[i] = s.String()
+	}
+	return strings.Join(strs, sep)
+}
+
+// unwrapSelector returns the element of And or Or that has only one
+// selector.
+func unwrapSelector(s Selector) Selector {
+	for {
+		switch ts := s.(type) {
+		case And:
+			if len(ts) != 1 {
+				return s
+			}
+			s = ts[0]
+		case Or:
+			if len(ts) != 1 {
+				return s
+			}
+			s = ts[0]
+		default:
+			return s
+		}
+	}
 }
 
 var (
-	_ Selector = (And)(nil)
-	_ Selector = (Or)(nil)
-	_ Selector = (*Comparator)(nil)
-	_ Selector = (*SelectQuery)(nil)
+	_ ContextSelector = (And)(nil)
+	_ ContextSelector = (Or)(nil)
+	_ ContextSelector = (*Comparator)(nil)
+	_ Selector        = (*SelectQuery)(nil)
+	_ ContextQuery    = (FilterQuery)(nil)
+	_ ContextQuery    = (WalkQuery)("")
+	_ ContextQuery    = (*SelectQuery)(nil)
 )
 
 // ParseQuery parses the provided expr to a Query.
 // See https://github.com/jarxorg/tree#Query
 func ParseQuery(expr string) (Query, error) {
+	if trimmed := strings.TrimSpace(expr); strings.HasPrefix(trimmed, "{") {
+		return parseObjectQuery(trimmed, expr)
+	}
 	token, err := tokenizeQuery(expr)
 	if err != nil {
 		return nil, err
@@ -603,10 +783,42 @@ func ParseQuery(expr string) (Query, error) {
 	return tokenToQuery(token, expr)
 }
 
+// QueryText holds a Query that implements encoding.TextMarshaler and
+// encoding.TextUnmarshaler, so the queries can be the fields of the
+// configurations in JSON or YAML. The text of the query is Query.String
+// that ParseQuery parses to the same query.
+type QueryText struct {
+	Query
+}
+
+// MarshalText returns the string of the query. It returns an empty text if
+// the query is nil.
+func (q QueryText) MarshalText() ([]byte, error) {
+	if q.Query == nil {
+		return []byte{}, nil
+	}
+	return []byte(q.Query.String()), nil
+}
+
+// UnmarshalText parses the text as the query. An empty text is a nil query.
+func (q *QueryText) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		q.Query = nil
+		return nil
+	}
+	query, err := ParseQuery(string(text))
+	if err != nil {
+		return err
+	}
+	q.Query = query
+	return nil
+}
+
 type token struct {
 	cmd      string
 	quoted   bool
 	value    string
+	literal  Node
 	parent   *token
 	children []*token
 }
@@ -616,6 +828,9 @@ func (t *token) toValue() Node {
 		if t.value == "" {
 			return Nil
 		}
+		if t.value == "null" {
+			return Nil
+		}
 		if t.value == "true" {
 			return BoolValue(true)
 		}
@@ -638,48 +853,102 @@ func (t *token) indexOfCmd(cmd string) int {
 	return -1
 }
 
-var tokenRegexp = regexp.MustCompile(`"([^"]*)"|(and|or|==|<=|>=|!=|~=|\.\.|[\.\[\]\(\)\|<>:]|[a-z]+\(\))|(\w+)`)
-
 func tokenizeQuery(expr string) (*token, error) {
+	re := currentTokenRegexp()
 	current := &token{}
-	ms := tokenRegexp.FindAllStringSubmatch(expr, -1)
-	for _, m := range ms {
-		quoted := m[1]
-		cmd := m[2]
-		word := m[3]
-		// NOTE: detect node name
-		if quoted != "" || word != "" {
-			value := quoted
-			if value == "" {
-				value = word
-			}
-			var lastChild *token
-			if len(current.children) > 0 {
-				lastChild = current.children[len(current.children)-1]
-			}
-			if lastChild != nil && (lastChild.cmd == "." || lastChild.cmd == "..") {
-				lastChild.value = value
-				lastChild.quoted = quoted != ""
+	rest := expr
+TOKENIZE:
+	for {
+		prev := 0
+		for _, loc := range re.FindAllStringSubmatchIndex(rest, -1) {
+			if err := checkQueryGap(rest[prev:loc[0]], expr); err != nil {
+				return nil, err
+			}
+			adjacent := loc[0] == prev
+			prev = loc[1]
+			m := make([]string, len(loc)/2)
+			for i := range m {
+				if loc[i*2] >= 0 {
+					m[i] = rest[loc[i*2]:loc[i*2+1]]
+				}
+			}
+			isQuoted := loc[2] >= 0
+			cmd := m[2]
+			word := m[3]
+			// NOTE: detect node name
+			if isQuoted || word != "" {
+				value := word
+				if isQuoted {
+					value = unquoteQueryString(m[1])
+				}
+				var lastChild *token
+				if len(current.children) > 0 {
+					lastChild = current.children[len(current.children)-1]
+				}
+				if adjacent && isEmptyKeyToken(lastChild) {
+					// NOTE: The key like .0.1 is not a number.
+					if i := strings.IndexByte(value, '.'); !isQuoted && i != -1 {
+						lastChild.value = value[:i]
+						rest = rest[loc[6]+i:]
+						continue TOKENIZE
+					}
+					lastChild.value = value
+					lastChild.quoted = isQuoted
+					continue
+				}
+				if !isOperandStart(lastChild) {
+					return nil, fmt.Errorf("syntax error: unexpected %s: %q", m[0], expr)
+				}
+				t := &token{value: value, quoted: isQuoted}
+				current.children = append(current.children, t)
 				continue
 			}
-			t := &token{value: value, quoted: quoted != ""}
-			current.children = append(current.children, t)
-			continue
+			// NOTE: detect keywords
+			if (cmd == "(" || isMethodCmd(cmd)) && len(current.children) > 0 {
+				lastChild := current.children[len(current.children)-1]
+				if !isOperandStart(lastChild) && !isEmptyKeyToken(lastChild) {
+					return nil, fmt.Errorf("syntax error: unexpected %s: %q", cmd, expr)
+				}
+			}
+			t := &token{cmd: cmd, parent: current}
+			switch {
+			case cmd == "]" || cmd == ")":
+				if (cmd == "]" && current.cmd != "[") || (cmd == ")" && !strings.HasSuffix(current.cmd, "(")) {
+					return nil, fmt.Errorf("syntax error: no left bracket: %q", expr)
+				}
+				current = current.parent
+			case cmd == "[" || strings.HasSuffix(cmd, "("):
+				current.children = append(current.children, t)
+				current = t
+			default:
+				current.children = append(current.children, t)
+			}
+			// NOTE: detect array or object literal on the right side of the
+			// operator or as the whole argument of the method
+			isOperand := (current.cmd == "[" || strings.HasSuffix(current.cmd, "(")) && isOperator(Operator(cmd))
+			isArg := len(current.cmd) > 1 && strings.HasSuffix(current.cmd, "(") && (t == current || cmd == ",")
+			if isOperand || isArg {
+				literal, n, err := literalPrefix(rest[loc[1]:], expr)
+				if err != nil && !isArg {
+					return nil, err
+				}
+				// NOTE: The argument like "[0].name" is not a literal.
+				if isArg && literal != nil {
+					if next := strings.TrimLeft(rest[loc[1]+n:], " \t\r\n"); next == "" || (next[0] != ',' && next[0] != ')') {
+						literal = nil
+					}
+				}
+				if literal != nil {
+					current.children = append(current.children, &token{literal: literal, parent: current})
+					rest = rest[loc[1]+n:]
+					continue TOKENIZE
+				}
+			}
 		}
-		// NOTE: detect keywords
-		t := &token{cmd: cmd, parent: current}
-		switch cmd {
-		case "]", ")":
-			if (cmd == "]" && current.cmd != "[") || (cmd == ")" && current.cmd != "(") {
-				return nil, fmt.Errorf("syntax error: no left bracket: %q", expr)
-			}
-			current = current.parent
-		case "[", "(":
-			current.children = append(current.children, t)
-			current = t
-		default:
-			current.children = append(current.children, t)
+		if err := checkQueryGap(rest[prev:], expr); err != nil {
+			return nil, err
 		}
+		break
 	}
 	if current.parent != nil {
 		return nil, fmt.Errorf("syntax error: no right brackets: %q", expr)
@@ -687,36 +956,160 @@ func tokenizeQuery(expr string) (*token, error) {
 	return current, nil
 }
 
+// checkQueryGap returns an error if the string between the tokens is not
+// spaces.
+func checkQueryGap(gap, expr string) error {
+	if trimmed := strings.TrimLeft(gap, " \t\r\n"); trimmed != "" {
+		r, _ := utf8.DecodeRuneInString(trimmed)
+		return fmt.Errorf("syntax error: unexpected %q: %q", r, expr)
+	}
+	return nil
+}
+
+// isEmptyKeyToken reports whether t is "." or ".." that waits for the key.
+func isEmptyKeyToken(t *token) bool {
+	return t != nil && (t.cmd == "." || t.cmd == "..") && t.value == "" && !t.quoted
+}
+
+// isOperandStart reports whether a value or a method can follow t, that is
+// the previous token in the same group.
+func isOperandStart(t *token) bool {
+	if t == nil {
+		return true
+	}
+	switch t.cmd {
+	case ",", ":", "|", "and", "or":
+		return true
+	}
+	return isOperator(Operator(t.cmd))
+}
+
+// plainKeyRegexp matches the keys those are written without quotes.
+var plainKeyRegexp = regexp.MustCompile(`^\w+$`)
+
+// quoteQueryKey quotes the key of MapQuery and WalkQuery if it is needed.
+func quoteQueryKey(key string) string {
+	if plainKeyRegexp.MatchString(key) && key != "and" && key != "or" {
+		return key
+	}
+	return quoteQueryString(key)
+}
+
+// quoteQueryString quotes s to be parsed by ParseQuery. `\` is escaped only
+// before `"`, `\` and the end of s, so the regular expressions like "^\d+$"
+// are written as is.
+func quoteQueryString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			if i+1 == len(s) || s[i+1] == '"' || s[i+1] == '\\' {
+				b.WriteString(`\\`)
+			} else {
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// unquoteQueryString unescapes `\"` and `\\` of the quoted string of the
+// query. The other backslashes are kept as is.
+func unquoteQueryString(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+			b.WriteByte(s[i+1])
+			i++
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// literalPrefix decodes the JSON array or object at the beginning of s
+// and returns it with the length of the consumed string. It returns nil if
+// s does not begin with an array or object.
+func literalPrefix(s, expr string) (Node, int, error) {
+	trimmed := strings.TrimLeft(s, " \t\r\n")
+	if trimmed == "" || (trimmed[0] != '[' && trimmed[0] != '{') {
+		return nil, 0, nil
+	}
+	depth := 0
+	inString := false
+	for i := 0; i < len(trimmed); i++ {
+		c := trimmed[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				literal := trimmed[:i+1]
+				n, err := UnmarshalJSON([]byte(literal))
+				if err != nil {
+					return nil, 0, fmt.Errorf("syntax error: invalid literal %s: %q", literal, expr)
+				}
+				return n, len(s) - len(trimmed) + i + 1, nil
+			}
+		}
+	}
+	return nil, 0, fmt.Errorf("syntax error: no right brackets: %q", expr)
+}
+
 func tokenToQuery(t *token, expr string) (Query, error) {
+	if isMethodCmd(t.cmd) {
+		return tokenToMethodQuery(t, expr)
+	}
+	if strings.HasPrefix(t.cmd, "$") {
+		return VariableQuery(t.cmd[1:]), nil
+	}
 	child := len(t.children)
 	switch t.cmd {
 	case "":
+		if t.literal != nil {
+			return ValueQuery{t.literal}, nil
+		}
 		if child == 0 {
 			return ValueQuery{t.toValue()}, nil
 		}
 	case "|":
 		return SlurpQuery{}, nil
 	case ".":
-		if t.value != "" {
+		if t.value != "" || t.quoted {
 			return MapQuery(t.value), nil
 		}
 		return NopQuery{}, nil
 	case "..":
-		if t.value != "" {
+		if t.value != "" || t.quoted {
 			return WalkQuery(t.value), nil
 		}
 		return NopQuery{}, nil
-	case "count()":
-		return CountQuery{}, nil
-	case "keys()":
-		return KeysQuery{}, nil
-	case "values()":
-		return ValuesQuery{}, nil
 	case "[":
 		if child == 0 {
 			return SelectQuery{}, nil
 		}
-		if child == 1 {
+		if child == 1 && t.children[0].cmd == "" {
 			i, err := strconv.Atoi(t.children[0].value)
 			if err != nil {
 				return nil, fmt.Errorf("syntax error: invalid array index: %q", expr)
@@ -800,16 +1193,23 @@ func tokensToSelector(ts []*token, expr string) (Selector, error) {
 				if err != nil {
 					return nil, err
 				}
-				ss = append(ss, sss)
+				ss = append(ss, unwrapSelector(sss))
 				break
 			}
-			switch Operator(t.cmd) {
-			case EQ, GT, GE, LT, LE, NE, RE:
+			if isOperator(Operator(t.cmd)) {
 				op = i
 				break GROUP
 			}
 		}
 		if op == -1 {
+			if len(group) == 0 || group[0].cmd == "(" {
+				continue
+			}
+			q, err := tokenToQuery(&token{children: group}, expr)
+			if err != nil {
+				return nil, err
+			}
+			ss = append(ss, TruthySelector{q})
 			continue
 		}
 		left, err := tokenToQuery(&token{children: group[0:op]}, expr)
@@ -825,11 +1225,23 @@ func tokensToSelector(ts []*token, expr string) (Selector, error) {
 	if andOr == "or" {
 		return Or(ss), nil
 	}
+	if len(ss) == 1 {
+		// NOTE: The selector in the parentheses like [(.a or .b)].
+		switch ss[0].(type) {
+		case And, Or:
+			return ss[0], nil
+		}
+	}
 	return And(ss), nil
 }
 
 // Find finds a node from n using the Query.
 func Find(n Node, expr string) ([]Node, error) {
+	return FindContext(context.Background(), n, expr)
+}
+
+// FindContext finds a node from n using the Query with ctx.
+func FindContext(ctx context.Context, n Node, expr string) ([]Node, error) {
 	if n.IsNil() {
 		return nil, nil
 	}
@@ -837,7 +1249,7 @@ func Find(n Node, expr string) ([]Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	return q.Exec(n)
+	return ExecContext(ctx, q, n)
 }
 
 type arrayHolder struct{ a *Array }
@@ -857,8 +1269,24 @@ func (h *arrayHolder) Set(key interface{}, v Node) error                 { retur
 
 var _ EditorNode = (*arrayHolder)(nil)
 
+// anyHolder holds an Any value to be edited as an EditorNode.
+type anyHolder struct{ *Any }
+
 func holdArray(pn *Node) *Node {
 	n := *pn
+	switch tn := n.(type) {
+	case Any:
+		if tn.Node != nil {
+			holdArray(&tn.Node)
+		}
+		*pn = anyHolder{&tn}
+		return pn
+	case *Any:
+		if tn != nil && tn.Node != nil {
+			holdArray(&tn.Node)
+		}
+		return pn
+	}
 	if a := n.Array(); a != nil {
 		ah := &arrayHolder{&a}
 		*pn = ah
@@ -881,6 +1309,19 @@ func holdArray(pn *Node) *Node {
 
 func unholdArray(pn *Node) {
 	n := *pn
+	switch tn := n.(type) {
+	case anyHolder:
+		if tn.Node != nil {
+			unholdArray(&tn.Node)
+		}
+		*pn = *tn.Any
+		return
+	case *Any:
+		if tn != nil && tn.Node != nil {
+			unholdArray(&tn.Node)
+		}
+		return
+	}
 	if a := n.Array(); a != nil {
 		if ah, ok := n.(*arrayHolder); ok {
 			a = *ah.a
@@ -902,22 +1343,112 @@ func unholdArray(pn *Node) {
 	}
 }
 
-var editRegexp = regexp.MustCompile(`^([^\+]+) ?((=|\+=) ?(.+)|(\^\?))$`)
+// editOperator is an operator of the edit expressions.
+type editOperator struct {
+	op       string
+	hasValue bool
+	// alias is the operator that this operator is an alias of.
+	alias string
+	// value is the value of the alias that takes no value.
+	value string
+}
+
+// editOperators are the operators of the edit expressions. The longer
+// operators are matched first, so "+=" is not matched as "=".
+var editOperators = []editOperator{
+	{op: "+=", hasValue: true}, // Appends the value to the array.
+	{op: "-=", hasValue: true}, // Removes the elements equal to the value from the array.
+	{op: "?=", hasValue: true}, // Sets the value if the node is missing or null.
+	{op: "^?"},                 // Deletes the node.
+	{op: "=", hasValue: true},  // Sets the value.
+	{op: "delete", alias: "^?"},
+	{op: "ensure", alias: "?=", value: "{}"},
+}
+
+// isWordOperator returns true if op is a word like "delete" that must be
+// separated from the query by spaces.
+func isWordOperator(op string) bool {
+	return op[0] >= 'a' && op[0] <= 'z'
+}
+
+// parseEdit splits the edit expression at the first operator outside the
+// quoted strings and the brackets into the query, the operator and the value.
+// The spaces around the operator are optional like ".a^?".
+func parseEdit(expr string) (string, string, string, error) {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		if quoted {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				quoted = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			quoted = true
+			continue
+		case '[', '(':
+			depth++
+			continue
+		case ']', ')':
+			depth--
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+		for _, eo := range editOperators {
+			if !strings.HasPrefix(expr[i:], eo.op) {
+				continue
+			}
+			if isWordOperator(eo.op) && (i == 0 || expr[i-1] != ' ' || strings.TrimSpace(expr[i+len(eo.op):]) != "") {
+				continue
+			}
+			left := strings.TrimSpace(expr[:i])
+			right := strings.TrimSpace(expr[i+len(eo.op):])
+			// NOTE: "==" is the comparison operator of the selectors.
+			if left == "" || (right != "") != eo.hasValue || strings.HasPrefix(right, "=") {
+				return "", "", "", fmt.Errorf("syntax error: invalid edit expression %q", expr)
+			}
+			if eo.alias != "" {
+				if eo.value != "" {
+					right = eo.value
+				}
+				return left, eo.alias, right, nil
+			}
+			return left, eo.op, right, nil
+		}
+	}
+	return "", "", "", fmt.Errorf("syntax error: invalid edit expression %q", expr)
+}
 
+// Edit edits the node pointed to by pn using the edit expression.
 func Edit(pn *Node, expr string) error {
-	ms := editRegexp.FindStringSubmatch(expr)
-	if len(ms) != 6 {
-		return fmt.Errorf("syntax error: invalid edit expression %q, %v", expr, ms)
-	}
-	left, op, right := ms[1], ms[3], ms[4]
-	if op == "" {
-		op = ms[5]
+	return EditContext(context.Background(), pn, expr)
+}
+
+// EditContext edits the node pointed to by pn using the edit expression with ctx.
+func EditContext(ctx context.Context, pn *Node, expr string) error {
+	left, op, right, err := parseEdit(expr)
+	if err != nil {
+		return err
 	}
 
 	var v Node
-	if right != "" {
+	if strings.HasPrefix(right, "$") {
+		var err error
+		v, err = execEditVariable(ctx, *pn, right)
+		if err != nil {
+			return err
+		}
+	} else if right != "" {
 		var err error
-		v, err = UnmarshalJSON([]byte(right))
+		v, err = unmarshalEditValue(right, expr)
 		if err != nil {
 			return err
 		}
@@ -930,29 +1461,114 @@ func Edit(pn *Node, expr string) error {
 	holdArray(pn)
 	defer unholdArray(pn)
 
-	return editQuery(pn, q, op, v)
+	return editQuery(ctx, pn, q, op, v)
 }
 
-func editQuery(pn *Node, q Query, op string, v Node) error {
+// SetPath sets v to the node pointed to by the query expression such as
+// .a.b[0]. The intermediate maps and arrays are created if they do not exist,
+// and *pn is replaced by a new map or array if it is nil.
+func SetPath(pn *Node, expr string, v Node) error {
+	q, err := ParseQuery(expr)
+	if err != nil {
+		return err
+	}
+	if *pn == nil || (*pn).IsNil() {
+		first := q
+		if fq, ok := q.(FilterQuery); ok && len(fq) > 0 {
+			first = fq[0]
+			if _, ok := first.(NopQuery); ok && len(fq) > 1 {
+				first = fq[1]
+			}
+		}
+		switch first.(type) {
+		case MapQuery:
+			*pn = Map{}
+		case ArrayQuery:
+			*pn = Array{}
+		}
+	}
+
+	holdArray(pn)
+	defer unholdArray(pn)
+
+	return editQuery(context.Background(), pn, q, "=", v)
+}
+
+// unmarshalEditValue decodes the JSON value on the right side of the edit
+// expression. The characters after the value are an error.
+func unmarshalEditValue(right, expr string) (Node, error) {
+	dec := json.NewDecoder(strings.NewReader(right))
+	v, err := DecodeJSON(dec)
+	if err == io.EOF {
+		err = errors.New("no value")
+		if strings.TrimSpace(right) != "" {
+			err = io.ErrUnexpectedEOF
+		}
+	} else if err == nil {
+		if _, terr := dec.Token(); terr != io.EOF {
+			err = errors.New("invalid characters after the value")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("syntax error: invalid edit value: %v: %q", err, expr)
+	}
+	return v, nil
+}
+
+// execEditVariable executes the right side of the edit expression that
+// starts with a variable (eg. $name.key) and returns the copy of the single
+// result.
+func execEditVariable(ctx context.Context, n Node, right string) (Node, error) {
+	q, err := ParseQuery(right)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := ExecContext(ctx, q, n)
+	if err != nil {
+		return nil, err
+	}
+	switch len(rs) {
+	case 0:
+		return Nil, nil
+	case 1:
+		// NOTE: The value is copied so that the following edits of the
+		// document do not change the variable.
+		return CloneDeep(rs[0]), nil
+	}
+	return nil, fmt.Errorf("%q returns no single value %+v", right, rs)
+}
+
+func editQuery(ctx context.Context, pn *Node, q Query, op string, v Node) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	switch tq := q.(type) {
 	case FilterQuery:
-		return execForEdit(pn, tq, op, v)
+		return execForEdit(ctx, pn, tq, op, v)
+	case SelectQuery:
+		if op == "^?" {
+			return deleteSelected(ctx, pn, tq)
+		}
 	case EditorQuery:
-		return execEdit(pn, tq, op, v)
+		return execEdit(ctx, pn, tq, op, v)
 	}
 	return fmt.Errorf("syntax error: unsupported edit query: %s", q)
 }
 
-func execForEdit(pn *Node, fq FilterQuery, op string, v Node) error {
+func execForEdit(ctx context.Context, pn *Node, fq FilterQuery, op string, v Node) error {
 	l := len(fq)
 	if l == 0 {
 		return nil
 	}
 
+	if i := embeddedIndex(fq); i != -1 {
+		return execForEditEmbedded(ctx, pn, fq, i, op, v)
+	}
+
 	nn := []Node{*pn}
 	if l > 1 {
 		var err error
-		nn, err = fq.execForEdit(*pn)
+		nn, err = fq.execForEdit(ctx, *pn)
 		if err != nil {
 			return err
 		}
@@ -960,21 +1576,122 @@ func execForEdit(pn *Node, fq FilterQuery, op string, v Node) error {
 
 	q := fq[l-1]
 	for _, n := range nn {
-		if err := editQuery(&n, q, op, v); err != nil {
+		if err := editQuery(ctx, &n, q, op, v); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func execEdit(pn *Node, eq EditorQuery, op string, v Node) error {
+// contextEditorQuery is implemented by the queries that walk nodes to edit.
+type contextEditorQuery interface {
+	setContext(ctx context.Context, pn *Node, v Node) error
+	appendContext(ctx context.Context, pn *Node, v Node) error
+	deleteContext(ctx context.Context, pn *Node) error
+}
+
+var _ contextEditorQuery = (WalkQuery)("")
+
+func execEdit(ctx context.Context, pn *Node, eq EditorQuery, op string, v Node) error {
+	if op == "?=" {
+		rs, err := ExecContext(ctx, eq, *pn)
+		if err != nil {
+			return err
+		}
+		for _, r := range rs {
+			if r != nil && !r.IsNil() {
+				return nil
+			}
+		}
+		op = "="
+	}
+	if ceq, ok := eq.(contextEditorQuery); ok {
+		switch op {
+		case "=":
+			return ceq.setContext(ctx, pn, v)
+		case "+=":
+			return ceq.appendContext(ctx, pn, v)
+		case "^?":
+			return ceq.deleteContext(ctx, pn)
+		}
+	}
 	switch op {
 	case "=":
 		return eq.Set(pn, v)
 	case "+=":
 		return eq.Append(pn, v)
+	case "-=":
+		return removeEditValue(ctx, pn, eq, v)
 	case "^?":
 		return eq.Delete(pn)
 	}
 	return fmt.Errorf("syntax error: unsupported edit operation %q", op)
 }
+
+// deleteSelected deletes the elements of the array or the values of the map
+// those are matched by the selector of q.
+func deleteSelected(ctx context.Context, pn *Node, q SelectQuery) error {
+	matches := func(n Node) (bool, error) {
+		if q.Selector == nil {
+			return true, nil
+		}
+		if n == nil {
+			return false, nil
+		}
+		return MatchesContext(ctx, q.Selector, n)
+	}
+	if h, ok := (*pn).(*arrayHolder); ok {
+		a := Array{}
+		for _, e := range *h.a {
+			ok, err := matches(e)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				a = append(a, e)
+			}
+		}
+		*h.a = a
+		return nil
+	}
+	if m := (*pn).Map(); m != nil {
+		for _, key := range m.Keys() {
+			ok, err := matches(m[key])
+			if err != nil {
+				return err
+			}
+			if ok {
+				delete(m, key)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot delete %s", q)
+}
+
+// removeEditValue removes the elements equal to v from the arrays found by
+// eq. The elements are compared as canonical JSON.
+func removeEditValue(ctx context.Context, pn *Node, eq EditorQuery, v Node) error {
+	rs, err := ExecContext(ctx, eq, *pn)
+	if err != nil {
+		return err
+	}
+	want, err := MarshalCanonicalJSON(v)
+	if err != nil {
+		return err
+	}
+	for _, r := range rs {
+		h, ok := r.(*arrayHolder)
+		if !ok {
+			return fmt.Errorf("cannot remove from %s", eq)
+		}
+		a := Array{}
+		for _, e := range *h.a {
+			if got, err := MarshalCanonicalJSON(e); err != nil || !bytes.Equal(got, want) {
+				a = append(a, e)
+			}
+		}
+		*h.a = a
+	}
+	return nil
+}
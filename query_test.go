@@ -1,8 +1,14 @@
 package tree
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_Query(t *testing.T) {
@@ -40,6 +46,22 @@ func Test_Query(t *testing.T) {
 			q:    ArrayRangeQuery{1, -1},
 			n:    Array{ToValue(0), ToValue(1), ToValue(2)},
 			want: []Node{ToValue(1), ToValue(2)},
+		}, {
+			q:    ArrayRangeQuery{-1, -1},
+			n:    Array{ToValue(0), ToValue(1)},
+			want: []Node{ToValue(0), ToValue(1)},
+		}, {
+			q:    ArrayRangeQuery{1, 10},
+			n:    Array{ToValue(0), ToValue(1)},
+			want: []Node{ToValue(1)},
+		}, {
+			q:    ArrayRangeQuery{5, -1},
+			n:    Array{ToValue(0), ToValue(1)},
+			want: []Node{},
+		}, {
+			q:    ArrayRangeQuery{2, 1},
+			n:    Array{ToValue(0), ToValue(1), ToValue(2)},
+			want: []Node{},
 		}, {
 			q:      ArrayRangeQuery{0, 1, 2},
 			n:      Array{},
@@ -167,7 +189,20 @@ func Test_Query_String(t *testing.T) {
 					Comparator{MapQuery("key1"), LE, ValueQuery{ToValue(1)}},
 				},
 			},
-			want: `[((.key2 == "a" or .key2 == "b") and .key1 <= 1)]`,
+			want: `[(.key2 == "a" or .key2 == "b") and .key1 <= 1]`,
+		}, {
+			q:    SelectQuery{And{Comparator{MapQuery("tags"), EQ, ValueQuery{ToArrayValues("a", "b")}}}},
+			want: `[.tags == ["a","b"]]`,
+		}, {
+			q:    FilterQuery{MapQuery("a b"), MapQuery("and"), MapQuery(""), MapQuery("0.1"), WalkQuery("x-y")},
+			want: `."a b"."and".""."0.1".."x-y"`,
+		}, {
+			q: SelectQuery{Or{
+				Comparator{MapQuery("a"), RE, ValueQuery{ToValue(`^\d+"\`)}},
+				Comparator{MapQuery("b"), EQ, ValueQuery{Nil}},
+				TruthySelector{MapQuery("c")},
+			}},
+			want: `[.a ~= "^\d+\"\\" or .b == null or .c]`,
 		}, {
 			q:    WalkQuery("key"),
 			want: "..key",
@@ -241,6 +276,28 @@ func Test_ParseQuery(t *testing.T) {
 				},
 				MapQuery("title"),
 			},
+		}, {
+			expr: `[.a==1 and(.b==2 or(.c))]`,
+			want: SelectQuery{
+				And{
+					Comparator{MapQuery("a"), EQ, ValueQuery{NumberValue(1)}},
+					Or{
+						Comparator{MapQuery("b"), EQ, ValueQuery{NumberValue(2)}},
+						TruthySelector{MapQuery("c")},
+					},
+				},
+			},
+		}, {
+			expr: `.select(.a==1 or(.b==2))`,
+			want: FilterQuery{
+				NopQuery{},
+				MethodQuery{Name: "select", Args: []Query{
+					MatchQuery{Or{
+						Comparator{MapQuery("a"), EQ, ValueQuery{NumberValue(1)}},
+						Comparator{MapQuery("b"), EQ, ValueQuery{NumberValue(2)}},
+					}},
+				}},
+			},
 		}, {
 			expr: `.store.book[.authors[0] == "Nigel Rees"]`,
 			want: FilterQuery{
@@ -252,6 +309,27 @@ func Test_ParseQuery(t *testing.T) {
 					},
 				},
 			},
+		}, {
+			expr: `.users[.id == $user.id]`,
+			want: FilterQuery{
+				MapQuery("users"),
+				SelectQuery{
+					And{
+						Comparator{MapQuery("id"), EQ, FilterQuery{VariableQuery("user"), MapQuery("id")}},
+					},
+				},
+			},
+		}, {
+			expr: `.orders[.android == 1 or .origin == 2]`,
+			want: FilterQuery{
+				MapQuery("orders"),
+				SelectQuery{
+					Or{
+						Comparator{MapQuery("android"), EQ, ValueQuery{NumberValue(1)}},
+						Comparator{MapQuery("origin"), EQ, ValueQuery{NumberValue(2)}},
+					},
+				},
+			},
 		}, {
 			expr: `.store.book[].author|[0]`,
 			want: FilterQuery{
@@ -262,6 +340,56 @@ func Test_ParseQuery(t *testing.T) {
 				SlurpQuery{},
 				ArrayQuery(0),
 			},
+		}, {
+			expr: `.setpath(["a", 0], {"b": 1})`,
+			want: FilterQuery{
+				NopQuery{},
+				MethodQuery{Name: "setpath", Args: []Query{
+					ValueQuery{ToArrayValues("a", 0)},
+					ValueQuery{Map{"b": ToValue(1)}},
+				}},
+			},
+		}, {
+			expr: `.a[.x < -1.5 or .x == null or .x == "" or .y]`,
+			want: FilterQuery{
+				MapQuery("a"),
+				SelectQuery{
+					Or{
+						Comparator{MapQuery("x"), LT, ValueQuery{NumberValue(-1.5)}},
+						Comparator{MapQuery("x"), EQ, ValueQuery{Nil}},
+						Comparator{MapQuery("x"), EQ, ValueQuery{StringValue("")}},
+						TruthySelector{MapQuery("y")},
+					},
+				},
+			},
+		}, {
+			expr: `[(.a == 1 or .b == 2)]`,
+			want: SelectQuery{
+				Or{
+					Comparator{MapQuery("a"), EQ, ValueQuery{NumberValue(1)}},
+					Comparator{MapQuery("b"), EQ, ValueQuery{NumberValue(2)}},
+				},
+			},
+		}, {
+			expr: `."a \"b\"".""[.c ~= "^\d+$"]`,
+			want: FilterQuery{
+				MapQuery(`a "b"`),
+				MapQuery(""),
+				SelectQuery{
+					And{Comparator{MapQuery("c"), RE, ValueQuery{StringValue(`^\d+$`)}}},
+				},
+			},
+		}, {
+			expr: `.0.1[-1]`,
+			want: FilterQuery{MapQuery("0"), MapQuery("1"), ArrayQuery(-1)},
+		}, {
+			expr: `.index_by([0].x)`,
+			want: FilterQuery{
+				NopQuery{},
+				MethodQuery{Name: "index_by", Args: []Query{
+					FilterQuery{ArrayQuery(0), MapQuery("x")},
+				}},
+			},
 		},
 	}
 
@@ -276,6 +404,117 @@ func Test_ParseQuery(t *testing.T) {
 	}
 }
 
+func Test_ParseQuery_RoundTrip(t *testing.T) {
+	keys := []string{"a", "b_1", "0", "1e5", "a b", "and", "or", "", "x-y", "0.1", `q"`, `\`, "日本"}
+	values := []string{`1`, `-2.5`, `1e+21`, `true`, `false`, `null`, `""`, `"x y"`, `"^\d+$"`, `"\""`, `"a\\"`, `[1,"a"]`, `{"k":[null]}`}
+	ops := []string{"==", "!=", "<", "<=", ">", ">=", "~="}
+	methods := []string{"count()", "keys()", "values()", "tostring()", "numbers()", `format("%s", .a)`, `select(.a == 1 or .b)`, `walk(.numbers().tostring())`}
+
+	r := rand.New(rand.NewSource(1))
+	pick := func(ss []string) string {
+		return ss[r.Intn(len(ss))]
+	}
+	var path, selector func(depth int) string
+	path = func(depth int) string {
+		var b strings.Builder
+		n := 1 + r.Intn(3)
+		for i := 0; i < n; i++ {
+			switch r.Intn(7) {
+			case 0:
+				fmt.Fprintf(&b, "[%d]", r.Intn(5)-1)
+			case 1:
+				fmt.Fprintf(&b, "[%d:%d]", r.Intn(2), 2+r.Intn(2))
+			case 2:
+				b.WriteString(".." + quoteQueryKey(pick(keys)))
+			case 3:
+				b.WriteString("." + pick(methods))
+			case 4:
+				if depth > 0 {
+					fmt.Fprintf(&b, "[%s]", selector(depth-1))
+					continue
+				}
+				b.WriteString("[]")
+			default:
+				b.WriteString("." + quoteQueryKey(pick(keys)))
+			}
+		}
+		return b.String()
+	}
+	selector = func(depth int) string {
+		n := 1 + r.Intn(3)
+		andOr := pick([]string{" and ", " or "})
+		ss := make([]string, n)
+		for i := range ss {
+			switch r.Intn(4) {
+			case 0:
+				ss[i] = "." + quoteQueryKey(pick(keys)) + path(depth)
+			case 1:
+				if depth > 0 {
+					ss[i] = "(" + selector(depth-1) + ")"
+					continue
+				}
+				fallthrough
+			default:
+				ss[i] = "." + quoteQueryKey(pick(keys)) + path(depth) + " " + pick(ops) + " " + pick(values)
+			}
+		}
+		return strings.Join(ss, andOr)
+	}
+
+	for i := 0; i < 1000; i++ {
+		expr := path(2)
+		if r.Intn(4) == 0 {
+			expr += " | " + path(1)
+		}
+		q, err := ParseQuery(expr)
+		if err != nil {
+			t.Fatalf("tests[%d] %s: %v", i, expr, err)
+		}
+		s := q.String()
+		got, err := ParseQuery(s)
+		if err != nil {
+			t.Fatalf("tests[%d] %s: %s: %v", i, expr, s, err)
+		}
+		if !reflect.DeepEqual(got, q) {
+			t.Errorf("tests[%d] %s: got %#v; want %#v", i, expr, got, q)
+		}
+		if got := got.String(); got != s {
+			t.Errorf("tests[%d] %s: got %s; want %s", i, expr, got, s)
+		}
+	}
+}
+
+func Test_QueryText(t *testing.T) {
+	var v struct {
+		Q QueryText `json:"q"`
+		E QueryText `json:"e"`
+	}
+	if err := json.Unmarshal([]byte(`{"q": ".a[.b == \"x\"].c", "e": ""}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	want := FilterQuery{
+		MapQuery("a"),
+		SelectQuery{And{Comparator{MapQuery("b"), EQ, ValueQuery{StringValue("x")}}}},
+		MapQuery("c"),
+	}
+	if !reflect.DeepEqual(v.Q.Query, want) {
+		t.Errorf("got %#v; want %#v", v.Q.Query, want)
+	}
+	if v.E.Query != nil {
+		t.Errorf("got %#v; want nil", v.E.Query)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `{"q":".a[.b == \"x\"].c","e":""}`; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+	if err := json.Unmarshal([]byte(`{"q": "["}`), &v); err == nil {
+		t.Errorf("no error")
+	}
+}
+
 func Test_ParseQuery_Errors(t *testing.T) {
 	tests := []struct {
 		expr   string
@@ -304,10 +543,34 @@ func Test_ParseQuery_Errors(t *testing.T) {
 			errstr: `syntax error: invalid array index: "[[l] == .r]"`,
 		}, {
 			expr:   `[.l == [r]]`,
-			errstr: `syntax error: invalid array index: "[.l == [r]]"`,
+			errstr: `syntax error: invalid literal [r]: "[.l == [r]]"`,
+		}, {
+			expr:   `[.l == {"a": 1]`,
+			errstr: `syntax error: invalid literal {"a": 1]: "[.l == {\"a\": 1]"`,
+		}, {
+			expr:   `[.l == ["a"`,
+			errstr: `syntax error: no right brackets: "[.l == [\"a\""`,
 		}, {
 			expr:   `.a[a]`,
 			errstr: `syntax error: invalid array index: ".a[a]"`,
+		}, {
+			expr:   `.a # b`,
+			errstr: `syntax error: unexpected '#': ".a # b"`,
+		}, {
+			expr:   `.a{`,
+			errstr: `syntax error: unexpected '{': ".a{"`,
+		}, {
+			expr:   `.日本`,
+			errstr: `syntax error: unexpected '日': ".日本"`,
+		}, {
+			expr:   `.a 1`,
+			errstr: `syntax error: unexpected 1: ".a 1"`,
+		}, {
+			expr:   `.a select(.b)`,
+			errstr: `syntax error: unexpected select(: ".a select(.b)"`,
+		}, {
+			expr:   `0("")`,
+			errstr: `syntax error: unexpected (: "0(\"\")"`,
 		},
 	}
 	for i, test := range tests {
@@ -473,6 +736,21 @@ func Test_Find(t *testing.T) {
 		}, {
 			expr: `.store.book[0].values()`,
 			want: []Node{ToArrayValues("Nigel Rees", ToArrayValues("Nigel Rees"), "reference", 8.95, "Sayings of the Century")},
+		}, {
+			expr: `.store.book[.authors == ["Nigel Rees"]].title`,
+			want: []Node{StringValue("Sayings of the Century")},
+		}, {
+			expr: `.store.book[.authors != ["X"]].title`,
+			want: []Node{StringValue("Sayings of the Century")},
+		}, {
+			expr: `.store[.color == "red"]`,
+			want: []Node{n.Get("store").Get("bicycle")},
+		}, {
+			expr: `.store[. == {"color": "red", "price": 19.95}].price`,
+			want: []Node{NumberValue(19.95)},
+		}, {
+			expr: `.store.book[.authors > ["A"]].title`,
+			want: nil,
 		},
 	}
 	for i, test := range tests {
@@ -543,6 +821,10 @@ func Test_Edit(t *testing.T) {
 			n:    Map{},
 			expr: `.store.book = {}`,
 			want: Map{"store": Map{"book": Map{}}},
+		}, {
+			n:    Map{},
+			expr: `.store.book[1].title = "x"`,
+			want: Map{"store": Map{"book": Array{Nil, Map{"title": StringValue("x")}}}},
 		}, {
 			n:    Map{},
 			expr: `.store.pen = [{"color":"red"},{"color":"blue"}]`,
@@ -581,11 +863,19 @@ func Test_Edit(t *testing.T) {
 		}, {
 			n:    Array{},
 			expr: `[0][1] = "red"`,
-			want: Array{Array{nil, StringValue("red")}},
+			want: Array{Array{Nil, StringValue("red")}},
 		}, {
 			n:      StringValue("str"),
 			expr:   `[0] = "red"`,
 			errstr: `cannot index array with 0`,
+		}, {
+			n:      Map{},
+			expr:   `.a[10000000000] = 1`,
+			errstr: `cannot index array with 10000000000`,
+		}, {
+			n:      Array{},
+			expr:   `[10000000000] = 1`,
+			errstr: `cannot index array with 10000000000`,
 		}, {
 			n:    Array{},
 			expr: `.0 = "red"`,
@@ -617,7 +907,7 @@ func Test_Edit(t *testing.T) {
 		}, {
 			n:    Array{Array{StringValue("red")}},
 			expr: `[2] += "blue"`,
-			want: Array{Array{StringValue("red")}, nil, Array{StringValue("blue")}},
+			want: Array{Array{StringValue("red")}, Nil, Array{StringValue("blue")}},
 		}, {
 			n:      Array{StringValue("red")},
 			expr:   `[0] += "blue"`,
@@ -650,6 +940,110 @@ func Test_Edit(t *testing.T) {
 			n:      Map{},
 			expr:   `. ^?`,
 			errstr: "cannot delete .",
+		}, {
+			n:      Map{},
+			expr:   `.a = 1 2`,
+			errstr: `syntax error: invalid edit value: invalid characters after the value: ".a = 1 2"`,
+		}, {
+			n:      Map{},
+			expr:   `.a = {`,
+			errstr: `syntax error: invalid edit value: unexpected EOF: ".a = {"`,
+		}, {
+			n:      Map{},
+			expr:   `.a == 1`,
+			errstr: `syntax error: invalid edit expression ".a == 1"`,
+		}, {
+			n:      Map{},
+			expr:   `.a ^? 1`,
+			errstr: `syntax error: invalid edit expression ".a ^? 1"`,
+		}, {
+			n:      Map{},
+			expr:   `= 1`,
+			errstr: `syntax error: invalid edit expression "= 1"`,
+		}, {
+			n:    Map{},
+			expr: `.a = "x=y"`,
+			want: Map{"a": StringValue("x=y")},
+		}, {
+			n:      Map{"a+b": ToValue(1)},
+			expr:   `."a+b" += 2`,
+			errstr: `cannot append to "a+b"`,
+		}, {
+			n:    Map{"colors": ToArrayValues("red", "blue", "red")},
+			expr: `.colors -= "red"`,
+			want: Map{"colors": ToArrayValues("blue")},
+		}, {
+			n:    Map{"colors": ToArrayValues("red")},
+			expr: `.colors-="blue"`, // NOTE: trim spaces
+			want: Map{"colors": ToArrayValues("red")},
+		}, {
+			n:    Array{ToArrayValues(1, 2), ToArrayValues(1), Map{"a": ToValue(1)}},
+			expr: `. -= [1, 2]`,
+			want: Array{ToArrayValues(1), Map{"a": ToValue(1)}},
+		}, {
+			n:    Map{"users": Array{Map{"tags": ToArrayValues("a", "b")}, Map{"tags": ToArrayValues("b")}}},
+			expr: `..tags -= "b"`,
+			want: Map{"users": Array{Map{"tags": ToArrayValues("a")}, Map{"tags": Array{}}}},
+		}, {
+			n:    Map{"users": Array{Map{"id": ToValue(1), "active": ToValue(false)}, Map{"id": ToValue(2), "active": ToValue(true)}}},
+			expr: `.users[.active == false] ^?`,
+			want: Map{"users": Array{Map{"id": ToValue(2), "active": ToValue(true)}}},
+		}, {
+			n:    Map{"users": Array{Map{"id": ToValue(1), "active": ToValue(false)}, Map{"id": ToValue(2), "active": ToValue(true)}}},
+			expr: `.users[.active == false] delete`,
+			want: Map{"users": Array{Map{"id": ToValue(2), "active": ToValue(true)}}},
+		}, {
+			n:    Map{"users": Map{"one": Map{"active": ToValue(false)}, "two": Map{"active": ToValue(true)}}},
+			expr: `..users[.active == false] delete`,
+			want: Map{"users": Map{"two": Map{"active": ToValue(true)}}},
+		}, {
+			n:    Map{"tags": ToArrayValues("a", "b")},
+			expr: `.tags[] delete`,
+			want: Map{"tags": Array{}},
+		}, {
+			n:    Map{"deleted": ToValue(false), "tags": ToArrayValues("a")},
+			expr: `.deleted delete`,
+			want: Map{"tags": ToArrayValues("a")},
+		}, {
+			n:      Map{},
+			expr:   `.tags.delete`,
+			errstr: `syntax error: invalid edit expression ".tags.delete"`,
+		}, {
+			n:      Map{"users": ToValue("x")},
+			expr:   `.users[.active] ^?`,
+			errstr: `cannot delete [.active]`,
+		}, {
+			n:    Map{"a": ToValue(1), "b": Nil},
+			expr: `.a ?= 2`,
+			want: Map{"a": ToValue(1), "b": Nil},
+		}, {
+			n:    Map{"a": ToValue(1), "b": Nil},
+			expr: `.b ?= 2`,
+			want: Map{"a": ToValue(1), "b": ToValue(2)},
+		}, {
+			n:    Map{"a": Map{"x": ToValue(1)}},
+			expr: `.a.b.c?=3`, // NOTE: trim spaces
+			want: Map{"a": Map{"x": ToValue(1), "b": Map{"c": ToValue(3)}}},
+		}, {
+			n:    Map{"users": Array{Map{"role": ToValue("admin")}, Map{}}},
+			expr: `.users[].role ?= "user"`,
+			want: Map{"users": Array{Map{"role": ToValue("admin")}, Map{"role": ToValue("user")}}},
+		}, {
+			n:    Map{"a": Map{"x": ToValue(1)}},
+			expr: `.a ensure`,
+			want: Map{"a": Map{"x": ToValue(1)}},
+		}, {
+			n:    Map{},
+			expr: `.a.b[0].c ensure`,
+			want: Map{"a": Map{"b": Array{Map{"c": Map{}}}}},
+		}, {
+			n:      Map{},
+			expr:   `.a ensure {}`,
+			errstr: `syntax error: invalid edit expression ".a ensure {}"`,
+		}, {
+			n:      Map{"a": ToValue("red")},
+			expr:   `.a -= "red"`,
+			errstr: `cannot remove from .a`,
 		}, {
 			n:      StringValue("str"),
 			expr:   `.key ^?`,
@@ -777,3 +1171,180 @@ func Test_Edit(t *testing.T) {
 		}
 	}
 }
+
+func Test_FindContext(t *testing.T) {
+	n := Map{"users": Array{Map{"name": ToValue("one")}, Map{"name": ToValue("two")}}}
+
+	got, err := FindContext(context.Background(), n, `..name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ToNodeValues("one", "two"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FindContext(ctx, n, `..name`); err != context.Canceled {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+}
+
+func Test_EditContext(t *testing.T) {
+	var n Node = Map{"users": Array{Map{"name": ToValue("one")}, Map{"name": ToValue("two")}}}
+
+	if err := EditContext(context.Background(), &n, `..name = "NAME"`); err != nil {
+		t.Fatal(err)
+	}
+	want := Map{"users": Array{Map{"name": ToValue("NAME")}, Map{"name": ToValue("NAME")}}}
+	if !reflect.DeepEqual(n, want) {
+		t.Errorf("got %v; want %v", n, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	exprs := []string{`..name = "NAME"`, `.users[].name = "NAME"`, `.users ^?`}
+	for i, expr := range exprs {
+		if err := EditContext(ctx, &n, expr); err != context.Canceled {
+			t.Errorf("tests[%d] got error %v; want %v", i, err, context.Canceled)
+		}
+	}
+}
+
+func Test_FindContext_Variables(t *testing.T) {
+	n := Map{"users": Array{Map{"id": ToValue(1), "name": ToValue("one")}, Map{"id": ToValue(2), "name": ToValue("two")}}}
+	ctx := WithVariables(context.Background(), Map{
+		"user": Map{"id": ToValue(2)},
+	})
+	tests := []struct {
+		ctx    context.Context
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			ctx:  ctx,
+			expr: `$user`,
+			want: []Node{Map{"id": ToValue(2)}},
+		}, {
+			ctx:  ctx,
+			expr: `.users[.id == $user.id].name`,
+			want: ToNodeValues("two"),
+		}, {
+			ctx:  WithVariables(ctx, Map{"id": ToValue(1)}),
+			expr: `.users[.id == $id or .id == $user.id].name`,
+			want: ToNodeValues("one", "two"),
+		}, {
+			ctx:    context.Background(),
+			expr:   `.users[.id == $user.id]`,
+			errstr: `undefined variable $user`,
+		},
+	}
+	for i, test := range tests {
+		got, err := FindContext(test.ctx, n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got %s; want %s", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func Test_EditContext_Variables(t *testing.T) {
+	ctx := WithVariables(context.Background(), Map{
+		"defaults": Map{"color": ToValue("red"), "size": ToValue(1)},
+	})
+	var n Node = Map{}
+	exprs := []string{`.color = $defaults.color`, `.defaults = $defaults`}
+	for i, expr := range exprs {
+		if err := EditContext(ctx, &n, expr); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+	}
+	want := Map{
+		"color":    ToValue("red"),
+		"defaults": Map{"color": ToValue("red"), "size": ToValue(1)},
+	}
+	if !reflect.DeepEqual(n, want) {
+		t.Errorf("got %v; want %v", n, want)
+	}
+	if err := EditContext(context.Background(), &n, `.color = $defaults.color`); err == nil {
+		t.Errorf("no error")
+	}
+}
+
+func Test_EditContext_Variables_Documents(t *testing.T) {
+	v := Map{"items": Array{Map{"n": ToValue(1)}}}
+	ctx := WithVariables(context.Background(), Map{"v": v})
+	docs := []Node{Map{"id": ToValue(1)}, Map{"id": ToValue(2)}}
+	for i := range docs {
+		for _, expr := range []string{`.x = $v.items[0]`, `.x.n = 5`} {
+			if err := EditContext(ctx, &docs[i], expr); err != nil {
+				t.Fatalf("docs[%d] %v", i, err)
+			}
+		}
+	}
+	for i, doc := range docs {
+		if got := doc.Get("x", "n"); !reflect.DeepEqual(got, ToValue(5)) {
+			t.Errorf("docs[%d] got %v; want 5", i, got)
+		}
+	}
+	if want := (Map{"items": Array{Map{"n": ToValue(1)}}}); !reflect.DeepEqual(v, want) {
+		t.Errorf("got variable %v; want %v", v, want)
+	}
+}
+
+func FuzzParseQuery(f *testing.F) {
+	for _, expr := range []string{
+		`.store.book[0].title`, `..author | [0]`, `.store.book[:2].price`,
+		`.store.book[(.category == "fiction" or .category == "reference") and .price < 10].title`,
+		`.store.book[.title ~= "^S"].title`, `.store[. == {"color": "red"}]`,
+		`.store.book.count()`, `."first name"`, `.a[-1]`, `.[]`, `$var.a`,
+		`.a.select(.b and (.c or .d != null))`, `.a.format("%s", .b)`, `[`, `(`, `"`,
+		`{"a": .b, c: {"d": $var}}`, `.setpath(["a", 100000000000], 1)`,
+	} {
+		f.Add(expr)
+	}
+	n, err := UnmarshalJSON([]byte(testStoreJSON))
+	if err != nil {
+		f.Fatal(err)
+	}
+	opts := ExecOptions{MaxDepth: 10, MaxResults: 1000, Timeout: time.Second}
+	f.Fuzz(func(t *testing.T, expr string) {
+		q, err := ParseQuery(expr)
+		if err != nil {
+			return
+		}
+		if _, err := ParseQuery(q.String()); err != nil {
+			t.Errorf("ParseQuery(%q).String() = %q does not parse: %v", expr, q.String(), err)
+		}
+		opts.Exec(q, n)
+	})
+}
+
+func FuzzEdit(f *testing.F) {
+	for _, expr := range []string{
+		`.store = {}`, `.store.book[1].title = "x"`, `.a += 1`, `.store.book[0] ^?`,
+		`..price = 0`, `.store.book[.price > 10] ^?`, `.a = $b`, `. += {}`, `=`, `^?`,
+		`.a[10000000000] = 1`, `.store.book[100000000000].title = "x"`,
+	} {
+		f.Add(expr)
+	}
+	f.Fuzz(func(t *testing.T, expr string) {
+		n, err := UnmarshalJSON([]byte(testStoreJSON))
+		if err != nil {
+			t.Fatal(err)
+		}
+		Edit(&n, expr)
+	})
+}
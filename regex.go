@@ -0,0 +1,139 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterMethod("capture", capture)
+	RegisterMethod("test", test)
+	RegisterMethod("match", match)
+}
+
+// execMethodRegexp executes the argument query against n and compiles the
+// result as a regular expression.
+func execMethodRegexp(ctx context.Context, name string, arg Query, n Node) (*regexp.Regexp, error) {
+	v, err := execMethodArg(ctx, arg, n)
+	if err != nil {
+		return nil, err
+	}
+	if !v.Type().IsStringValue() {
+		return nil, fmt.Errorf("invalid regexp for %s(): %v", name, v)
+	}
+	return pooledRegexp(v.Value().String())
+}
+
+// capture is the method "capture(regexp)" that returns a map of the named
+// groups of the first match in the string. It returns no results if the
+// string does not match.
+func capture(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("capture", args, 1, 1); err != nil {
+		return nil, err
+	}
+	if !n.Type().IsStringValue() {
+		return nil, nil
+	}
+	re, err := execMethodRegexp(ctx, "capture", args[0], n)
+	if err != nil {
+		return nil, err
+	}
+	s := n.Value().String()
+	m := re.FindStringSubmatchIndex(s)
+	if m == nil {
+		return nil, nil
+	}
+	captures := Map{}
+	for i, name := range re.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		if m[i*2] < 0 {
+			captures[name] = Nil
+			continue
+		}
+		captures[name] = StringValue(s[m[i*2]:m[i*2+1]])
+	}
+	return []Node{captures}, nil
+}
+
+// test is the method "test(regexp)" that reports whether the string matches
+// the regular expression. It returns false if n is not a string.
+func test(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("test", args, 1, 1); err != nil {
+		return nil, err
+	}
+	re, err := execMethodRegexp(ctx, "test", args[0], n)
+	if err != nil {
+		return nil, err
+	}
+	if !n.Type().IsStringValue() {
+		return []Node{BoolValue(false)}, nil
+	}
+	return []Node{BoolValue(re.MatchString(n.Value().String()))}, nil
+}
+
+// match is the method "match(regexp)" that returns the details of the first
+// match in the string as a map like
+// {"offset": 0, "length": 3, "string": "abc", "captures": [...]}.
+// With the flags "g" as the second argument, it returns all matches.
+// The offsets and lengths are counted in characters.
+func match(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("match", args, 1, 2); err != nil {
+		return nil, err
+	}
+	re, err := execMethodRegexp(ctx, "match", args[0], n)
+	if err != nil {
+		return nil, err
+	}
+	limit := 1
+	if len(args) == 2 {
+		flags, err := execMethodArg(ctx, args[1], n)
+		if err != nil {
+			return nil, err
+		}
+		switch flags.Value().String() {
+		case "":
+		case "g":
+			limit = -1
+		default:
+			return nil, fmt.Errorf("invalid flags for match(): %q", flags.Value().String())
+		}
+	}
+	if !n.Type().IsStringValue() {
+		return nil, nil
+	}
+	s := n.Value().String()
+	var results []Node
+	names := re.SubexpNames()
+	for _, m := range re.FindAllStringSubmatchIndex(s, limit) {
+		result := matchNode(s, m[0], m[1])
+		captures := Array{}
+		for i := 1; i < len(names); i++ {
+			var c Map
+			if m[i*2] < 0 {
+				c = Map{"offset": NumberValue(-1), "length": NumberValue(0), "string": Nil}
+			} else {
+				c = matchNode(s, m[i*2], m[i*2+1])
+			}
+			c["name"] = Nil
+			if names[i] != "" {
+				c["name"] = StringValue(names[i])
+			}
+			captures = append(captures, c)
+		}
+		result["captures"] = captures
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func matchNode(s string, start, end int) Map {
+	return Map{
+		"offset": NumberValue(utf8.RuneCountInString(s[:start])),
+		"length": NumberValue(utf8.RuneCountInString(s[start:end])),
+		"string": StringValue(s[start:end]),
+	}
+}
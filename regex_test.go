@@ -0,0 +1,133 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Capture(t *testing.T) {
+	n := Map{
+		"version": ToValue("1.22.3"),
+		"log":     ToValue("2024-01-02 ERROR disk full"),
+		"number":  ToValue(1),
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.version.capture("^(?P<major>\d+)\.(?P<minor>\d+)(\.(?P<patch>\d+))?")`,
+			want: []Node{Map{"major": ToValue("1"), "minor": ToValue("22"), "patch": ToValue("3")}},
+		}, {
+			expr: `.log.capture("^(?P<date>\S+) (?P<level>[A-Z]+) (?P<msg>.*)$").level`,
+			want: ToNodeValues("ERROR"),
+		}, {
+			expr: `.log.capture("(?P<x>z)?disk")`,
+			want: []Node{Map{"x": Nil}},
+		}, {
+			expr: `.log.capture("^(?P<major>\d+)\.")`,
+		}, {
+			expr: `.number.capture("(?P<n>\d)")`,
+		}, {
+			expr:   `.version.capture("(")`,
+			errstr: "error parsing regexp: missing closing ): `(`",
+		}, {
+			expr:   `.version.capture()`,
+			errstr: "invalid number of arguments for capture(): 0",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func Test_TestAndMatch(t *testing.T) {
+	n := Map{
+		"name":   ToValue("héllo world"),
+		"number": ToValue(1),
+		"tags":   ToArrayValues("api", "web", "apiserver"),
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.name.test("^h")`,
+			want: ToNodeValues(true),
+		}, {
+			expr: `.name.test("^w")`,
+			want: ToNodeValues(false),
+		}, {
+			expr: `.number.test("1")`,
+			want: ToNodeValues(false),
+		}, {
+			expr: `.tags[].test("^api")`,
+			want: ToNodeValues(true, false, true),
+		}, {
+			expr: `.name.match("(?P<first>l+)(o)")`,
+			want: []Node{Map{
+				"offset": ToValue(2),
+				"length": ToValue(3),
+				"string": ToValue("llo"),
+				"captures": Array{
+					Map{"offset": ToValue(2), "length": ToValue(2), "string": ToValue("ll"), "name": ToValue("first")},
+					Map{"offset": ToValue(4), "length": ToValue(1), "string": ToValue("o"), "name": Nil},
+				},
+			}},
+		}, {
+			expr: `.name.match("o(x)?", "g").offset`,
+			want: ToNodeValues(4, 7),
+		}, {
+			expr: `.name.match("o(x)?", "g") | [0].captures[0]`,
+			want: []Node{Map{"offset": ToValue(-1), "length": ToValue(0), "string": Nil, "name": Nil}},
+		}, {
+			expr: `.name.match("z")`,
+		}, {
+			expr: `.number.match("1")`,
+		}, {
+			expr:   `.name.match("o", "x")`,
+			errstr: `invalid flags for match(): "x"`,
+		}, {
+			expr:   `.name.test(1)`,
+			errstr: "invalid regexp for test(): 1",
+		}, {
+			expr:   `.name.test()`,
+			errstr: "invalid number of arguments for test(): 0",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
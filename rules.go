@@ -0,0 +1,168 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+)
+
+// Rule is a transformation rule of ApplyRules. The edits are applied to each
+// node that the match query finds and the when query is truthy for.
+type Rule struct {
+	// Name is the name of the rule used in the errors.
+	Name string
+	// Match is the query of the nodes to edit. "." is used if it is empty.
+	Match string
+	// When is the condition like `.imagePullPolicy == null` tested by
+	// select() for each matched node. The rule is always applied if it is
+	// empty.
+	When string
+	// Edits are the edit expressions applied to each matched node.
+	Edits []string
+}
+
+func (r Rule) label(i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// ParseRules returns the rules of the node that is an array of the rules or
+// a map that has the array as "rules".
+//
+//	rules:
+//	  - name: pull-always
+//	    match: ..containers[]
+//	    when: .imagePullPolicy == null
+//	    edits:
+//	      - .imagePullPolicy = "Always"
+func ParseRules(n Node) ([]Rule, error) {
+	n = OrNil(n)
+	if n.Type().IsMap() {
+		n = OrNil(n.Get("rules"))
+	}
+	if !n.Type().IsArray() {
+		return nil, fmt.Errorf("rules must be an array")
+	}
+	rules := make([]Rule, len(n.Array()))
+	for i, rn := range n.Array() {
+		rn = OrNil(rn)
+		if !rn.Type().IsMap() {
+			return nil, fmt.Errorf("rule #%d must be a map", i+1)
+		}
+		r := Rule{
+			Name:  rn.Get("name").Value().String(),
+			Match: rn.Get("match").Value().String(),
+			When:  rn.Get("when").Value().String(),
+		}
+		switch edits := OrNil(rn.Get("edits")); {
+		case edits.Type().IsStringValue():
+			r.Edits = []string{edits.Value().String()}
+		case edits.Type().IsArray():
+			for _, e := range edits.Array() {
+				if !OrNil(e).Type().IsStringValue() {
+					return nil, fmt.Errorf("rule %s: edits must be strings", r.label(i))
+				}
+				r.Edits = append(r.Edits, e.Value().String())
+			}
+		default:
+			return nil, fmt.Errorf("rule %s: edits must be a string or an array of strings", r.label(i))
+		}
+		rules[i] = r
+	}
+	return rules, nil
+}
+
+// ApplyRules applies the rules to the node pointed to by pn in order, so the
+// latter rules see the results of the former rules. The matched nodes of
+// each rule are found before the edits of the rule.
+func ApplyRules(pn *Node, rules []Rule) error {
+	return ApplyRulesContext(context.Background(), pn, rules)
+}
+
+// ApplyRulesContext is like ApplyRules but executes the queries and the edits
+// with ctx.
+func ApplyRulesContext(ctx context.Context, pn *Node, rules []Rule) error {
+	for i, r := range rules {
+		if err := applyRule(ctx, pn, r); err != nil {
+			return fmt.Errorf("rule %s: %w", r.label(i), err)
+		}
+	}
+	return nil
+}
+
+func applyRule(ctx context.Context, pn *Node, r Rule) error {
+	match := r.Match
+	if match == "" {
+		match = "."
+	}
+	q, err := ParseQuery(match)
+	if err != nil {
+		return err
+	}
+	var when Query
+	if r.When != "" {
+		if when, err = ParseQuery("select(" + r.When + ")"); err != nil {
+			return err
+		}
+	}
+	root := OrNil(*pn)
+	rctx := context.WithValue(withExecRoot(ctx, root), execPathKey{}, &execPath{})
+	rs, ps, err := execQueryWithPaths(rctx, q, root, &execPath{})
+	if err != nil {
+		return err
+	}
+	for i, n := range rs {
+		if ps[i] == nil {
+			return fmt.Errorf("unknown path of the result of %s", match)
+		}
+		if when != nil {
+			ws, err := ExecContext(ctx, when, n)
+			if err != nil {
+				return err
+			}
+			if len(ws) == 0 {
+				continue
+			}
+		}
+		for _, expr := range r.Edits {
+			if err := EditContext(ctx, &n, expr); err != nil {
+				return err
+			}
+		}
+		if err := setByKeys(pn, ps[i].keys, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setByKeys sets v to the node of the keys that exists.
+func setByKeys(pn *Node, keys []interface{}, v Node) error {
+	if len(keys) == 0 {
+		*pn = v
+		return nil
+	}
+	parent := *pn
+	for _, key := range keys[:len(keys)-1] {
+		switch k := key.(type) {
+		case string:
+			parent = parent.Map()[k]
+		case int:
+			parent = parent.Array()[k]
+		}
+	}
+	switch k := keys[len(keys)-1].(type) {
+	case string:
+		if m := parent.Map(); m != nil {
+			m[k] = v
+			return nil
+		}
+	case int:
+		if a := parent.Array(); k < len(a) {
+			a[k] = v
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot set the node of the path %v", keys)
+}
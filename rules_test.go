@@ -0,0 +1,136 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		n      Node
+		want   []Rule
+		errstr string
+	}{
+		{
+			n: Map{"rules": Array{
+				Map{"name": ToValue("a"), "match": ToValue(".a"), "when": ToValue(".b"), "edits": ToArrayValues(".c = 1", ".d = 2")},
+				Map{"edits": ToValue(".e = 3")},
+			}},
+			want: []Rule{
+				{Name: "a", Match: ".a", When: ".b", Edits: []string{".c = 1", ".d = 2"}},
+				{Edits: []string{".e = 3"}},
+			},
+		}, {
+			n:    Array{Map{"edits": ToValue(".a = 1")}},
+			want: []Rule{{Edits: []string{".a = 1"}}},
+		}, {
+			n:      Map{},
+			errstr: "rules must be an array",
+		}, {
+			n:      ToArrayValues(1),
+			errstr: "rule #1 must be a map",
+		}, {
+			n:      Array{Map{"name": ToValue("x")}},
+			errstr: "rule x: edits must be a string or an array of strings",
+		}, {
+			n:      Array{Map{"edits": ToArrayValues(1)}},
+			errstr: "rule #1: edits must be strings",
+		},
+	}
+	for i, test := range tests {
+		got, err := ParseRules(test.n)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	newNode := func() Node {
+		return Map{
+			"kind": ToValue("Deployment"),
+			"containers": Array{
+				Map{"name": ToValue("app"), "image": ToValue("app:1")},
+				Map{"name": ToValue("sidecar"), "image": ToValue("proxy:2"), "imagePullPolicy": ToValue("Never")},
+			},
+		}
+	}
+	tests := []struct {
+		rules  []Rule
+		want   Node
+		errstr string
+	}{
+		{
+			rules: []Rule{
+				{Match: ".containers[]", When: `.imagePullPolicy == null`, Edits: []string{`.imagePullPolicy = "Always"`}},
+				{Match: ".containers[]", Edits: []string{`.resources.limits.cpu = "1"`}},
+			},
+			want: Map{
+				"kind": ToValue("Deployment"),
+				"containers": Array{
+					Map{"name": ToValue("app"), "image": ToValue("app:1"), "imagePullPolicy": ToValue("Always"),
+						"resources": Map{"limits": Map{"cpu": ToValue("1")}}},
+					Map{"name": ToValue("sidecar"), "image": ToValue("proxy:2"), "imagePullPolicy": ToValue("Never"),
+						"resources": Map{"limits": Map{"cpu": ToValue("1")}}},
+				},
+			},
+		}, {
+			rules: []Rule{
+				{When: `.kind == "Service"`, Edits: []string{`.kind = "x"`}},
+				{When: `.kind == "Deployment"`, Edits: []string{`.replicas = 2`}},
+			},
+			want: Map{
+				"kind":     ToValue("Deployment"),
+				"replicas": ToValue(2),
+				"containers": Array{
+					Map{"name": ToValue("app"), "image": ToValue("app:1")},
+					Map{"name": ToValue("sidecar"), "image": ToValue("proxy:2"), "imagePullPolicy": ToValue("Never")},
+				},
+			},
+		}, {
+			rules: []Rule{
+				{Match: ".containers[].image", Edits: []string{`. = "registry/image"`}},
+				{Match: ".containers", Edits: []string{`. += "x"`}},
+			},
+			want: Map{
+				"kind": ToValue("Deployment"),
+				"containers": Array{
+					Map{"name": ToValue("app"), "image": ToValue("registry/image")},
+					Map{"name": ToValue("sidecar"), "image": ToValue("registry/image"), "imagePullPolicy": ToValue("Never")},
+					ToValue("x"),
+				},
+			},
+		}, {
+			rules:  []Rule{{Name: "bad", Edits: []string{"x"}}},
+			errstr: `rule bad: syntax error: invalid edit expression "x"`,
+		}, {
+			rules:  []Rule{{Match: ".containers[].name.count()", Edits: []string{". = 1"}}},
+			errstr: "rule #1: unknown path of the result of .containers[].name.count()",
+		},
+	}
+	for i, test := range tests {
+		n := newNode()
+		err := ApplyRules(&n, test.rules)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(n, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, n, test.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterMethod("sample", sample)
+	RegisterMethod("nth", nth)
+}
+
+// sampleSource is the random source of sample() that can be shared by the
+// goroutines.
+type sampleSource struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newSampleSource(seed int64) *sampleSource {
+	return &sampleSource{rand: rand.New(rand.NewSource(seed))}
+}
+
+// intn returns a random int in [0, n).
+func (s *sampleSource) intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Intn(n)
+}
+
+// defaultSampleSource is the source of sample() without WithSampleSeed.
+var defaultSampleSource = newSampleSource(time.Now().UnixNano())
+
+type sampleSourceKey struct{}
+
+// WithSampleSeed returns a copy of ctx that holds a random source of
+// sample() seeded by seed, so that the results are deterministic.
+func WithSampleSeed(ctx context.Context, seed int64) context.Context {
+	return context.WithValue(ctx, sampleSourceKey{}, newSampleSource(seed))
+}
+
+// sampleSourceFrom returns the random source held by ctx.
+func sampleSourceFrom(ctx context.Context) *sampleSource {
+	if s, _ := ctx.Value(sampleSourceKey{}).(*sampleSource); s != nil {
+		return s
+	}
+	return defaultSampleSource
+}
+
+// sampleElems returns the elements of the array or the values of the map.
+func sampleElems(n Node) []Node {
+	switch n.Type() {
+	case TypeArray:
+		return n.Array()
+	case TypeMap:
+		return n.Map().Values()
+	}
+	return nil
+}
+
+// sampleCount returns the positive integer of the method argument.
+func sampleCount(ctx context.Context, name string, arg Query, n Node) (int, error) {
+	v, err := execMethodArg(ctx, arg, n)
+	if err != nil {
+		return 0, err
+	}
+	if v.Type().IsNumberValue() {
+		f := v.Value().Float64()
+		if f >= 1 && f == float64(int(f)) {
+			return int(f), nil
+		}
+	}
+	return 0, fmt.Errorf("%s(): %s is not a positive integer", name, v)
+}
+
+// sample is the method "sample(n)" that returns an array of n random
+// elements in the original order. It returns all elements if the array has
+// n or less elements.
+func sample(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("sample", args, 1, 1); err != nil {
+		return nil, err
+	}
+	if !n.Type().IsArray() && !n.Type().IsMap() {
+		return nil, nil
+	}
+	c, err := sampleCount(ctx, "sample", args[0], n)
+	if err != nil {
+		return nil, err
+	}
+	elems := sampleElems(n)
+	if c >= len(elems) {
+		return []Node{append(Array{}, elems...)}, nil
+	}
+	// NOTE: Floyd's algorithm selects the indexes without the permutation
+	// of all elements.
+	src := sampleSourceFrom(ctx)
+	selected := make(map[int]bool, c)
+	for j := len(elems) - c; j < len(elems); j++ {
+		t := src.intn(j + 1)
+		if selected[t] {
+			t = j
+		}
+		selected[t] = true
+	}
+	indexes := make([]int, 0, c)
+	for index := range selected {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	a := make(Array, c)
+	for i, index := range indexes {
+		a[i] = elems[index]
+	}
+	return []Node{a}, nil
+}
+
+// nth is the method "nth(k)" that returns an array of every k-th element
+// from the first.
+func nth(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("nth", args, 1, 1); err != nil {
+		return nil, err
+	}
+	if !n.Type().IsArray() && !n.Type().IsMap() {
+		return nil, nil
+	}
+	k, err := sampleCount(ctx, "nth", args[0], n)
+	if err != nil {
+		return nil, err
+	}
+	elems := sampleElems(n)
+	a := Array{}
+	for i := 0; i < len(elems); i += k {
+		a = append(a, elems[i])
+	}
+	return []Node{a}, nil
+}
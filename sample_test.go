@@ -0,0 +1,81 @@
+package tree
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func Test_Sample(t *testing.T) {
+	n := Map{"a": ToArrayValues(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)}
+
+	got, err := FindContext(WithSampleSeed(context.Background(), 1), n, `.a.sample(3)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || len(got[0].Array()) != 3 {
+		t.Fatalf("got %v", got)
+	}
+	prev := -1.0
+	for _, v := range got[0].Array() {
+		f := v.Value().Float64()
+		if f <= prev {
+			t.Errorf("got %v; want the original order", got)
+		}
+		prev = f
+	}
+
+	again, err := FindContext(WithSampleSeed(context.Background(), 1), n, `.a.sample(3)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, got) {
+		t.Errorf("got %v; want %v with the same seed", again, got)
+	}
+
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.a.sample(20)`,
+			want: []Node{n["a"]},
+		}, {
+			expr: `.a.nth(3)`,
+			want: []Node{ToArrayValues(0, 3, 6, 9)},
+		}, {
+			expr: `.a.nth(1)`,
+			want: []Node{n["a"]},
+		}, {
+			expr: `.a.nth(20)`,
+			want: []Node{ToArrayValues(0)},
+		}, {
+			expr: `.a[0].nth(2)`,
+		}, {
+			expr:   `.a.sample(0)`,
+			errstr: "sample(): 0 is not a positive integer",
+		}, {
+			expr:   `.a.nth(1.5)`,
+			errstr: "nth(): 1.5 is not a positive integer",
+		}, {
+			expr:   `.a.nth()`,
+			errstr: "invalid number of arguments for nth(): 0",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
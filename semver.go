@@ -0,0 +1,163 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterMethod("semver_cmp", semverCmp)
+	RegisterMethod("semver_gt", semverMethod("semver_gt", func(c int) bool { return c > 0 }))
+	RegisterMethod("semver_ge", semverMethod("semver_ge", func(c int) bool { return c >= 0 }))
+	RegisterMethod("semver_lt", semverMethod("semver_lt", func(c int) bool { return c < 0 }))
+	RegisterMethod("semver_le", semverMethod("semver_le", func(c int) bool { return c <= 0 }))
+}
+
+var semverRegexp = regexp.MustCompile(`^v?(0|[1-9]\d*)(?:\.(0|[1-9]\d*))?(?:\.(0|[1-9]\d*))?(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+// semver is a parsed semantic version.
+type semver struct {
+	nums [3]int
+	pre  []string
+}
+
+func parseSemver(s string) (*semver, error) {
+	m := semverRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid semantic version %q", s)
+	}
+	v := &semver{}
+	for i := 0; i < 3; i++ {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid semantic version %q", s)
+		}
+		v.nums[i] = n
+	}
+	if m[4] != "" {
+		v.pre = strings.Split(m[4], ".")
+	}
+	return v, nil
+}
+
+func (v *semver) compare(o *semver) int {
+	for i := 0; i < 3; i++ {
+		if c := compareInt(v.nums[i], o.nums[i]); c != 0 {
+			return c
+		}
+	}
+	// NOTE: A version without the pre-release has the higher precedence.
+	switch {
+	case len(v.pre) == 0 && len(o.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(o.pre); i++ {
+		if c := comparePrerelease(v.pre[i], o.pre[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(v.pre), len(o.pre))
+}
+
+// comparePrerelease compares the identifiers of the pre-releases. The numeric
+// identifiers are compared numerically and are lower than the others.
+func comparePrerelease(a, b string) int {
+	na, erra := strconv.Atoi(a)
+	nb, errb := strconv.Atoi(b)
+	switch {
+	case erra == nil && errb == nil:
+		return compareInt(na, nb)
+	case erra == nil:
+		return -1
+	case errb == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// CompareSemver compares the semantic versions a and b, and returns -1, 0 or
+// 1 by the precedence of Semantic Versioning 2.0.0. The prefix "v" is allowed
+// and the missing minor and patch versions are 0 like "v1.2". The build
+// metadata is ignored.
+func CompareSemver(a, b string) (int, error) {
+	va, err := parseSemver(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseSemver(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.compare(vb), nil
+}
+
+// execSemverArgs parses n and the argument as the semantic versions. The
+// returned version of n is nil if n is not a semantic version.
+func execSemverArgs(ctx context.Context, name string, n Node, args []Query) (*semver, *semver, error) {
+	if err := checkMethodArgs(name, args, 1, 1); err != nil {
+		return nil, nil, err
+	}
+	arg, err := execMethodArg(ctx, args[0], n)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !arg.Type().IsStringValue() {
+		return nil, nil, fmt.Errorf("%s(): invalid semantic version %v", name, arg)
+	}
+	o, err := parseSemver(arg.Value().String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	if !n.Type().IsStringValue() {
+		return nil, o, nil
+	}
+	v, err := parseSemver(n.Value().String())
+	if err != nil {
+		return nil, o, nil
+	}
+	return v, o, nil
+}
+
+// semverCmp is the method "semver_cmp(version)" that compares the semantic
+// version of the string with the version and returns -1, 0 or 1. It returns
+// no results if the string is not a semantic version.
+func semverCmp(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	v, o, err := execSemverArgs(ctx, "semver_cmp", n, args)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return []Node{NumberValue(v.compare(o))}, nil
+}
+
+// semverMethod returns the method like "semver_lt(version)" that returns
+// whether the result of the comparison with the version holds the condition.
+// It returns no results if the string is not a semantic version.
+func semverMethod(name string, cond func(c int) bool) MethodFunc {
+	return func(ctx context.Context, n Node, args []Query) ([]Node, error) {
+		v, o, err := execSemverArgs(ctx, name, n, args)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		return []Node{BoolValue(cond(v.compare(o)))}, nil
+	}
+}
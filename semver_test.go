@@ -0,0 +1,100 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		want   int
+		errstr string
+	}{
+		{a: "1.2.3", b: "1.2.3", want: 0},
+		{a: "1.2.3", b: "1.10.0", want: -1},
+		{a: "2.0.0", b: "1.99.99", want: 1},
+		{a: "v1.2", b: "1.2.0", want: 0},
+		{a: "1.0.0-alpha", b: "1.0.0", want: -1},
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", want: -1},
+		{a: "1.0.0-alpha.1", b: "1.0.0-alpha.beta", want: -1},
+		{a: "1.0.0-beta.2", b: "1.0.0-beta.11", want: -1},
+		{a: "1.0.0-rc.1", b: "1.0.0-beta.11", want: 1},
+		{a: "1.0.0+build.1", b: "1.0.0+build.2", want: 0},
+		{a: "1.0", b: "1", want: 0},
+		{a: "01.0.0", b: "1.0.0", errstr: `invalid semantic version "01.0.0"`},
+		{a: "1.0.0", b: "latest", errstr: `invalid semantic version "latest"`},
+	}
+	for i, test := range tests {
+		got, err := CompareSemver(test.a, test.b)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("tests[%d] got %d; want %d", i, got, test.want)
+		}
+	}
+}
+
+func TestSemverMethods(t *testing.T) {
+	n := Map{
+		"dependencies": Array{
+			Map{"name": ToValue("a"), "version": ToValue("1.9.0")},
+			Map{"name": ToValue("b"), "version": ToValue("2.0.0-rc.1")},
+			Map{"name": ToValue("c"), "version": ToValue("v2.1.0")},
+			Map{"name": ToValue("d"), "version": ToValue("workspace:*")},
+		},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.dependencies[.version.semver_lt("2.0.0")].name`,
+			want: ToNodeValues("a", "b"),
+		}, {
+			expr: `.dependencies[.version.semver_ge("2.0.0")].name`,
+			want: ToNodeValues("c"),
+		}, {
+			expr: `.dependencies[.version.semver_gt("1.9.0") and .version.semver_le("2.1.0")].name`,
+			want: ToNodeValues("b", "c"),
+		}, {
+			expr: `.dependencies[].version.semver_cmp("2.0.0")`,
+			want: ToNodeValues(-1, -1, 1),
+		}, {
+			expr: `.dependencies[.version.semver_cmp("1.9") == 0].name`,
+			want: ToNodeValues("a"),
+		}, {
+			expr:   `.dependencies[0].version.semver_lt("x")`,
+			errstr: `semver_lt(): invalid semantic version "x"`,
+		}, {
+			expr:   `.dependencies[0].version.semver_lt(1)`,
+			errstr: `semver_lt(): invalid semantic version 1`,
+		}, {
+			expr:   `.dependencies[0].version.semver_cmp()`,
+			errstr: `invalid number of arguments for semver_cmp(): 0`,
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
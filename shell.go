@@ -0,0 +1,51 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// FormatShell represents the exports of shell variables like
+// "export KEY='value'". It is only used for output.
+const FormatShell Format = "shell"
+
+var shellNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// QuoteShell quotes s with single quotes for POSIX shells. The single
+// quotes in s are escaped by backslashes outside of the quotes.
+func QuoteShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ShellEncoder writes a flat map as the exports of shell variables, one
+// "export KEY='value'" line for each key, so that the output can be
+// evaluated like eval "$(tq -o shell .env config.yaml)".
+type ShellEncoder struct {
+	Out io.Writer
+}
+
+// Encode writes the map n as the exports. The keys must be the names of
+// shell variables. The strings are written without JSON quotes, null is
+// written as an empty string, and arrays and maps are written as JSON.
+func (e *ShellEncoder) Encode(n Node) error {
+	n = OrNil(n)
+	if !n.Type().IsMap() {
+		return fmt.Errorf("cannot format %v as shell variables", n)
+	}
+	m := n.Map()
+	b := new(strings.Builder)
+	for _, k := range m.Keys() {
+		if !shellNameRegexp.MatchString(k) {
+			return fmt.Errorf("invalid shell variable name %q", k)
+		}
+		s, err := tableCellString(m[k])
+		if err != nil {
+			return err
+		}
+		b.WriteString("export " + k + "=" + QuoteShell(s) + "\n")
+	}
+	_, err := io.WriteString(e.Out, b.String())
+	return err
+}
@@ -0,0 +1,75 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestShellEncoder(t *testing.T) {
+	tests := []struct {
+		n      Node
+		want   string
+		errstr string
+	}{
+		{
+			n: Map{
+				"DB_HOST": ToValue("localhost"),
+				"DB_PORT": ToValue(5432),
+				"DEBUG":   ToValue(true),
+				"EMPTY":   Nil,
+				"MOTD":    ToValue("it's $HOME\n`date`"),
+				"TAGS":    ToArrayValues("a", "b"),
+			},
+			want: "export DB_HOST='localhost'\n" +
+				"export DB_PORT='5432'\n" +
+				"export DEBUG='true'\n" +
+				"export EMPTY=''\n" +
+				"export MOTD='it'\\''s $HOME\n`date`'\n" +
+				"export TAGS='[\"a\",\"b\"]'\n",
+		}, {
+			n:    Map{},
+			want: "",
+		}, {
+			n:      Map{"a-b": ToValue(1)},
+			errstr: `invalid shell variable name "a-b"`,
+		}, {
+			n:      Map{"1a": ToValue(1)},
+			errstr: `invalid shell variable name "1a"`,
+		}, {
+			n:      ToArrayValues("a"),
+			errstr: `cannot format [a] as shell variables`,
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		err := (&ShellEncoder{Out: buf}).Encode(test.n)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestQuoteShell(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "", want: "''"},
+		{s: "a b", want: "'a b'"},
+		{s: "it's", want: `'it'\''s'`},
+	}
+	for i, test := range tests {
+		if got := QuoteShell(test.s); got != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+	}
+}
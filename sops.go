@@ -0,0 +1,125 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SOPSMetadataKey is the key of the metadata block of SOPS encrypted documents.
+const SOPSMetadataKey = "sops"
+
+var sopsEncryptedRegexp = regexp.MustCompile(`^ENC\[[A-Z0-9_]+,data:[^,\]]*,iv:[^,\]]*,tag:[^,\]]*,type:[a-z]+\]$`)
+
+// IsSOPSEncrypted reports whether n is a value encrypted by SOPS like
+// "ENC[AES256_GCM,data:...,iv:...,tag:...,type:str]".
+func IsSOPSEncrypted(n Node) bool {
+	if n == nil || !n.Type().IsStringValue() {
+		return false
+	}
+	return sopsEncryptedRegexp.MatchString(n.Value().String())
+}
+
+// IsSOPSDocument reports whether n is a map that has the SOPS metadata block.
+func IsSOPSDocument(n Node) bool {
+	if n == nil || !n.Type().IsMap() {
+		return false
+	}
+	return n.Map().Has(SOPSMetadataKey)
+}
+
+// EditSOPS is like Edit but keeps SOPS encrypted values and the metadata
+// block untouched, so partially encrypted documents can be edited safely.
+// It returns an error if the expression changes or removes an encrypted value
+// or edits the metadata block.
+func EditSOPS(pn *Node, expr string) error {
+	return EditSOPSContext(context.Background(), pn, expr)
+}
+
+// EditSOPSContext is like EditSOPS but returns ctx.Err() if ctx is done.
+func EditSOPSContext(ctx context.Context, pn *Node, expr string) error {
+	var metadata Node
+	if IsSOPSDocument(*pn) {
+		m := (*pn).Map()
+		metadata = m[SOPSMetadataKey]
+		delete(m, SOPSMetadataKey)
+	}
+
+	type encryptedValue struct {
+		keys []interface{}
+		v    Node
+	}
+	var encrypted []encryptedValue
+	err := Walk(*pn, func(n Node, keys []interface{}) error {
+		if IsSOPSEncrypted(n) {
+			encrypted = append(encrypted, encryptedValue{
+				keys: append([]interface{}{}, keys...),
+				v:    n,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	editErr := EditContext(ctx, pn, expr)
+	if metadata != nil && *pn != nil && (*pn).Type().IsMap() {
+		m := (*pn).Map()
+		if _, ok := m[SOPSMetadataKey]; ok && editErr == nil {
+			editErr = errors.New("cannot edit SOPS metadata")
+		}
+		m[SOPSMetadataKey] = metadata
+	}
+	if editErr != nil {
+		return editErr
+	}
+	for _, e := range encrypted {
+		if v := nodeAtKeys(*pn, e.keys); !reflect.DeepEqual(v, e.v) {
+			return fmt.Errorf("cannot edit SOPS encrypted value at %s", keysString(e.keys))
+		}
+	}
+	return nil
+}
+
+func nodeAtKeys(n Node, keys []interface{}) Node {
+	for _, key := range keys {
+		if n == nil {
+			return nil
+		}
+		switch k := key.(type) {
+		case string:
+			if !n.Type().IsMap() {
+				return nil
+			}
+			n = n.Map()[k]
+		case int:
+			a := n.Array()
+			if a == nil || k < 0 || k >= len(a) {
+				return nil
+			}
+			n = a[k]
+		}
+	}
+	return n
+}
+
+func keysString(keys []interface{}) string {
+	if len(keys) == 0 {
+		return "."
+	}
+	b := new(strings.Builder)
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			b.WriteString("." + k)
+		case int:
+			b.WriteString("[" + strconv.Itoa(k) + "]")
+		}
+	}
+	return b.String()
+}
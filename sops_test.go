@@ -0,0 +1,86 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testEncrypted = "ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:2=,type:str]"
+
+func Test_IsSOPSEncrypted(t *testing.T) {
+	tests := []struct {
+		n    Node
+		want bool
+	}{
+		{n: ToValue(testEncrypted), want: true},
+		{n: ToValue("ENC[AES256_GCM,data:x]"), want: false},
+		{n: ToValue("plain"), want: false},
+		{n: ToValue(1), want: false},
+		{n: nil, want: false},
+	}
+	for i, test := range tests {
+		if got := IsSOPSEncrypted(test.n); got != test.want {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func Test_EditSOPS(t *testing.T) {
+	newDoc := func() Node {
+		return Map{
+			"user":     ToValue("admin"),
+			"password": ToValue(testEncrypted),
+			"keys":     Array{ToValue(testEncrypted)},
+			SOPSMetadataKey: Map{
+				"mac":     ToValue(testEncrypted),
+				"version": ToValue("3.7.3"),
+			},
+		}
+	}
+	tests := []struct {
+		expr   string
+		want   Node
+		errstr string
+	}{
+		{
+			expr: `.user = "root"`,
+			want: Map{
+				"user":     ToValue("root"),
+				"password": ToValue(testEncrypted),
+				"keys":     Array{ToValue(testEncrypted)},
+				SOPSMetadataKey: Map{
+					"mac":     ToValue(testEncrypted),
+					"version": ToValue("3.7.3"),
+				},
+			},
+		}, {
+			expr:   `.password = "plain"`,
+			errstr: "cannot edit SOPS encrypted value at .password",
+		}, {
+			expr:   `.keys ^?`,
+			errstr: "cannot edit SOPS encrypted value at .keys[0]",
+		}, {
+			expr:   `.sops.version = "0"`,
+			errstr: "cannot edit SOPS metadata",
+		},
+	}
+	for i, test := range tests {
+		n := newDoc()
+		err := EditSOPS(&n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(n, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, n, test.want)
+		}
+	}
+}
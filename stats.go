@@ -0,0 +1,124 @@
+package tree
+
+import "sort"
+
+// statsTop is the number of the top keys and the largest subtrees of
+// NodeStats.
+const statsTop = 10
+
+// NodeStats represents the statistics of a node tree.
+type NodeStats struct {
+	// Nodes is the total number of nodes including arrays and maps.
+	Nodes int `json:"nodes"`
+	// Types is the number of nodes per type: "map", "array", "string",
+	// "number", "bool" and "null".
+	Types map[string]int `json:"types"`
+	// MaxDepth is the max nesting depth. The depth of the values of the root
+	// array or map is 1.
+	MaxDepth int `json:"maxDepth"`
+	// DistinctKeys is the number of distinct map keys.
+	DistinctKeys int `json:"distinctKeys"`
+	// TopKeys are the 10 most frequent map keys.
+	TopKeys []KeyStats `json:"topKeys"`
+	// Largest are the 10 largest arrays and maps except the root.
+	Largest []SubtreeStats `json:"largest"`
+}
+
+// KeyStats represents the number of occurrences of a map key.
+type KeyStats struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// SubtreeStats represents the number of nodes of a subtree.
+type SubtreeStats struct {
+	// Path is the path of the subtree like ".a[0].b".
+	Path  string `json:"path"`
+	Nodes int    `json:"nodes"`
+}
+
+// Stats returns the statistics of n: the numbers of nodes per type, the max
+// depth, the key cardinalities and the largest subtrees. It helps to
+// understand unexpectedly huge documents.
+func Stats(n Node) *NodeStats {
+	s := &NodeStats{Types: map[string]int{}}
+	keys := map[string]int{}
+	s.walk(n, nil, keys)
+	s.DistinctKeys = len(keys)
+	for k, c := range keys {
+		s.TopKeys = append(s.TopKeys, KeyStats{Key: k, Count: c})
+	}
+	sort.Slice(s.TopKeys, func(i, j int) bool {
+		a, b := s.TopKeys[i], s.TopKeys[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Key < b.Key
+	})
+	if len(s.TopKeys) > statsTop {
+		s.TopKeys = s.TopKeys[:statsTop]
+	}
+	return s
+}
+
+// walk counts n and its children and returns the number of the nodes of n.
+func (s *NodeStats) walk(n Node, path []interface{}, keys map[string]int) int {
+	n = OrNil(n)
+	s.Nodes++
+	s.Types[typeName(n.Type())]++
+	if len(path) > s.MaxDepth {
+		s.MaxDepth = len(path)
+	}
+	count := 1
+	switch {
+	case n.Type().IsArray():
+		for i, v := range n.Array() {
+			count += s.walk(v, append(path, i), keys)
+		}
+	case n.Type().IsMap():
+		m := n.Map()
+		for _, k := range m.Keys() {
+			keys[k]++
+			count += s.walk(m[k], append(path, k), keys)
+		}
+	default:
+		return count
+	}
+	if len(path) > 0 {
+		s.addLargest(path, count)
+	}
+	return count
+}
+
+// addLargest adds the subtree to Largest if it is one of the largest.
+func (s *NodeStats) addLargest(path []interface{}, count int) {
+	if len(s.Largest) == statsTop && s.Largest[statsTop-1].Nodes >= count {
+		return
+	}
+	i := sort.Search(len(s.Largest), func(i int) bool {
+		return s.Largest[i].Nodes < count
+	})
+	st := SubtreeStats{Path: keysString(path), Nodes: count}
+	s.Largest = append(s.Largest, SubtreeStats{})
+	copy(s.Largest[i+1:], s.Largest[i:])
+	s.Largest[i] = st
+	if len(s.Largest) > statsTop {
+		s.Largest = s.Largest[:statsTop]
+	}
+}
+
+func typeName(t Type) string {
+	switch t {
+	case TypeArray:
+		return "array"
+	case TypeMap:
+		return "map"
+	case TypeStringValue:
+		return "string"
+	case TypeNumberValue:
+		return "number"
+	case TypeBoolValue:
+		return "bool"
+	}
+	return "null"
+}
@@ -0,0 +1,68 @@
+package tree
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	n := Map{
+		"users": Array{
+			Map{"id": ToValue(1), "name": ToValue("a"), "tags": ToArrayValues("x", "y")},
+			Map{"id": ToValue(2), "name": ToValue("b"), "admin": ToValue(true)},
+		},
+		"meta": Map{"id": Nil},
+	}
+	got := Stats(n)
+	want := &NodeStats{
+		Nodes: 14,
+		Types: map[string]int{
+			"map": 4, "array": 2, "string": 4, "number": 2, "bool": 1, "null": 1,
+		},
+		MaxDepth:     4,
+		DistinctKeys: 6,
+		TopKeys: []KeyStats{
+			{Key: "id", Count: 3},
+			{Key: "name", Count: 2},
+			{Key: "admin", Count: 1},
+			{Key: "meta", Count: 1},
+			{Key: "tags", Count: 1},
+			{Key: "users", Count: 1},
+		},
+		Largest: []SubtreeStats{
+			{Path: ".users", Nodes: 11},
+			{Path: ".users[0]", Nodes: 6},
+			{Path: ".users[1]", Nodes: 4},
+			{Path: ".users[0].tags", Nodes: 3},
+			{Path: ".meta", Nodes: 2},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v; want %+v", got, want)
+	}
+
+	if got, want := Stats(ToValue(1)), (&NodeStats{Nodes: 1, Types: map[string]int{"number": 1}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v; want %+v", got, want)
+	}
+}
+
+func TestStats_Top(t *testing.T) {
+	m := Map{}
+	for i := 0; i < 20; i++ {
+		m[fmt.Sprintf("k%02d", i)] = Map{"v": make(Array, i)}
+	}
+	got := Stats(m)
+	if len(got.TopKeys) != 10 || got.TopKeys[0] != (KeyStats{Key: "v", Count: 20}) {
+		t.Errorf("got top keys %v", got.TopKeys)
+	}
+	if len(got.Largest) != 10 {
+		t.Fatalf("got %d largest", len(got.Largest))
+	}
+	if want := (SubtreeStats{Path: ".k19", Nodes: 21}); got.Largest[0] != want {
+		t.Errorf("got %v; want %v", got.Largest[0], want)
+	}
+	if want := (SubtreeStats{Path: ".k14", Nodes: 16}); got.Largest[9] != want {
+		t.Errorf("got %v; want %v", got.Largest[9], want)
+	}
+}
@@ -0,0 +1,277 @@
+// Package stream provides a query engine that evaluates queries directly
+// against a JSON token stream without building the whole tree.
+//
+// Leading steps of a query those are simple paths (.key, [0], [1:3], [])
+// and selects ([.price < 10]) are evaluated while reading tokens, so only
+// the matched nodes (and each array element tested by a select) are built
+// in memory. The remaining steps are executed on the built nodes.
+//
+// Unlike tree.SelectQuery, the values of a map selected by [] are visited
+// in document order instead of sorted key order.
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jarxorg/tree"
+)
+
+// FindFunc is the type of the function called by Find for each matched node.
+type FindFunc func(n tree.Node) error
+
+// Find finds nodes from JSON documents read from r using the query expression
+// and calls fn for each matched node.
+func Find(r io.Reader, expr string, fn FindFunc) error {
+	q, err := tree.ParseQuery(expr)
+	if err != nil {
+		return err
+	}
+	s := &streamer{dec: json.NewDecoder(r), fn: fn}
+	steps := toSteps(q)
+	for {
+		t, err := s.dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := s.exec(t, steps); err != nil {
+			return err
+		}
+	}
+}
+
+// Exec reads the next JSON document from dec, executes q and calls fn for
+// each matched node.
+func Exec(dec *json.Decoder, q tree.Query, fn FindFunc) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	return (&streamer{dec: dec, fn: fn}).exec(t, toSteps(q))
+}
+
+func toSteps(q tree.Query) []tree.Query {
+	if fq, ok := q.(tree.FilterQuery); ok {
+		var steps []tree.Query
+		for _, qq := range fq {
+			steps = append(steps, toSteps(qq)...)
+		}
+		return steps
+	}
+	if _, ok := q.(tree.NopQuery); ok {
+		return nil
+	}
+	return []tree.Query{q}
+}
+
+type streamer struct {
+	dec *json.Decoder
+	fn  FindFunc
+}
+
+func (s *streamer) exec(t json.Token, steps []tree.Query) error {
+	if len(steps) == 0 {
+		n, err := s.node(t)
+		if err != nil {
+			return err
+		}
+		return s.fn(n)
+	}
+	switch q := steps[0].(type) {
+	case tree.NopQuery:
+		return s.exec(t, steps[1:])
+	case tree.MapQuery:
+		if !isDelim(t, '{') {
+			if !isDelim(t, '[') {
+				return fmt.Errorf("cannot index array with %q", string(q))
+			}
+			return s.skipRest(1)
+		}
+		return s.eachMap(func(key string, t json.Token) error {
+			if key == string(q) {
+				return s.exec(t, steps[1:])
+			}
+			return s.skip(t)
+		})
+	case tree.ArrayQuery:
+		if !isDelim(t, '[') {
+			return fmt.Errorf("cannot index array with %d", int(q))
+		}
+		return s.eachArray(func(i int, t json.Token) error {
+			if i == int(q) {
+				return s.exec(t, steps[1:])
+			}
+			return s.skip(t)
+		})
+	case tree.ArrayRangeQuery:
+		if len(q) != 2 {
+			return fmt.Errorf("invalid array range %s", q)
+		}
+		if !isDelim(t, '[') {
+			return fmt.Errorf("cannot index array with range %d:%d", q[0], q[1])
+		}
+		return s.eachArray(func(i int, t json.Token) error {
+			if (q[0] == -1 || i >= q[0]) && (q[1] == -1 || i < q[1]) {
+				return s.exec(t, steps[1:])
+			}
+			return s.skip(t)
+		})
+	case tree.SelectQuery:
+		each := func(t json.Token) error {
+			if q.Selector == nil {
+				return s.exec(t, steps[1:])
+			}
+			n, err := s.node(t)
+			if err != nil {
+				return err
+			}
+			ok, err := q.Selector.Matches(n)
+			if err != nil || !ok {
+				return err
+			}
+			return s.execNode(n, steps[1:])
+		}
+		if isDelim(t, '[') {
+			return s.eachArray(func(_ int, t json.Token) error {
+				return each(t)
+			})
+		}
+		if isDelim(t, '{') {
+			return s.eachMap(func(_ string, t json.Token) error {
+				return each(t)
+			})
+		}
+		return nil
+	}
+	n, err := s.node(t)
+	if err != nil {
+		return err
+	}
+	return s.execNode(n, steps)
+}
+
+func (s *streamer) execNode(n tree.Node, steps []tree.Query) error {
+	rs := []tree.Node{n}
+	if len(steps) > 0 {
+		var err error
+		if rs, err = tree.FilterQuery(steps).Exec(n); err != nil {
+			return err
+		}
+	}
+	for _, r := range rs {
+		if err := s.fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *streamer) eachMap(fn func(key string, t json.Token) error) error {
+	for s.dec.More() {
+		t, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := t.(string)
+		if !ok {
+			return fmt.Errorf("unknown token %#v", t)
+		}
+		if t, err = s.dec.Token(); err != nil {
+			return err
+		}
+		if err := fn(key, t); err != nil {
+			return err
+		}
+	}
+	_, err := s.dec.Token()
+	return err
+}
+
+func (s *streamer) eachArray(fn func(i int, t json.Token) error) error {
+	for i := 0; s.dec.More(); i++ {
+		t, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := fn(i, t); err != nil {
+			return err
+		}
+	}
+	_, err := s.dec.Token()
+	return err
+}
+
+// skip skips the value that starts with t.
+func (s *streamer) skip(t json.Token) error {
+	if isDelim(t, '{') || isDelim(t, '[') {
+		return s.skipRest(1)
+	}
+	return nil
+}
+
+// skipRest skips tokens until the depth becomes 0.
+func (s *streamer) skipRest(depth int) error {
+	for depth > 0 {
+		t, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := t.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+// node builds the node that starts with t.
+func (s *streamer) node(t json.Token) (tree.Node, error) {
+	switch {
+	case isDelim(t, '{'):
+		m := tree.Map{}
+		err := s.eachMap(func(key string, t json.Token) error {
+			n, err := s.node(t)
+			if err != nil {
+				return err
+			}
+			m[key] = n
+			return nil
+		})
+		return m, err
+	case isDelim(t, '['):
+		a := tree.Array{}
+		err := s.eachArray(func(_ int, t json.Token) error {
+			n, err := s.node(t)
+			if err != nil {
+				return err
+			}
+			a = append(a, n)
+			return nil
+		})
+		return a, err
+	case t == nil:
+		return tree.Nil, nil
+	}
+	switch tt := t.(type) {
+	case string:
+		return tree.StringValue(tt), nil
+	case float64:
+		return tree.NumberValue(tt), nil
+	case bool:
+		return tree.BoolValue(tt), nil
+	}
+	return nil, fmt.Errorf("unknown token %#v", t)
+}
+
+func isDelim(t json.Token, d json.Delim) bool {
+	td, ok := t.(json.Delim)
+	return ok && td == d
+}
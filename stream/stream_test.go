@@ -0,0 +1,150 @@
+package stream
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarxorg/tree"
+)
+
+var testStoreJSON = `{
+  "store": {
+    "book": [
+      {
+        "category": "reference",
+        "author": "Nigel Rees",
+        "title": "Sayings of the Century",
+        "price": 8.95
+      },
+      {
+        "category": "fiction",
+        "author": "Evelyn Waugh",
+        "title": "Sword of Honour",
+        "price": 12.99
+      },
+      {
+        "category": "fiction",
+        "author": "Herman Melville",
+        "title": "Moby Dick",
+        "isbn": "0-553-21311-3",
+        "price": 8.99
+      }
+    ],
+    "bicycle": {
+      "color": "red",
+      "price": 19.95
+    }
+  }
+}
+`
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		data   string
+		expr   string
+		want   []tree.Node
+		errstr string
+	}{
+		{
+			data: testStoreJSON,
+			expr: `.store.bicycle.color`,
+			want: tree.ToNodeValues("red"),
+		}, {
+			data: testStoreJSON,
+			expr: `.store.book[1].title`,
+			want: tree.ToNodeValues("Sword of Honour"),
+		}, {
+			data: testStoreJSON,
+			expr: `.store.book[].author`,
+			want: tree.ToNodeValues("Nigel Rees", "Evelyn Waugh", "Herman Melville"),
+		}, {
+			data: testStoreJSON,
+			expr: `.store.book[1:].price`,
+			want: tree.ToNodeValues(12.99, 8.99),
+		}, {
+			data: testStoreJSON,
+			expr: `.store.book[.category == "fiction" and .price < 10].title`,
+			want: tree.ToNodeValues("Moby Dick"),
+		}, {
+			data: testStoreJSON,
+			expr: `.store.bicycle[]`,
+			want: tree.ToNodeValues("red", 19.95),
+		}, {
+			data: testStoreJSON,
+			expr: `.store.book.count()`,
+			want: tree.ToNodeValues(3),
+		}, {
+			data: testStoreJSON,
+			expr: `.store..price`,
+			want: tree.ToNodeValues(19.95, 8.95, 12.99, 8.99),
+		}, {
+			data: testStoreJSON,
+			expr: `.store.pen`,
+		}, {
+			data: `{"id":1} {"id":2} {"name":"x"} [{"id":3}]`,
+			expr: `.id`,
+			want: tree.ToNodeValues(1, 2),
+		}, {
+			data: `[null, true, {"a": [1, 2]}]`,
+			expr: `.`,
+			want: []tree.Node{
+				tree.Array{tree.Nil, tree.BoolValue(true), tree.Map{"a": tree.ToArrayValues(1, 2)}},
+			},
+		}, {
+			data:   `"str"`,
+			expr:   `.key`,
+			errstr: `cannot index array with "key"`,
+		}, {
+			data:   `{}`,
+			expr:   `[0]`,
+			errstr: `cannot index array with 0`,
+		}, {
+			data:   `}`,
+			expr:   `.a`,
+			errstr: `invalid character '}' looking for beginning of value`,
+		},
+	}
+	for i, test := range tests {
+		var got []tree.Node
+		err := Find(strings.NewReader(test.data), test.expr, func(n tree.Node) error {
+			got = append(got, n)
+			return nil
+		})
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got %s; want %s", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func TestExec(t *testing.T) {
+	dec := json.NewDecoder(strings.NewReader(`{"a":{"b":1}} {"a":{"b":2}}`))
+	q := tree.FilterQuery{tree.MapQuery("a"), tree.MapQuery("b")}
+
+	var got []tree.Node
+	for dec.More() {
+		err := Exec(dec, q, func(n tree.Node) error {
+			got = append(got, n)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := tree.ToNodeValues(1, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
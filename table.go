@@ -0,0 +1,162 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FormatTable represents a plain-text table with aligned columns. It is
+// only used for output.
+const FormatTable Format = "table"
+
+// TableEncoder writes an array of maps as a plain-text table: the first
+// line is the header of the columns and each map is a row. A map is written
+// as a table of one row.
+type TableEncoder struct {
+	Out io.Writer
+	// Columns are the keys of the columns. If it is empty, all keys of the
+	// maps are the columns in the order of Map.Keys.
+	Columns []string
+	// MaxWidth truncates the cells that are wider than the width if it is
+	// positive. The East Asian wide characters are two columns wide.
+	MaxWidth int
+}
+
+var tableCellReplacer = strings.NewReplacer("\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// Encode writes n as a table. The strings are written without quotes, null
+// is written as an empty cell, and arrays and maps are written as JSON.
+func (e *TableEncoder) Encode(n Node) error {
+	n = OrNil(n)
+	var rows []Map
+	switch {
+	case n.Type().IsMap():
+		rows = []Map{n.Map()}
+	case n.Type().IsArray():
+		for _, v := range n.Array() {
+			if v == nil || !v.Type().IsMap() {
+				return fmt.Errorf("cannot format %v as a table row", OrNil(v))
+			}
+			rows = append(rows, v.Map())
+		}
+	default:
+		return fmt.Errorf("cannot format %v as a table", n)
+	}
+
+	columns := e.Columns
+	if len(columns) == 0 {
+		seen := map[string]bool{}
+		for _, row := range rows {
+			for _, k := range row.Keys() {
+				if !seen[k] {
+					seen[k] = true
+					columns = append(columns, k)
+				}
+			}
+		}
+	}
+
+	lines := make([][]string, len(rows)+1)
+	lines[0] = make([]string, len(columns))
+	for i, c := range columns {
+		lines[0][i] = e.cell(c)
+	}
+	for i, row := range rows {
+		cells := make([]string, len(columns))
+		for j, c := range columns {
+			s, err := tableCellString(row[c])
+			if err != nil {
+				return err
+			}
+			cells[j] = e.cell(s)
+		}
+		lines[i+1] = cells
+	}
+
+	widths := make([]int, len(columns))
+	for _, cells := range lines {
+		for i, cell := range cells {
+			if w := textWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	b := new(strings.Builder)
+	for _, cells := range lines {
+		line := new(strings.Builder)
+		for i, cell := range cells {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(cell)
+			line.WriteString(strings.Repeat(" ", widths[i]-textWidth(cell)))
+		}
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(e.Out, b.String())
+	return err
+}
+
+// cell escapes the line breaks and tabs of s and truncates it by MaxWidth.
+func (e *TableEncoder) cell(s string) string {
+	return truncateText(tableCellReplacer.Replace(s), e.MaxWidth)
+}
+
+// truncateText truncates s to the width with "…" if the width is positive.
+func truncateText(s string, width int) string {
+	if width <= 0 || textWidth(s) <= width {
+		return s
+	}
+	w := 0
+	for i, r := range s {
+		w += runeWidth(r)
+		if w > width-1 {
+			return s[:i] + "…"
+		}
+	}
+	return s
+}
+
+// textWidth returns the number of the columns of s on terminals.
+func textWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// runeWidth returns 2 if r is an East Asian wide character, otherwise 1.
+func runeWidth(r rune) int {
+	switch {
+	case r >= 0x1100 && r <= 0x115f,
+		r >= 0x2e80 && r <= 0xa4cf && r != 0x303f,
+		r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff,
+		r >= 0xfe30 && r <= 0xfe4f,
+		r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6,
+		r >= 0x1f300 && r <= 0x1f64f,
+		r >= 0x1f900 && r <= 0x1f9ff,
+		r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+func tableCellString(n Node) (string, error) {
+	n = OrNil(n)
+	switch {
+	case n.IsNil():
+		return "", nil
+	case n.Type().IsValue():
+		return n.Value().String(), nil
+	}
+	b, err := MarshalJSON(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
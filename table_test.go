@@ -0,0 +1,76 @@
+package tree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTableEncoder(t *testing.T) {
+	users := Array{
+		Map{"id": ToValue(1), "name": ToValue("Alice"), "tags": ToArrayValues("a", "b")},
+		Map{"id": ToValue(10), "name": ToValue("Bob\tSmith"), "admin": ToValue(true)},
+		Map{"id": ToValue(100), "name": ToValue("名前"), "tags": Nil},
+	}
+	tests := []struct {
+		e      TableEncoder
+		n      Node
+		want   string
+		errstr string
+	}{
+		{
+			n: users,
+			want: `id   name        tags       admin
+1    Alice       ["a","b"]
+10   Bob\tSmith             true
+100  名前
+`,
+		}, {
+			e: TableEncoder{Columns: []string{"name", "id", "none"}, MaxWidth: 4},
+			n: users,
+			want: `name  id   none
+Ali…  1
+Bob…  10
+名前  100
+`,
+		}, {
+			e: TableEncoder{MaxWidth: 4},
+			n: Map{"k": ToValue("日本語")},
+			want: `k
+日…
+`,
+		}, {
+			e: TableEncoder{Columns: []string{"name"}},
+			n: users[0],
+			want: `name
+Alice
+`,
+		}, {
+			n:    Array{},
+			want: "\n",
+		}, {
+			n:      ToArrayValues(1),
+			errstr: "cannot format 1 as a table row",
+		}, {
+			n:      ToValue("a"),
+			errstr: "cannot format a as a table",
+		},
+	}
+	for i, test := range tests {
+		buf := new(bytes.Buffer)
+		e := test.e
+		e.Out = buf
+		err := e.Encode(test.n)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
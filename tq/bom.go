@@ -0,0 +1,53 @@
+package tq
+
+import (
+	"io"
+	"unicode/utf8"
+
+	"github.com/jarxorg/tree"
+)
+
+// detectTextEncoding detects the encoding of in by tree.DetectTextEncoding
+// and reports whether in begins with the byte order mark. The offset of in is
+// reset to the start.
+func detectTextEncoding(in io.ReadSeeker) (tree.TextEncoding, bool, error) {
+	head := make([]byte, 4)
+	n, err := io.ReadFull(in, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return tree.TextUTF8, false, err
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return tree.TextUTF8, false, err
+	}
+	return tree.DetectTextEncoding(head[:n]), tree.HasBOM(head[:n]), nil
+}
+
+// textWriter writes UTF-8 as the encoding by tree.FromUTF8, so the updated
+// documents are written back in the encoding of the input. The incomplete
+// UTF-8 sequence at the end of a write is held until the next write.
+type textWriter struct {
+	w       io.Writer
+	enc     tree.TextEncoding
+	bom     bool
+	started bool
+	rest    []byte
+}
+
+func (w *textWriter) Write(p []byte) (int, error) {
+	data := append(w.rest, p...)
+	n := len(data)
+	for i := n - 1; i >= 0 && i >= n-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				n = i
+			}
+			break
+		}
+	}
+	w.rest = append([]byte{}, data[n:]...)
+	if _, err := w.w.Write(tree.FromUTF8(data[:n], w.enc, w.bom && !w.started)); err != nil {
+		return 0, err
+	}
+	w.started = true
+	return len(p), nil
+}
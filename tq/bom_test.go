@@ -0,0 +1,52 @@
+package tq
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jarxorg/tree"
+)
+
+func TestTextWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := &textWriter{w: buf, enc: tree.TextUTF16LE, bom: true}
+	// NOTE: "é" is split into 2 writes.
+	for _, s := range []string{"a\xc3", "\xa9", "\n"} {
+		n, err := w.Write([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(s) {
+			t.Errorf("got %d; want %d", n, len(s))
+		}
+	}
+	if got, want := buf.String(), "\xff\xfea\x00\xe9\x00\n\x00"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestRunner_Update_TextEncoding(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "\xef\xbb\xbfa: 1\n", want: "\xef\xbb\xbfa: 2\n"},
+		{in: "\xff\xfea\x00:\x00 \x001\x00\r\x00\n\x00", want: "\xff\xfea\x00:\x00 \x002\x00\r\x00\n\x00"},
+		{in: "\x00a\x00:\x00 \x001\x00\n", want: "\x00a\x00:\x00 \x002\x00\n"},
+	}
+	for i, test := range tests {
+		r, err := NewRunner(Options{Edits: []string{".a = 2"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := new(bytes.Buffer)
+		if _, err := r.Update(context.Background(), strings.NewReader(test.in), buf); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
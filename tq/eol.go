@@ -0,0 +1,47 @@
+package tq
+
+import (
+	"bufio"
+	"io"
+)
+
+// maxEOLScan is the maximum number of bytes that hasCRLF reads to find the
+// first line ending.
+const maxEOLScan = 64 << 10
+
+// hasCRLF reports whether the first line of in ends with CRLF. The offset of
+// in is reset to the start.
+func hasCRLF(in io.ReadSeeker) (bool, error) {
+	br := bufio.NewReader(io.LimitReader(in, maxEOLScan))
+	line, err := br.ReadSlice('\n')
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return false, err
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	n := len(line)
+	return n >= 2 && line[n-1] == '\n' && line[n-2] == '\r', nil
+}
+
+// crlfWriter writes the line endings LF as CRLF. The line endings those are
+// already CRLF are written as is.
+type crlfWriter struct {
+	w    io.Writer
+	last byte
+}
+
+func (w *crlfWriter) Write(p []byte) (int, error) {
+	buf := make([]byte, 0, len(p)+len(p)/16)
+	for _, c := range p {
+		if c == '\n' && w.last != '\r' {
+			buf = append(buf, '\r')
+		}
+		buf = append(buf, c)
+		w.last = c
+	}
+	if _, err := w.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,51 @@
+package tq
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestHasCRLF(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{in: "a: 1\r\nb: 2\n", want: true},
+		{in: "a: 1\nb: 2\r\n", want: false},
+		{in: `{"a":1}`, want: false},
+		{in: "\r\n", want: true},
+		{in: "", want: false},
+	}
+	for i, test := range tests {
+		in := strings.NewReader(test.in)
+		got, err := hasCRLF(in)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+		if off, _ := in.Seek(0, io.SeekCurrent); off != 0 {
+			t.Errorf("tests[%d] got offset %d; want 0", i, off)
+		}
+	}
+}
+
+func TestCRLFWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := &crlfWriter{w: buf}
+	for _, s := range []string{"a\nb\r", "\nc\n", "\n"} {
+		n, err := w.Write([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(s) {
+			t.Errorf("got %d; want %d", n, len(s))
+		}
+	}
+	if got, want := buf.String(), "a\r\nb\r\nc\r\n\r\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+package tq
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/jarxorg/tree"
+)
+
+// The escape modes of Options.Escape.
+const (
+	EscapeJSON  = "json"
+	EscapeCSV   = "csv"
+	EscapeShell = "shell"
+	EscapeHTML  = "html"
+)
+
+// TemplateFuncs are the functions available in Options.Template. Each
+// function escapes the value for the context: json encodes it as JSON, csv
+// quotes it as a CSV field if needed, shell quotes it with single quotes if
+// needed, and html escapes the HTML special characters. Maps and arrays are
+// formatted as JSON and null is empty except json.
+var TemplateFuncs = template.FuncMap{
+	EscapeJSON:  escapeJSON,
+	EscapeCSV:   escapeCSV,
+	EscapeShell: escapeShell,
+	EscapeHTML:  escapeHTML,
+}
+
+// templateString returns the string of the template value v.
+func templateString(v interface{}) (string, error) {
+	n, ok := v.(tree.Node)
+	if !ok {
+		if v == nil {
+			return "", nil
+		}
+		return fmt.Sprint(v), nil
+	}
+	n = tree.OrNil(n)
+	switch {
+	case n.IsNil():
+		return "", nil
+	case n.Type().IsValue():
+		return n.Value().String(), nil
+	}
+	b, err := tree.MarshalJSON(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func escapeJSON(v interface{}) (string, error) {
+	var b []byte
+	var err error
+	if n, ok := v.(tree.Node); ok {
+		b, err = tree.MarshalJSON(tree.OrNil(n))
+	} else {
+		b, err = json.Marshal(v)
+	}
+	return string(b), err
+}
+
+func escapeCSV(v interface{}) (string, error) {
+	s, err := templateString(v)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if err := w.Write([]string{s}); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+var shellSafeRegexp = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// quoteShell quotes s with single quotes for POSIX shells if s contains
+// characters those are not safe.
+func quoteShell(s string) string {
+	if shellSafeRegexp.MatchString(s) {
+		return s
+	}
+	return tree.QuoteShell(s)
+}
+
+func escapeShell(v interface{}) (string, error) {
+	s, err := templateString(v)
+	if err != nil {
+		return "", err
+	}
+	return quoteShell(s), nil
+}
+
+func escapeHTML(v interface{}) (string, error) {
+	s, err := templateString(v)
+	if err != nil {
+		return "", err
+	}
+	return template.HTMLEscapeString(s), nil
+}
+
+// parseTemplate parses text as Options.Template with TemplateFuncs. If
+// escape is not empty, the value of each action is escaped by the function
+// of escape unless the action ends with one of TemplateFuncs.
+func parseTemplate(text, escape string) (*template.Template, error) {
+	tmpl, err := template.New("").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if escape != "" {
+		for _, t := range tmpl.Templates() {
+			if t.Tree != nil {
+				escapeActions(t.Tree, t.Tree.Root, escape)
+			}
+		}
+	}
+	return tmpl, nil
+}
+
+// escapeActions appends the escape function to the pipelines of the actions
+// those output values in node.
+func escapeActions(t *parse.Tree, node parse.Node, escape string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			escapeActions(t, c, escape)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) == 0 {
+			return
+		}
+		last := n.Pipe.Cmds[len(n.Pipe.Cmds)-1]
+		if id, ok := last.Args[0].(*parse.IdentifierNode); ok {
+			if _, ok := TemplateFuncs[id.Ident]; ok {
+				return
+			}
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier(escape).SetTree(t).SetPos(n.Pos)},
+		})
+	case *parse.IfNode:
+		escapeActions(t, n.List, escape)
+		escapeActions(t, n.ElseList, escape)
+	case *parse.RangeNode:
+		escapeActions(t, n.List, escape)
+		escapeActions(t, n.ElseList, escape)
+	case *parse.WithNode:
+		escapeActions(t, n.List, escape)
+		escapeActions(t, n.ElseList, escape)
+	}
+}
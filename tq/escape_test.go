@@ -0,0 +1,87 @@
+package tq
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jarxorg/tree"
+)
+
+func TestParseTemplate_Escape(t *testing.T) {
+	n := tree.Map{
+		"name": tree.ToValue("o'neil; rm -rf /"),
+		"bio":  tree.ToValue(`<b>"x"</b>, y`),
+		"tags": tree.ToArrayValues("a", "b"),
+		"id":   tree.ToValue(1),
+	}
+	tests := []struct {
+		text   string
+		escape string
+		want   string
+	}{
+		{
+			text: `{{.name}} {{.id}}`,
+			want: `o'neil; rm -rf / 1`,
+		}, {
+			text: `{{.name | shell}} {{.bio | csv}} {{.tags | json}} {{.bio | html}}`,
+			want: `'o'\''neil; rm -rf /' "<b>""x""</b>, y" ["a","b"] &lt;b&gt;&#34;x&#34;&lt;/b&gt;, y`,
+		}, {
+			text:   `echo {{.name}} {{.id}} {{.missing}}`,
+			escape: EscapeShell,
+			want:   `echo 'o'\''neil; rm -rf /' 1 ''`,
+		}, {
+			text:   `{{.id}},{{.bio}},{{.tags}}`,
+			escape: EscapeCSV,
+			want:   `1,"<b>""x""</b>, y","[""a"",""b""]"`,
+		}, {
+			text:   `{"name": {{.name}}, "tags": {{.tags}}, "missing": {{.missing}}}`,
+			escape: EscapeJSON,
+			want:   `{"name": "o'neil; rm -rf /", "tags": ["a","b"], "missing": null}`,
+		}, {
+			text:   `<p>{{.bio}}</p>{{range .tags}}<i>{{.}}</i>{{end}}`,
+			escape: EscapeHTML,
+			want:   `<p>&lt;b&gt;&#34;x&#34;&lt;/b&gt;, y</p><i>a</i><i>b</i>`,
+		}, {
+			text:   `{{if .id}}{{.name | json}}{{else}}{{.bio}}{{end}} {{$v := .id}}{{$v}}`,
+			escape: EscapeShell,
+			want:   `"o'neil; rm -rf /" 1`,
+		}, {
+			text:   `{{define "t"}}{{.}}{{end}}{{template "t" .name}}`,
+			escape: EscapeShell,
+			want:   `'o'\''neil; rm -rf /'`,
+		},
+	}
+	for i, test := range tests {
+		tmpl, err := parseTemplate(test.text, test.escape)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		buf := new(bytes.Buffer)
+		if err := tmpl.Execute(buf, n); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+	}
+}
+
+func TestQuoteShell(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "abc", want: "abc"},
+		{s: "/usr/local/bin:a=b,c@d%e+f", want: "/usr/local/bin:a=b,c@d%e+f"},
+		{s: "", want: "''"},
+		{s: "a b", want: "'a b'"},
+		{s: "$HOME", want: "'$HOME'"},
+		{s: "it's", want: `'it'\''s'`},
+		{s: "a\nb", want: "'a\nb'"},
+	}
+	for i, test := range tests {
+		if got := quoteShell(test.s); got != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+	}
+}
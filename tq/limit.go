@@ -0,0 +1,63 @@
+package tq
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// outputTruncatedMarker is the line written after the output truncated by
+// Options.MaxOutputBytes.
+const outputTruncatedMarker = "…(output truncated at %d bytes)"
+
+// colorReset resets the colors of the output truncated in an escape sequence.
+const colorReset = "\x1b[0m"
+
+// limitWriter writes to w until the output of the runner reaches
+// Options.MaxOutputBytes. The rest of the output is discarded, so the
+// encoders never fail in the middle of the results. last is the last byte
+// written by the run that begins with a new line.
+type limitWriter struct {
+	r    *Runner
+	w    io.Writer
+	last byte
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if w.r.truncated {
+		return len(p), nil
+	}
+	max := w.r.opts.MaxOutputBytes
+	if w.r.outputBytes+len(p) <= max {
+		n, err := w.w.Write(p)
+		w.r.outputBytes += n
+		if n > 0 {
+			w.last = p[n-1]
+		}
+		return n, err
+	}
+	cut := max - w.r.outputBytes
+	for cut > 0 && !utf8.RuneStart(p[cut]) {
+		cut--
+	}
+	if cut > 0 {
+		if _, err := w.w.Write(p[:cut]); err != nil {
+			return 0, err
+		}
+		w.last = p[cut-1]
+	}
+	w.r.outputBytes += cut
+	w.r.truncated = true
+	marker := ""
+	if w.r.opts.Color {
+		marker = colorReset
+	}
+	if w.last != '\n' {
+		marker += "\n"
+	}
+	marker += fmt.Sprintf(outputTruncatedMarker, max) + "\n"
+	if _, err := io.WriteString(w.w, marker); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,89 @@
+package tq
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRunner_MaxOutputBytes(t *testing.T) {
+	tests := []struct {
+		opts Options
+		ins  []string
+		want string
+		done bool
+	}{
+		{
+			opts: Options{Query: ".[]", MaxOutputBytes: 6},
+			ins:  []string{`["abc", "def"]`},
+			want: "\"abc\"\n…(output truncated at 6 bytes)\n",
+			done: true,
+		}, {
+			opts: Options{Query: ".[]", MaxOutputBytes: 8},
+			ins:  []string{`["abc", "def"]`},
+			want: "\"abc\"\n\"d\n…(output truncated at 8 bytes)\n",
+			done: true,
+		}, {
+			opts: Options{Query: ".", MaxOutputBytes: 7},
+			ins:  []string{`"あいう"`},
+			want: "\"あい\n…(output truncated at 7 bytes)\n",
+			done: true,
+		}, {
+			opts: Options{Query: ".", MaxOutputBytes: 4},
+			ins:  []string{"1", "2", "3"},
+			want: "1\n2\n…(output truncated at 4 bytes)\n",
+			done: true,
+		}, {
+			opts: Options{Query: ".", MaxOutputBytes: 7, Color: true},
+			ins:  []string{`"abcdef"`},
+			want: "\x1b[0;32m\x1b[0m\n…(output truncated at 7 bytes)\n",
+			done: true,
+		}, {
+			opts: Options{Query: ".[]", MaxOutputBytes: 12},
+			ins:  []string{`["abc", "def"]`},
+			want: "\"abc\"\n\"def\"\n",
+		},
+	}
+	for i, test := range tests {
+		r, err := NewRunner(test.opts)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		buf := new(bytes.Buffer)
+		for _, in := range test.ins {
+			if r.Done() {
+				break
+			}
+			if _, err := r.Run(context.Background(), strings.NewReader(in), buf); err != nil {
+				t.Fatalf("tests[%d] %v", i, err)
+			}
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+		if got := r.Done(); got != test.done {
+			t.Errorf("tests[%d] got done %v; want %v", i, got, test.done)
+		}
+		if got := r.Truncated(); got != test.done {
+			t.Errorf("tests[%d] got truncated %v; want %v", i, got, test.done)
+		}
+	}
+}
+
+func TestRunner_MaxOutputBytes_Update(t *testing.T) {
+	r, err := NewRunner(Options{Query: ".", MaxOutputBytes: 4, TruncateStrings: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if _, err := r.Update(context.Background(), strings.NewReader("a: abcdef\n"), buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "a: abcdef\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if r.Truncated() {
+		t.Errorf("got truncated")
+	}
+}
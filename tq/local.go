@@ -0,0 +1,12 @@
+//go:build go1.20
+
+package tq
+
+import "path/filepath"
+
+// IsLocalPath reports whether name is a relative path that stays within the
+// directory: it is not empty, not absolute, has no volume name and does not
+// begin with ".." after cleaning. See filepath.IsLocal.
+func IsLocalPath(name string) bool {
+	return filepath.IsLocal(name)
+}
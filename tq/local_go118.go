@@ -0,0 +1,24 @@
+//go:build !go1.20
+
+package tq
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// IsLocalPath reports whether name is a relative path that stays within the
+// directory: it is not empty, not absolute, has no volume name and does not
+// begin with ".." after cleaning. It is filepath.IsLocal of Go 1.20 except
+// the reserved names of Windows.
+func IsLocalPath(name string) bool {
+	if name == "" || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return false
+	}
+	slashed := filepath.ToSlash(name)
+	if strings.HasPrefix(slashed, "/") {
+		return false
+	}
+	cleaned := filepath.ToSlash(filepath.Clean(name))
+	return cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
@@ -0,0 +1,25 @@
+package tq
+
+import "testing"
+
+func TestIsLocalPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "a.json", want: true},
+		{name: "a/b.json", want: true},
+		{name: "a/../b.json", want: true},
+		{name: "./a.json", want: true},
+		{name: "", want: false},
+		{name: "..", want: false},
+		{name: "../a.json", want: false},
+		{name: "a/../../b.json", want: false},
+		{name: "/tmp/a.json", want: false},
+	}
+	for i, test := range tests {
+		if got := IsLocalPath(test.name); got != test.want {
+			t.Errorf("tests[%d] %q got %v; want %v", i, test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package tq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/jarxorg/tree"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+	// RPCExecError is the error code of the failures to decode or execute.
+	RPCExecError = -32000
+)
+
+// RPCRequest is a JSON-RPC 2.0 request.
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// RPCResponse is a JSON-RPC 2.0 response.
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC 2.0 error.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+// RPCParams is the params of the methods.
+type RPCParams struct {
+	Document     string      `json:"document,omitempty"`
+	Query        string      `json:"query,omitempty"`
+	Edits        []string    `json:"edits,omitempty"`
+	InputFormat  tree.Format `json:"inputFormat,omitempty"`
+	OutputFormat tree.Format `json:"outputFormat,omitempty"`
+	Expand       bool        `json:"expand,omitempty"`
+	Slurp        bool        `json:"slurp,omitempty"`
+	Raw          bool        `json:"raw,omitempty"`
+	Template     string      `json:"template,omitempty"`
+	Escape       string      `json:"escape,omitempty"`
+}
+
+func (p RPCParams) options() Options {
+	return Options{
+		Query:        p.Query,
+		Edits:        p.Edits,
+		InputFormat:  p.InputFormat,
+		OutputFormat: p.OutputFormat,
+		Expand:       p.Expand,
+		Slurp:        p.Slurp,
+		Raw:          p.Raw,
+		Template:     p.Template,
+		Escape:       p.Escape,
+	}
+}
+
+// RPCResult is the result of the methods.
+type RPCResult struct {
+	Query  string `json:"query,omitempty"`
+	Output string `json:"output"`
+}
+
+type rpcMethod func(ctx context.Context, p RPCParams) (*RPCResult, error)
+
+var rpcMethods = map[string]rpcMethod{
+	"parse":  rpcParse,
+	"query":  rpcQuery,
+	"edit":   rpcEdit,
+	"format": rpcFormat,
+}
+
+// ServeRPC reads JSON-RPC 2.0 requests from r and writes the responses to w
+// until r reaches EOF or ctx is done. Each message is a single line of JSON.
+//
+// The methods are:
+//
+//	parse   parses params.query and returns the normalized query.
+//	query   executes params.query to params.document and returns the output.
+//	edit    applies params.edits to params.document and returns the updated document.
+//	format  returns params.document formatted as params.outputFormat.
+func ServeRPC(ctx context.Context, r io.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), DefaultMaxBodyBytes)
+	for s.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		res := handleRPC(ctx, line)
+		if res == nil {
+			continue
+		}
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+func handleRPC(ctx context.Context, line []byte) *RPCResponse {
+	res := &RPCResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+	var req RPCRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		res.Error = &RPCError{Code: RPCParseError, Message: err.Error()}
+		return res
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		res.Error = &RPCError{Code: RPCInvalidRequest, Message: "invalid request"}
+		return res
+	}
+	isNotification := len(req.ID) == 0
+	if !isNotification {
+		res.ID = req.ID
+	}
+
+	result, err := callRPC(ctx, req)
+	if isNotification {
+		return nil
+	}
+	if err != nil {
+		rerr, ok := err.(*RPCError)
+		if !ok {
+			rerr = &RPCError{Code: RPCInternalError, Message: err.Error()}
+		}
+		res.Error = rerr
+		return res
+	}
+	res.Result = result
+	return res
+}
+
+func callRPC(ctx context.Context, req RPCRequest) (*RPCResult, error) {
+	m, ok := rpcMethods[req.Method]
+	if !ok {
+		return nil, &RPCError{Code: RPCMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	var p RPCParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &RPCError{Code: RPCInvalidParams, Message: err.Error()}
+		}
+	}
+	return m(ctx, p)
+}
+
+func rpcParse(_ context.Context, p RPCParams) (*RPCResult, error) {
+	q, err := tree.ParseQuery(p.Query)
+	if err != nil {
+		return nil, &RPCError{Code: RPCInvalidParams, Message: err.Error()}
+	}
+	return &RPCResult{Query: q.String()}, nil
+}
+
+func rpcQuery(ctx context.Context, p RPCParams) (*RPCResult, error) {
+	return rpcRun(ctx, p, false)
+}
+
+func rpcEdit(ctx context.Context, p RPCParams) (*RPCResult, error) {
+	return rpcRun(ctx, p, true)
+}
+
+func rpcFormat(ctx context.Context, p RPCParams) (*RPCResult, error) {
+	return rpcRun(ctx, RPCParams{
+		Document:     p.Document,
+		InputFormat:  p.InputFormat,
+		OutputFormat: p.OutputFormat,
+	}, false)
+}
+
+func rpcRun(ctx context.Context, p RPCParams, update bool) (*RPCResult, error) {
+	r, err := NewRunner(p.options())
+	if err != nil {
+		return nil, &RPCError{Code: RPCInvalidParams, Message: err.Error()}
+	}
+	run := r.Run
+	if update {
+		run = r.Update
+	}
+	buf := new(strings.Builder)
+	if _, err := run(ctx, strings.NewReader(p.Document), buf); err != nil {
+		return nil, &RPCError{Code: RPCExecError, Message: err.Error()}
+	}
+	return &RPCResult{Output: buf.String()}, nil
+}
@@ -0,0 +1,66 @@
+package tq
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestServeRPC(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{
+			in:   `{"jsonrpc":"2.0","id":1,"method":"parse","params":{"query":".a[.b==1]"}}`,
+			want: `{"jsonrpc":"2.0","id":1,"result":{"query":".a[.b == 1]","output":""}}`,
+		}, {
+			in:   `{"jsonrpc":"2.0","id":"q","method":"query","params":{"document":"{\"a\":[1,2]}","query":".a[0]"}}`,
+			want: `{"jsonrpc":"2.0","id":"q","result":{"output":"1\n"}}`,
+		}, {
+			in:   `{"jsonrpc":"2.0","id":2,"method":"edit","params":{"document":"a: 1\n---\nb: 2\n","edits":[".c = 3"]}}`,
+			want: `{"jsonrpc":"2.0","id":2,"result":{"output":"a: 1\nc: 3\n---\nb: 2\nc: 3\n"}}`,
+		}, {
+			in:   `{"jsonrpc":"2.0","id":3,"method":"format","params":{"document":"a: 1","query":".a","outputFormat":"json"}}`,
+			want: `{"jsonrpc":"2.0","id":3,"result":{"output":"{\n  \"a\": 1\n}\n"}}`,
+		}, {
+			in:   `{"jsonrpc":"2.0","method":"query","params":{"document":"{}"}}`,
+			want: ``,
+		}, {
+			in:   `{"jsonrpc":"2.0","id":4,"method":"parse","params":{"query":".a["}}`,
+			want: `{"jsonrpc":"2.0","id":4,"error":{"code":-32602,"message":"syntax error: no right brackets: \".a[\""}}`,
+		}, {
+			in:   `{"jsonrpc":"2.0","id":5,"method":"query","params":{"document":"a: [","inputFormat":"yaml"}}`,
+			want: `{"jsonrpc":"2.0","id":5,"error":{"code":-32000,"message":"yaml: line 1: did not find expected node content"}}`,
+		}, {
+			in:   `{"jsonrpc":"2.0","id":6,"method":"unknown"}`,
+			want: `{"jsonrpc":"2.0","id":6,"error":{"code":-32601,"message":"method not found: unknown"}}`,
+		}, {
+			in:   `{"jsonrpc":"2.0","id":7,"method":"query","params":[]}`,
+			want: `{"jsonrpc":"2.0","id":7,"error":{"code":-32602,"message":"json: cannot unmarshal array into Go value of type tq.RPCParams"}}`,
+		}, {
+			in:   `{"id":8,"method":"query"}`,
+			want: `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"invalid request"}}`,
+		},
+	}
+	for i, test := range tests {
+		out := new(bytes.Buffer)
+		if err := ServeRPC(context.Background(), strings.NewReader(test.in+"\n\n"), out); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := strings.TrimSpace(out.String()); got != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+	}
+}
+
+func TestServeRPC_ParseError(t *testing.T) {
+	out := new(bytes.Buffer)
+	if err := ServeRPC(context.Background(), strings.NewReader("{\n"), out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,`; !strings.HasPrefix(got, want) {
+		t.Errorf("got %s; want prefix %s", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package tq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/jarxorg/tree"
+)
+
+// DefaultMaxBodyBytes is the default limit of the request body size of Handler.
+const DefaultMaxBodyBytes = 10 << 20
+
+// Handler is an http.Handler that runs the pipeline to the document posted
+// as the request body. The options are provided as the URL query parameters:
+//
+//	query          the query expression
+//	edit           the edit expression (multiple)
+//	input-format   json or yaml
+//	output-format  json or yaml
+//	expand, slurp, raw  true to enable
+//	template       the text/template string
+//	escape         json, csv, shell or html to escape the values of template
+//
+// For example:
+//
+//	curl -X POST -d '{"a":[1,2]}' 'http://localhost:8080/?query=.a&expand=true'
+type Handler struct {
+	// MaxBodyBytes limits the size of the request body.
+	// The zero value means DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+	// ExecOptions limits the evaluation of each request. The Timeout is the
+	// deadline of the evaluation, and the request fails with 503 Service
+	// Unavailable if it is exceeded.
+	ExecOptions tree.ExecOptions
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// ServeHTTP runs the pipeline and writes the output.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	opts, err := optionsFromRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r, err := NewRunner(opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	max := h.MaxBodyBytes
+	if max <= 0 {
+		max = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, max))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	ctx := req.Context()
+	if h.ExecOptions.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.ExecOptions.Timeout)
+		defer cancel()
+	}
+	ctx = tree.WithExecOptions(ctx, h.ExecOptions)
+
+	buf := new(bytes.Buffer)
+	if _, err := r.Run(ctx, bytes.NewReader(body), buf); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", contentType(opts, r.OutputFormat()))
+	buf.WriteTo(w)
+}
+
+func optionsFromRequest(req *http.Request) (Options, error) {
+	q := req.URL.Query()
+	opts := Options{
+		Query:        q.Get("query"),
+		Edits:        q["edit"],
+		InputFormat:  tree.Format(q.Get("input-format")),
+		OutputFormat: tree.Format(q.Get("output-format")),
+		Template:     q.Get("template"),
+		Escape:       q.Get("escape"),
+	}
+	for _, f := range []tree.Format{opts.InputFormat, opts.OutputFormat} {
+		if f != "" && f != tree.FormatJSON && f != tree.FormatYAML {
+			return opts, fmt.Errorf("unknown format %q", f)
+		}
+	}
+	bools := []struct {
+		name string
+		v    *bool
+	}{
+		{"expand", &opts.Expand},
+		{"slurp", &opts.Slurp},
+		{"raw", &opts.Raw},
+	}
+	for _, b := range bools {
+		s := q.Get(b.name)
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return opts, errors.New("invalid " + b.name + ": " + strconv.Quote(s))
+		}
+		*b.v = v
+	}
+	return opts, nil
+}
+
+func contentType(opts Options, format tree.Format) string {
+	switch {
+	case opts.Raw || opts.Template != "":
+		return "text/plain; charset=utf-8"
+	case format == tree.FormatYAML:
+		return "application/yaml"
+	}
+	return "application/json"
+}
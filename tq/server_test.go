@@ -0,0 +1,61 @@
+package tq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarxorg/tree"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		h      *Handler
+		method string
+		target string
+		body   string
+		status int
+		want   string
+	}{
+		{
+			h:      &Handler{},
+			method: http.MethodPost,
+			target: "/?query=.a&expand=true",
+			body:   `{"a":[1,2]}`,
+			status: http.StatusOK,
+			want:   "1\n2\n",
+		}, {
+			h:      &Handler{},
+			method: http.MethodGet,
+			target: "/",
+			status: http.StatusMethodNotAllowed,
+			want:   "method not allowed\n",
+		}, {
+			h:      &Handler{ExecOptions: tree.ExecOptions{MaxResults: 2}},
+			method: http.MethodPost,
+			target: "/?query=.a[]",
+			body:   `{"a":[1,2,3]}`,
+			status: http.StatusUnprocessableEntity,
+			want:   "max results exceeded\n",
+		}, {
+			h:      &Handler{ExecOptions: tree.ExecOptions{Timeout: time.Nanosecond}},
+			method: http.MethodPost,
+			target: "/?query=.a",
+			body:   `{"a":1}`,
+			status: http.StatusServiceUnavailable,
+			want:   "context deadline exceeded\n",
+		},
+	}
+	for i, test := range tests {
+		w := httptest.NewRecorder()
+		test.h.ServeHTTP(w, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if w.Code != test.status {
+			t.Errorf("tests[%d] got status %d; want %d", i, w.Code, test.status)
+		}
+		if got := w.Body.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,991 @@
+// Package tq provides the pipeline of the tq command that decodes JSON or
+// YAML documents, edits and queries them, and encodes the results, so that
+// other programs can embed tq behavior without executing the binary.
+package tq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/jarxorg/tree"
+	"gopkg.in/yaml.v3"
+)
+
+// Options represents the options of Runner.
+type Options struct {
+	// Query is the query expression. The default is ".".
+	Query string
+	// Edits are the edit expressions applied to each document before the query.
+	Edits []string
+	// Defaults fills the missing keys of each document before the edits.
+	// See tree.ApplyDefaults.
+	Defaults tree.Node
+	// Keys converts the keys of the maps of each document to the case before
+	// the defaults. See tree.TransformKeys.
+	Keys tree.KeyCase
+	// Partial evaluates the valid leading documents of an input that has
+	// the truncated or invalid data after them, and returns *PartialError
+	// instead of *DecodeError. If no documents are valid, the input is
+	// rejected as before. Update ignores it.
+	Partial bool
+	// InputFormat is the format of the input. If it is empty, the input is
+	// decoded as JSON first and then as YAML. tree.FormatJSONC allows
+	// comments and trailing commas, and tree.FormatFrontMatter evaluates the
+	// front matter of Markdown.
+	InputFormat tree.Format
+	// OutputFormat is the format of the output. If it is empty, the format of
+	// the input is used. tree.FormatTable and tree.FormatTree write each result
+	// as a table and an outline, tree.FormatDot and tree.FormatMermaid write
+	// the graph of each result, and tree.FormatShell writes each map as the
+	// exports of shell variables.
+	OutputFormat tree.Format
+	// Columns are the columns of tree.FormatTable. See tree.TableEncoder.
+	Columns []string
+	// ColumnWidth truncates the cells of tree.FormatTable and the values of
+	// tree.FormatTree, tree.FormatDot and tree.FormatMermaid if it is positive.
+	ColumnWidth int
+	// GraphDepth omits the nodes deeper than the depth of tree.FormatDot and
+	// tree.FormatMermaid if it is positive.
+	GraphDepth int
+	// GraphValues shows the values of tree.FormatDot and tree.FormatMermaid.
+	GraphValues bool
+	// Expand outputs each element of the results.
+	Expand bool
+	// Slurp outputs all results of an input into an array.
+	Slurp bool
+	// Raw outputs string values without quotes. CRLF in the values are
+	// written as LF.
+	Raw bool
+	// Color outputs with colors.
+	Color bool
+	// EncodeOptions are the options to encode the results.
+	EncodeOptions tree.EncodeOptions
+	// DocumentStart writes "---" before each YAML document including the first.
+	DocumentStart bool
+	// DocumentEnd writes "..." after each YAML document.
+	DocumentEnd bool
+	// Seq writes the results as a JSON text sequence (RFC 7464): each result
+	// is prefixed by the record separator (0x1E). Update ignores it.
+	Seq bool
+	// Separator terminates each result instead of the newline if it is not
+	// empty, and the YAML documents are not separated by "---".
+	// Update ignores it.
+	Separator string
+	// Limit stops the evaluation after the number of results if it is positive.
+	Limit int
+	// TruncateStrings cuts the string values of the results written to out
+	// that are longer than the number of characters if it is positive. See
+	// tree.TruncateStrings. Update ignores it.
+	TruncateStrings int
+	// MaxOutputBytes stops the evaluation when the output written to out by
+	// the runner exceeds the bytes if it is positive. The output is cut at
+	// the bytes and ends with the line of outputTruncatedMarker. Update
+	// ignores it.
+	MaxOutputBytes int
+	// CountOnly counts the results without writing them. See Runner.Results.
+	CountOnly bool
+	// Template is a text/template string to format each result. The
+	// functions of TemplateFuncs are available.
+	Template string
+	// Escape escapes the values of all actions of Template by the function
+	// of TemplateFuncs: EscapeJSON, EscapeCSV, EscapeShell or EscapeHTML. The
+	// actions those end with the functions are not escaped twice.
+	Escape string
+	// JSONTemplate is an object construction query like
+	// {"name": .name, "env": $env} that constructs each result. Unlike
+	// Template, the results are always valid documents, and they are encoded
+	// as JSON if OutputFormat is empty.
+	JSONTemplate string
+	// OutputPattern is a text/template string of the filename that Run writes
+	// each result to instead of out, like "{{.kind}}-{{.metadata.name}}.yaml".
+	// The results of the same filename are written to the file as multiple
+	// documents. The directories are created if they do not exist, and the
+	// filenames must be local paths in the directory of the pattern before
+	// the first action. See IsLocalPath. Update ignores it.
+	OutputPattern string
+	// SOPS keeps SOPS encrypted values and the metadata block untouched by
+	// the edits. See tree.EditSOPS.
+	SOPS bool
+	// Filter selects the documents to evaluate if it is not nil. The other
+	// documents are skipped, or written unchanged by Update.
+	Filter func(n tree.Node) bool
+	// OnDocumentError handles the error of evaluating the document of the
+	// number in the input if it is not nil. If it returns nil, the rest of
+	// the document is skipped and the following documents are evaluated.
+	// Update ignores it.
+	OnDocumentError func(doc int, err error) error
+	// Variables are the variables referred as $name in queries.
+	Variables tree.Map
+	// Truthiness is the truthiness model of select(). nil is
+	// tree.IsLooseTruthy.
+	Truthiness func(n tree.Node) bool
+	// SampleSeed seeds the random source of sample() for each document if it
+	// is not nil, so that the results are deterministic.
+	SampleSeed *int64
+	// Logf logs each stage of the pipeline if it is not nil.
+	Logf func(format string, args ...interface{})
+}
+
+// DecodeError is an error that occurred while decoding the input as the format.
+type DecodeError struct {
+	Format tree.Format
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// PartialError is the error of Options.Partial that the input failed to be
+// decoded after the valid leading documents those are evaluated.
+type PartialError struct {
+	Format tree.Format
+	// Documents is the number of the valid documents.
+	Documents int
+	// Offset is the byte offset where the invalid data begins after the
+	// valid documents. It is -1 if it is unknown, and the line of Err shows
+	// where the decoding failed.
+	Offset int64
+	Err    error
+}
+
+func (e *PartialError) Error() string {
+	if e.Documents == 1 {
+		return fmt.Sprintf("invalid %s after 1 document: %v", e.Format, e.Err)
+	}
+	return fmt.Sprintf("invalid %s after %d documents: %v", e.Format, e.Documents, e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// FormatErrors is the errors of decoding an input as each format.
+type FormatErrors []error
+
+func (errs FormatErrors) Error() string {
+	ss := make([]string, len(errs))
+	for i, err := range errs {
+		ss[i] = err.Error()
+	}
+	return strings.Join(ss, "; ")
+}
+
+// errLimitReached stops the evaluation when Options.Limit is reached.
+var errLimitReached = errors.New("limit reached")
+
+// Runner runs the pipeline: decode, edit, query and encode.
+type Runner struct {
+	opts        Options
+	tmpl        *template.Template
+	jsonTmpl    tree.Query
+	outputTmpl  *template.Template
+	outputBase  string
+	outputFiles map[string]int
+
+	out          io.Writer
+	format       tree.Format
+	updating     bool
+	docCount     int
+	outputCount  int
+	resultCount  int
+	lastResult   tree.Node
+	outputBytes  int
+	truncated    bool
+	slurpResults tree.Array
+
+	// The markers of the YAML input that Update preserves.
+	yamlHead  string
+	yamlStart bool
+	yamlEnd   bool
+	// The original documents of the YAML input those are written as is by
+	// Update if they are unchanged.
+	yamlDocs   []yamlDocument
+	yamlDoc    *yamlDocument
+	yamlDocEnd bool
+}
+
+// NewRunner returns a Runner with the options.
+func NewRunner(opts Options) (*Runner, error) {
+	if opts.Query == "" {
+		opts.Query = "."
+	}
+	if _, err := tree.ParseQuery(opts.Query); err != nil {
+		return nil, err
+	}
+	r := &Runner{opts: opts}
+	if opts.JSONTemplate != "" {
+		q, err := tree.ParseQuery(opts.JSONTemplate)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := q.(tree.ObjectQuery); !ok {
+			return nil, fmt.Errorf("JSON template must be an object like {\"key\": .query}: %q", opts.JSONTemplate)
+		}
+		r.jsonTmpl = q
+		if opts.OutputFormat == "" {
+			r.opts.OutputFormat = tree.FormatJSON
+		}
+	}
+	if _, ok := TemplateFuncs[opts.Escape]; opts.Escape != "" && !ok {
+		return nil, fmt.Errorf("unknown escape %q", opts.Escape)
+	}
+	if opts.Template != "" {
+		tmpl, err := parseTemplate(opts.Template, opts.Escape)
+		if err != nil {
+			return nil, err
+		}
+		r.tmpl = tmpl
+	}
+	if opts.OutputPattern != "" {
+		tmpl, err := template.New("").Option("missingkey=error").Parse(opts.OutputPattern)
+		if err != nil {
+			return nil, err
+		}
+		r.outputTmpl = tmpl
+		r.outputBase = outputBase(opts.OutputPattern)
+		r.outputFiles = map[string]int{}
+	}
+	return r, nil
+}
+
+// Run decodes all documents from in, and writes the results of each
+// document to out. It returns the number of the evaluated documents.
+func (r *Runner) Run(ctx context.Context, in io.ReadSeeker, out io.Writer) (int, error) {
+	return r.run(ctx, in, out, false)
+}
+
+// Update decodes all documents from in, and writes the updated documents to
+// out: each document is replaced by its results and the documents that the
+// query does not match are written unchanged. The unchanged YAML documents
+// are written as the original text with the comments. The line endings are
+// written as CRLF if the first line of in ends with CRLF, and the byte order
+// mark and UTF-16 of in are restored. It returns the number of the evaluated
+// documents.
+func (r *Runner) Update(ctx context.Context, in io.ReadSeeker, out io.Writer) (int, error) {
+	outputCount := r.outputCount
+	r.outputCount = 0
+	defer func() { r.outputCount = outputCount }()
+	enc, bom, err := detectTextEncoding(in)
+	if err != nil {
+		return 0, err
+	}
+	if in, err = toUTF8(in); err != nil {
+		return 0, err
+	}
+	crlf, err := hasCRLF(in)
+	if err != nil {
+		return 0, err
+	}
+	if enc != tree.TextUTF8 {
+		out = &textWriter{w: out, enc: enc, bom: bom}
+	}
+	if crlf {
+		out = &crlfWriter{w: out}
+	}
+	return r.run(ctx, in, out, true)
+}
+
+func (r *Runner) run(ctx context.Context, in io.ReadSeeker, out io.Writer, updating bool) (int, error) {
+	r.out = out
+	r.updating = updating
+	r.docCount = 0
+	r.slurpResults = nil
+	r.yamlHead, r.yamlStart, r.yamlEnd = "", false, false
+	r.yamlDocs, r.yamlDocEnd = nil, false
+	defer func() { r.out = nil }()
+	if !updating && r.opts.MaxOutputBytes > 0 {
+		r.out = &limitWriter{r: r, w: out, last: '\n'}
+	}
+
+	if r.Done() {
+		return 0, nil
+	}
+	in, err := toUTF8(in)
+	if err != nil {
+		return 0, err
+	}
+	err = r.runFormat(ctx, in)
+	if err == errLimitReached {
+		err = nil
+	}
+	return r.docCount, err
+}
+
+// toUTF8 returns the reader of the data transcoded by tree.ToUTF8 if in
+// begins with the byte order mark or is UTF-16. Otherwise in is returned as
+// is, so the large inputs are not read at once.
+func toUTF8(in io.ReadSeeker) (io.ReadSeeker, error) {
+	enc, _, err := detectTextEncoding(in)
+	if err != nil {
+		return nil, err
+	}
+	if enc == tree.TextUTF8 {
+		return in, nil
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = tree.ToUTF8(data); err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// Done reports whether the number of the results reaches Options.Limit or
+// the output is truncated by Options.MaxOutputBytes, so the following inputs
+// can be skipped.
+func (r *Runner) Done() bool {
+	return (r.opts.Limit > 0 && r.resultCount >= r.opts.Limit) || r.truncated
+}
+
+// Truncated reports whether the output is truncated by Options.MaxOutputBytes.
+func (r *Runner) Truncated() bool {
+	return r.truncated
+}
+
+func (r *Runner) runFormat(ctx context.Context, in io.ReadSeeker) error {
+	switch r.opts.InputFormat {
+	case tree.FormatJSON:
+		return r.runJSON(ctx, in)
+	case tree.FormatYAML:
+		return r.runYAML(ctx, in)
+	case tree.FormatJSONC:
+		return r.runJSONC(ctx, in)
+	case tree.FormatFrontMatter:
+		return r.runFrontMatter(ctx, in)
+	}
+	fns := []func(context.Context, io.Reader) error{
+		r.runJSON,
+		r.runYAML,
+	}
+	var errs FormatErrors
+	for _, fn := range fns {
+		if _, err := in.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		err := fn(ctx, in)
+		if _, ok := err.(*PartialError); ok || err == nil || err == errLimitReached {
+			return err
+		}
+		errs = append(errs, err)
+		derr, ok := err.(*DecodeError)
+		if !ok {
+			break
+		}
+		r.logf("failed to decode as %s: %v", derr.Format, derr.Err)
+	}
+	return errs
+}
+
+func (r *Runner) logf(format string, args ...interface{}) {
+	if r.opts.Logf != nil {
+		r.opts.Logf(format, args...)
+	}
+}
+
+func (r *Runner) runJSON(ctx context.Context, in io.Reader) error {
+	dec := json.NewDecoder(in)
+	for docs := 0; dec.More(); docs++ {
+		offset := dec.InputOffset()
+		n, err := tree.DecodeJSON(dec)
+		if err != nil {
+			if r.opts.Partial && !r.updating && docs > 0 {
+				return r.partialError(&PartialError{Format: tree.FormatJSON, Documents: docs, Offset: offset, Err: err})
+			}
+			return &DecodeError{Format: tree.FormatJSON, Err: err}
+		}
+		if err := r.evaluateDocument(ctx, n, tree.FormatJSON); err != nil {
+			return err
+		}
+	}
+	return r.flushSlurpResults()
+}
+
+// runJSONC evaluates JSON with comments and trailing commas as JSON.
+// The comments are not preserved.
+func (r *Runner) runJSONC(ctx context.Context, in io.Reader) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	return r.runJSON(ctx, bytes.NewReader(tree.StripJSONC(data)))
+}
+
+func (r *Runner) runYAML(ctx context.Context, in io.Reader) error {
+	if r.updating {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		r.yamlHead, r.yamlStart, r.yamlEnd = yamlMarkers(data)
+		if len(r.yamlHead) <= len(data) {
+			r.yamlDocs = yamlDocuments(string(data[len(r.yamlHead):]))
+		}
+		in = bytes.NewReader(data)
+	}
+	in, err := yamlVersionComment(in)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(in)
+	for docs := 0; ; docs++ {
+		n, err := tree.DecodeYAML(dec)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			if r.opts.Partial && !r.updating && docs > 0 {
+				return r.partialError(&PartialError{Format: tree.FormatYAML, Documents: docs, Offset: -1, Err: err})
+			}
+			return &DecodeError{Format: tree.FormatYAML, Err: err}
+		}
+		if err := r.evaluateDocument(ctx, n, tree.FormatYAML); err != nil {
+			return err
+		}
+	}
+	if err := r.flushSlurpResults(); err != nil {
+		return err
+	}
+	if r.yamlEnd && !r.yamlDocEnd && !r.opts.DocumentEnd && r.outputCount > 0 && r.OutputFormat() == tree.FormatYAML {
+		_, err := fmt.Fprintln(r.out, "...")
+		return err
+	}
+	return nil
+}
+
+// yamlMarkers returns the shebang and the directives at the head of the YAML
+// data, whether the first document starts with "---" and whether the last
+// document ends with "...".
+func yamlMarkers(data []byte) (head string, start, end bool) {
+	lines := strings.Split(string(data), "\n")
+	i := 0
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+		i++
+	}
+	for ; i < len(lines) && strings.HasPrefix(lines[i], "%"); i++ {
+	}
+	head = strings.Join(lines[:i], "\n")
+	if head != "" {
+		head += "\n"
+	}
+	for ; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		start = line == "---" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "---\t")
+		break
+	}
+	for j := len(lines) - 1; j >= i; j-- {
+		line := strings.TrimRight(lines[j], " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		end = line == "..." || strings.HasPrefix(line, "... ")
+		break
+	}
+	return head, start, end
+}
+
+// yamlVersionRegexp matches the %YAML directives of YAML 1.x.
+var yamlVersionRegexp = regexp.MustCompile(`^%YAML[ \t]+1\.[0-9]+[ \t\r]*(?:#.*)?\n?$`)
+
+// yamlVersionComment returns the reader of in those %YAML directives of
+// YAML 1.x at the head are turned into comments, because the decoder rejects
+// the versions except 1.1 like "%YAML 1.2". The lines are kept so the lines
+// of the errors are not changed.
+func yamlVersionComment(in io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(in)
+	head := new(bytes.Buffer)
+	for {
+		peek, err := br.Peek(1)
+		if err != nil || (peek[0] != '%' && peek[0] != '#') {
+			break
+		}
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if yamlVersionRegexp.MatchString(line) {
+			line = "#" + line[1:]
+		}
+		head.WriteString(line)
+	}
+	return io.MultiReader(head, br), nil
+}
+
+// yamlDocument is the original text of a YAML document.
+type yamlDocument struct {
+	text string
+	// start and end report whether the text contains "---" and "...".
+	start, end bool
+}
+
+// yamlDocuments splits the YAML data following the head of yamlMarkers into
+// the documents. The comments between the documents are included in the
+// following document, and the trailing comments are included in the last
+// document, so the texts of all documents are joined to the data.
+func yamlDocuments(data string) []yamlDocument {
+	var docs []yamlDocument
+	doc := yamlDocument{}
+	begin, open, content := 0, false, false
+	flush := func(end int) {
+		doc.text = data[begin:end]
+		docs = append(docs, doc)
+		doc = yamlDocument{}
+		begin, open, content = end, false, false
+	}
+	for pos := 0; pos < len(data); {
+		next := strings.IndexByte(data[pos:], '\n') + 1
+		if next == 0 {
+			next = len(data) - pos
+		}
+		line := strings.TrimRight(data[pos:pos+next], " \t\r\n")
+		switch {
+		case line == "---" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "---\t"):
+			if open || content {
+				flush(pos)
+			}
+			doc.start, open = true, true
+			pos += next
+		case line == "..." || strings.HasPrefix(line, "... "):
+			pos += next
+			doc.end = true
+			if open || content {
+				flush(pos)
+			}
+		default:
+			if l := strings.TrimLeft(line, " \t"); l != "" && !strings.HasPrefix(l, "#") {
+				content = true
+			}
+			pos += next
+		}
+	}
+	if open || content {
+		flush(len(data))
+	} else if len(docs) > 0 {
+		docs[len(docs)-1].text += data[begin:]
+	}
+	return docs
+}
+
+// runFrontMatter evaluates the front matter of Markdown. Update writes the
+// first result as the front matter followed by the untouched body.
+func (r *Runner) runFrontMatter(ctx context.Context, in io.Reader) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	fm, err := tree.ParseFrontMatter(data)
+	if err != nil {
+		return &DecodeError{Format: tree.FormatFrontMatter, Err: err}
+	}
+	if !r.updating {
+		if err := r.evaluateDocument(ctx, fm.Node, tree.FormatYAML); err != nil {
+			return err
+		}
+		return r.flushSlurpResults()
+	}
+	r.format = tree.FormatYAML
+	r.docCount++
+	results, err := r.Evaluate(ctx, fm.Node)
+	if err != nil {
+		return err
+	}
+	if len(results) > 0 {
+		fm.Node = results[0]
+	}
+	return fm.Encode(r.out)
+}
+
+// partialError flushes the slurped results of the valid documents and
+// returns err.
+func (r *Runner) partialError(err *PartialError) error {
+	if ferr := r.flushSlurpResults(); ferr != nil {
+		return ferr
+	}
+	return err
+}
+
+func (r *Runner) flushSlurpResults() error {
+	if len(r.slurpResults) == 0 && !(r.opts.Slurp && r.updating) {
+		return nil
+	}
+	defer func() { r.slurpResults = nil }()
+	if r.slurpResults == nil {
+		return r.output(tree.Array{})
+	}
+	return r.output(r.slurpResults)
+}
+
+func (r *Runner) evaluateDocument(ctx context.Context, n tree.Node, format tree.Format) error {
+	err := r.runDocument(ctx, n, format)
+	if err == nil || err == errLimitReached || r.updating || r.opts.OnDocumentError == nil {
+		return err
+	}
+	return r.opts.OnDocumentError(r.docCount, err)
+}
+
+func (r *Runner) runDocument(ctx context.Context, n tree.Node, format tree.Format) error {
+	r.format = format
+	r.docCount++
+	r.logf("decoded document %d as %s", r.docCount, format)
+	if r.opts.Filter != nil && !r.opts.Filter(n) {
+		r.logf("skipped document %d", r.docCount)
+		if r.updating && !r.opts.Slurp {
+			return r.outputOriginal(n)
+		}
+		return nil
+	}
+
+	var orig tree.Node
+	if r.updating && !r.opts.Slurp {
+		orig = tree.CloneDeep(n)
+	}
+	results, err := r.Evaluate(ctx, n)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		if orig != nil {
+			return r.outputOriginal(orig)
+		}
+		return nil
+	}
+	if r.opts.Slurp {
+		r.slurpResults = append(r.slurpResults, results...)
+		return nil
+	}
+	if r.opts.Expand {
+		cb := func(_ interface{}, v tree.Node) error {
+			return r.output(v)
+		}
+		for _, result := range results {
+			if err := result.Each(cb); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(results) == 1 && orig != nil {
+		return r.outputOriginal(results[0])
+	}
+	for _, result := range results {
+		if err := r.output(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evaluate converts the keys, applies the defaults and the edits to n and
+// returns the results of the query that are constructed by the JSON template.
+func (r *Runner) Evaluate(ctx context.Context, n tree.Node) ([]tree.Node, error) {
+	ctx = tree.WithVariables(ctx, r.opts.Variables)
+	ctx = tree.WithTruthiness(ctx, r.opts.Truthiness)
+	if r.opts.SampleSeed != nil {
+		ctx = tree.WithSampleSeed(ctx, *r.opts.SampleSeed)
+	}
+	if r.opts.Keys != tree.KeyCaseNone {
+		var err error
+		if n, err = tree.TransformKeys(n, r.opts.Keys); err != nil {
+			return nil, err
+		}
+	}
+	if r.opts.Defaults != nil {
+		n = tree.ApplyDefaults(n, r.opts.Defaults)
+	}
+	edit := tree.EditContext
+	if r.opts.SOPS {
+		edit = tree.EditSOPSContext
+	}
+	for _, expr := range r.opts.Edits {
+		if err := edit(ctx, &n, expr); err != nil {
+			return nil, err
+		}
+		r.logf("edited %s", expr)
+	}
+	if r.opts.Logf != nil {
+		ctx = tree.WithTraceFunc(ctx, func(q tree.Query, results []tree.Node) {
+			r.logf("query %s: %d results", strings.TrimSpace(q.String()), len(results))
+		})
+	}
+	results, err := tree.FindContext(ctx, n, r.opts.Query)
+	if err != nil || r.jsonTmpl == nil {
+		return results, err
+	}
+	for i, result := range results {
+		rs, err := tree.ExecContext(ctx, r.jsonTmpl, tree.OrNil(result))
+		if err != nil {
+			return nil, err
+		}
+		results[i] = rs[0]
+	}
+	return results, nil
+}
+
+// OutputFormat returns the format of the output of the last document.
+// It returns an empty format if no documents are evaluated.
+func (r *Runner) OutputFormat() tree.Format {
+	if r.opts.OutputFormat != "" {
+		return r.opts.OutputFormat
+	}
+	return r.format
+}
+
+// Results returns the number of the results written by the runner.
+func (r *Runner) Results() int {
+	return r.resultCount
+}
+
+// LastResult returns the last result of the runner. It returns nil if there
+// are no results.
+func (r *Runner) LastResult() tree.Node {
+	return r.lastResult
+}
+
+// outputOriginal outputs n as the original text of the YAML document that
+// is updated if n equals to the document, so the comments and the styles of
+// the unchanged documents are preserved.
+func (r *Runner) outputOriginal(n tree.Node) error {
+	if i := r.docCount - 1; i < len(r.yamlDocs) && !r.opts.Color {
+		doc := r.yamlDocs[i]
+		m, err := tree.DecodeYAML(yaml.NewDecoder(strings.NewReader(doc.text)))
+		if err == nil && reflect.DeepEqual(m, n) {
+			r.yamlDoc = &doc
+			defer func() { r.yamlDoc = nil }()
+		}
+	}
+	return r.output(n)
+}
+
+func (r *Runner) output(n tree.Node) error {
+	r.resultCount++
+	r.lastResult = tree.OrNil(n)
+	if r.outputTmpl != nil && !r.updating && !r.opts.CountOnly {
+		if err := r.writeFile(n); err != nil {
+			return err
+		}
+	} else if !r.opts.CountOnly {
+		if !r.updating {
+			n = tree.TruncateStrings(n, r.opts.TruncateStrings)
+		}
+		if err := r.writeRecord(n); err != nil {
+			return err
+		}
+	}
+	if r.Done() {
+		return errLimitReached
+	}
+	return nil
+}
+
+// outputFilename returns the filename of n by Options.OutputPattern.
+func (r *Runner) outputFilename(n tree.Node) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := r.outputTmpl.Execute(buf, tree.OrNil(n)); err != nil {
+		return "", err
+	}
+	name := buf.String()
+	if name == "" {
+		return "", fmt.Errorf("empty output filename")
+	}
+	if !IsLocalPath(strings.TrimPrefix(name, r.outputBase)) {
+		return "", fmt.Errorf("invalid output filename %q", name)
+	}
+	return name, nil
+}
+
+// outputBase returns the directory of pattern before the first action, so
+// the filenames must be local paths in it.
+func outputBase(pattern string) string {
+	i := strings.Index(pattern, "{{")
+	if i == -1 {
+		i = len(pattern)
+	}
+	return pattern[:strings.LastIndexAny(pattern[:i], "/"+string(filepath.Separator))+1]
+}
+
+// writeFile writes n to the file of Options.OutputPattern. The file is
+// truncated when it is written first by the runner, and appended after that.
+func (r *Runner) writeFile(n tree.Node) error {
+	name, err := r.outputFilename(n)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	count := r.outputFiles[name]
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if count > 0 {
+		flag = os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(name, flag, 0o644)
+	if err != nil {
+		return err
+	}
+	out, outputCount := r.out, r.outputCount
+	r.out, r.outputCount = f, count
+	err = r.writeRecord(n)
+	r.out, r.outputCount = out, outputCount
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	r.outputFiles[name] = count + 1
+	r.logf("wrote %s", name)
+	return nil
+}
+
+// recordSeparator is the prefix of each JSON text of RFC 7464.
+const recordSeparator = 0x1e
+
+// writeRecord writes n with Options.Seq and Options.Separator.
+func (r *Runner) writeRecord(n tree.Node) error {
+	if r.updating || (!r.opts.Seq && r.opts.Separator == "") {
+		return r.write(n)
+	}
+	out := r.out
+	buf := new(bytes.Buffer)
+	r.out = buf
+	err := r.write(n)
+	r.out = out
+	if err != nil {
+		return err
+	}
+	b := buf.Bytes()
+	if r.opts.Separator != "" {
+		b = append(bytes.TrimSuffix(b, []byte{'\n'}), r.opts.Separator...)
+	}
+	if r.opts.Seq {
+		b = append([]byte{recordSeparator}, b...)
+	}
+	_, err = out.Write(b)
+	return err
+}
+
+func (r *Runner) write(n tree.Node) error {
+	n = tree.OrNil(n)
+	if r.opts.Raw && n.Type().IsValue() {
+		// NOTE: CRLF in the values are written as LF to end all lines by LF.
+		if _, err := fmt.Fprintln(r.out, strings.ReplaceAll(n.Value().String(), "\r\n", "\n")); err != nil {
+			return err
+		}
+		return nil
+	}
+	if r.tmpl != nil {
+		if err := r.tmpl.Execute(r.out, n); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(r.out); err != nil {
+			return err
+		}
+		return nil
+	}
+	switch r.OutputFormat() {
+	case tree.FormatYAML:
+		return r.outputYAML(n)
+	case tree.FormatTable:
+		e := &tree.TableEncoder{
+			Out:      r.out,
+			Columns:  r.opts.Columns,
+			MaxWidth: r.opts.ColumnWidth,
+		}
+		return e.Encode(n)
+	case tree.FormatTree:
+		e := &tree.OutlineEncoder{
+			Out:      r.out,
+			MaxWidth: r.opts.ColumnWidth,
+		}
+		return e.Encode(n)
+	case tree.FormatShell:
+		e := &tree.ShellEncoder{Out: r.out}
+		return e.Encode(n)
+	case tree.FormatDot, tree.FormatMermaid:
+		e := &tree.GraphEncoder{
+			Out:      r.out,
+			Format:   r.OutputFormat(),
+			MaxDepth: r.opts.GraphDepth,
+			Values:   r.opts.GraphValues,
+			MaxWidth: r.opts.ColumnWidth,
+		}
+		return e.Encode(n)
+	}
+	return r.outputJSON(n)
+}
+
+func (r *Runner) outputYAML(n tree.Node) error {
+	if r.outputCount == 0 && r.yamlHead != "" {
+		if _, err := io.WriteString(r.out, r.yamlHead); err != nil {
+			return err
+		}
+	}
+	start := (r.outputCount > 0 && (r.updating || r.opts.Separator == "")) || r.opts.DocumentStart || r.yamlStart
+	if r.yamlDoc != nil {
+		start = r.outputCount > 0 && !r.yamlDoc.start
+	}
+	if start {
+		if _, err := fmt.Fprintln(r.out, "---"); err != nil {
+			return err
+		}
+	}
+	r.outputCount++
+	r.yamlDocEnd = r.yamlDoc != nil && r.yamlDoc.end
+	if r.yamlDoc != nil {
+		_, err := io.WriteString(r.out, r.yamlDoc.text)
+		return err
+	}
+	if err := r.encodeYAML(n); err != nil {
+		return err
+	}
+	if r.opts.DocumentEnd {
+		if _, err := fmt.Fprintln(r.out, "..."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) encodeYAML(n tree.Node) error {
+	if r.opts.Color {
+		e := &tree.ColorEncoder{
+			Out:           r.out,
+			IndentSize:    2,
+			EncodeOptions: r.opts.EncodeOptions,
+		}
+		return e.EncodeYAML(n)
+	}
+	return r.opts.EncodeOptions.EncodeYAML(r.out, n)
+}
+
+func (r *Runner) outputJSON(n tree.Node) error {
+	if r.opts.Color {
+		e := &tree.ColorEncoder{
+			Out:           r.out,
+			IndentSize:    2,
+			EncodeOptions: r.opts.EncodeOptions,
+		}
+		return e.EncodeJSON(n)
+	}
+	return r.opts.EncodeOptions.EncodeJSON(r.out, n)
+}
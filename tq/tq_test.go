@@ -0,0 +1,712 @@
+package tq
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jarxorg/tree"
+)
+
+func TestRunner_Run(t *testing.T) {
+	tests := []struct {
+		opts   Options
+		in     string
+		want   string
+		count  int
+		errstr string
+	}{
+		{
+			opts:  Options{Query: ".a"},
+			in:    `{"a":1} {"a":2}`,
+			want:  "1\n2\n",
+			count: 2,
+		}, {
+			opts:  Options{Query: ".a"},
+			in:    "a: 1\n---\na: 2\n",
+			want:  "1\n---\n2\n",
+			count: 2,
+		}, {
+			opts:  Options{Query: ".a", TruncateStrings: 4},
+			in:    `{"a":{"b":"aGVsbG8gd29ybGQ=","c":"abc"}}`,
+			want:  "{\n  \"b\": \"aGVs…(16 bytes)\",\n  \"c\": \"abc\"\n}\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a", TruncateStrings: 4, Raw: true},
+			in:    `{"a":"aGVsbG8gd29ybGQ="}`,
+			want:  "aGVs…(16 bytes)\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a", OutputFormat: tree.FormatJSON},
+			in:    "a: [1, 2]\n",
+			want:  "[\n  1,\n  2\n]\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a", Expand: true, InputFormat: tree.FormatJSON},
+			in:    `{"a":[1,2]}`,
+			want:  "1\n2\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a", Slurp: true},
+			in:    `{"a":1} {"a":2}`,
+			want:  "[\n  1,\n  2\n]\n",
+			count: 2,
+		}, {
+			opts:  Options{Query: ".a", EncodeOptions: tree.EncodeOptions{ASCII: true, NoEscapeHTML: true}},
+			in:    `{"a":"<é>"}`,
+			want:  "\"<\\u00e9>\"\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".users", OutputFormat: tree.FormatTable, Columns: []string{"name", "id"}},
+			in:    `{"users":[{"id":1,"name":"one"},{"id":2,"name":"two"}]}`,
+			want:  "name  id\none   1\ntwo   2\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".env", OutputFormat: tree.FormatShell},
+			in:    "env:\n  HOST: localhost\n  PASS: it's\n",
+			want:  "export HOST='localhost'\nexport PASS='it'\\''s'\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a", OutputFormat: tree.FormatTree},
+			in:    `{"a":{"b":[1]}}`,
+			want:  ". (map, 1 key)\n└── b (array, 1 item)\n    └── [0] (number): 1\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a", OutputFormat: tree.FormatDot, GraphValues: true},
+			in:    `{"a":{"b":1}}`,
+			want:  "digraph tree {\n  node [shape=box];\n  n0 [label=\". (map, 1 key)\"];\n  n1 [label=\"b (number): 1\"];\n  n0 -> n1;\n}\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".name", Raw: true},
+			in:    `{"name":"one"}`,
+			want:  "one\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a", Raw: true},
+			in:    `{"a":"x\r\ny\rz"}`,
+			want:  "x\ny\rz\n",
+			count: 1,
+		}, {
+			opts:  Options{Template: "{{.id}}: {{.name}}", Edits: []string{`.name = "two"`}},
+			in:    `{"id":1,"name":"one"}`,
+			want:  "1: two\n",
+			count: 1,
+		}, {
+			opts: Options{
+				Query:        ".users[]",
+				JSONTemplate: `{"name": .name, "env": $env, "id": .id}`,
+				Variables:    tree.Map{"env": tree.ToValue("prod")},
+			},
+			in:    "users:\n- name: one\n- name: two\n  id: 2\n",
+			want:  "{\n  \"env\": \"prod\",\n  \"id\": null,\n  \"name\": \"one\"\n}\n{\n  \"env\": \"prod\",\n  \"id\": 2,\n  \"name\": \"two\"\n}\n",
+			count: 1,
+		}, {
+			opts:  Options{JSONTemplate: `{"a": .a}`, OutputFormat: tree.FormatYAML},
+			in:    `{"a":"<x>"}`,
+			want:  "a: <x>\n",
+			count: 1,
+		}, {
+			opts: Options{
+				Query:     "$v[0]",
+				Variables: tree.Map{"v": tree.ToArrayValues("x")},
+			},
+			in:    `{}`,
+			want:  "\"x\"\n",
+			count: 1,
+		}, {
+			opts: Options{
+				Query:  ".a",
+				Filter: func(n tree.Node) bool { return n.Get("kind").Value().String() == "B" },
+			},
+			in:    "kind: A\na: 1\n---\nkind: B\na: 2\n",
+			want:  "2\n",
+			count: 2,
+		}, {
+			opts:  Options{Query: ".title", InputFormat: tree.FormatFrontMatter},
+			in:    "---\ntitle: Hello\n---\n# Hello\n",
+			want:  "Hello\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a"},
+			in:    "\xef\xbb\xbf{\"a\":\"\xc3\xa9\"}",
+			want:  "\"é\"\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a", InputFormat: tree.FormatJSON},
+			in:    "\xff\xfe{\x00\"\x00a\x00\"\x00:\x001\x00}\x00",
+			want:  "1\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a", InputFormat: tree.FormatJSONC},
+			in:    "{\"a\": [1,], // comment\n}",
+			want:  "[\n  1\n]\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a", Limit: 1},
+			in:    `{"a":1} {"a":2} {"a":3}`,
+			want:  "1\n",
+			count: 1,
+		}, {
+			opts:  Options{Query: ".a[]", Expand: true, Limit: 3},
+			in:    `{"a":[1,2]} {"a":[3,4]} {"a":[5]}`,
+			want:  "1\n2\n3\n",
+			count: 2,
+		}, {
+			opts:  Options{Query: ".a", CountOnly: true},
+			in:    `{"a":1} {"b":2} {"a":3}`,
+			want:  "",
+			count: 3,
+		}, {
+			opts:   Options{InputFormat: tree.FormatJSON},
+			in:     "a: 1",
+			errstr: "invalid character 'a' looking for beginning of value",
+		}, {
+			opts:   Options{InputFormat: tree.FormatYAML},
+			in:     "a: [",
+			errstr: "yaml: line 1: did not find expected node content",
+		}, {
+			opts:  Options{},
+			in:    "%YAML 1.2\n---\na: 1\n",
+			want:  "a: 1\n",
+			count: 1,
+		}, {
+			opts:   Options{InputFormat: tree.FormatYAML},
+			in:     "# comment\n%YAML 1.2 # version\n---\na: [",
+			errstr: "yaml: line 4: did not find expected node content",
+		}, {
+			opts:   Options{InputFormat: tree.FormatYAML},
+			in:     "%YAML 2.0\n---\na: 1\n",
+			errstr: "yaml: found incompatible YAML document",
+		},
+	}
+	for i, test := range tests {
+		r, err := NewRunner(test.opts)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		buf := new(bytes.Buffer)
+		count, err := r.Run(context.Background(), strings.NewReader(test.in), buf)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+		if count != test.count {
+			t.Errorf("tests[%d] got count %d; want %d", i, count, test.count)
+		}
+	}
+}
+
+func TestRunner_Run_FormatErrors(t *testing.T) {
+	r, err := NewRunner(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Run(context.Background(), strings.NewReader("a: ["), new(bytes.Buffer))
+	errs, ok := err.(FormatErrors)
+	if !ok {
+		t.Fatalf("got %#v; want FormatErrors", err)
+	}
+	var formats []tree.Format
+	for _, err := range errs {
+		formats = append(formats, err.(*DecodeError).Format)
+	}
+	if want := []tree.Format{tree.FormatJSON, tree.FormatYAML}; !reflect.DeepEqual(formats, want) {
+		t.Errorf("got %v; want %v", formats, want)
+	}
+}
+
+func TestRunner_Run_Partial(t *testing.T) {
+	tests := []struct {
+		opts   Options
+		in     string
+		want   string
+		errstr string
+		offset int64
+	}{
+		{
+			opts:   Options{Query: ".a", Partial: true},
+			in:     "{\"a\":1}\n{\"a\":2}\n{\"a\":",
+			want:   "1\n2\n",
+			errstr: "invalid json after 2 documents: EOF",
+			offset: 16,
+		}, {
+			opts:   Options{Query: ".a", Partial: true, Slurp: true},
+			in:     "{\"a\":1} garbage",
+			want:   "[\n  1\n]\n",
+			errstr: "invalid json after 1 document: invalid character 'g' looking for beginning of value",
+			offset: 8,
+		}, {
+			opts:   Options{Query: ".a", Partial: true, InputFormat: tree.FormatYAML},
+			in:     "a: 1\n---\na: [\n",
+			want:   "1\n",
+			errstr: "invalid yaml after 1 document: yaml: line 3: did not find expected node content",
+			offset: -1,
+		}, {
+			opts:   Options{Query: ".a", Partial: true},
+			in:     "a: [",
+			errstr: "invalid character 'a' looking for beginning of value; yaml: line 1: did not find expected node content",
+		},
+	}
+	for i, test := range tests {
+		r, err := NewRunner(test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := new(bytes.Buffer)
+		_, err = r.Run(context.Background(), strings.NewReader(test.in), out)
+		if err == nil || err.Error() != test.errstr {
+			t.Fatalf("tests[%d] got error %v; want %s", i, err, test.errstr)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+		if perr, ok := err.(*PartialError); ok && perr.Offset != test.offset {
+			t.Errorf("tests[%d] got offset %d; want %d", i, perr.Offset, test.offset)
+		} else if !ok && test.offset != 0 {
+			t.Errorf("tests[%d] got %#v; want PartialError", i, err)
+		}
+	}
+}
+
+func TestRunner_Update(t *testing.T) {
+	tests := []struct {
+		opts Options
+		in   string
+		want string
+	}{
+		{
+			opts: Options{Query: ".a"},
+			in:   "a:\n  id: 1\n---\nb:\n  id: 2\n",
+			want: "id: 1\n---\nb:\n  id: 2\n",
+		}, {
+			opts: Options{Query: ".a", Slurp: true},
+			in:   "b: 1\n",
+			want: "[]\n",
+		}, {
+			opts: Options{Edits: []string{".ok = true"}},
+			in:   `{"id":1}`,
+			want: "{\n  \"id\": 1,\n  \"ok\": true\n}\n",
+		}, {
+			opts: Options{
+				Edits:  []string{".a = 0"},
+				Filter: func(n tree.Node) bool { return n.Get("kind").Value().String() == "B" },
+			},
+			in:   "kind: A\na: 1\n---\nkind: B\na: 2\n",
+			want: "kind: A\na: 1\n---\na: 0\nkind: B\n",
+		}, {
+			opts: Options{Edits: []string{".user = \"root\""}, SOPS: true},
+			in:   "user: admin\npassword: ENC[AES256_GCM,data:x=,iv:y=,tag:z=,type:str]\nsops:\n  version: 3.7.3\n",
+			want: "password: ENC[AES256_GCM,data:x=,iv:y=,tag:z=,type:str]\nsops:\n  version: 3.7.3\nuser: root\n",
+		}, {
+			opts: Options{Edits: []string{".draft = false"}, InputFormat: tree.FormatFrontMatter},
+			in:   "---\ndraft: true\n---\n# Hello\n\n---\n",
+			want: "---\ndraft: false\n---\n# Hello\n\n---\n",
+		}, {
+			opts: Options{Edits: []string{".a = 2"}},
+			in:   "%YAML 1.2\n---\na: 1\n",
+			want: "%YAML 1.2\n---\na: 2\n",
+		}, {
+			opts: Options{Edits: []string{".a = 2"}},
+			in:   "#!/usr/bin/env app\n%YAML 1.1\n%TAG ! tag:example.com,2000:\n# comment\n---\na: 1\n...\n",
+			want: "#!/usr/bin/env app\n%YAML 1.1\n%TAG ! tag:example.com,2000:\n---\na: 2\n...\n",
+		}, {
+			opts: Options{Edits: []string{".x = 1"}},
+			in:   "e: 2001-12-14\nx: 0\n",
+			want: "e: \"2001-12-14\"\nx: 1\n",
+		}, {
+			opts: Options{Edits: []string{".a = 2"}},
+			in:   "--- # first\na: 1\n---\na: 3\n",
+			want: "---\na: 2\n---\na: 2\n",
+		}, {
+			opts: Options{Edits: []string{".a = 2"}},
+			in:   "a: 1\r\nb: [1]\r\n",
+			want: "a: 2\r\nb:\r\n  - 1\r\n",
+		}, {
+			opts: Options{Edits: []string{".a = 2"}, DocumentEnd: true},
+			in:   "a: 1\n...\n---\na: 3\n...\n",
+			want: "a: 2\n...\n---\na: 2\n...\n",
+		}, {
+			opts: Options{
+				Edits:  []string{".a = 2"},
+				Filter: func(n tree.Node) bool { return n.Get("kind").Value().String() == "B" },
+			},
+			in:   "# A\nkind: A # the kind\nlist: [1, 2]\n---\n# B\nkind: B\na: 1\n",
+			want: "# A\nkind: A # the kind\nlist: [1, 2]\n---\na: 2\nkind: B\n",
+		}, {
+			opts: Options{Edits: []string{".a = 1"}},
+			in:   "---\na: 1 # one\n...\n# two\n---\na: 2\n...\n",
+			want: "---\na: 1 # one\n...\n---\na: 1\n...\n",
+		}, {
+			opts: Options{Edits: []string{".a = 1"}},
+			in:   "a: 2\n---\n# one\na: 1 # one\n\n# end\n",
+			want: "a: 1\n---\n# one\na: 1 # one\n\n# end\n",
+		}, {
+			opts: Options{Edits: []string{".a = 1"}},
+			in:   "a: 1 # one\r\n---\r\na: 2\r\n",
+			want: "a: 1 # one\r\n---\r\na: 1\r\n",
+		}, {
+			opts: Options{Query: ".a"},
+			in:   "a: {id: 1}\n---\n# b\nb: {id: 2}\n---\n---\n",
+			want: "id: 1\n---\n# b\nb: {id: 2}\n---\n---\n",
+		},
+	}
+	for i, test := range tests {
+		r, err := NewRunner(test.opts)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		buf := new(bytes.Buffer)
+		if _, err := r.Update(context.Background(), strings.NewReader(test.in), buf); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestRunner_DocumentMarkers(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{
+			opts: Options{Query: ".[]", OutputFormat: tree.FormatYAML},
+			want: "1\n---\n2\n",
+		}, {
+			opts: Options{Query: ".[]", OutputFormat: tree.FormatYAML, DocumentStart: true},
+			want: "---\n1\n---\n2\n",
+		}, {
+			opts: Options{Query: ".[]", OutputFormat: tree.FormatYAML, DocumentStart: true, DocumentEnd: true},
+			want: "---\n1\n...\n---\n2\n...\n",
+		}, {
+			opts: Options{Query: ".[]", DocumentStart: true, DocumentEnd: true},
+			want: "1\n2\n",
+		},
+	}
+	for i, test := range tests {
+		r, err := NewRunner(test.opts)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		buf := new(bytes.Buffer)
+		if _, err := r.Run(context.Background(), strings.NewReader("[1, 2]"), buf); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestRunner_Defaults(t *testing.T) {
+	r, err := NewRunner(Options{
+		Edits:    []string{".b.d = 4"},
+		Defaults: tree.Map{"a": tree.ToValue(1), "b": tree.Map{"c": tree.ToValue(2)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if _, err := r.Run(context.Background(), strings.NewReader(`{"a": 0}`), buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"a\": 0,\n  \"b\": {\n    \"c\": 2,\n    \"d\": 4\n  }\n}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestRunner_Keys(t *testing.T) {
+	r, err := NewRunner(Options{
+		Query:    ".user_name",
+		Keys:     tree.KeyCaseSnake,
+		Defaults: tree.Map{"user_id": tree.ToValue(1)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := r.Evaluate(context.Background(), tree.Map{"userName": tree.ToValue("a")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []tree.Node{tree.ToValue("a")}; !reflect.DeepEqual(rs, want) {
+		t.Errorf("got %v; want %v", rs, want)
+	}
+	if _, err := r.Evaluate(context.Background(), tree.Map{"userName": tree.ToValue("a"), "user_name": tree.ToValue("b")}); err == nil {
+		t.Errorf("no error")
+	}
+}
+
+func TestRunner_OnDocumentError(t *testing.T) {
+	var errs []string
+	r, err := NewRunner(Options{
+		Query: ".a",
+		Edits: []string{".a -= 1"},
+		OnDocumentError: func(doc int, err error) error {
+			errs = append(errs, fmt.Sprintf("%d: %v", doc, err))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	n, err := r.Run(context.Background(), strings.NewReader(`{"a":[1,2]} {"a":1} {"a":[1]}`), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("got %d documents; want 3", n)
+	}
+	if got, want := out.String(), "[\n  2\n]\n[]\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if want := []string{"2: cannot remove from .a"}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("got errors %v; want %v", errs, want)
+	}
+}
+
+func TestRunner_Separator(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{
+			opts: Options{Query: ".[]", Seq: true},
+			want: "\x1e1\n\x1e\"a\"\n",
+		}, {
+			opts: Options{Query: ".[]", Separator: "\x00"},
+			want: "1\x00\"a\"\x00",
+		}, {
+			opts: Options{Query: ".[]", Separator: "\x00", Raw: true},
+			want: "1\x00a\x00",
+		}, {
+			opts: Options{Query: ".[]", Separator: ";", OutputFormat: tree.FormatYAML},
+			want: "1;a;",
+		}, {
+			opts: Options{Query: ".", Seq: true, Separator: "\n\n"},
+			want: "\x1e[\n  1,\n  \"a\"\n]\n\n",
+		},
+	}
+	for i, test := range tests {
+		r, err := NewRunner(test.opts)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		buf := new(bytes.Buffer)
+		if _, err := r.Run(context.Background(), strings.NewReader(`[1, "a"]`), buf); err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.want)
+		}
+	}
+
+	r, err := NewRunner(Options{Seq: true, Separator: ";"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if _, err := r.Update(context.Background(), strings.NewReader("a: 1\n---\nb: 2\n"), buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "a: 1\n---\nb: 2\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestRunner_LastResult(t *testing.T) {
+	r, err := NewRunner(Options{Query: ".[]", CountOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.LastResult(); got != nil {
+		t.Errorf("got %v; want nil", got)
+	}
+	if _, err := r.Run(context.Background(), strings.NewReader(`[1, null]`), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.LastResult(); got != tree.Nil {
+		t.Errorf("got %v; want null", got)
+	}
+}
+
+func TestRunner_Evaluate(t *testing.T) {
+	var logs []string
+	r, err := NewRunner(Options{
+		Query: ".users[.id > 1].name",
+		Edits: []string{`.users += {"id": 3, "name": "three"}`},
+		Logf: func(format string, args ...interface{}) {
+			logs = append(logs, format)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := tree.Map{
+		"users": tree.Array{
+			tree.Map{"id": tree.ToValue(1), "name": tree.ToValue("one")},
+			tree.Map{"id": tree.ToValue(2), "name": tree.ToValue("two")},
+		},
+	}
+	got, err := r.Evaluate(context.Background(), n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []tree.Node{tree.ToValue("two"), tree.ToValue("three")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if len(logs) != 4 {
+		t.Errorf("got logs %v; want 4 logs", logs)
+	}
+}
+
+func TestNewRunner_Errors(t *testing.T) {
+	tests := []struct {
+		opts   Options
+		errstr string
+	}{
+		{
+			opts:   Options{Query: ".a["},
+			errstr: `syntax error: no right brackets: ".a["`,
+		}, {
+			opts:   Options{Template: "{{"},
+			errstr: "template: :1: unclosed action",
+		}, {
+			opts:   Options{Template: "{{.}}", Escape: "sh"},
+			errstr: `unknown escape "sh"`,
+		}, {
+			opts:   Options{JSONTemplate: ".name"},
+			errstr: `JSON template must be an object like {"key": .query}: ".name"`,
+		}, {
+			opts:   Options{JSONTemplate: `{"name": .name`},
+			errstr: `syntax error: no right brackets: "{\"name\": .name"`,
+		}, {
+			opts:   Options{OutputPattern: "{{"},
+			errstr: "template: :1: unclosed action",
+		},
+	}
+	for i, test := range tests {
+		_, err := NewRunner(test.opts)
+		if err == nil {
+			t.Fatalf("tests[%d] no error", i)
+		}
+		if err.Error() != test.errstr {
+			t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+		}
+	}
+}
+
+func TestRunner_OutputPattern(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRunner(Options{
+		Query:         ".items[]",
+		OutputPattern: filepath.Join(dir, "{{.kind}}/{{.metadata.name}}.yaml"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := `items:
+  - {kind: Service, metadata: {name: a}}
+  - {kind: Deployment, metadata: {name: a}}
+  - {kind: Service, metadata: {name: a}, spec: {}}
+`
+	out := new(bytes.Buffer)
+	if _, err := r.Run(context.Background(), strings.NewReader(in), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("got output %q", out.String())
+	}
+	if got := r.Results(); got != 3 {
+		t.Errorf("got %d results; want 3", got)
+	}
+	wants := map[string]string{
+		"Service/a.yaml": `kind: Service
+metadata:
+  name: a
+---
+kind: Service
+metadata:
+  name: a
+spec: {}
+`,
+		"Deployment/a.yaml": `kind: Deployment
+metadata:
+  name: a
+`,
+	}
+	for name, want := range wants {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s got %q; want %q", name, got, want)
+		}
+	}
+}
+
+func TestRunner_OutputPattern_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		pattern string
+		in      string
+		errstr  string
+	}{
+		{
+			pattern: "{{.name}}.json",
+			in:      `{"id":1}`,
+			errstr:  `template: :1:2: executing "" at <.name>: map has no entry for key "name"`,
+		}, {
+			pattern: "{{.name}}",
+			in:      `{"name":""}`,
+			errstr:  "empty output filename",
+		}, {
+			pattern: dir + "/{{.name}}.json",
+			in:      `{"name":"../x"}`,
+			errstr:  "invalid output filename " + strconv.Quote(dir+"/../x.json"),
+		}, {
+			pattern: dir + "/{{.name}}.json",
+			in:      `{"name":"/tmp/x"}`,
+			errstr:  "invalid output filename " + strconv.Quote(dir+"//tmp/x.json"),
+		}, {
+			pattern: "{{.name}}.json",
+			in:      `{"name":"/tmp/x"}`,
+			errstr:  `invalid output filename "/tmp/x.json"`,
+		}, {
+			pattern: "out/{{.name}}.json",
+			in:      `{"name":"a/../../x"}`,
+			errstr:  `invalid output filename "out/a/../../x.json"`,
+		},
+	}
+	for i, test := range tests {
+		r, err := NewRunner(Options{OutputPattern: test.pattern})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.Run(context.Background(), strings.NewReader(test.in), io.Discard)
+		if err == nil || err.Error() != test.errstr {
+			t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package treetest
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/jarxorg/tree"
+)
+
+// DefaultAlphabet is the default alphabet of the generated keys and strings.
+// It has the characters those need escapes or quotes in JSON and YAML and
+// the multibyte characters.
+const DefaultAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 _-.:#\"'\\<>&\t\néあ日本😀"
+
+// GenerateOptions are the options of Generate. The zero values use the
+// defaults.
+type GenerateOptions struct {
+	// MaxDepth is the max depth of the maps and the arrays. The default is 3.
+	// The negative depth generates only the values.
+	MaxDepth int
+	// MaxLen is the max number of the elements of the maps and the arrays.
+	// The default is 4.
+	MaxLen int
+	// MaxStringLen is the max length of the keys and the strings. The
+	// default is 8.
+	MaxStringLen int
+	// Alphabet is the characters of the keys and the strings. The default
+	// is DefaultAlphabet.
+	Alphabet string
+	// MapWeight, ArrayWeight, StringWeight, NumberWeight, BoolWeight and
+	// NullWeight are the relative frequencies of the types. The weights of
+	// the maps and the arrays are ignored at the max depth. All types are
+	// equally frequent if all weights are zero.
+	MapWeight    int
+	ArrayWeight  int
+	StringWeight int
+	NumberWeight int
+	BoolWeight   int
+	NullWeight   int
+	// Integers generates only integer numbers. Otherwise the half of the
+	// numbers are floats.
+	Integers bool
+}
+
+// Generate returns a random node by r for the property-based tests, for
+// example that encoding and decoding a node returns the equal node. The same
+// seed of r generates the same node.
+func Generate(r *rand.Rand, opts GenerateOptions) tree.Node {
+	g := &generator{r: r, opts: opts.withDefaults()}
+	return g.node(0)
+}
+
+func (o GenerateOptions) withDefaults() GenerateOptions {
+	if o.MaxDepth == 0 {
+		o.MaxDepth = 3
+	}
+	if o.MaxLen == 0 {
+		o.MaxLen = 4
+	}
+	if o.MaxStringLen == 0 {
+		o.MaxStringLen = 8
+	}
+	if o.Alphabet == "" {
+		o.Alphabet = DefaultAlphabet
+	}
+	if o.MapWeight == 0 && o.ArrayWeight == 0 && o.StringWeight == 0 &&
+		o.NumberWeight == 0 && o.BoolWeight == 0 && o.NullWeight == 0 {
+		o.MapWeight, o.ArrayWeight, o.StringWeight = 1, 1, 1
+		o.NumberWeight, o.BoolWeight, o.NullWeight = 1, 1, 1
+	}
+	return o
+}
+
+type generator struct {
+	r     *rand.Rand
+	opts  GenerateOptions
+	runes []rune
+}
+
+func (g *generator) node(depth int) tree.Node {
+	o := g.opts
+	mapWeight, arrayWeight := o.MapWeight, o.ArrayWeight
+	if depth >= o.MaxDepth {
+		mapWeight, arrayWeight = 0, 0
+	}
+	weights := []int{mapWeight, arrayWeight, o.StringWeight, o.NumberWeight, o.BoolWeight, o.NullWeight}
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		return tree.Nil
+	}
+	x := g.r.Intn(total)
+	kind := 0
+	for ; x >= weights[kind]; kind++ {
+		x -= weights[kind]
+	}
+	switch kind {
+	case 0:
+		m := tree.Map{}
+		for i := g.r.Intn(o.MaxLen + 1); i > 0; i-- {
+			m[g.string()] = g.node(depth + 1)
+		}
+		return m
+	case 1:
+		a := make(tree.Array, g.r.Intn(o.MaxLen+1))
+		for i := range a {
+			a[i] = g.node(depth + 1)
+		}
+		return a
+	case 2:
+		return tree.StringValue(g.string())
+	case 3:
+		return tree.NumberValue(g.number())
+	case 4:
+		return tree.BoolValue(g.r.Intn(2) == 0)
+	}
+	return tree.Nil
+}
+
+func (g *generator) string() string {
+	if g.runes == nil {
+		g.runes = []rune(g.opts.Alphabet)
+	}
+	rs := make([]rune, g.r.Intn(g.opts.MaxStringLen+1))
+	for i := range rs {
+		rs[i] = g.runes[g.r.Intn(len(g.runes))]
+	}
+	return string(rs)
+}
+
+func (g *generator) number() float64 {
+	if g.opts.Integers || g.r.Intn(2) == 0 {
+		return float64(g.r.Intn(2001) - 1000)
+	}
+	// NOTE: The floats are rounded to 6 digits to be readable in failures.
+	f := g.r.NormFloat64() * 1000
+	return math.Round(f*1e6) / 1e6
+}
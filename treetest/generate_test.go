@@ -0,0 +1,119 @@
+package treetest
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarxorg/tree"
+)
+
+func TestGenerate(t *testing.T) {
+	a := Generate(rand.New(rand.NewSource(1)), GenerateOptions{})
+	b := Generate(rand.New(rand.NewSource(1)), GenerateOptions{})
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("got %v and %v by the same seed", a, b)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		opts  GenerateOptions
+		check func(n tree.Node, keys []interface{}) bool
+	}{
+		{
+			opts: GenerateOptions{MaxDepth: 2, MapWeight: 1, ArrayWeight: 1, NumberWeight: 1},
+			check: func(n tree.Node, keys []interface{}) bool {
+				return len(keys) <= 2 && (len(keys) < 2 || n.Type().IsValue())
+			},
+		}, {
+			opts: GenerateOptions{MaxDepth: -1},
+			check: func(n tree.Node, keys []interface{}) bool {
+				return n.Type().IsValue()
+			},
+		}, {
+			opts: GenerateOptions{MaxLen: 2, MaxStringLen: 3, Alphabet: "ab", MapWeight: 1, StringWeight: 1},
+			check: func(n tree.Node, keys []interface{}) bool {
+				if n.Type().IsMap() {
+					return len(n.Map()) <= 2
+				}
+				s := n.Value().String()
+				return n.Type().IsStringValue() && len(s) <= 3 && len(s) == len([]rune(s)) && !containsOther(s, "ab")
+			},
+		}, {
+			opts: GenerateOptions{NumberWeight: 1, Integers: true},
+			check: func(n tree.Node, keys []interface{}) bool {
+				f := n.Value().Float64()
+				return n.Type().IsNumberValue() && f == float64(int(f))
+			},
+		},
+	}
+	for i, test := range tests {
+		for j := 0; j < 100; j++ {
+			n := Generate(r, test.opts)
+			tree.Walk(n, func(n tree.Node, keys []interface{}) error {
+				if !test.check(n, keys) {
+					t.Errorf("tests[%d] got %v at %v", i, n, keys)
+				}
+				return nil
+			})
+		}
+	}
+}
+
+func containsOther(s, alphabet string) bool {
+	for _, c := range s {
+		found := false
+		for _, a := range alphabet {
+			found = found || a == c
+		}
+		if !found {
+			return true
+		}
+	}
+	return false
+}
+
+// TestGenerate_Properties tests the properties of the encoders and the
+// merges with the generated nodes.
+func TestGenerate_Properties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		n := Generate(r, GenerateOptions{})
+
+		b, err := tree.MarshalJSON(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FromJSON(t, string(b)); !AssertEqual(t, n, got) {
+			t.Fatalf("JSON %s", b)
+		}
+
+		// NOTE: yaml.v3 drops the leading line breaks of the block scalars.
+		yn := Generate(r, GenerateOptions{Alphabet: strings.ReplaceAll(DefaultAlphabet, "\n", "")})
+		y, err := tree.MarshalYAML(yn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FromYAML(t, string(y)); !AssertEqual(t, yn, got) {
+			t.Fatalf("YAML %s", y)
+		}
+
+		c, err := tree.MarshalCanonicalJSON(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c2, _ := tree.MarshalCanonicalJSON(FromJSON(t, string(c))); string(c2) != string(c) {
+			t.Fatalf("canonical JSON %s; %s", c, c2)
+		}
+
+		if !AssertEqual(t, n, tree.CloneDeep(n)) {
+			t.Fatalf("CloneDeep %v", n)
+		}
+		other := Generate(r, GenerateOptions{})
+		merged := tree.Merge(tree.CloneDeep(n), tree.CloneDeep(other), tree.MergeOptionReplaceMap|tree.MergeOptionReplaceArray)
+		if !AssertEqual(t, other, merged) {
+			t.Fatalf("Merge %v and %v", n, other)
+		}
+	}
+}
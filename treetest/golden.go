@@ -0,0 +1,87 @@
+package treetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jarxorg/tree"
+)
+
+// UpdateEnv is the environment variable that makes Golden write the golden
+// files if it is set to true like "1".
+//
+// NOTE: It is not a flag so that the tests that import this package can
+// define their own -update flag.
+const UpdateEnv = "TREETEST_UPDATE"
+
+// Golden compares n with the snapshot of the golden file
+// testdata/<name>.json written in the canonical JSON indented by two spaces.
+// It reports the differences by the paths as an error of the test. The
+// golden file is written instead if the test runs with UpdateEnv.
+//
+//	TREETEST_UPDATE=1 go test .
+func Golden(t TB, name string, n tree.Node) {
+	t.Helper()
+	update, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+	golden(t, filepath.Join("testdata", name+".json"), n, update)
+}
+
+func golden(t TB, filename string, n tree.Node, update bool) {
+	t.Helper()
+	got, err := goldenJSON(n)
+	if err != nil {
+		t.Fatalf("treetest.Golden: %v", err)
+		return
+	}
+	if update {
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatalf("treetest.Golden: %v", err)
+			return
+		}
+		if err := os.WriteFile(filename, got, 0o644); err != nil {
+			t.Fatalf("treetest.Golden: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("treetest.Golden: %s does not exist, run the test with TREETEST_UPDATE=1 to write it", filename)
+			return
+		}
+		t.Fatalf("treetest.Golden: %v", err)
+		return
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+	wn, err := tree.UnmarshalJSON(want)
+	if err != nil {
+		t.Fatalf("treetest.Golden: %s: %v", filename, err)
+		return
+	}
+	diffs := Diff(wn, n)
+	if len(diffs) == 0 {
+		diffs = []string{"the formatting differs"}
+	}
+	t.Errorf("%s differs (run the test with TREETEST_UPDATE=1 to update it):\n  %s", filename, strings.Join(diffs, "\n  "))
+}
+
+// goldenJSON returns the canonical JSON of n indented by two spaces.
+func goldenJSON(n tree.Node) ([]byte, error) {
+	b, err := tree.MarshalCanonicalJSON(tree.OrNil(n))
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := json.Indent(buf, b, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
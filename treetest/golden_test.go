@@ -0,0 +1,76 @@
+package treetest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarxorg/tree"
+)
+
+func TestGolden(t *testing.T) {
+	Golden(t, "golden", tree.Map{
+		"name":     tree.ToValue("web"),
+		"replicas": tree.ToValue(2),
+		"ports":    tree.ToArrayValues(80, 443),
+		"html":     tree.ToValue("<a>"),
+	})
+}
+
+func TestGolden_Update(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "testdata", "config.json")
+	n := FromJSON(t, `{"b": [1, {"c": null}], "a": "x"}`)
+
+	ft := &fakeTB{}
+	golden(ft, filename, n, false)
+	if len(ft.fatals) != 1 || !strings.Contains(ft.fatals[0], "does not exist, run the test with TREETEST_UPDATE=1") {
+		t.Errorf("got fatals %q", ft.fatals)
+	}
+
+	golden(t, filename, n, true)
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "a": "x",
+  "b": [
+    1,
+    {
+      "c": null
+    }
+  ]
+}
+`
+	if string(got) != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+	golden(t, filename, n, false)
+
+	ft = &fakeTB{}
+	golden(ft, filename, FromJSON(t, `{"b": [2, {"c": null}], "a": "x"}`), false)
+	wantErrors := []string{filename + " differs (run the test with TREETEST_UPDATE=1 to update it):\n  .b[0]: got 2, want 1"}
+	if !reflect.DeepEqual(ft.errors, wantErrors) {
+		t.Errorf("got %q; want %q", ft.errors, wantErrors)
+	}
+
+	if err := os.WriteFile(filename, []byte(`{"a":"x","b":[1,{"c":null}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ft = &fakeTB{}
+	golden(ft, filename, n, false)
+	wantErrors = []string{filename + " differs (run the test with TREETEST_UPDATE=1 to update it):\n  the formatting differs"}
+	if !reflect.DeepEqual(ft.errors, wantErrors) {
+		t.Errorf("got %q; want %q", ft.errors, wantErrors)
+	}
+}
+
+func TestGolden_NoFlag(t *testing.T) {
+	// NOTE: The tests that import this package can define their own -update flag.
+	if f := flag.Lookup("update"); f != nil {
+		t.Errorf("got flag %q", f.Name)
+	}
+}
@@ -0,0 +1,159 @@
+// Package treetest provides helpers for the tests that compare the
+// structures of the documents: building nodes from JSON and YAML literals
+// and reporting the differences of the nodes by the paths.
+package treetest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jarxorg/tree"
+)
+
+// TB is the subset of testing.TB that the helpers use.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// FromJSON returns the node of the JSON string. It stops the test if the
+// string is not a valid JSON.
+func FromJSON(t TB, s string) tree.Node {
+	t.Helper()
+	n, err := tree.UnmarshalJSON([]byte(s))
+	if err != nil {
+		t.Fatalf("treetest.FromJSON: %v", err)
+		return nil
+	}
+	return n
+}
+
+// FromYAML returns the node of the YAML string. It stops the test if the
+// string is not a valid YAML.
+func FromYAML(t TB, s string) tree.Node {
+	t.Helper()
+	n, err := tree.UnmarshalYAML([]byte(s))
+	if err != nil {
+		t.Fatalf("treetest.FromYAML: %v", err)
+		return nil
+	}
+	return n
+}
+
+// AssertEqual reports the differences of the nodes by the paths as an error
+// of the test and returns false if got is not equal to want.
+func AssertEqual(t TB, want, got tree.Node) bool {
+	t.Helper()
+	diffs := Diff(want, got)
+	if len(diffs) == 0 {
+		return true
+	}
+	t.Errorf("nodes differ:\n  %s", strings.Join(diffs, "\n  "))
+	return false
+}
+
+// Diff returns the differences of the nodes by the paths like
+// `.users[0].name: got "a", want "b"`. The maps and the arrays are compared
+// by the keys and the indexes, and the values are compared as JSON, so the
+// nil and Nil are equal. It returns nil if the nodes are equal.
+func Diff(want, got tree.Node) []string {
+	var diffs []string
+	diff(&diffs, nil, tree.OrNil(want), tree.OrNil(got))
+	return diffs
+}
+
+func diff(diffs *[]string, keys []interface{}, want, got tree.Node) {
+	switch {
+	case want.Type().IsMap() && got.Type().IsMap():
+		wm, gm := want.Map(), got.Map()
+		for _, k := range unionKeys(wm, gm) {
+			w, wok := wm[k]
+			g, gok := gm[k]
+			ks := appendKey(keys, k)
+			switch {
+			case !gok:
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing, want %s", path(ks), jsonString(w)))
+			case !wok:
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected %s", path(ks), jsonString(g)))
+			default:
+				diff(diffs, ks, tree.OrNil(w), tree.OrNil(g))
+			}
+		}
+	case want.Type().IsArray() && got.Type().IsArray():
+		wa, ga := want.Array(), got.Array()
+		for i := 0; i < len(wa) || i < len(ga); i++ {
+			ks := appendKey(keys, i)
+			switch {
+			case i >= len(ga):
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing, want %s", path(ks), jsonString(wa[i])))
+			case i >= len(wa):
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected %s", path(ks), jsonString(ga[i])))
+			default:
+				diff(diffs, ks, tree.OrNil(wa[i]), tree.OrNil(ga[i]))
+			}
+		}
+	default:
+		if w, g := jsonString(want), jsonString(got); w != g {
+			*diffs = append(*diffs, fmt.Sprintf("%s: got %s, want %s", path(keys), g, w))
+		}
+	}
+}
+
+func unionKeys(a, b tree.Map) []string {
+	m := tree.Map{}
+	for k := range a {
+		m[k] = nil
+	}
+	for k := range b {
+		m[k] = nil
+	}
+	return m.Keys()
+}
+
+func appendKey(keys []interface{}, key interface{}) []interface{} {
+	ks := make([]interface{}, len(keys)+1)
+	copy(ks, keys)
+	ks[len(keys)] = key
+	return ks
+}
+
+var plainKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// path returns the path of the keys like `.a[0]."b c"`.
+func path(keys []interface{}) string {
+	if len(keys) == 0 {
+		return "."
+	}
+	b := new(strings.Builder)
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			b.WriteByte('.')
+			if plainKeyRegexp.MatchString(k) {
+				b.WriteString(k)
+			} else {
+				b.WriteString(strconv.Quote(k))
+			}
+		case int:
+			b.WriteString("[" + strconv.Itoa(k) + "]")
+		}
+	}
+	return b.String()
+}
+
+// jsonMaxLen is the max length of the JSON of the nodes in the differences.
+const jsonMaxLen = 80
+
+func jsonString(n tree.Node) string {
+	b, err := tree.MarshalJSON(tree.OrNil(n))
+	if err != nil {
+		return fmt.Sprintf("%v", n)
+	}
+	if rs := []rune(string(b)); len(rs) > jsonMaxLen {
+		return string(rs[:jsonMaxLen-3]) + "..."
+	}
+	return string(b)
+}
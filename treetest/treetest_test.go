@@ -0,0 +1,109 @@
+package treetest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarxorg/tree"
+)
+
+// fakeTB records the messages of the helpers.
+type fakeTB struct {
+	errors []string
+	fatals []string
+}
+
+func (t *fakeTB) Helper() {}
+
+func (t *fakeTB) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeTB) Fatalf(format string, args ...interface{}) {
+	t.fatals = append(t.fatals, fmt.Sprintf(format, args...))
+}
+
+func TestFromJSON(t *testing.T) {
+	got := FromJSON(t, `{"a": [1, "b", null]}`)
+	want := tree.Map{"a": tree.ToArrayValues(1, "b", nil)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	ft := &fakeTB{}
+	if got := FromJSON(ft, `{`); got != nil || len(ft.fatals) != 1 {
+		t.Errorf("got %v and fatals %v", got, ft.fatals)
+	}
+}
+
+func TestFromYAML(t *testing.T) {
+	got := FromYAML(t, "a:\n  - 1\n  - b\n")
+	want := tree.Map{"a": tree.ToArrayValues(1, "b")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	ft := &fakeTB{}
+	if got := FromYAML(ft, "a: [1"); got != nil || len(ft.fatals) != 1 {
+		t.Errorf("got %v and fatals %v", got, ft.fatals)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		want, got tree.Node
+		diffs     []string
+	}{
+		{
+			want: FromJSON(t, `{"a": [1, {"b": 2}], "c": null}`),
+			got:  FromJSON(t, `{"a": [1, {"b": 2.0}], "c": null}`),
+		}, {
+			want: nil,
+			got:  tree.Nil,
+		}, {
+			want: FromJSON(t, `{"users": [{"name": "a", "age": 1}, {"name": "b"}], "x y": true}`),
+			got:  FromJSON(t, `{"users": [{"name": "x", "id": 1}], "x y": "true"}`),
+			diffs: []string{
+				`.users[0].age: missing, want 1`,
+				`.users[0].id: unexpected 1`,
+				`.users[0].name: got "x", want "a"`,
+				`.users[1]: missing, want {"name":"b"}`,
+				`."x y": got "true", want true`,
+			},
+		}, {
+			want:  tree.ToArrayValues(1),
+			got:   tree.ToArrayValues(1, 2),
+			diffs: []string{`[1]: unexpected 2`},
+		}, {
+			want:  tree.Map{"a": tree.ToValue(1)},
+			got:   tree.ToArrayValues(1),
+			diffs: []string{`.: got [1], want {"a":1}`},
+		}, {
+			want:  tree.ToValue(strings.Repeat("a", 100)),
+			got:   tree.ToValue("b"),
+			diffs: []string{`.: got "b", want "` + strings.Repeat("a", 76) + `...`},
+		},
+	}
+	for i, test := range tests {
+		if got := Diff(test.want, test.got); !reflect.DeepEqual(got, test.diffs) {
+			t.Errorf("tests[%d] got %q; want %q", i, got, test.diffs)
+		}
+	}
+}
+
+func TestAssertEqual(t *testing.T) {
+	if !AssertEqual(t, FromJSON(t, `{"a": 1}`), tree.Map{"a": tree.ToValue(1)}) {
+		t.Errorf("got false")
+	}
+
+	ft := &fakeTB{}
+	if AssertEqual(ft, FromJSON(t, `{"a": 1, "b": 2}`), FromJSON(t, `{"a": 2}`)) {
+		t.Errorf("got true")
+	}
+	want := []string{"nodes differ:\n  .a: got 2, want 1\n  .b: missing, want 2"}
+	if !reflect.DeepEqual(ft.errors, want) {
+		t.Errorf("got %q; want %q", ft.errors, want)
+	}
+}
@@ -0,0 +1,47 @@
+package tree
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// TruncateStrings returns a copy of n that the string values longer than
+// max characters are cut to max characters followed by the ellipsis marker
+// like "…(12345 bytes)" of the original length. The keys of maps and the
+// other values are not changed. It returns n as is if max is not positive.
+func TruncateStrings(n Node, max int) Node {
+	if n == nil || max <= 0 {
+		return n
+	}
+	switch n.Type() {
+	case TypeMap:
+		m := n.Map()
+		x := make(Map, len(m))
+		for k, v := range m {
+			x[k] = TruncateStrings(v, max)
+		}
+		return x
+	case TypeArray:
+		a := n.Array()
+		x := make(Array, len(a))
+		for i, v := range a {
+			x[i] = TruncateStrings(v, max)
+		}
+		return x
+	}
+	if !n.Type().IsStringValue() {
+		return n
+	}
+	s := n.Value().String()
+	if utf8.RuneCountInString(s) <= max {
+		return n
+	}
+	i, count := 0, 0
+	for i = range s {
+		if count == max {
+			break
+		}
+		count++
+	}
+	return StringValue(s[:i] + fmt.Sprintf("…(%d bytes)", len(s)))
+}
@@ -0,0 +1,38 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTruncateStrings(t *testing.T) {
+	tests := []struct {
+		n    Node
+		max  int
+		want Node
+	}{
+		{n: StringValue("abcdef"), max: 3, want: StringValue("abc…(6 bytes)")},
+		{n: StringValue("abc"), max: 3, want: StringValue("abc")},
+		{n: StringValue("あいうえ"), max: 2, want: StringValue("あい…(12 bytes)")},
+		{n: StringValue("abcdef"), max: 0, want: StringValue("abcdef")},
+		{n: NumberValue(123456), max: 3, want: NumberValue(123456)},
+		{
+			n: Map{
+				"abcdef": StringValue("abcdef"),
+				"list":   Array{StringValue("ab"), StringValue("abcd"), BoolValue(true), Nil},
+			},
+			max: 3,
+			want: Map{
+				"abcdef": StringValue("abc…(6 bytes)"),
+				"list":   Array{StringValue("ab"), StringValue("abc…(4 bytes)"), BoolValue(true), Nil},
+			},
+		},
+		{n: nil, max: 3, want: nil},
+	}
+	for i, test := range tests {
+		got := TruncateStrings(test.n, test.max)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tree
+
+import (
+	"context"
+)
+
+func init() {
+	RegisterMethod("select", selectMethod)
+}
+
+type truthinessKey struct{}
+
+// WithTruthiness returns a copy of ctx that holds the truthiness model fn
+// of select() and TruthySelector. nil is IsLooseTruthy, and IsTruthy is the
+// model of jq.
+func WithTruthiness(ctx context.Context, fn func(n Node) bool) context.Context {
+	return context.WithValue(ctx, truthinessKey{}, fn)
+}
+
+// TruthyContext reports whether n is true in the truthiness model held by
+// ctx. The default model is IsLooseTruthy.
+func TruthyContext(ctx context.Context, n Node) bool {
+	if fn, _ := ctx.Value(truthinessKey{}).(func(n Node) bool); fn != nil {
+		return fn(n)
+	}
+	return IsLooseTruthy(n)
+}
+
+// IsLooseTruthy reports whether n is truthy: null, false, 0 and "" are falsy
+// and the others including empty arrays and maps are truthy.
+func IsLooseTruthy(n Node) bool {
+	if n == nil {
+		return false
+	}
+	switch n.Type() {
+	case TypeNilValue:
+		return false
+	case TypeBoolValue:
+		return n.Value().Bool()
+	case TypeNumberValue:
+		return n.Value().Float64() != 0
+	case TypeStringValue:
+		return n.Value().String() != ""
+	}
+	return IsTruthy(n)
+}
+
+// selectMethod is the method "select(f)" that returns the node if the
+// results of f are truthy by TruthyContext. f can be a comparison like
+// select(.price < 10).
+func selectMethod(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("select", args, 1, 1); err != nil {
+		return nil, err
+	}
+	ok, err := TruthySelector{args[0]}.MatchesContext(ctx, n)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return []Node{n}, nil
+}
+
+// TruthySelector is a selector that matches the node if the query returns
+// results and all of them are truthy by TruthyContext. (eg. [.price and .title])
+type TruthySelector struct {
+	Query
+}
+
+var _ ContextSelector = (*TruthySelector)(nil)
+
+// Matches returns true if the results of the query are truthy.
+func (s TruthySelector) Matches(n Node) (bool, error) {
+	return s.MatchesContext(context.Background(), n)
+}
+
+// MatchesContext returns true if the results of the query are truthy with
+// ctx.
+func (s TruthySelector) MatchesContext(ctx context.Context, n Node) (bool, error) {
+	rs, err := ExecContext(ctx, s.Query, n)
+	if err != nil || len(rs) == 0 {
+		return false, err
+	}
+	for _, r := range rs {
+		if !TruthyContext(ctx, r) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// MatchQuery is a query that returns the result of the selector as a bool
+// value, so the comparisons can be the arguments of the methods.
+type MatchQuery struct {
+	Selector
+}
+
+var _ ContextQuery = (*MatchQuery)(nil)
+
+// Exec returns the result of the selector.
+func (q MatchQuery) Exec(n Node) ([]Node, error) {
+	return q.ExecContext(context.Background(), n)
+}
+
+// ExecContext returns the result of the selector with ctx.
+func (q MatchQuery) ExecContext(ctx context.Context, n Node) ([]Node, error) {
+	ok, err := MatchesContext(ctx, q.Selector, n)
+	if err != nil {
+		return nil, err
+	}
+	return []Node{BoolValue(ok)}, nil
+}
+
+func (q MatchQuery) String() string {
+	return selectorString(q.Selector)
+}
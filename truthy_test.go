@@ -0,0 +1,138 @@
+package tree
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestIsLooseTruthy(t *testing.T) {
+	tests := []struct {
+		n    Node
+		want bool
+	}{
+		{n: nil, want: false},
+		{n: Nil, want: false},
+		{n: ToValue(false), want: false},
+		{n: ToValue(0), want: false},
+		{n: ToValue(""), want: false},
+		{n: ToValue(true), want: true},
+		{n: ToValue(-1), want: true},
+		{n: ToValue("0"), want: true},
+		{n: Array{}, want: true},
+		{n: Map{}, want: true},
+		{n: plainValue{NumberValue(0)}, want: false},
+	}
+	for i, test := range tests {
+		if got := IsLooseTruthy(test.n); got != test.want {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestWithTruthiness(t *testing.T) {
+	ctx := context.Background()
+	if TruthyContext(ctx, ToValue(0)) {
+		t.Errorf("0 is truthy by default")
+	}
+	if !TruthyContext(WithTruthiness(ctx, IsTruthy), ToValue(0)) {
+		t.Errorf("0 is not truthy by IsTruthy")
+	}
+	if TruthyContext(WithTruthiness(ctx, nil), ToValue(0)) {
+		t.Errorf("0 is truthy by nil")
+	}
+
+	n := ToArrayValues(0, 1)
+	got, err := FindContext(WithTruthiness(ctx, IsTruthy), n, `[].select(.)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ToNodeValues(0, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func Test_SelectMethod(t *testing.T) {
+	n := Array{
+		Map{"id": ToValue(1), "price": ToValue(5), "tag": ToValue("a")},
+		Map{"id": ToValue(2), "price": ToValue(15), "tag": ToValue("")},
+		Map{"id": ToValue(3), "price": ToValue(0), "tags": ToArrayValues("a")},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `[].select(.price < 10).id`,
+			want: ToNodeValues(1, 3),
+		}, {
+			expr: `[].select(.price).id`,
+			want: ToNodeValues(1, 2),
+		}, {
+			expr: `[].select(.tag).id`,
+			want: ToNodeValues(1),
+		}, {
+			expr: `[].select(.price > 1 and .tag == "a").id`,
+			want: ToNodeValues(1),
+		}, {
+			expr: `[].select((.id == 1 or .id == 3) and .price == 0).id`,
+			want: ToNodeValues(3),
+		}, {
+			expr: `[].select(.price==0 and(.id==1 or(.id==3))).id`,
+			want: ToNodeValues(3),
+		}, {
+			expr: `[].select(.tags == ["a"]).id`,
+			want: ToNodeValues(3),
+		}, {
+			expr: `[].select(.tag.test("^a")).id`,
+			want: ToNodeValues(1),
+		}, {
+			expr: `[].select(.price < 10 and .tag).id`,
+			want: ToNodeValues(1),
+		}, {
+			expr: `[.price < 10 and .tag].id`,
+			want: ToNodeValues(1),
+		}, {
+			expr: `[.tags or .price > 10].id`,
+			want: ToNodeValues(2, 3),
+		}, {
+			expr: `[.tag.test("^a")].id`,
+			want: ToNodeValues(1),
+		}, {
+			expr: `[].select(.none).id`,
+		}, {
+			expr:   `[].select()`,
+			errstr: "invalid number of arguments for select(): 0",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestMatchQuery(t *testing.T) {
+	q := MatchQuery{Comparator{Left: MapQuery("a"), Op: EQ, Right: ValueQuery{ToValue(1)}}}
+	got, err := q.Exec(Map{"a": ToValue(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ToNodeValues(true); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if got, want := q.String(), `.a == 1`; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -1,14 +1,27 @@
 package tree
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"reflect"
 	"regexp"
 	"sync"
+	"time"
 )
 
 // ToValue converts the specified v to a Value as Node.
 // Node.Value() returns converted value.
+//
+// time.Time, time.Duration and []byte are converted as "encoding/json" does:
+// the time is formatted in RFC 3339, the duration is the number of
+// nanoseconds and the bytes are encoded in base64, so they can be restored
+// by UnmarshalViaJSON. A pointer is converted as the value that it points
+// to, and the other types that implement fmt.Stringer are converted to
+// their strings.
 func ToValue(v interface{}) Node {
 	if v == nil {
 		return Nil
@@ -24,16 +37,54 @@ func ToValue(v interface{}) Node {
 		return NumberValue(tv)
 	case int32:
 		return NumberValue(int64(tv))
+	case int16:
+		return NumberValue(int64(tv))
+	case int8:
+		return NumberValue(int64(tv))
 	case float64:
 		return NumberValue(tv)
 	case float32:
 		return NumberValue(float64(tv))
+	case uint:
+		return NumberValue(float64(tv))
 	case uint64:
 		return NumberValue(float64(tv))
 	case uint32:
 		return NumberValue(float64(tv))
+	case uint16:
+		return NumberValue(float64(tv))
+	case uint8:
+		return NumberValue(float64(tv))
+	case json.Number:
+		if f, err := tv.Float64(); err == nil {
+			return NumberValue(f)
+		}
+		return StringValue(tv)
+	case time.Time:
+		return StringValue(tv.Format(time.RFC3339Nano))
+	case time.Duration:
+		return NumberValue(int64(tv))
+	case []byte:
+		return StringValue(base64.StdEncoding.EncodeToString(tv))
 	case Node:
 		return v.(Node)
+	case fmt.Stringer:
+		if reflect.ValueOf(v).Kind() != reflect.Ptr {
+			return StringValue(tv.String())
+		}
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return Nil
+		}
+		elem := rv.Elem().Interface()
+		if s, ok := v.(fmt.Stringer); ok {
+			// NOTE: The String method has the pointer receiver.
+			if _, ok := elem.(fmt.Stringer); !ok {
+				return StringValue(s.String())
+			}
+		}
+		return ToValue(elem)
 	}
 	// NOTE: Unsupported type.
 	return StringValue(fmt.Sprintf("%#v", v))
@@ -57,6 +108,16 @@ func ToNodeValues(vs ...interface{}) []Node {
 	return ns
 }
 
+// OrNil returns Nil if n is nil, otherwise n. The nodes of this package
+// return Nil instead of nil from Get, Each and queries, and OrNil converts
+// nil from the other sources such as the elements of Array and Map.
+func OrNil(n Node) Node {
+	if n == nil {
+		return Nil
+	}
+	return n
+}
+
 // ToNode converts the specified v to an Node.
 func ToNode(v interface{}) Node {
 	if v == nil {
@@ -84,10 +145,43 @@ func ToNode(v interface{}) Node {
 		}
 		return m
 	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		switch rv.Elem().Kind() {
+		case reflect.Slice, reflect.Map:
+			return ToNode(rv.Elem().Interface())
+		}
+	}
 	return ToValue(v)
 }
 
+// ToAny converts n to the value of Go that consists of []interface{},
+// map[string]interface{}, string, bool, float64 and nil.
 func ToAny(n Node) interface{} {
+	return ToAnyWithOptions(n, ToAnyOptions{})
+}
+
+// ToAnyOptions represents the options of ToAnyWithOptions.
+type ToAnyOptions struct {
+	// Int converts the integral numbers to int instead of float64 if they
+	// are exactly representable.
+	Int bool
+	// Ordered converts maps to []KeyValue in the order of Map.Keys instead
+	// of map[string]interface{}.
+	Ordered bool
+}
+
+// KeyValue represents an entry of a map converted by ToAnyWithOptions.
+type KeyValue struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// maxExactInt is the maximum integer that float64 represents exactly.
+const maxExactInt = 1 << 53
+
+// ToAnyWithOptions converts n to the value of Go like ToAny with opts.
+// nil and the nodes those types are TypeNilValue are converted to nil.
+func ToAnyWithOptions(n Node, opts ToAnyOptions) interface{} {
 	if n == nil {
 		return nil
 	}
@@ -97,14 +191,21 @@ func ToAny(n Node) interface{} {
 		a := n.Array()
 		x := make([]interface{}, len(a))
 		for i, v := range a {
-			x[i] = ToAny(v)
+			x[i] = ToAnyWithOptions(v, opts)
 		}
 		return x
 	case TypeMap:
 		m := n.Map()
+		if opts.Ordered {
+			x := make([]KeyValue, 0, len(m))
+			for _, k := range m.Keys() {
+				x = append(x, KeyValue{k, ToAnyWithOptions(m[k], opts)})
+			}
+			return x
+		}
 		x := make(map[string]interface{}, len(m))
 		for k, v := range m {
-			x[k] = ToAny(v)
+			x[k] = ToAnyWithOptions(v, opts)
 		}
 		return x
 	case TypeNilValue:
@@ -114,7 +215,11 @@ func ToAny(n Node) interface{} {
 	case TypeBoolValue:
 		return n.Value().Bool()
 	case TypeNumberValue:
-		return n.Value().Float64()
+		f := n.Value().Float64()
+		if opts.Int && f == math.Trunc(f) && f >= -maxExactInt && f <= maxExactInt && f >= math.MinInt && f <= math.MaxInt {
+			return int(f)
+		}
+		return f
 	}
 	panic(fmt.Errorf("unknown type %v", t))
 }
@@ -136,6 +241,17 @@ func Walk(n Node, fn WalkFunc) error {
 	return walk(n, []interface{}{}, fn)
 }
 
+// WalkContext is like Walk but returns ctx.Err() if ctx is done before
+// visiting each node.
+func WalkContext(ctx context.Context, n Node, fn WalkFunc) error {
+	return walk(n, []interface{}{}, func(n Node, keys []interface{}) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(n, keys)
+	})
+}
+
 func walk(n Node, lastKeys []interface{}, fn WalkFunc) error {
 	if n == nil {
 		return nil
@@ -206,7 +322,7 @@ func clone(n Node, deep bool) Node {
 		aa := make(Array, len(a))
 		for i := 0; i < len(a); i++ {
 			if deep {
-				aa[i] = Clone(a[i])
+				aa[i] = clone(a[i], true)
 			} else {
 				aa[i] = a[i]
 			}
@@ -217,7 +333,7 @@ func clone(n Node, deep bool) Node {
 		mm := make(Map, len(m))
 		for k, v := range m {
 			if deep {
-				mm[k] = Clone(v)
+				mm[k] = clone(v, true)
 			} else {
 				mm[k] = v
 			}
@@ -1,8 +1,13 @@
 package tree
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_ToValue(t *testing.T) {
@@ -43,6 +48,48 @@ func Test_ToValue(t *testing.T) {
 		}, {
 			v:    BoolValue(true),
 			want: BoolValue(true),
+		}, {
+			v:    uint(8),
+			want: NumberValue(8),
+		}, {
+			v:    uint16(9),
+			want: NumberValue(9),
+		}, {
+			v:    uint8(10),
+			want: NumberValue(10),
+		}, {
+			v:    int8(-11),
+			want: NumberValue(-11),
+		}, {
+			v:    json.Number("12.5"),
+			want: NumberValue(12.5),
+		}, {
+			v:    json.Number("x"),
+			want: StringValue("x"),
+		}, {
+			v:    time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			want: StringValue("2006-01-02T15:04:05Z"),
+		}, {
+			v:    1500 * time.Millisecond,
+			want: NumberValue(1.5e9),
+		}, {
+			v:    []byte("hello"),
+			want: StringValue("aGVsbG8="),
+		}, {
+			v:    &testInt,
+			want: NumberValue(13),
+		}, {
+			v:    (*int)(nil),
+			want: Nil,
+		}, {
+			v:    &testTime,
+			want: StringValue("2006-01-02T15:04:05Z"),
+		}, {
+			v:    net.IPv4(127, 0, 0, 1),
+			want: StringValue("127.0.0.1"),
+		}, {
+			v:    bytes.NewBufferString("buf"),
+			want: StringValue("buf"),
 		}, {
 			v:    struct{}{},
 			want: StringValue("struct {}{}"),
@@ -56,6 +103,48 @@ func Test_ToValue(t *testing.T) {
 	}
 }
 
+var (
+	testInt  = 13
+	testTime = time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+)
+
+func Test_ToValue_RoundTrip(t *testing.T) {
+	tm := time.Date(2006, 1, 2, 15, 4, 5, 999, time.FixedZone("", 9*60*60))
+	gotTime, err := NodeTo[time.Time](ToValue(tm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotTime.Equal(tm) {
+		t.Errorf("got %v; want %v", gotTime, tm)
+	}
+	if got, ok := ValueTime(ToValue(tm).Value()); !ok || !got.Equal(tm) {
+		t.Errorf("got %v; want %v", got, tm)
+	}
+	d := 90 * time.Second
+	gotDuration, err := NodeTo[time.Duration](ToValue(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotDuration != d {
+		t.Errorf("got %v; want %v", gotDuration, d)
+	}
+	b := []byte{0, 1, 0xff}
+	gotBytes, err := NodeTo[[]byte](ToValue(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotBytes, b) {
+		t.Errorf("got %v; want %v", gotBytes, b)
+	}
+	gotNumber, err := NodeTo[json.Number](ToValue(json.Number("12.5")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotNumber != "12.5" {
+		t.Errorf("got %v; want 12.5", gotNumber)
+	}
+}
+
 func Test_ToNode(t *testing.T) {
 	tests := []struct {
 		v    interface{}
@@ -73,6 +162,15 @@ func Test_ToNode(t *testing.T) {
 		}, {
 			v:    []interface{}{"a", true, 1},
 			want: Array{StringValue("a"), BoolValue(true), NumberValue(1)},
+		}, {
+			v:    &[]interface{}{"a"},
+			want: Array{StringValue("a")},
+		}, {
+			v:    &map[string]interface{}{"a": 1},
+			want: Map{"a": NumberValue(1)},
+		}, {
+			v:    (*map[string]interface{})(nil),
+			want: Nil,
 		},
 	}
 	for i, test := range tests {
@@ -83,6 +181,77 @@ func Test_ToNode(t *testing.T) {
 	}
 }
 
+func Test_ToAny(t *testing.T) {
+	n := Map{
+		"b": Array{ToValue(1), ToValue(1.5), Nil},
+		"a": ToValue("x"),
+		"c": ToValue(true),
+	}
+	want := map[string]interface{}{
+		"b": []interface{}{1.0, 1.5, nil},
+		"a": "x",
+		"c": true,
+	}
+	if got := ToAny(n); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v; want %#v", got, want)
+	}
+	if got := ToAny(nil); got != nil {
+		t.Errorf("got %#v; want nil", got)
+	}
+}
+
+func Test_ToAnyWithOptions(t *testing.T) {
+	tests := []struct {
+		n    Node
+		opts ToAnyOptions
+		want interface{}
+	}{
+		{
+			n:    ToArrayValues(1, 1.5, -2, 1e20),
+			opts: ToAnyOptions{Int: true},
+			want: []interface{}{1, 1.5, -2, 1e20},
+		}, {
+			n:    Map{"b": ToValue(1), "a": Map{"d": Nil, "c": ToValue("x")}},
+			opts: ToAnyOptions{Ordered: true},
+			want: []KeyValue{
+				{Key: "a", Value: []KeyValue{{Key: "c", Value: "x"}, {Key: "d", Value: nil}}},
+				{Key: "b", Value: 1.0},
+			},
+		}, {
+			n:    Map{"a": ToValue(1)},
+			opts: ToAnyOptions{Int: true, Ordered: true},
+			want: []KeyValue{{Key: "a", Value: 1}},
+		}, {
+			n:    Map{},
+			opts: ToAnyOptions{Ordered: true},
+			want: []KeyValue{},
+		}, {
+			n:    Nil,
+			opts: ToAnyOptions{Int: true},
+			want: nil,
+		}, {
+			n:    Any{Nil},
+			want: nil,
+		}, {
+			n:    Any{},
+			want: nil,
+		},
+	}
+	for i, test := range tests {
+		if got := ToAnyWithOptions(test.n, test.opts); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+
+	b, err := json.Marshal(ToAnyWithOptions(Map{"b": ToValue(2), "a": ToValue(1)}, ToAnyOptions{Int: true, Ordered: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `[{"key":"a","value":1},{"key":"b","value":2}]`; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
 func Test_Walk(t *testing.T) {
 	root := Array{
 		Map{"ID": ToValue(1)},
@@ -236,6 +405,12 @@ func TestCloneDeep(t *testing.T) {
 			update: func(n Node) {
 				n.Map().Get("a").Array()[0] = ToValue(5)
 			},
+		}, {
+			n:    Map{"a": Array{Map{"b": ToArrayValues(1)}}},
+			want: Map{"a": Array{Map{"b": ToArrayValues(1)}}},
+			update: func(n Node) {
+				n.Map().Get("a").Array()[0].Map()["b"].Array()[0] = ToValue(2)
+			},
 		},
 	}
 	for i, test := range tests {
@@ -249,3 +424,25 @@ func TestCloneDeep(t *testing.T) {
 		}
 	}
 }
+
+func Test_WalkContext(t *testing.T) {
+	root := Array{Map{"ID": ToValue(1)}, Map{"ID": ToValue(2)}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	count := 0
+	err := WalkContext(ctx, root, func(n Node, keys []interface{}) error {
+		count++
+		if len(keys) == 1 {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+	if count != 2 {
+		t.Errorf("fn is called %d times; want 2", count)
+	}
+}
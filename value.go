@@ -1,7 +1,9 @@
 package tree
 
 import (
+	"math"
 	"strconv"
+	"time"
 )
 
 // Operator represents an operator.
@@ -107,6 +109,26 @@ func (n NilValue) Float64() float64 {
 	return 0
 }
 
+// Uint64 returns 0.
+func (n NilValue) Uint64() uint64 {
+	return 0
+}
+
+// Time returns the zero time and false.
+func (n NilValue) Time() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Bytes returns nil.
+func (n NilValue) Bytes() []byte {
+	return nil
+}
+
+// IsTruthy returns false.
+func (n NilValue) IsTruthy() bool {
+	return false
+}
+
 // String returns this as string.
 func (n NilValue) String() string {
 	return ""
@@ -193,12 +215,35 @@ func (n StringValue) Float64() float64 {
 	return 0
 }
 
+// Uint64 returns 0.
+func (n StringValue) Uint64() uint64 {
+	return 0
+}
+
+// Time parses this as RFC 3339 and returns the time.
+func (n StringValue) Time() (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, string(n))
+	return t, err == nil
+}
+
+// Bytes returns this as []byte.
+func (n StringValue) Bytes() []byte {
+	return []byte(n)
+}
+
+// IsTruthy returns true.
+func (n StringValue) IsTruthy() bool {
+	return true
+}
+
 // String returns this as string.
 func (n StringValue) String() string {
 	return string(n)
 }
 
-// Compare compares n and v.
+// Compare compares n and v. The ordering operators compare the quantities
+// like "2Gi" and the durations like "1h30m" as numbers if both are the same
+// kind. See ParseQuantity and ParseDuration.
 func (n StringValue) Compare(op Operator, v Value) bool {
 	if v == nil || !v.Type().IsStringValue() {
 		return (op == NE)
@@ -206,6 +251,12 @@ func (n StringValue) Compare(op Operator, v Value) bool {
 	sn := n.String()
 	sv := v.String()
 	switch op {
+	case GT, GE, LT, LE:
+		if a, b, ok := compareQuantities(sn, sv); ok {
+			return NumberValue(a).Compare(op, NumberValue(b))
+		}
+	}
+	switch op {
 	case EQ:
 		return sn == sv
 	case GT:
@@ -295,6 +346,26 @@ func (n BoolValue) Float64() float64 {
 	return 0
 }
 
+// Uint64 returns 0.
+func (n BoolValue) Uint64() uint64 {
+	return 0
+}
+
+// Time returns the zero time and false.
+func (n BoolValue) Time() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Bytes returns nil.
+func (n BoolValue) Bytes() []byte {
+	return nil
+}
+
+// IsTruthy returns this.
+func (n BoolValue) IsTruthy() bool {
+	return bool(n)
+}
+
 // String returns this as string.
 func (n BoolValue) String() string {
 	return strconv.FormatBool(bool(n))
@@ -384,6 +455,30 @@ func (n NumberValue) Float64() float64 {
 	return float64(n)
 }
 
+// Uint64 returns uint64(n), or 0 if n is negative.
+func (n NumberValue) Uint64() uint64 {
+	if n < 0 {
+		return 0
+	}
+	return uint64(n)
+}
+
+// Time returns this as the Unix time in seconds.
+func (n NumberValue) Time() (time.Time, bool) {
+	sec, frac := math.Modf(float64(n))
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+}
+
+// Bytes returns nil.
+func (n NumberValue) Bytes() []byte {
+	return nil
+}
+
+// IsTruthy returns true.
+func (n NumberValue) IsTruthy() bool {
+	return true
+}
+
 // String returns this as string using strconv.FormatFloat(float64(n), 'f', -1, 64).
 func (n NumberValue) String() string {
 	return strconv.FormatFloat(float64(n), 'f', -1, 64)
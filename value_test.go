@@ -104,6 +104,14 @@ func Test_Value_Compare(t *testing.T) {
 		{StringValue("xyz"), RE, StringValue(`^z`), false},
 		{StringValue("xyz"), RE, StringValue(`^[0-9]+$`), false},
 		{StringValue("x"), Operator("unknown"), StringValue("x"), false},
+		{StringValue("2Gi"), GT, StringValue("512Mi"), true},
+		{StringValue("500m"), LT, StringValue("1"), true},
+		{StringValue("1.5k"), GE, StringValue("1500"), true},
+		{StringValue("1h30m"), GT, StringValue("45m"), true},
+		{StringValue("500ms"), LE, StringValue("1s"), true},
+		{StringValue("10"), GT, StringValue("9"), false},
+		{StringValue("2Gi"), EQ, StringValue("2048Mi"), false},
+		{StringValue("1h"), GT, StringValue("2Gi"), false},
 		{NumberValue(1), EQ, nil, false},
 		{NumberValue(1), EQ, NumberValue(1), true},
 		{NumberValue(1), EQ, NumberValue(0), false},
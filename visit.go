@@ -0,0 +1,189 @@
+package tree
+
+import "fmt"
+
+// QueryVisitFunc is the type of the function called by VisitQuery for each
+// query. Returning SkipWalk skips the queries in the query.
+type QueryVisitFunc func(q Query) error
+
+// VisitQuery calls fn for q and the queries in q in depth-first order: the
+// queries of FilterQuery, the arguments of MethodQuery, the fields of
+// ObjectQuery, and the queries in
+// the selectors of SelectQuery and MatchQuery such as the left and right of
+// Comparator. It is the base of the tools those analyze queries, for example
+// collecting the keys that a query refers.
+func VisitQuery(q Query, fn QueryVisitFunc) error {
+	if q == nil {
+		return nil
+	}
+	if err := fn(q); err != nil {
+		if err == SkipWalk {
+			return nil
+		}
+		return err
+	}
+	switch tq := q.(type) {
+	case FilterQuery:
+		for _, qq := range tq {
+			if err := VisitQuery(qq, fn); err != nil {
+				return err
+			}
+		}
+	case MethodQuery:
+		for _, arg := range tq.Args {
+			if err := VisitQuery(arg, fn); err != nil {
+				return err
+			}
+		}
+	case ObjectQuery:
+		for _, f := range tq {
+			if err := VisitQuery(f.Query, fn); err != nil {
+				return err
+			}
+		}
+	case SelectQuery:
+		return visitSelector(tq.Selector, fn)
+	case MatchQuery:
+		return visitSelector(tq.Selector, fn)
+	}
+	return nil
+}
+
+func visitSelector(s Selector, fn QueryVisitFunc) error {
+	switch ts := s.(type) {
+	case And:
+		for _, ss := range ts {
+			if err := visitSelector(ss, fn); err != nil {
+				return err
+			}
+		}
+	case Or:
+		for _, ss := range ts {
+			if err := visitSelector(ss, fn); err != nil {
+				return err
+			}
+		}
+	case Comparator:
+		if err := VisitQuery(ts.Left, fn); err != nil {
+			return err
+		}
+		return VisitQuery(ts.Right, fn)
+	case TruthySelector:
+		return VisitQuery(ts.Query, fn)
+	case SelectQuery:
+		return VisitQuery(ts, fn)
+	}
+	return nil
+}
+
+// QueryRewriteFunc is the type of the function called by RewriteQuery for
+// each query. It returns the query that replaces q.
+type QueryRewriteFunc func(q Query) (Query, error)
+
+// RewriteQuery returns a copy of q that each query is replaced by fn. The
+// queries are rewritten bottom-up like VisitQuery visits, so fn receives the
+// query that has the rewritten queries. The provided query is not modified.
+func RewriteQuery(q Query, fn QueryRewriteFunc) (Query, error) {
+	if q == nil {
+		return nil, nil
+	}
+	switch tq := q.(type) {
+	case FilterQuery:
+		x := make(FilterQuery, len(tq))
+		for i, qq := range tq {
+			r, err := RewriteQuery(qq, fn)
+			if err != nil {
+				return nil, err
+			}
+			x[i] = r
+		}
+		q = x
+	case MethodQuery:
+		var args []Query
+		if tq.Args != nil {
+			args = make([]Query, len(tq.Args))
+		}
+		for i, arg := range tq.Args {
+			r, err := RewriteQuery(arg, fn)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = r
+		}
+		q = MethodQuery{Name: tq.Name, Args: args}
+	case ObjectQuery:
+		x := make(ObjectQuery, len(tq))
+		for i, f := range tq {
+			r, err := RewriteQuery(f.Query, fn)
+			if err != nil {
+				return nil, err
+			}
+			x[i] = ObjectField{Key: f.Key, Query: r}
+		}
+		q = x
+	case SelectQuery:
+		s, err := rewriteSelector(tq.Selector, fn)
+		if err != nil {
+			return nil, err
+		}
+		q = SelectQuery{s}
+	case MatchQuery:
+		s, err := rewriteSelector(tq.Selector, fn)
+		if err != nil {
+			return nil, err
+		}
+		q = MatchQuery{s}
+	}
+	return fn(q)
+}
+
+func rewriteSelector(s Selector, fn QueryRewriteFunc) (Selector, error) {
+	switch ts := s.(type) {
+	case And:
+		x := make(And, len(ts))
+		for i, ss := range ts {
+			r, err := rewriteSelector(ss, fn)
+			if err != nil {
+				return nil, err
+			}
+			x[i] = r
+		}
+		return x, nil
+	case Or:
+		x := make(Or, len(ts))
+		for i, ss := range ts {
+			r, err := rewriteSelector(ss, fn)
+			if err != nil {
+				return nil, err
+			}
+			x[i] = r
+		}
+		return x, nil
+	case Comparator:
+		left, err := RewriteQuery(ts.Left, fn)
+		if err != nil {
+			return nil, err
+		}
+		right, err := RewriteQuery(ts.Right, fn)
+		if err != nil {
+			return nil, err
+		}
+		return Comparator{left, ts.Op, right}, nil
+	case TruthySelector:
+		r, err := RewriteQuery(ts.Query, fn)
+		if err != nil {
+			return nil, err
+		}
+		return TruthySelector{r}, nil
+	case SelectQuery:
+		r, err := RewriteQuery(ts, fn)
+		if err != nil {
+			return nil, err
+		}
+		if sq, ok := r.(Selector); ok {
+			return sq, nil
+		}
+		return nil, fmt.Errorf("cannot rewrite selector %s to %s", ts, r)
+	}
+	return s, nil
+}
@@ -0,0 +1,132 @@
+package tree
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVisitQuery(t *testing.T) {
+	q, err := ParseQuery(`.users[.id == $id and (.role == "admin" or .active)].walk(.numbers().format("%d", .n)).name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	err = VisitQuery(q, func(q Query) error {
+		if mq, ok := q.(MapQuery); ok {
+			keys = append(keys, string(mq))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"users", "id", "role", "active", "n", "name"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got %v; want %v", keys, want)
+	}
+
+	keys = nil
+	err = VisitQuery(q, func(q Query) error {
+		switch tq := q.(type) {
+		case SelectQuery, MethodQuery:
+			return SkipWalk
+		case MapQuery:
+			keys = append(keys, string(tq))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"users", "name"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got %v; want %v", keys, want)
+	}
+
+	errStop := errors.New("stop")
+	count := 0
+	err = VisitQuery(q, func(q Query) error {
+		if _, ok := q.(VariableQuery); ok {
+			return errStop
+		}
+		count++
+		return nil
+	})
+	if err != errStop {
+		t.Errorf("got error %v; want %v", err, errStop)
+	}
+	if count != 4 {
+		t.Errorf("got %d; want 4", count)
+	}
+	if err := VisitQuery(nil, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRewriteQuery(t *testing.T) {
+	tests := []struct {
+		expr string
+		fn   QueryRewriteFunc
+		want string
+	}{
+		{
+			expr: `.items[.name == "a" or .tags.count() > 1].name.format("%s", .name)`,
+			fn: func(q Query) (Query, error) {
+				if q == MapQuery("name") {
+					return MapQuery("title"), nil
+				}
+				return q, nil
+			},
+			want: `.items[.title == "a" or .tags.count() > 1].title.format("%s", .title)`,
+		}, {
+			expr: `.a[].select(.b > 1 and .c)`,
+			fn: func(q Query) (Query, error) {
+				if vq, ok := q.(ValueQuery); ok {
+					return ValueQuery{ToValue(vq.Value().Float64() * 10)}, nil
+				}
+				return q, nil
+			},
+			want: `.a[].select(.b > 10 and .c)`,
+		}, {
+			expr: `.metadata.name`,
+			fn: func(q Query) (Query, error) {
+				if fq, ok := q.(FilterQuery); ok {
+					return append(FilterQuery{MapQuery("spec")}, fq...), nil
+				}
+				return q, nil
+			},
+			want: `.spec.metadata.name`,
+		},
+	}
+	for i, test := range tests {
+		q, err := ParseQuery(test.expr)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		orig := q.String()
+		got, err := RewriteQuery(q, test.fn)
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if got.String() != test.want {
+			t.Errorf("tests[%d] got %s; want %s", i, got, test.want)
+		}
+		if q.String() != orig {
+			t.Errorf("tests[%d] modified %s; want %s", i, q, orig)
+		}
+	}
+
+	errDenied := errors.New("denied")
+	q, err := ParseQuery(`.users[.password == "x"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = RewriteQuery(q, func(q Query) (Query, error) {
+		if q == MapQuery("password") {
+			return nil, errDenied
+		}
+		return q, nil
+	})
+	if err != errDenied {
+		t.Errorf("got error %v; want %v", err, errDenied)
+	}
+}
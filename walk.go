@@ -0,0 +1,89 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+)
+
+func init() {
+	RegisterMethod("walk", walkMethod)
+	RegisterMethod("arrays", typeFilter("arrays", Type.IsArray))
+	RegisterMethod("maps", typeFilter("maps", Type.IsMap))
+	RegisterMethod("strings", typeFilter("strings", Type.IsStringValue))
+	RegisterMethod("numbers", typeFilter("numbers", Type.IsNumberValue))
+	RegisterMethod("booleans", typeFilter("booleans", Type.IsBoolValue))
+	RegisterMethod("nulls", typeFilter("nulls", Type.IsNilValue))
+}
+
+// walkMethod is the method "walk(f)" that applies f to every node bottom-up
+// and returns the transformed node. The node is kept as is if f returns no
+// results, so f can transform only the nodes of a type like
+// walk(.numbers().tostring()). The provided node is not modified.
+func walkMethod(ctx context.Context, n Node, args []Query) ([]Node, error) {
+	if err := checkMethodArgs("walk", args, 1, 1); err != nil {
+		return nil, err
+	}
+	r, err := walkNode(ctx, n, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return []Node{r}, nil
+}
+
+func walkNode(ctx context.Context, n Node, f Query) (Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, nil
+	}
+	switch n.Type() {
+	case TypeArray:
+		a := n.Array()
+		aa := make(Array, len(a))
+		for i, v := range a {
+			x, err := walkNode(ctx, v, f)
+			if err != nil {
+				return nil, err
+			}
+			aa[i] = x
+		}
+		n = aa
+	case TypeMap:
+		m := n.Map()
+		mm := make(Map, len(m))
+		for k, v := range m {
+			x, err := walkNode(ctx, v, f)
+			if err != nil {
+				return nil, err
+			}
+			mm[k] = x
+		}
+		n = mm
+	}
+	rs, err := ExecContext(ctx, f, n)
+	if err != nil {
+		return nil, err
+	}
+	switch len(rs) {
+	case 0:
+		return n, nil
+	case 1:
+		return rs[0], nil
+	}
+	return nil, fmt.Errorf("walk(): %q returns no single value %+v", f, rs)
+}
+
+// typeFilter returns the method like "numbers()" that returns the node only
+// if the type of the node matches.
+func typeFilter(name string, match func(t Type) bool) MethodFunc {
+	return func(ctx context.Context, n Node, args []Query) ([]Node, error) {
+		if err := checkMethodArgs(name, args, 0, 0); err != nil {
+			return nil, err
+		}
+		if match(n.Type()) {
+			return []Node{n}, nil
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,72 @@
+package tree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_WalkMethod(t *testing.T) {
+	n := Map{
+		"id":    ToValue(1),
+		"name":  ToValue("one"),
+		"tags":  Array{ToValue("a"), ToValue(2), Nil},
+		"items": Array{Map{"price": ToValue(8.95), "ok": ToValue(true)}},
+	}
+	tests := []struct {
+		expr   string
+		want   []Node
+		errstr string
+	}{
+		{
+			expr: `.walk(.numbers().tostring())`,
+			want: []Node{Map{
+				"id":    ToValue("1"),
+				"name":  ToValue("one"),
+				"tags":  Array{ToValue("a"), ToValue("2"), Nil},
+				"items": Array{Map{"price": ToValue("8.95"), "ok": ToValue(true)}},
+			}},
+		}, {
+			expr: `.walk(.maps().keys())`,
+			want: []Node{ToArrayValues("id", "items", "name", "tags")},
+		}, {
+			expr: `.tags.walk(.arrays().count())`,
+			want: ToNodeValues(3),
+		}, {
+			expr: `.items.walk(.booleans().format("%v!"))[0].ok`,
+			want: ToNodeValues("true!"),
+		}, {
+			expr: `.tags.walk(.nulls().tojson())`,
+			want: []Node{Array{ToValue("a"), ToValue(2), ToValue("null")}},
+		}, {
+			expr: `.name.walk(.strings().md5())`,
+			want: ToNodeValues("f97c5d29941bfb1b2fdab0874906ab82"),
+		}, {
+			expr: `.id`,
+			want: ToNodeValues(1),
+		}, {
+			expr:   `.tags.walk(.arrays()[])`,
+			errstr: `walk(): ".arrays()[]" returns no single value [a 2 ]`,
+		}, {
+			expr:   `.walk()`,
+			errstr: "invalid number of arguments for walk(): 0",
+		},
+	}
+	for i, test := range tests {
+		got, err := Find(n, test.expr)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d] no error", i)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d] got error %q; want %q", i, err.Error(), test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d] got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
@@ -1,30 +1,240 @@
 package tree
 
 import (
-	"gopkg.in/yaml.v2"
+	"bytes"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
 )
 
 // MarshalYAML returns the YAML encoding of the specified node.
+// The nested nodes are indented by two spaces.
 func MarshalYAML(n Node) ([]byte, error) {
-	return yaml.Marshal(n)
+	buf := new(bytes.Buffer)
+	if err := newYAMLEncoder(buf).Encode(n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-// DecodeYAML decodes YAML as a node using the provided decoder.
+// newYAMLEncoder returns the yaml.v3 encoder that indents by two spaces like
+// the other encoders of this package.
+func newYAMLEncoder(w io.Writer) *yaml.Encoder {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	return enc
+}
+
+// DecodeYAML decodes YAML as a node using the provided decoder. The
+// timestamps like 2001-12-14 are decoded as the strings of the original text.
 func DecodeYAML(dec *yaml.Decoder) (Node, error) {
-	var v interface{}
-	if err := dec.Decode(&v); err != nil {
+	var yn yaml.Node
+	if err := dec.Decode(&yn); err != nil {
 		return nil, err
 	}
-	return ToNode(v), nil
+	return DecodeYAMLNode(&yn)
+}
+
+// DecodeYAMLNode decodes the yaml.Node as a node. The aliases are resolved,
+// the comments are dropped and the timestamps are decoded as the strings of
+// the original text.
+func DecodeYAMLNode(yn *yaml.Node) (Node, error) {
+	return new(decodeLimiter).yamlNode(yn, 0)
 }
 
 // UnmarshalYAML returns the YAML encoding of the specified node.
 func UnmarshalYAML(data []byte) (Node, error) {
+	var yn yaml.Node
+	if err := yaml.Unmarshal(data, &yn); err != nil {
+		return nil, err
+	}
+	return DecodeYAMLNode(&yn)
+}
+
+// yamlNode converts yn to the node. The scalars are converted like yaml.v3
+// decodes them to interface{} except the timestamps those are kept as the
+// strings, so the untouched timestamps are written back as they were. The
+// keys are the strings of the original text.
+func (l *decodeLimiter) yamlNode(yn *yaml.Node, depth int) (Node, error) {
+	switch yn.Kind {
+	case 0:
+		return Nil, nil
+	case yaml.DocumentNode:
+		if len(yn.Content) == 0 {
+			return Nil, nil
+		}
+		return l.yamlNode(yn.Content[0], depth)
+	case yaml.AliasNode:
+		return l.yamlAlias(yn, depth)
+	}
+	if err := l.node(depth); err != nil {
+		return nil, err
+	}
+	if err := l.checkAliasing(); err != nil {
+		return nil, err
+	}
+	switch yn.Kind {
+	case yaml.SequenceNode:
+		a := make(Array, len(yn.Content))
+		for i, c := range yn.Content {
+			v, err := l.yamlNode(c, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = v
+		}
+		return a, nil
+	case yaml.MappingNode:
+		return l.yamlMap(yn, depth)
+	}
+	switch yn.ShortTag() {
+	case "!!null":
+		return Nil, nil
+	case "!!str", "!!timestamp":
+		if err := l.string(yn.Value); err != nil {
+			return nil, err
+		}
+		return StringValue(yn.Value), nil
+	}
 	var v interface{}
-	if err := yaml.Unmarshal(data, &v); err != nil {
+	if err := yn.Decode(&v); err != nil {
 		return nil, err
 	}
-	return ToNode(v), nil
+	if s, ok := v.(string); ok {
+		if err := l.string(s); err != nil {
+			return nil, err
+		}
+	}
+	return ToValue(v), nil
+}
+
+// yamlMap converts the mapping yn to the map. The keys of the merge keys
+// "<<" are set unless the mapping or the preceding merges have them like
+// yaml.v3.
+func (l *decodeLimiter) yamlMap(yn *yaml.Node, depth int) (Node, error) {
+	m := Map{}
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(yn.Content); i += 2 {
+		k, v := yn.Content[i], yn.Content[i+1]
+		if isYAMLMerge(k) {
+			merges = append(merges, v)
+			continue
+		}
+		key, err := l.yamlKey(k)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[key]; ok {
+			return nil, yamlDuplicateKeyError(yn, i, key)
+		}
+		if m[key], err = l.yamlNode(v, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	for _, merge := range merges {
+		vs := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			vs = merge.Content
+		}
+		for _, v := range vs {
+			mv, err := l.yamlNode(v, depth)
+			if err != nil {
+				return nil, err
+			}
+			if !mv.Type().IsMap() {
+				return nil, fmt.Errorf("yaml: map merge requires map or sequence of maps as the value")
+			}
+			for k, vv := range mv.Map() {
+				if _, ok := m[k]; !ok {
+					m[k] = vv
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// yamlDuplicateKeyError returns the error of the key of yn.Content[i] that
+// is already defined like yaml.v3.
+func yamlDuplicateKeyError(yn *yaml.Node, i int, key string) error {
+	line := 0
+	for j := 0; j < i; j += 2 {
+		if k := yn.Content[j]; k.Value == key {
+			line = k.Line
+			break
+		}
+	}
+	return fmt.Errorf("yaml: unmarshal errors:\n  line %d: mapping key %q already defined at line %d", yn.Content[i].Line, key, line)
+}
+
+func isYAMLMerge(yn *yaml.Node) bool {
+	return yn.Kind == yaml.ScalarNode && yn.Value == "<<" && (yn.Tag == "" || yn.Tag == "!" || yn.ShortTag() == "!!merge")
+}
+
+// yamlKey returns the key of the scalar yn that is interned if
+// DecodeOptions.InternKeys is set.
+func (l *decodeLimiter) yamlKey(yn *yaml.Node) (string, error) {
+	if yn.Kind == yaml.AliasNode && yn.Alias != nil {
+		yn = yn.Alias
+	}
+	if yn.Kind != yaml.ScalarNode {
+		return "", fmt.Errorf("yaml: line %d: invalid map key", yn.Line)
+	}
+	if err := l.string(yn.Value); err != nil {
+		return "", err
+	}
+	if yn.ShortTag() == "!!null" {
+		return "null", nil
+	}
+	return l.key(yn.Value), nil
+}
+
+// yamlAlias converts the node of the alias yn. The nodes of the aliases are
+// converted each time like yaml.v3, so the aliases those contain themselves
+// and the excessive aliasing are errors.
+func (l *decodeLimiter) yamlAlias(yn *yaml.Node, depth int) (Node, error) {
+	if yn.Alias == nil {
+		return Nil, nil
+	}
+	if l.aliases[yn.Alias] {
+		return nil, fmt.Errorf("yaml: anchor '%s' value contains itself", yn.Value)
+	}
+	if l.aliases == nil {
+		l.aliases = map[*yaml.Node]bool{}
+	}
+	l.aliases[yn.Alias] = true
+	l.aliasDepth++
+	n, err := l.yamlNode(yn.Alias, depth)
+	l.aliasDepth--
+	delete(l.aliases, yn.Alias)
+	return n, err
+}
+
+// checkAliasing returns an error if the nodes converted by the aliases
+// exceed the ratio of all nodes like yaml.v3, which protects from the alias
+// bombs like "billion laughs".
+func (l *decodeLimiter) checkAliasing() error {
+	if l.aliasDepth > 0 {
+		l.aliasNodes++
+	}
+	if l.aliasNodes > 100 && l.nodes > 1000 && float64(l.aliasNodes)/float64(l.nodes) > allowedAliasRatio(l.nodes) {
+		return fmt.Errorf("yaml: document contains excessive aliasing")
+	}
+	return nil
+}
+
+// allowedAliasRatio is the ratio of the nodes converted by the aliases that
+// yaml.v3 allows for the number of the nodes.
+func allowedAliasRatio(nodes int) float64 {
+	const low, high = 400000, 4000000
+	switch {
+	case nodes <= low:
+		return 0.99
+	case nodes >= high:
+		return 0.10
+	}
+	return 0.99 - 0.89*(float64(nodes-low)/float64(high-low))
 }
 
 // UnmarshalYAML is an implementation of yaml.Unmarshaler.
@@ -60,7 +270,7 @@ func (n NilValue) MarshalYAML() (interface{}, error) {
 	return nil, nil
 }
 
-// MarshalViaYAML returns the node encoding of v via "gopkg.in/yaml.v2".
+// MarshalViaYAML returns the node encoding of v via "gopkg.in/yaml.v3".
 func MarshalViaYAML(v interface{}) (Node, error) {
 	if v == nil {
 		return Nil, nil
@@ -75,7 +285,7 @@ func MarshalViaYAML(v interface{}) (Node, error) {
 	return UnmarshalYAML(data)
 }
 
-// UnmarshalViaYAML stores the node in the value pointed to by v via "gopkg.in/yaml.v2".
+// UnmarshalViaYAML stores the node in the value pointed to by v via "gopkg.in/yaml.v3".
 func UnmarshalViaYAML(n Node, v interface{}) error {
 	data, err := MarshalYAML(n)
 	if err != nil {
@@ -5,16 +5,16 @@ import (
 	"reflect"
 	"testing"
 
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 func Test_MarshalYAML(t *testing.T) {
 	want := `a:
-- "1"
-- 2
-- true
-- null
-- null
+  - "1"
+  - 2
+  - true
+  - null
+  - null
 `
 	n := Map{
 		"a": Array{
@@ -36,9 +36,9 @@ func Test_MarshalYAML(t *testing.T) {
 
 func Test_Map_MarshalYAML(t *testing.T) {
 	want := `a:
-- "1"
-- 2
-- true
+    - "1"
+    - 2
+    - true
 `
 	n := Map{
 		"a": Array{
@@ -89,6 +89,25 @@ func Test_DecodeYAML_Errors(t *testing.T) {
 		}, {
 			data:   []byte("{\n1"),
 			errstr: `yaml: line 2: did not find expected ',' or '}'`,
+		}, {
+			data:   []byte("a: 1\nb: 2\na: 3\n"),
+			errstr: "yaml: unmarshal errors:\n  line 3: mapping key \"a\" already defined at line 1",
+		}, {
+			data:   []byte("a: &a [*a]\n"),
+			errstr: "yaml: anchor 'a' value contains itself",
+		}, {
+			data:   []byte("a: &a 1\nb:\n  <<: *a\n"),
+			errstr: "yaml: map merge requires map or sequence of maps as the value",
+		}, {
+			data: []byte(`a: &a [0,0,0,0,0,0,0,0,0,0]
+b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a,*a]
+c: &c [*b,*b,*b,*b,*b,*b,*b,*b,*b,*b]
+d: &d [*c,*c,*c,*c,*c,*c,*c,*c,*c,*c]
+e: &e [*d,*d,*d,*d,*d,*d,*d,*d,*d,*d]
+f: &f [*e,*e,*e,*e,*e,*e,*e,*e,*e,*e]
+g: &g [*f,*f,*f,*f,*f,*f,*f,*f,*f,*f]
+`),
+			errstr: "yaml: document contains excessive aliasing",
 		},
 	}
 	for i, test := range tests {
@@ -103,6 +122,69 @@ func Test_DecodeYAML_Errors(t *testing.T) {
 	}
 }
 
+func Test_DecodeYAMLNode(t *testing.T) {
+	data := []byte(`# comment
+base: &base
+  name: a # name
+copy: *base
+1: one
+`)
+	var yn yaml.Node
+	if err := yaml.Unmarshal(data, &yn); err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeYAMLNode(&yn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Map{
+		"base": Map{"name": ToValue("a")},
+		"copy": Map{"name": ToValue("a")},
+		"1":    ToValue("one"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func Test_DecodeYAML_RoundTrip(t *testing.T) {
+	data := []byte(`date: 2001-12-14
+datetime: 2001-12-14t21:59:43.10-05:00
+quoted: "2001-12-14"
+tagged: !!timestamp 2001-12-14
+base: &base {a: 1, b: 2}
+merged:
+  <<: *base
+  b: 3
+`)
+	n, err := DecodeYAML(yaml.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Map{
+		"date":     StringValue("2001-12-14"),
+		"datetime": StringValue("2001-12-14t21:59:43.10-05:00"),
+		"quoted":   StringValue("2001-12-14"),
+		"tagged":   StringValue("2001-12-14"),
+		"base":     Map{"a": NumberValue(1), "b": NumberValue(2)},
+		"merged":   Map{"a": NumberValue(1), "b": NumberValue(3)},
+	}
+	if !reflect.DeepEqual(n, want) {
+		t.Fatalf("got %#v; want %#v", n, want)
+	}
+	b, err := MarshalYAML(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalYAML(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v; want %#v", got, want)
+	}
+}
+
 func Test_UnmarshalYAML(t *testing.T) {
 	tests := []struct {
 		want Node